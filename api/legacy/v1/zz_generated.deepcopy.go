@@ -8,6 +8,41 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FilesystemUsage) DeepCopyInto(out *FilesystemUsage) {
+	*out = *in
+	out.Total = in.Total.DeepCopy()
+	out.Used = in.Used.DeepCopy()
+	out.Available = in.Available.DeepCopy()
+	in.ObservedAt.DeepCopyInto(&out.ObservedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FilesystemUsage.
+func (in *FilesystemUsage) DeepCopy() *FilesystemUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(FilesystemUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FsckStatus) DeepCopyInto(out *FsckStatus) {
+	*out = *in
+	in.CheckedAt.DeepCopyInto(&out.CheckedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FsckStatus.
+func (in *FsckStatus) DeepCopy() *FsckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FsckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogicalVolume) DeepCopyInto(out *LogicalVolume) {
 	*out = *in
@@ -71,6 +106,16 @@ func (in *LogicalVolumeList) DeepCopyObject() runtime.Object {
 func (in *LogicalVolumeSpec) DeepCopyInto(out *LogicalVolumeSpec) {
 	*out = *in
 	out.Size = in.Size.DeepCopy()
+	if in.Seed != nil {
+		in, out := &in.Seed, &out.Seed
+		*out = new(SeedReference)
+		**out = **in
+	}
+	if in.MkfsOptions != nil {
+		in, out := &in.MkfsOptions, &out.MkfsOptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalVolumeSpec.
@@ -91,6 +136,31 @@ func (in *LogicalVolumeStatus) DeepCopyInto(out *LogicalVolumeStatus) {
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.FilesystemUsage != nil {
+		in, out := &in.FilesystemUsage, &out.FilesystemUsage
+		*out = new(FilesystemUsage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Revert != nil {
+		in, out := &in.Revert, &out.Revert
+		*out = new(RevertStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Population != nil {
+		in, out := &in.Population, &out.Population
+		*out = new(PopulationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(VerificationStatus)
+		**out = **in
+	}
+	if in.Fsck != nil {
+		in, out := &in.Fsck, &out.Fsck
+		*out = new(FsckStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalVolumeStatus.
@@ -102,3 +172,73 @@ func (in *LogicalVolumeStatus) DeepCopy() *LogicalVolumeStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PopulationStatus) DeepCopyInto(out *PopulationStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PopulationStatus.
+func (in *PopulationStatus) DeepCopy() *PopulationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PopulationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RevertStatus) DeepCopyInto(out *RevertStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RevertStatus.
+func (in *RevertStatus) DeepCopy() *RevertStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RevertStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedReference) DeepCopyInto(out *SeedReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedReference.
+func (in *SeedReference) DeepCopy() *SeedReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VerificationStatus) DeepCopyInto(out *VerificationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerificationStatus.
+func (in *VerificationStatus) DeepCopy() *VerificationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VerificationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
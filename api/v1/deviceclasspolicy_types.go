@@ -0,0 +1,47 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeviceClassPolicySpec defines the namespaces allowed to use a device-class.
+type DeviceClassPolicySpec struct {
+	// DeviceClass is the name of the device-class restricted by this policy.
+	// Use "00default" for the default device-class.
+	DeviceClass string `json:"deviceClass"`
+
+	// Namespaces are the names of the namespaces allowed to use the device-class.
+	//+kubebuilder:validation:Optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// NamespaceSelector selects the namespaces allowed to use the device-class.
+	//+kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="DeviceClass",type=string,JSONPath=`.spec.deviceClass`
+
+// DeviceClassPolicy is the Schema for the deviceclasspolicies API.
+// Once a device-class has a DeviceClassPolicy, only the namespaces allowed by
+// any of its DeviceClassPolicies can create volumes of the device-class.
+type DeviceClassPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeviceClassPolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// DeviceClassPolicyList contains a list of DeviceClassPolicy
+type DeviceClassPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeviceClassPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeviceClassPolicy{}, &DeviceClassPolicyList{})
+}
@@ -4,6 +4,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
@@ -30,6 +31,41 @@ type LogicalVolumeSpec struct {
 	// This field is populated only when LogicalVolume has a source.
 	//+kubebuilder:validation:Optional
 	AccessType string `json:"accessType,omitempty"`
+
+	// 'revertSnapshot' specifies the name of the snapshot LogicalVolume to be merged into this volume.
+	// The snapshot must have been taken from this volume, and it is consumed by the merge.
+	//+kubebuilder:validation:Optional
+	RevertSnapshot string `json:"revertSnapshot,omitempty"`
+
+	// 'copySource' specifies the name of the LogicalVolume whose data is copied into this volume.
+	// Unlike 'source', the source may be on another node. The volume is provisioned after the copy completes.
+	//+kubebuilder:validation:Optional
+	CopySource string `json:"copySource,omitempty"`
+
+	// 'seed' specifies the VolumeSeed whose data is written into this volume.
+	// The volume is provisioned after the data is written.
+	//+kubebuilder:validation:Optional
+	Seed *SeedReference `json:"seed,omitempty"`
+
+	// 'fsType' is the filesystem the volume is formatted with when it is published. It is empty for block volumes.
+	// It lets topolvm-node claim a pre-formatted volume from the warm pool.
+	//+kubebuilder:validation:Optional
+	FsType string `json:"fsType,omitempty"`
+
+	// 'mkfsOptions' are the extra arguments of mkfs used when the volume is formatted for the first time.
+	//+kubebuilder:validation:Optional
+	MkfsOptions []string `json:"mkfsOptions,omitempty"`
+
+	// 'fsckPolicy' is the policy of the filesystem check before the volume is mounted.
+	// One of never, check-only, auto-repair-safe and force. Empty is the same as never.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=never;check-only;auto-repair-safe;force
+	FsckPolicy string `json:"fsckPolicy,omitempty"`
+
+	// 'undelete' specifies the volume ID of a removed volume in the trash of the node to be restored as this volume.
+	// The size must not be smaller than the removed volume.
+	//+kubebuilder:validation:Optional
+	Undelete string `json:"undelete,omitempty"`
 }
 
 // LogicalVolumeStatus defines the observed state of LogicalVolume
@@ -40,6 +76,144 @@ type LogicalVolumeStatus struct {
 	Code        codes.Code         `json:"code,omitempty"`
 	Message     string             `json:"message,omitempty"`
 	CurrentSize *resource.Quantity `json:"currentSize,omitempty"`
+
+	// FilesystemUsage is the usage of the filesystem on the volume reported by topolvm-node.
+	// It is updated when kubelet collects the volume stats, and is not set for block volumes.
+	//+kubebuilder:validation:Optional
+	FilesystemUsage *FilesystemUsage `json:"filesystemUsage,omitempty"`
+
+	// Revert is the progress of the revert to the snapshot given by spec.revertSnapshot.
+	//+kubebuilder:validation:Optional
+	Revert *RevertStatus `json:"revert,omitempty"`
+
+	// Population is the progress of the copy from spec.copySource or spec.seed.
+	//+kubebuilder:validation:Optional
+	Population *PopulationStatus `json:"population,omitempty"`
+
+	// Verification is the result of the check of the LVM logical volume requested by
+	// the verify-requested-at annotation.
+	//+kubebuilder:validation:Optional
+	Verification *VerificationStatus `json:"verification,omitempty"`
+
+	// Fsck is the result of the last filesystem check before the volume was mounted.
+	//+kubebuilder:validation:Optional
+	Fsck *FsckStatus `json:"fsck,omitempty"`
+}
+
+// PopulationPhase is the phase of the copy from the source volume.
+type PopulationPhase string
+
+const (
+	// PopulationPopulating means the data is being copied from the source volume.
+	PopulationPopulating PopulationPhase = "Populating"
+	// PopulationCompleted means the data has been copied.
+	PopulationCompleted PopulationPhase = "Completed"
+)
+
+// PopulationStatus represents the progress of the copy from the source volume.
+type PopulationStatus struct {
+	// Phase is the phase of the copy.
+	Phase PopulationPhase `json:"phase"`
+
+	// CopiedBytes is the amount of data copied from the source volume.
+	//+kubebuilder:validation:Optional
+	CopiedBytes int64 `json:"copiedBytes,omitempty"`
+
+	// StartTime is the time when the copy was started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is the time when the copy was completed.
+	//+kubebuilder:validation:Optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message describes the last error of the copy, which is retried.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// SeedReference refers to a VolumeSeed.
+type SeedReference struct {
+	// Namespace is the namespace of the VolumeSeed.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the VolumeSeed.
+	Name string `json:"name"`
+}
+
+// RevertPhase is the phase of a revert to a snapshot.
+type RevertPhase string
+
+const (
+	// RevertMerging means the snapshot is being merged into the volume.
+	RevertMerging RevertPhase = "Merging"
+	// RevertCompleted means the snapshot has been merged into the volume.
+	RevertCompleted RevertPhase = "Completed"
+	// RevertFailed means the merge failed. The reason is given in the message.
+	RevertFailed RevertPhase = "Failed"
+)
+
+// RevertStatus represents the progress of a revert to a snapshot.
+type RevertStatus struct {
+	// Snapshot is the name of the snapshot LogicalVolume being merged.
+	Snapshot string `json:"snapshot"`
+
+	// Phase is the phase of the revert.
+	Phase RevertPhase `json:"phase"`
+
+	// Message is the reason of the failure.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is the time when the merge was started.
+	StartTime metav1.Time `json:"startTime"`
+
+	// CompletionTime is the time when the merge was completed.
+	//+kubebuilder:validation:Optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// VerificationStatus represents the result of the check of the LVM logical volume on the node.
+type VerificationStatus struct {
+	// RequestedAt is the value of the verify-requested-at annotation when the check was done.
+	RequestedAt string `json:"requestedAt"`
+
+	// Found is true if the LVM logical volume exists on the node.
+	Found bool `json:"found"`
+
+	// SizeBytes is the size of the LVM logical volume.
+	//+kubebuilder:validation:Optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+}
+
+// FsckStatus represents the result of a filesystem check on the node.
+type FsckStatus struct {
+	// Policy is the policy the check was done with.
+	Policy string `json:"policy"`
+
+	// Result is one of Clean, Repaired, Corrupted and Failed.
+	Result string `json:"result"`
+
+	// Message is the summary of the output of the check.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+
+	// CheckedAt is the time when the check was done.
+	CheckedAt metav1.Time `json:"checkedAt"`
+}
+
+// FilesystemUsage represents the usage of a filesystem.
+type FilesystemUsage struct {
+	// Total is the size of the filesystem.
+	Total resource.Quantity `json:"total"`
+
+	// Used is the size used in the filesystem.
+	Used resource.Quantity `json:"used"`
+
+	// Available is the size available to unprivileged users.
+	Available resource.Quantity `json:"available"`
+
+	// ObservedAt is the time when the usage was observed.
+	ObservedAt metav1.Time `json:"observedAt"`
 }
 
 //+kubebuilder:object:root=true
@@ -63,12 +237,28 @@ func (lv *LogicalVolume) IsCompatibleWith(lv2 *LogicalVolume) bool {
 	if lv.Spec.Source != lv2.Spec.Source {
 		return false
 	}
+	if lv.Spec.CopySource != lv2.Spec.CopySource {
+		return false
+	}
+	if !ptr.Equal(lv.Spec.Seed, lv2.Spec.Seed) {
+		return false
+	}
 	if lv.Spec.Size.Cmp(lv2.Spec.Size) != 0 {
 		return false
 	}
 	return true
 }
 
+// IsReverting returns true if the snapshot given by spec.revertSnapshot has not been merged yet.
+// The volume must not be published while it is reverting.
+func (lv *LogicalVolume) IsReverting() bool {
+	if lv.Spec.RevertSnapshot == "" {
+		return false
+	}
+	r := lv.Status.Revert
+	return r == nil || r.Snapshot != lv.Spec.RevertSnapshot || r.Phase != RevertCompleted
+}
+
 //+kubebuilder:object:root=true
 
 // LogicalVolumeList contains a list of LogicalVolume
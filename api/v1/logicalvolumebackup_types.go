@@ -0,0 +1,128 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupLocation is where a backup is stored. Exactly one of path and s3 must be set.
+type BackupLocation struct {
+	// Path is the absolute path of a directory on the node to store the backup.
+	// It must be in the directory given to topolvm-node by --backup-base-dir, e.g. a mount point of NFS.
+	//+kubebuilder:validation:Optional
+	Path string `json:"path,omitempty"`
+
+	// S3 is an S3-compatible object storage to store the backup.
+	//+kubebuilder:validation:Optional
+	S3 *S3Location `json:"s3,omitempty"`
+}
+
+// S3Location is a location in an S3-compatible object storage.
+type S3Location struct {
+	// Endpoint is the URL of the object storage without a path, e.g. "https://s3.us-east-1.amazonaws.com".
+	// The objects are addressed in the path style.
+	Endpoint string `json:"endpoint"`
+
+	// Region is the region of the bucket. The default is us-east-1.
+	//+kubebuilder:validation:Optional
+	Region string `json:"region,omitempty"`
+
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+
+	// Prefix is the prefix of the keys of the objects of the backup.
+	//+kubebuilder:validation:Optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretRef is the Secret having AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	// It must be in the namespace given to topolvm-node by --backup-credentials-namespace.
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+}
+
+// BackupPhase is the phase of LogicalVolumeBackup and LogicalVolumeRestore.
+type BackupPhase string
+
+const (
+	// BackupRunning means the data is being transferred.
+	BackupRunning BackupPhase = "Running"
+	// BackupCompleted means the data has been transferred.
+	BackupCompleted BackupPhase = "Completed"
+	// BackupFailed means the transfer has failed. It is not retried.
+	BackupFailed BackupPhase = "Failed"
+)
+
+// LogicalVolumeBackupSpec defines the desired state of LogicalVolumeBackup
+type LogicalVolumeBackupSpec struct {
+	// LogicalVolumeName is the name of the LogicalVolume to back up.
+	// It must be a snapshot because the data of a volume in use may be changed during the backup.
+	LogicalVolumeName string `json:"logicalVolumeName"`
+
+	// Location is where the backup is stored.
+	Location BackupLocation `json:"location"`
+}
+
+// LogicalVolumeBackupStatus defines the observed state of LogicalVolumeBackup
+type LogicalVolumeBackupStatus struct {
+	// Phase is the phase of the backup.
+	//+kubebuilder:validation:Optional
+	Phase BackupPhase `json:"phase,omitempty"`
+
+	// NodeName is the node of the LogicalVolume which runs the backup.
+	//+kubebuilder:validation:Optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// SizeBytes is the size of the volume.
+	//+kubebuilder:validation:Optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// StoredBytes is the total size of the compressed chunks.
+	//+kubebuilder:validation:Optional
+	StoredBytes int64 `json:"storedBytes,omitempty"`
+
+	// Chunks is the number of the chunks.
+	//+kubebuilder:validation:Optional
+	Chunks int32 `json:"chunks,omitempty"`
+
+	// StartTime is the time when the backup started.
+	//+kubebuilder:validation:Optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time when the backup completed or failed.
+	//+kubebuilder:validation:Optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message describes the error of the backup, if any.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="LogicalVolume",type=string,JSONPath=`.spec.logicalVolumeName`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Stored",type=integer,JSONPath=`.status.storedBytes`
+//+kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+
+// LogicalVolumeBackup is the Schema for the logicalvolumebackups API.
+// It stores the data of a LogicalVolume in a file system or an object storage.
+type LogicalVolumeBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LogicalVolumeBackupSpec   `json:"spec,omitempty"`
+	Status LogicalVolumeBackupStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// LogicalVolumeBackupList contains a list of LogicalVolumeBackup
+type LogicalVolumeBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LogicalVolumeBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LogicalVolumeBackup{}, &LogicalVolumeBackupList{})
+}
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogicalVolumeReplicationSpec defines the desired state of LogicalVolumeReplication
+type LogicalVolumeReplicationSpec struct {
+	// LogicalVolumeName is the name of the LogicalVolume to replicate. It must be on a thin device class.
+	LogicalVolumeName string `json:"logicalVolumeName"`
+
+	// PeerNodeName is the node to which the volume is replicated.
+	PeerNodeName string `json:"peerNodeName"`
+
+	// PeerDeviceClass is the thin device class of the replica on the peer node.
+	// The device class of the LogicalVolume is used if it is omitted.
+	//+kubebuilder:validation:Optional
+	PeerDeviceClass string `json:"peerDeviceClass,omitempty"`
+
+	// Interval is the interval of the replication, e.g. "30m". The default is 5m.
+	//+kubebuilder:validation:Optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Failover repoints the LogicalVolume and the PersistentVolume to the replica on the peer node.
+	// The replication stops once it is set to true.
+	//+kubebuilder:validation:Optional
+	Failover bool `json:"failover,omitempty"`
+}
+
+// LogicalVolumeReplicationPhase is the phase of LogicalVolumeReplication.
+type LogicalVolumeReplicationPhase string
+
+const (
+	// LogicalVolumeReplicationReplicating means the volume is being replicated to the peer node.
+	LogicalVolumeReplicationReplicating LogicalVolumeReplicationPhase = "Replicating"
+	// LogicalVolumeReplicationFailedOver means the volume has been repointed to the replica on the peer node.
+	LogicalVolumeReplicationFailedOver LogicalVolumeReplicationPhase = "FailedOver"
+)
+
+// LogicalVolumeReplicationStatus defines the observed state of LogicalVolumeReplication
+type LogicalVolumeReplicationStatus struct {
+	// Phase is the phase of the replication.
+	//+kubebuilder:validation:Optional
+	Phase LogicalVolumeReplicationPhase `json:"phase,omitempty"`
+
+	// SourceNodeName is the node of the replicated volume.
+	// It is kept after the failover to clean up the source node.
+	//+kubebuilder:validation:Optional
+	SourceNodeName string `json:"sourceNodeName,omitempty"`
+
+	// VolumeID is the volume ID of the replicated volume, which is also the name of the replica.
+	//+kubebuilder:validation:Optional
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// DeviceClass is the device class of the replicated volume on the source node.
+	//+kubebuilder:validation:Optional
+	DeviceClass string `json:"deviceClass,omitempty"`
+
+	// LastSnapshot is the snapshot on the source node which was replicated last.
+	// The next replication sends only the changes since this snapshot.
+	//+kubebuilder:validation:Optional
+	LastSnapshot string `json:"lastSnapshot,omitempty"`
+
+	// LastSyncTime is the time when the last replicated snapshot was taken.
+	//+kubebuilder:validation:Optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// LastSyncBytes is the number of bytes transferred by the last replication.
+	//+kubebuilder:validation:Optional
+	LastSyncBytes int64 `json:"lastSyncBytes,omitempty"`
+
+	// Lag is how far the replica is behind the volume as of the last reconciliation.
+	//+kubebuilder:validation:Optional
+	Lag *metav1.Duration `json:"lag,omitempty"`
+
+	// FailoverTime is the time when the volume was repointed to the replica.
+	//+kubebuilder:validation:Optional
+	FailoverTime *metav1.Time `json:"failoverTime,omitempty"`
+
+	// Message describes the error of the replication or the failover, if any.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="LogicalVolume",type=string,JSONPath=`.spec.logicalVolumeName`
+//+kubebuilder:printcolumn:name="Peer",type=string,JSONPath=`.spec.peerNodeName`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Last Sync",type=date,JSONPath=`.status.lastSyncTime`
+//+kubebuilder:printcolumn:name="Lag",type=string,JSONPath=`.status.lag`
+
+// LogicalVolumeReplication is the Schema for the logicalvolumereplications API.
+// It replicates a thin LogicalVolume to a peer node asynchronously.
+type LogicalVolumeReplication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LogicalVolumeReplicationSpec   `json:"spec,omitempty"`
+	Status LogicalVolumeReplicationStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// LogicalVolumeReplicationList contains a list of LogicalVolumeReplication
+type LogicalVolumeReplicationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LogicalVolumeReplication `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LogicalVolumeReplication{}, &LogicalVolumeReplicationList{})
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LogicalVolumeRestoreSpec defines the desired state of LogicalVolumeRestore
+type LogicalVolumeRestoreSpec struct {
+	// Location is where the backup is stored.
+	Location BackupLocation `json:"location"`
+
+	// NodeName is the node to create the LogicalVolume.
+	NodeName string `json:"nodeName"`
+
+	// DeviceClass is the device class of the LogicalVolume. The default device class is used if it is omitted.
+	//+kubebuilder:validation:Optional
+	DeviceClass string `json:"deviceClass,omitempty"`
+}
+
+// LogicalVolumeRestoreStatus defines the observed state of LogicalVolumeRestore
+type LogicalVolumeRestoreStatus struct {
+	// Phase is the phase of the restore.
+	//+kubebuilder:validation:Optional
+	Phase BackupPhase `json:"phase,omitempty"`
+
+	// LogicalVolumeName is the name of the LogicalVolume created for the restore.
+	// It is the same as the name of the LogicalVolumeRestore.
+	//+kubebuilder:validation:Optional
+	LogicalVolumeName string `json:"logicalVolumeName,omitempty"`
+
+	// VolumeID is the volume ID of the LogicalVolume, which is used as volumeHandle of a PV.
+	//+kubebuilder:validation:Optional
+	VolumeID string `json:"volumeID,omitempty"`
+
+	// SizeBytes is the size of the volume in the backup.
+	//+kubebuilder:validation:Optional
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// StartTime is the time when the restore started.
+	//+kubebuilder:validation:Optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time when the restore completed or failed.
+	//+kubebuilder:validation:Optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message describes the error of the restore, if any.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.spec.nodeName`
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="VolumeID",type=string,JSONPath=`.status.volumeID`
+//+kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+
+// LogicalVolumeRestore is the Schema for the logicalvolumerestores API.
+// It creates a LogicalVolume on a node from a backup taken by LogicalVolumeBackup.
+type LogicalVolumeRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LogicalVolumeRestoreSpec   `json:"spec,omitempty"`
+	Status LogicalVolumeRestoreStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// LogicalVolumeRestoreList contains a list of LogicalVolumeRestore
+type LogicalVolumeRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LogicalVolumeRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LogicalVolumeRestore{}, &LogicalVolumeRestoreList{})
+}
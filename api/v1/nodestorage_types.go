@@ -0,0 +1,170 @@
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StorageHealth represents the health of a device-class.
+type StorageHealth string
+
+const (
+	// StorageHealthy means the volume group and the thin pool of the device-class are healthy.
+	StorageHealthy StorageHealth = "Healthy"
+
+	// StorageUnhealthy means some physical volumes are missing or the thin pool is not healthy.
+	StorageUnhealthy StorageHealth = "Unhealthy"
+)
+
+// PhysicalVolumeStatus defines the observed state of a physical volume.
+type PhysicalVolumeStatus struct {
+	// Name is the device path of the physical volume.
+	Name string            `json:"name"`
+	Size resource.Quantity `json:"size"`
+	Free resource.Quantity `json:"free"`
+
+	// Missing is true if the device of the physical volume is missing.
+	//+kubebuilder:validation:Optional
+	Missing bool `json:"missing,omitempty"`
+}
+
+// ThinPoolStatus defines the observed state of a thin pool.
+type ThinPoolStatus struct {
+	Size resource.Quantity `json:"size"`
+
+	// DataPercent is the percentage of the data space used in the thin pool, e.g. "12.34".
+	DataPercent string `json:"dataPercent"`
+
+	// MetadataPercent is the percentage of the metadata space used in the thin pool, e.g. "12.34".
+	MetadataPercent string `json:"metadataPercent"`
+}
+
+// VolumeWipeStatus defines the progress of wiping a removed volume.
+type VolumeWipeStatus struct {
+	// Name is the volume ID of the removed volume.
+	Name  string            `json:"name"`
+	Size  resource.Quantity `json:"size"`
+	Wiped resource.Quantity `json:"wiped"`
+}
+
+// TrashedVolumeStatus defines a removed volume kept in the trash until it expires.
+type TrashedVolumeStatus struct {
+	// Name is the volume ID of the removed volume, which can be undeleted by LogicalVolume spec.undelete.
+	Name      string            `json:"name"`
+	Size      resource.Quantity `json:"size"`
+	TrashedAt metav1.Time       `json:"trashedAt"`
+	ExpiresAt metav1.Time       `json:"expiresAt"`
+}
+
+// DeviceClassStorageStatus defines the observed state of a device-class.
+type DeviceClassStorageStatus struct {
+	// Name is the name of the device-class. It is empty for the default device-class if not named.
+	//+kubebuilder:validation:Optional
+	Name string `json:"name"`
+
+	// Default is true if the device-class is the default one.
+	//+kubebuilder:validation:Optional
+	Default bool `json:"default,omitempty"`
+
+	// VolumeGroup is the name of the volume group of the device-class.
+	VolumeGroup string `json:"volumeGroup"`
+
+	// VolumeGroupUUID is the UUID of the volume group, which identifies the disks when the node re-joins the cluster.
+	//+kubebuilder:validation:Optional
+	VolumeGroupUUID string `json:"volumeGroupUUID,omitempty"`
+
+	// FsType is the default filesystem of the volumes in the device-class.
+	//+kubebuilder:validation:Optional
+	FsType string `json:"fsType,omitempty"`
+
+	// MkfsOptions are the default extra arguments of mkfs for each filesystem.
+	//+kubebuilder:validation:Optional
+	MkfsOptions map[string][]string `json:"mkfsOptions,omitempty"`
+
+	// PhysicalVolumes are the physical volumes of the volume group.
+	//+kubebuilder:validation:Optional
+	PhysicalVolumes []PhysicalVolumeStatus `json:"physicalVolumes,omitempty"`
+
+	// Size is the size of the volume group.
+	Size resource.Quantity `json:"size"`
+
+	// Free is the capacity available for new volumes.
+	// For thin device-classes, it takes the overprovision ratio into account.
+	// This is the same value as the capacity annotation of the Node.
+	Free resource.Quantity `json:"free"`
+
+	// ThinPool is the thin pool of the device-class, which is set only for thin device-classes.
+	//+kubebuilder:validation:Optional
+	ThinPool *ThinPoolStatus `json:"thinPool,omitempty"`
+
+	// PendingFree is the capacity of the removed volumes being wiped, which becomes free after the wipe.
+	//+kubebuilder:validation:Optional
+	PendingFree *resource.Quantity `json:"pendingFree,omitempty"`
+
+	// Wipes are the progress of wiping the removed volumes.
+	//+kubebuilder:validation:Optional
+	Wipes []VolumeWipeStatus `json:"wipes,omitempty"`
+
+	// Trash is the capacity of the removed volumes kept in the trash, which becomes free after they are purged.
+	//+kubebuilder:validation:Optional
+	Trash *resource.Quantity `json:"trash,omitempty"`
+
+	// TrashedVolumes are the removed volumes kept in the trash.
+	//+kubebuilder:validation:Optional
+	TrashedVolumes []TrashedVolumeStatus `json:"trashedVolumes,omitempty"`
+
+	//+kubebuilder:validation:Enum=Healthy;Unhealthy
+	Health StorageHealth `json:"health"`
+
+	// Message describes why the device-class is unhealthy.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// NodeStorageStatus defines the observed state of NodeStorage
+type NodeStorageStatus struct {
+	//+kubebuilder:validation:Optional
+	DeviceClasses []DeviceClassStorageStatus `json:"deviceClasses,omitempty"`
+
+	// LvcreateOptionClasses are the names of the lvcreate-option-classes configured on the node.
+	//+kubebuilder:validation:Optional
+	LvcreateOptionClasses []string `json:"lvcreateOptionClasses,omitempty"`
+}
+
+// DeviceClass returns the status of the named device-class.
+// The default device-class is returned for an empty name.
+func (s *NodeStorageStatus) DeviceClass(name string) *DeviceClassStorageStatus {
+	for i := range s.DeviceClasses {
+		dc := &s.DeviceClasses[i]
+		if dc.Name == name || (name == "" && dc.Default) {
+			return dc
+		}
+	}
+	return nil
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// NodeStorage is the Schema for the nodestorages API.
+// It describes the storage managed by TopoLVM on the node of the same name.
+type NodeStorage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Status NodeStorageStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NodeStorageList contains a list of NodeStorage
+type NodeStorageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeStorage `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NodeStorage{}, &NodeStorageList{})
+}
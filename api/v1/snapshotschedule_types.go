@@ -0,0 +1,83 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotScheduleSpec defines the desired state of SnapshotSchedule
+type SnapshotScheduleSpec struct {
+	// Schedule is a cron expression in UTC, e.g. "0 3 * * *" or "@daily".
+	Schedule string `json:"schedule"`
+
+	// PVCSelector selects the PVCs in the namespace to take snapshots.
+	// Only the PVCs provisioned by TopoLVM are selected.
+	PVCSelector metav1.LabelSelector `json:"pvcSelector"`
+
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the snapshots.
+	// The default VolumeSnapshotClass is used if it is omitted.
+	//+kubebuilder:validation:Optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Retention is the policy to prune the snapshots taken by this schedule.
+	//+kubebuilder:validation:Optional
+	Retention SnapshotRetention `json:"retention,omitempty"`
+
+	// MaxDataPercent is the ceiling of the data usage of the thin pool.
+	// A snapshot is skipped if the thin pool of the PVC uses more than this percentage.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=0
+	//+kubebuilder:validation:Maximum=100
+	MaxDataPercent *int32 `json:"maxDataPercent,omitempty"`
+}
+
+// SnapshotRetention defines how long the snapshots are kept.
+// Both limits are applied per PVC. The snapshots are kept forever if both are omitted.
+type SnapshotRetention struct {
+	// MaxCount is the number of the latest snapshots kept for each PVC.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Minimum=1
+	MaxCount *int32 `json:"maxCount,omitempty"`
+
+	// MaxAge is the duration to keep the snapshots, e.g. "168h".
+	//+kubebuilder:validation:Optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// SnapshotScheduleStatus defines the observed state of SnapshotSchedule
+type SnapshotScheduleStatus struct {
+	// LastScheduleTime is the last time the snapshots were scheduled.
+	//+kubebuilder:validation:Optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Message describes the error of the schedule, if any.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+//+kubebuilder:printcolumn:name="Last Schedule",type=date,JSONPath=`.status.lastScheduleTime`
+
+// SnapshotSchedule is the Schema for the snapshotschedules API.
+// It takes VolumeSnapshots of the selected PVCs periodically and prunes the old ones.
+type SnapshotSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SnapshotScheduleSpec   `json:"spec,omitempty"`
+	Status SnapshotScheduleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SnapshotScheduleList contains a list of SnapshotSchedule
+type SnapshotScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SnapshotSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SnapshotSchedule{}, &SnapshotScheduleList{})
+}
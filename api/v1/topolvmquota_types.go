@@ -0,0 +1,47 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TopoLVMQuotaSpec defines the desired state of TopoLVMQuota
+type TopoLVMQuotaSpec struct {
+	// Hard is the total size of the logical volumes allowed in the namespace per device-class.
+	// The key is the device-class name, or "00default" for the default device-class.
+	Hard corev1.ResourceList `json:"hard"`
+}
+
+// TopoLVMQuotaStatus defines the observed state of TopoLVMQuota
+type TopoLVMQuotaStatus struct {
+	// Used is the total size of the logical volumes in the namespace per device-class.
+	//+kubebuilder:validation:Optional
+	Used corev1.ResourceList `json:"used,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:path=topolvmquotas
+
+// TopoLVMQuota is the Schema for the topolvmquotas API.
+// It limits the total size of the logical volumes created for PVCs and VolumeSnapshots in its namespace.
+type TopoLVMQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TopoLVMQuotaSpec   `json:"spec,omitempty"`
+	Status TopoLVMQuotaStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TopoLVMQuotaList contains a list of TopoLVMQuota
+type TopoLVMQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TopoLVMQuota `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TopoLVMQuota{}, &TopoLVMQuotaList{})
+}
@@ -0,0 +1,94 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SeedFormat is the format of the data of a VolumeSeed.
+type SeedFormat string
+
+const (
+	// SeedFormatRaw means the data is the image of the whole volume, which is written as is.
+	SeedFormatRaw SeedFormat = "Raw"
+	// SeedFormatTar means the data is a tarball extracted into a new filesystem on the volume.
+	SeedFormatTar SeedFormat = "Tar"
+)
+
+// VolumeSeedSpec defines the desired state of VolumeSeed.
+// Exactly one of http and image must be given.
+type VolumeSeedSpec struct {
+	// HTTP is a file downloaded by HTTP GET.
+	//+kubebuilder:validation:Optional
+	HTTP *HTTPSeedSource `json:"http,omitempty"`
+
+	// Image is an OCI image whose layers are extracted into a new filesystem on the volume.
+	//+kubebuilder:validation:Optional
+	Image *ImageSeedSource `json:"image,omitempty"`
+
+	// Format is the format of the file given by http. The default is Raw.
+	// The file may be compressed by gzip in either format. It is ignored for image, which is always extracted.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=Raw;Tar
+	Format SeedFormat `json:"format,omitempty"`
+
+	// FsType is the filesystem created on the volume to extract a tarball or an image into.
+	// The default is the fs-type of the device-class, or ext4 if it is empty.
+	// It is also set to the PersistentVolumes, so it must be the filesystem in a raw image of a Filesystem volume.
+	//+kubebuilder:validation:Optional
+	//+kubebuilder:validation:Enum=ext4;xfs;btrfs
+	FsType string `json:"fsType,omitempty"`
+
+	// Cache keeps the data in a LogicalVolume on each node and device class where it is populated,
+	// and creates the later volumes by copying it. The copy is a thin snapshot in a thin device class.
+	//+kubebuilder:validation:Optional
+	Cache bool `json:"cache,omitempty"`
+}
+
+// HTTPSeedSource is a file downloaded by HTTP GET.
+type HTTPSeedSource struct {
+	// URL is the http or https URL of the file.
+	URL string `json:"url"`
+
+	// SHA256 is the hex-encoded SHA-256 digest of the file. The population fails if it does not match.
+	//+kubebuilder:validation:Optional
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// ImageSeedSource is an OCI image pulled from a registry.
+type ImageSeedSource struct {
+	// Reference is the reference of the image, e.g. "ghcr.io/example/model:v1" or "registry.example.com/data@sha256:...".
+	Reference string `json:"reference"`
+
+	// PullSecrets are the Secrets of type kubernetes.io/dockerconfigjson in the namespace of TopoLVM
+	// to pull the image with. The image is pulled anonymously if none of them has the credentials of the registry.
+	//+kubebuilder:validation:Optional
+	PullSecrets []corev1.LocalObjectReference `json:"pullSecrets,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Format",type=string,JSONPath=`.spec.format`
+//+kubebuilder:printcolumn:name="Cache",type=boolean,JSONPath=`.spec.cache`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// VolumeSeed is the Schema for the volumeseeds API.
+// It is referred by dataSourceRef of PVCs to create them with the data downloaded from an URL or an image.
+type VolumeSeed struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec VolumeSeedSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VolumeSeedList contains a list of VolumeSeed
+type VolumeSeedList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VolumeSeed `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VolumeSeed{}, &VolumeSeedList{})
+}
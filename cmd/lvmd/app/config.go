@@ -21,6 +21,12 @@ type Config struct {
 	// LVMCommandPrefix is a list of strings necessary to run a LVM command.
 	// For example, if it's X, `/sbin/lvm lvcreate ...` will be run as `X /sbin/lvm lvcreate ...`.
 	LVMCommandPrefix []string `json:"lvm-command-prefix"`
+	// ReplicationAddress is the TCP address to serve the replication API for peer nodes, e.g. ":9445".
+	// The API is disabled if it is empty.
+	ReplicationAddress string `json:"replication-address"`
+	// ReplicationTLS is the mutual TLS configuration of the replication API.
+	// It is required to serve the replication API and to replicate volumes to the peers.
+	ReplicationTLS *lvmdTypes.ReplicationTLS `json:"replication-tls"`
 }
 
 var config = &Config{
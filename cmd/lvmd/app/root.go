@@ -24,6 +24,7 @@ import (
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -112,19 +113,56 @@ func subMain(parentCtx context.Context) error {
 	if err != nil {
 		return err
 	}
+	var replicationClientCreds, replicationServerCreds credentials.TransportCredentials
+	if config.ReplicationTLS != nil {
+		replicationClientCreds, err = lvmd.ReplicationClientCredentials(config.ReplicationTLS)
+		if err != nil {
+			return err
+		}
+		replicationServerCreds, err = lvmd.ReplicationServerCredentials(config.ReplicationTLS)
+		if err != nil {
+			return err
+		}
+	} else if config.ReplicationAddress != "" {
+		return errors.New("replication-tls is required to serve the replication API")
+	}
+
 	grpcServer := grpc.NewServer()
 	dcm := lvmd.NewDeviceClassManager(config.DeviceClasses)
 	ocm := lvmd.NewLvcreateOptionClassManager(config.LvcreateOptionClasses)
-	vgService, notifier := lvmd.NewVGService(dcm)
+	wiper := lvmd.NewWiper(dcm)
+	vgService, notifier := lvmd.NewVGService(dcm, ocm, wiper)
 	proto.RegisterVGServiceServer(grpcServer, vgService)
-	proto.RegisterLVServiceServer(grpcServer, lvmd.NewLVService(dcm, ocm, notifier))
+	warmPool := lvmd.NewWarmPool(dcm, notifier)
+	recycleBin := lvmd.NewRecycleBin(dcm, wiper)
+	proto.RegisterLVServiceServer(grpcServer, lvmd.NewLVService(dcm, ocm, warmPool, wiper, recycleBin, notifier, replicationClientCreds))
 	grpc_health_v1.RegisterHealthServer(grpcServer, lvmd.NewHealthService())
 
+	// The replication API is served on TCP separately with mutual TLS because peer nodes call it.
+	var replicationServer *grpc.Server
+	if config.ReplicationAddress != "" {
+		replicationLis, err := net.Listen("tcp", config.ReplicationAddress)
+		if err != nil {
+			return err
+		}
+		replicationServer = grpc.NewServer(grpc.Creds(replicationServerCreds))
+		proto.RegisterReplicationServiceServer(replicationServer, lvmd.NewReplicationService(dcm, notifier))
+		go func() {
+			if err := replicationServer.Serve(replicationLis); err != nil {
+				logger.Error(err, "replication server error")
+			}
+		}()
+	}
+
 	ctx, stop := signal.NotifyContext(parentCtx, os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
 	wg, pprofServer, metricsServer := startMetricsAndProfilingServers(logger)
 
+	go warmPool.Run(ctx)
+	go wiper.Run(ctx, notifier)
+	go recycleBin.Run(ctx, notifier)
+
 	go func() {
 		ticker := time.NewTicker(10 * time.Minute)
 		for {
@@ -141,6 +179,9 @@ func subMain(parentCtx context.Context) error {
 						logger.Error(err, "failed to shutdown metrics server")
 					}
 				}
+				if replicationServer != nil {
+					replicationServer.GracefulStop()
+				}
 				grpcServer.GracefulStop()
 				wg.Wait()
 				return
@@ -9,6 +9,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/topolvm/topolvm"
 	"github.com/topolvm/topolvm/pkg/driver"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -46,9 +47,14 @@ var config struct {
 	leaderElectionRenewDeadline time.Duration
 	leaderElectionRetryPeriod   time.Duration
 	skipNodeFinalize            bool
+	orphanVolumesOnNodeDeletion bool
+	enablePVReattach            bool
 	zapOpts                     zap.Options
 	controllerServerSettings    driver.ControllerServerSettings
 	profilingBindAddress        string
+	enableDRA                   bool
+	replicationPort             int
+	replicationTLS              lvmdTypes.ReplicationTLS
 }
 
 var rootCmd = &cobra.Command{
@@ -90,7 +96,15 @@ func init() {
 	fs.DurationVar(&config.leaderElectionRenewDeadline, "leader-election-renew-deadline", 10*time.Second, "Duration that the acting controlplane will retry refreshing leadership before giving up. This is measured against time of last observed ack.")
 	fs.DurationVar(&config.leaderElectionRetryPeriod, "leader-election-retry-period", 2*time.Second, "Duration the LeaderElector clients should wait between tries of actions.")
 	fs.BoolVar(&config.skipNodeFinalize, "skip-node-finalize", false, "skips automatic cleanup of PhysicalVolumeClaims when a Node is deleted")
+	fs.BoolVar(&config.orphanVolumesOnNodeDeletion, "orphan-volumes-on-node-deletion", false, "keeps the volumes of a deleted Node orphaned and reattaches them when a Node with the same volume groups joins")
+	fs.BoolVar(&config.enablePVReattach, "enable-pv-reattach", false, "binds released PVs to the PVCs naming them by the topolvm.io/reattach-volume annotation in the same namespace, or in the namespace granted by the PV annotation")
 	fs.StringVar(&config.profilingBindAddress, "profiling-bind-address", "", "Bind pprof profiling to the given network address. If empty, profiling is disabled.")
+	fs.BoolVar(&config.enableDRA, "enable-dra", false, "Creates LogicalVolumes for ResourceClaims allocated by Dynamic Resource Allocation")
+	fs.IntVar(&config.replicationPort, "replication-port", 0, "The port of the replication API of lvmd on the nodes. If non-zero, the CSI SnapshotMetadata service is served by calling it.")
+	fs.StringVar(&config.replicationTLS.CertFile, "replication-tls-cert-file", "", "The client certificate to call the replication API of lvmd on the nodes. Required with --replication-port.")
+	fs.StringVar(&config.replicationTLS.KeyFile, "replication-tls-key-file", "", "The private key of the client certificate to call the replication API. Required with --replication-port.")
+	fs.StringVar(&config.replicationTLS.CAFile, "replication-tls-ca-file", "", "The CA certificate to verify the certificates of the replication API. Required with --replication-port.")
+	fs.StringVar(&config.replicationTLS.PeerName, "replication-tls-peer-name", "", "The DNS name that the certificates of the replication API must have. Required with --replication-port.")
 
 	driver.QuantityVar(fs, &config.controllerServerSettings.Block,
 		"minimum-allocation-block",
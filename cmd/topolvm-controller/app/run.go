@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
 	"github.com/topolvm/topolvm"
 	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
 	topolvmv1 "github.com/topolvm/topolvm/api/v1"
@@ -15,6 +16,7 @@ import (
 	"github.com/topolvm/topolvm/internal/runners"
 	"github.com/topolvm/topolvm/pkg/controller"
 	"github.com/topolvm/topolvm/pkg/driver"
+	"github.com/topolvm/topolvm/pkg/lvmd"
 	"google.golang.org/grpc"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -41,6 +43,7 @@ func init() {
 
 	utilruntime.Must(topolvmv1.AddToScheme(scheme))
 	utilruntime.Must(topolvmlegacyv1.AddToScheme(scheme))
+	utilruntime.Must(snapapi.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -100,13 +103,17 @@ func subMain() error {
 		dec := admission.NewDecoder(scheme)
 		wh := mgr.GetWebhookServer()
 		wh.Register("/pod/mutate", hook.PodMutator(client, apiReader, dec))
+		wh.Register("/storageclass/validate", hook.StorageClassValidator(client, dec))
+		wh.Register("/quota/validate", hook.QuotaValidator(client, apiReader, dec))
+		wh.Register("/deviceclasspolicy/validate", hook.DeviceClassPolicyValidator(client, apiReader, dec))
+		wh.Register("/capacity/validate", hook.CapacityValidator(client, apiReader, dec))
 		if err := mgr.AddReadyzCheck("webhook", wh.StartedChecker()); err != nil {
 			return err
 		}
 	}
 
 	// register controllers
-	if err := controller.SetupNodeReconciler(mgr, client, config.skipNodeFinalize); err != nil {
+	if err := controller.SetupNodeReconciler(mgr, client, config.skipNodeFinalize, config.orphanVolumesOnNodeDeletion); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Node")
 		return err
 	}
@@ -116,6 +123,55 @@ func subMain() error {
 		return err
 	}
 
+	if err := controller.SetupTopoLVMQuotaReconciler(mgr, client); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "TopoLVMQuota")
+		return err
+	}
+
+	if err := controller.SetupAutoResizeReconciler(mgr, client); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AutoResize")
+		return err
+	}
+
+	if err := controller.SetupSnapshotScheduleReconciler(mgr, client); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SnapshotSchedule")
+		return err
+	}
+
+	if err := controller.SetupSnapshotRevertReconciler(mgr, client, apiReader); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SnapshotRevert")
+		return err
+	}
+
+	if config.enablePVReattach {
+		if err := controller.SetupPersistentVolumeReattachReconciler(mgr, client); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "PersistentVolumeReattach")
+			return err
+		}
+	}
+
+	if err := controller.SetupLogicalVolumeFailoverReconciler(mgr, client); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LogicalVolumeFailover")
+		return err
+	}
+
+	if err := controller.SetupVolumeSeedReconciler(mgr, client); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VolumeSeed")
+		return err
+	}
+
+	if err := controller.SetupVolumeSeedPopulatorReconciler(mgr, client); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "VolumeSeedPopulator")
+		return err
+	}
+
+	if config.enableDRA {
+		if err := controller.SetupResourceClaimReconciler(mgr, client); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ResourceClaim")
+			return err
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	// Add health checker to manager
@@ -134,12 +190,21 @@ func subMain() error {
 
 	// Add gRPC server to manager.
 	grpcServer := grpc.NewServer()
-	csi.RegisterIdentityServer(grpcServer, driver.NewIdentityServer(checker.Ready))
+	csi.RegisterIdentityServer(grpcServer, driver.NewIdentityServer(checker.Ready, config.replicationPort != 0))
 	controllerSever, err := driver.NewControllerServer(mgr, config.controllerServerSettings)
 	if err != nil {
 		return err
 	}
 	csi.RegisterControllerServer(grpcServer, controllerSever)
+	// the SnapshotMetadata service relays the requests to lvmd on the node of each snapshot.
+	if config.replicationPort != 0 {
+		replicationCreds, err := lvmd.NewReplicationClientCredentials(&config.replicationTLS)
+		if err != nil {
+			return err
+		}
+		dialNode := controller.NewNodeReplicationDialer(client, config.replicationPort, replicationCreds)
+		csi.RegisterSnapshotMetadataServer(grpcServer, driver.NewSnapshotMetadataServer(mgr, dialNode))
+	}
 
 	// gRPC service itself should run even when the manager is *not* a leader
 	// because CSI sidecar containers choose a leader.
@@ -10,20 +10,30 @@ import (
 	"github.com/spf13/viper"
 	"github.com/topolvm/topolvm"
 	lvmd "github.com/topolvm/topolvm/cmd/lvmd/app"
+	"github.com/topolvm/topolvm/internal/runners"
+	"github.com/topolvm/topolvm/pkg/controller"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 )
 
 var config struct {
-	csiSocket            string
-	lvmdSocket           string
-	metricsAddr          string
-	secureMetricsServer  bool
-	zapOpts              zap.Options
-	embedLvmd            bool
-	lvmPath              string
-	lvmd                 lvmd.Config
-	profilingBindAddress string
+	csiSocket                string
+	lvmdSocket               string
+	metricsAddr              string
+	secureMetricsServer      bool
+	zapOpts                  zap.Options
+	embedLvmd                bool
+	lvmPath                  string
+	lvmd                     lvmd.Config
+	profilingBindAddress     string
+	enableDRA                bool
+	draPlugin                runners.DRAKubeletPluginConfig
+	replicationPort          int
+	replicationTLS           lvmdTypes.ReplicationTLS
+	backup                   controller.BackupConfig
+	seedAllowedNetworks      []string
+	seedPullSecretsNamespace string
 }
 
 var rootCmd = &cobra.Command{
@@ -63,6 +73,21 @@ func init() {
 	fs.StringVar(&config.lvmPath, "lvm-path", "", "lvm command path on the host OS. This is deprecated and users should use lvm-command-prefix setting instead.")
 	fs.StringVar(&cfgFilePath, "config", filepath.Join("/etc", "topolvm", "lvmd.yaml"), "config file")
 	fs.StringVar(&config.profilingBindAddress, "profiling-bind-address", "", "Bind pprof profiling to the given network address. If empty, profiling is disabled.")
+	fs.BoolVar(&config.enableDRA, "enable-dra", false, "Publishes a ResourceSlice for the node and serves the DRA kubelet plugin to allocate volumes with Dynamic Resource Allocation")
+	fs.StringVar(&config.draPlugin.PluginDir, "dra-plugin-dir", "/var/lib/kubelet/plugins/"+topolvm.GetPluginName()+"/dra", "The directory where the socket of the DRA kubelet plugin is created")
+	fs.StringVar(&config.draPlugin.RegistrarDir, "dra-registrar-dir", "/var/lib/kubelet/plugins_registry", "The directory where kubelet watches the registration sockets of plugins")
+	fs.StringVar(&config.draPlugin.CDIDir, "cdi-dir", "/var/run/cdi", "The directory where the container runtime reads CDI specs")
+	fs.IntVar(&config.replicationPort, "replication-port", 0, "The port of the replication API of lvmd on the peer nodes. If zero, LogicalVolumeReplications are not handled and volumes are not copied from other nodes.")
+	fs.StringVar(&config.replicationTLS.CertFile, "replication-tls-cert-file", "", "The client certificate to call the replication API of lvmd on the peer nodes. Required with --replication-port.")
+	fs.StringVar(&config.replicationTLS.KeyFile, "replication-tls-key-file", "", "The private key of the client certificate to call the replication API. Required with --replication-port.")
+	fs.StringVar(&config.replicationTLS.CAFile, "replication-tls-ca-file", "", "The CA certificate to verify the certificates of the replication API. Required with --replication-port.")
+	fs.StringVar(&config.replicationTLS.PeerName, "replication-tls-peer-name", "", "The DNS name that the certificates of the replication API must have. Required with --replication-port.")
+
+	fs.StringVar(&config.backup.BaseDir, "backup-base-dir", "", "The directory in which the paths of LogicalVolumeBackups and LogicalVolumeRestores must be. If empty, the locations in paths are rejected.")
+	fs.StringVar(&config.backup.CredentialsNamespace, "backup-credentials-namespace", "", "The namespace of the Secrets of S3 credentials for LogicalVolumeBackups and LogicalVolumeRestores. If empty, the locations in S3 are rejected.")
+
+	fs.StringSliceVar(&config.seedAllowedNetworks, "seed-allowed-networks", nil, "The networks in CIDR notation that the data of VolumeSeeds may be downloaded from even if they are loopback, link-local or private ones.")
+	fs.StringVar(&config.seedPullSecretsNamespace, "seed-pull-secrets-namespace", "", "The namespace of the pull secrets of the images of VolumeSeeds. If empty, the VolumeSeeds with pull secrets are rejected.")
 
 	_ = viper.BindEnv("nodename", "NODE_NAME")
 	_ = viper.BindPFlag("nodename", fs.Lookup("nodename"))
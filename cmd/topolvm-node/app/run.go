@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
 	"os/signal"
 	"syscall"
@@ -21,12 +22,14 @@ import (
 	"github.com/topolvm/topolvm/pkg/lvmd"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -85,6 +88,7 @@ func subMain(ctx context.Context) error {
 
 	var lvService proto.LVServiceClient
 	var vgService proto.VGServiceClient
+	var replicationService proto.ReplicationServiceServer
 	var health grpc_health_v1.HealthClient
 
 	lvmd.SetLVMPath(config.lvmPath)
@@ -101,11 +105,18 @@ func subMain(ctx context.Context) error {
 			lvmd.SetLVMCommandPrefix(config.lvmd.LVMCommandPrefix)
 		}
 
-		lvService, vgService = lvmd.NewEmbeddedServiceClients(
+		if config.lvmd.ReplicationAddress != "" && config.lvmd.ReplicationTLS == nil {
+			return errors.New("replication-tls is required to serve the replication API")
+		}
+		lvService, vgService, replicationService, err = lvmd.NewEmbeddedServices(
 			ctx,
 			config.lvmd.DeviceClasses,
 			config.lvmd.LvcreateOptionClasses,
+			config.lvmd.ReplicationTLS,
 		)
+		if err != nil {
+			return err
+		}
 	} else {
 		conn, err := grpc.NewClient(
 			"unix:"+config.lvmdSocket,
@@ -119,11 +130,49 @@ func subMain(ctx context.Context) error {
 		health = grpc_health_v1.NewHealthClient(conn)
 	}
 
+	var replicationCreds credentials.TransportCredentials
+	if config.replicationPort != 0 {
+		replicationCreds, err = lvmd.NewReplicationClientCredentials(&config.replicationTLS)
+		if err != nil {
+			return err
+		}
+	}
+
+	seedConfig := controller.SeedConfig{PullSecretsNamespace: config.seedPullSecretsNamespace}
+	for _, network := range config.seedAllowedNetworks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return fmt.Errorf("invalid --seed-allowed-networks: %w", err)
+		}
+		seedConfig.AllowedNetworks = append(seedConfig.AllowedNetworks, prefix)
+	}
+
 	if err := controller.SetupLogicalVolumeReconcilerWithServices(
-		mgr, client, nodename, vgService, lvService); err != nil {
+		mgr, client, apiReader, nodename, vgService, lvService, config.replicationPort, replicationCreds, seedConfig); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "LogicalVolume")
 		return err
 	}
+	if err := controller.SetupLogicalVolumeBackupReconciler(
+		mgr, client, apiReader, nodename, vgService, lvService, config.backup); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LogicalVolumeBackup")
+		return err
+	}
+	if err := controller.SetupLogicalVolumeRestoreReconciler(
+		mgr, client, apiReader, nodename, vgService, config.backup); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LogicalVolumeRestore")
+		return err
+	}
+	if config.replicationPort != 0 {
+		if err := controller.SetupLogicalVolumeReplicationReconciler(
+			mgr, client, nodename, lvService, config.replicationPort, replicationCreds); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LogicalVolumeReplication")
+			return err
+		}
+		if err := controller.SetupLogicalVolumeCopyReconciler(mgr, client, nodename, lvService); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "LogicalVolumeCopy")
+			return err
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	// Add health checker to manager
@@ -139,9 +188,25 @@ func subMain(ctx context.Context) error {
 		return err
 	}
 
+	if config.enableDRA {
+		if err := mgr.Add(runners.NewResourceSlicePublisher(vgService, client, nodename)); err != nil {
+			return err
+		}
+		kubeClient, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(config.draPlugin.PluginDir, 0755); err != nil {
+			return err
+		}
+		if err := mgr.Add(runners.NewDRAKubeletPlugin(vgService, client, kubeClient, nodename, config.draPlugin)); err != nil {
+			return err
+		}
+	}
+
 	// Add gRPC server to manager.
 	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(ErrorLoggingInterceptor))
-	csi.RegisterIdentityServer(grpcServer, driver.NewIdentityServer(checker.Ready))
+	csi.RegisterIdentityServer(grpcServer, driver.NewIdentityServer(checker.Ready, false))
 	nodeServer, err := driver.NewNodeServer(nodename, vgService, lvService, mgr) // adjusted signature
 	if err != nil {
 		return err
@@ -152,6 +217,19 @@ func subMain(ctx context.Context) error {
 		return err
 	}
 
+	// Serve the replication API of the embedded lvmd for peer nodes with mutual TLS.
+	if config.embedLvmd && config.lvmd.ReplicationAddress != "" {
+		creds, err := lvmd.NewReplicationServerCredentials(config.lvmd.ReplicationTLS)
+		if err != nil {
+			return err
+		}
+		replicationServer := grpc.NewServer(grpc.Creds(creds), grpc.UnaryInterceptor(ErrorLoggingInterceptor))
+		proto.RegisterReplicationServiceServer(replicationServer, replicationService)
+		if err := mgr.Add(runners.NewGRPCTCPRunner(replicationServer, config.lvmd.ReplicationAddress, false)); err != nil {
+			return err
+		}
+	}
+
 	c := make(chan os.Signal, 2)
 	signal.Notify(c, []os.Signal{os.Interrupt, syscall.SIGTERM}...)
 	go func() {
@@ -13,9 +13,18 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/getter"
 	"github.com/topolvm/topolvm/internal/profiling"
 	"github.com/topolvm/topolvm/internal/scheduler"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/yaml"
@@ -37,6 +46,9 @@ type Config struct {
 	DefaultDivisor float64 `json:"default-divisor"`
 	// ProfilingBindAddress is the bind address to expose pprof profiling. If empty, profiling is disabled.
 	ProfilingBindAddress string `json:"profiling-bind-address"`
+	// ResolvePodVolumes makes topolvm-scheduler resolve the requested capacity from
+	// PVCs and StorageClasses when a pod has no capacity annotations.
+	ResolvePodVolumes bool `json:"resolve-pod-volumes"`
 }
 
 var config = &Config{
@@ -63,6 +75,10 @@ It scores nodes with this formula:
     min(10, max(0, log2(capacity >> 30 / divisor)))
 
 The default divisor is 1.  It can be changed with a command-line option.
+
+If "resolve-pod-volumes" is enabled in the config file, the requested
+capacity of pods without the annotations is calculated from their PVCs,
+generic ephemeral volumes and StorageClasses.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		cmd.SilenceUsage = true
@@ -85,7 +101,22 @@ func subMain(parentCtx context.Context) error {
 		}
 	}
 
-	h, err := scheduler.NewHandler(config.DefaultDivisor, config.Divisors)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, stop := signal.NotifyContext(parentCtx, os.Interrupt, syscall.SIGTERM)
+	defer stop() // stop() should be called before wg.Wait() to stop the goroutine correctly.
+
+	var g, ns getter.Interface
+	if config.ResolvePodVolumes {
+		var err error
+		g, ns, err = newVolumeGetter(ctx, &wg)
+		if err != nil {
+			return err
+		}
+	}
+
+	h, err := scheduler.NewHandler(config.DefaultDivisor, config.Divisors, g, ns)
 	if err != nil {
 		return err
 	}
@@ -96,12 +127,6 @@ func subMain(parentCtx context.Context) error {
 		ReadTimeout: 30 * time.Second,
 	}
 
-	var wg sync.WaitGroup
-	defer wg.Wait()
-
-	ctx, stop := signal.NotifyContext(parentCtx, os.Interrupt, syscall.SIGTERM)
-	defer stop() // stop() should be called before wg.Wait() to stop the goroutine correctly.
-
 	var pprofServer *http.Server
 	if config.ProfilingBindAddress != "" {
 		pprofServer = profiling.NewProfilingServer(config.ProfilingBindAddress)
@@ -133,6 +158,69 @@ func subMain(parentCtx context.Context) error {
 	return nil
 }
 
+// newVolumeGetter starts an informer cache for PVCs, StorageClasses and NodeStorages.
+// It returns a getter for PVCs and StorageClasses, and a getter for NodeStorages
+// which is nil if the NodeStorage CRD is not installed.
+func newVolumeGetter(ctx context.Context, wg *sync.WaitGroup) (getter.Interface, getter.Interface, error) {
+	logger := log.FromContext(ctx)
+
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, nil, err
+	}
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		return nil, nil, err
+	}
+
+	c, err := cache.New(cfg, cache.Options{Scheme: scheme})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, obj := range []client.Object{&corev1.PersistentVolumeClaim{}, &storagev1.StorageClass{}} {
+		if _, err := c.GetInformer(ctx, obj); err != nil {
+			return nil, nil, err
+		}
+	}
+	var nodeStorages getter.Interface = cacheGetter{c}
+	if _, err := c.GetInformer(ctx, &topolvmv1.NodeStorage{}); err != nil {
+		if !meta.IsNoMatchError(err) {
+			return nil, nil, err
+		}
+		logger.Info("NodeStorage is not available", "error", err.Error())
+		nodeStorages = nil
+	}
+	apiReader, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := c.Start(ctx); err != nil {
+			logger.Error(err, "failed to run the cache")
+		}
+	}()
+	if !c.WaitForCacheSync(ctx) {
+		return nil, nil, errors.New("failed to sync the cache")
+	}
+	return getter.NewRetryMissingGetter(c, apiReader), nodeStorages, nil
+}
+
+// cacheGetter reads objects only from the cache.
+// NodeStorage missing in the cache is not retried because nodes without TopoLVM have none.
+type cacheGetter struct {
+	reader client.Reader
+}
+
+func (g cacheGetter) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return g.reader.Get(ctx, key, obj)
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -50,11 +50,128 @@ func GetLvcreateOptionClassKey() string {
 	return fmt.Sprintf("%s/lvcreate-option-class", GetPluginName())
 }
 
+// GetMkfsOptionsKey returns the key of StorageClass parameter that represents the extra arguments of mkfs
+// separated by spaces.
+func GetMkfsOptionsKey() string {
+	return fmt.Sprintf("%s/mkfs-options", GetPluginName())
+}
+
+// GetFsckPolicyKey returns the key of StorageClass parameter that represents the policy of
+// the filesystem check before a volume is mounted.
+func GetFsckPolicyKey() string {
+	return fmt.Sprintf("%s/fsck-policy", GetPluginName())
+}
+
+// GetSourceNodeKey returns the key of Pod annotation that represents the nodes
+// where the source volumes of the Pod's PVCs reside.
+func GetSourceNodeKey() string {
+	return fmt.Sprintf("%s/source-node", GetPluginName())
+}
+
+// GetAllowCrossNodeCopyKey returns the key of StorageClass parameter that allows restoring snapshots and cloning
+// volumes on other nodes than the source by copying the data.
+func GetAllowCrossNodeCopyKey() string {
+	return fmt.Sprintf("%s/allow-cross-node-copy", GetPluginName())
+}
+
+// GetMaintenanceKey returns the key of Node annotation that stops provisioning new volumes on the node.
+func GetMaintenanceKey() string {
+	return fmt.Sprintf("%s/maintenance", GetPluginName())
+}
+
+// GetResourceClaimKey returns the key of LogicalVolume label that represents the UID of
+// the ResourceClaim for which the LogicalVolume was created.
+func GetResourceClaimKey() string {
+	return fmt.Sprintf("%s/resource-claim", GetPluginName())
+}
+
+// GetNamespaceKey returns the key of LogicalVolume label that represents the namespace of
+// the PVC or VolumeSnapshot for which the LogicalVolume was created.
+func GetNamespaceKey() string {
+	return fmt.Sprintf("%s/namespace", GetPluginName())
+}
+
+// GetAutoResizeThresholdKey returns the key of PVC and StorageClass annotation that enables the automatic
+// expansion of PVCs when the used space of the filesystem reaches the percentage.
+func GetAutoResizeThresholdKey() string {
+	return fmt.Sprintf("%s/auto-resize-threshold", GetPluginName())
+}
+
+// GetAutoResizeIncreaseKey returns the key of PVC and StorageClass annotation that represents
+// the size or the percentage of the current size added by each automatic expansion.
+func GetAutoResizeIncreaseKey() string {
+	return fmt.Sprintf("%s/auto-resize-increase", GetPluginName())
+}
+
+// GetAutoResizeLimitKey returns the key of PVC and StorageClass annotation that represents
+// the maximum size of automatic expansion.
+func GetAutoResizeLimitKey() string {
+	return fmt.Sprintf("%s/auto-resize-limit", GetPluginName())
+}
+
+// GetAutoResizedAtKey returns the key of PVC annotation that represents the timestamp of the last automatic expansion.
+func GetAutoResizedAtKey() string {
+	return fmt.Sprintf("%s/auto-resized-at", GetPluginName())
+}
+
+// GetRevertToSnapshotKey returns the key of PVC annotation that requests to revert the volume
+// to the VolumeSnapshot named by the value.
+func GetRevertToSnapshotKey() string {
+	return fmt.Sprintf("%s/revert-to-snapshot", GetPluginName())
+}
+
+// GetSnapshotScheduleKey returns the key of VolumeSnapshot label that represents
+// the SnapshotSchedule which created the VolumeSnapshot.
+func GetSnapshotScheduleKey() string {
+	return fmt.Sprintf("%s/snapshot-schedule", GetPluginName())
+}
+
+// GetSeedKey returns the key of LogicalVolume label that represents the UID of the VolumeSeed
+// whose data is cached in the LogicalVolume.
+func GetSeedKey() string {
+	return fmt.Sprintf("%s/seed", GetPluginName())
+}
+
+// GetOrphanedByNodeKey returns the key of LogicalVolume annotation that represents the name of the deleted node
+// which left the LogicalVolume orphaned.
+func GetOrphanedByNodeKey() string {
+	return fmt.Sprintf("%s/orphaned-by-node", GetPluginName())
+}
+
+// GetVolumeGroupUUIDKey returns the key of LogicalVolume annotation that represents the UUID of the volume group
+// of an orphaned LogicalVolume, which identifies the node when it re-joins the cluster.
+func GetVolumeGroupUUIDKey() string {
+	return fmt.Sprintf("%s/volume-group-uuid", GetPluginName())
+}
+
+// GetReattachVolumeKey returns the key of PVC annotation that requests to bind the PVC to the released PV
+// named by the value. The value may also be the volume ID of the PV.
+func GetReattachVolumeKey() string {
+	return fmt.Sprintf("%s/reattach-volume", GetPluginName())
+}
+
+// GetResourceClaimFinalizer returns the name of ResourceClaim finalizer of TopoLVM
+func GetResourceClaimFinalizer() string {
+	return fmt.Sprintf("%s/resourceclaim", GetPluginName())
+}
+
 // GetResizeRequestedAtKey returns the key of LogicalVolume that represents the timestamp of the resize request.
 func GetResizeRequestedAtKey() string {
 	return fmt.Sprintf("%s/resize-requested-at", GetPluginName())
 }
 
+// GetReattachAllowedToKey returns the key of PV annotation that allows a PVC in another namespace to reattach
+// the PV. The value is a namespace or "<namespace>/<PVC name>".
+func GetReattachAllowedToKey() string {
+	return fmt.Sprintf("%s/reattach-allowed-to", GetPluginName())
+}
+
+// GetVerifyRequestedAtKey returns the key of LogicalVolume annotation that requests topolvm-node to check
+// the LVM logical volume. The value is an arbitrary string reported back in status.verification.
+func GetVerifyRequestedAtKey() string {
+	return fmt.Sprintf("%s/verify-requested-at", GetPluginName())
+}
+
 // GetPendingDeletionKey returns the name of the pending-deletion annotation
 func GetLVPendingDeletionKey() string {
 	return fmt.Sprintf("%s/pendingdeletion", GetPluginName())
@@ -70,6 +187,22 @@ func GetNodeFinalizer() string {
 	return fmt.Sprintf("%s/node", GetPluginName())
 }
 
+// GetLogicalVolumeReplicationFinalizer returns the name of LogicalVolumeReplication finalizer
+func GetLogicalVolumeReplicationFinalizer() string {
+	return fmt.Sprintf("%s/logicalvolumereplication", GetPluginName())
+}
+
+// GetCopySourceFinalizer returns the name of the finalizer of the LogicalVolumes copied from other nodes,
+// which is removed by the node of the source after removing the snapshot taken for the copy.
+func GetCopySourceFinalizer() string {
+	return fmt.Sprintf("%s/copysource", GetPluginName())
+}
+
+// GetVolumeSeedFinalizer returns the name of VolumeSeed finalizer
+func GetVolumeSeedFinalizer() string {
+	return fmt.Sprintf("%s/volumeseed", GetPluginName())
+}
+
 // Deprecated: the finalizer is no longer used. will be removed in future releases.
 // PVCFinalizer is a finalizer of PVC.
 const PVCFinalizer = pluginName + "/pvc"
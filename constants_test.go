@@ -88,6 +88,66 @@ func TestGetDeviceClassKey(t *testing.T) {
 	doContainTest(t, GetDeviceClassKey)
 }
 
+func TestGetSourceNodeKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetSourceNodeKey)
+}
+
+func TestGetMaintenanceKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetMaintenanceKey)
+}
+
+func TestGetResourceClaimKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetResourceClaimKey)
+}
+
+func TestGetNamespaceKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetNamespaceKey)
+}
+
+func TestGetAutoResizeThresholdKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetAutoResizeThresholdKey)
+}
+
+func TestGetAutoResizeIncreaseKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetAutoResizeIncreaseKey)
+}
+
+func TestGetAutoResizeLimitKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetAutoResizeLimitKey)
+}
+
+func TestGetAutoResizedAtKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetAutoResizedAtKey)
+}
+
+func TestGetAllowCrossNodeCopyKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetAllowCrossNodeCopyKey)
+}
+
+func TestGetRevertToSnapshotKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetRevertToSnapshotKey)
+}
+
+func TestGetSnapshotScheduleKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetSnapshotScheduleKey)
+}
+
+func TestGetResourceClaimFinalizer(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetResourceClaimFinalizer)
+}
+
 func TestGetResizeRequestedAtKey(t *testing.T) {
 	testingutil.DoEnvCheck(t)
 	doContainTest(t, GetResizeRequestedAtKey)
@@ -108,6 +168,61 @@ func TestGetNodeFinalizer(t *testing.T) {
 	doContainTest(t, GetNodeFinalizer)
 }
 
+func TestGetLogicalVolumeReplicationFinalizer(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetLogicalVolumeReplicationFinalizer)
+}
+
+func TestGetCopySourceFinalizer(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetCopySourceFinalizer)
+}
+
+func TestGetSeedKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetSeedKey)
+}
+
+func TestGetVolumeSeedFinalizer(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetVolumeSeedFinalizer)
+}
+
+func TestGetOrphanedByNodeKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetOrphanedByNodeKey)
+}
+
+func TestGetVolumeGroupUUIDKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetVolumeGroupUUIDKey)
+}
+
+func TestGetReattachVolumeKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetReattachVolumeKey)
+}
+
+func TestGetReattachAllowedToKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetReattachAllowedToKey)
+}
+
+func TestGetVerifyRequestedAtKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetVerifyRequestedAtKey)
+}
+
+func TestGetMkfsOptionsKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetMkfsOptionsKey)
+}
+
+func TestGetFsckPolicyKey(t *testing.T) {
+	testingutil.DoEnvCheck(t)
+	doContainTest(t, GetFsckPolicyKey)
+}
+
 func doContainTest(t *testing.T, f func() string) {
 	tests := []struct {
 		name      string
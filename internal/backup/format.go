@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// formatVersion is the version of the backup format.
+	formatVersion = 1
+
+	// DefaultChunkSize is the size of data in a chunk.
+	DefaultChunkSize = 4 << 20
+
+	manifestKey = "manifest.json"
+)
+
+// Range represents a range of a volume in bytes.
+type Range struct {
+	Offset uint64
+	Length uint64
+}
+
+// Manifest describes a backup. It is stored as "manifest.json" after all the chunks are stored.
+type Manifest struct {
+	Version   int     `json:"version"`
+	SizeBytes uint64  `json:"sizeBytes"`
+	Chunks    []Chunk `json:"chunks"`
+}
+
+// Chunk is a part of the volume stored as a gzip-compressed object.
+// The ranges of the volume not covered by the chunks are zero.
+type Chunk struct {
+	Offset uint64 `json:"offset"`
+	Length uint64 `json:"length"`
+	// SHA256 is the checksum of the uncompressed data.
+	SHA256 string `json:"sha256"`
+	// StoredBytes is the size of the compressed object.
+	StoredBytes uint64 `json:"storedBytes"`
+}
+
+// StoredBytes returns the total size of the chunks in the store.
+func (m *Manifest) StoredBytes() uint64 {
+	var total uint64
+	for _, c := range m.Chunks {
+		total += c.StoredBytes
+	}
+	return total
+}
+
+func chunkKey(offset uint64) string {
+	return fmt.Sprintf("chunks/%016x.gz", offset)
+}
+
+// Export stores the ranges of src in chunks of at most chunkSize, and then the manifest.
+// The chunks filled with zeros are skipped.
+func Export(ctx context.Context, store Store, src io.ReaderAt, size uint64, ranges []Range, chunkSize uint64) (*Manifest, error) {
+	manifest := &Manifest{Version: formatVersion, SizeBytes: size}
+	buf := make([]byte, chunkSize)
+	for _, r := range ranges {
+		end := min(r.Offset+r.Length, size)
+		for offset := r.Offset; offset < end; {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			n := min(chunkSize, end-offset)
+			data := buf[:n]
+			if _, err := src.ReadAt(data, int64(offset)); err != nil {
+				return nil, fmt.Errorf("failed to read at %d: %w", offset, err)
+			}
+			if !isZero(data) {
+				chunk, err := putChunk(ctx, store, offset, data)
+				if err != nil {
+					return nil, err
+				}
+				manifest.Chunks = append(manifest.Chunks, *chunk)
+			}
+			offset += n
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Put(ctx, manifestKey, data); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func putChunk(ctx context.Context, store Store, offset uint64, data []byte) (*Chunk, error) {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := store.Put(ctx, chunkKey(offset), compressed.Bytes()); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	return &Chunk{
+		Offset:      offset,
+		Length:      uint64(len(data)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		StoredBytes: uint64(compressed.Len()),
+	}, nil
+}
+
+// ReadManifest returns the manifest of the backup in store.
+func ReadManifest(ctx context.Context, store Store) (*Manifest, error) {
+	data, err := store.Get(ctx, manifestKey)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported backup format version: %d", manifest.Version)
+	}
+	return manifest, nil
+}
+
+// Import writes the chunks of the backup described by manifest to dst after verifying their checksums.
+// If zeroGaps is true, the ranges not covered by the chunks are filled with zeros.
+// It is unnecessary for a new thin volume, which reads zeros.
+func Import(ctx context.Context, store Store, manifest *Manifest, dst io.WriterAt, zeroGaps bool) error {
+	var next uint64
+	for _, c := range manifest.Chunks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if c.Offset < next || c.Offset+c.Length > manifest.SizeBytes {
+			return fmt.Errorf("invalid chunk: offset=%d, length=%d", c.Offset, c.Length)
+		}
+		if zeroGaps {
+			if err := WriteZeros(dst, next, c.Offset); err != nil {
+				return err
+			}
+		}
+		data, err := getChunk(ctx, store, c)
+		if err != nil {
+			return err
+		}
+		if _, err := dst.WriteAt(data, int64(c.Offset)); err != nil {
+			return fmt.Errorf("failed to write at %d: %w", c.Offset, err)
+		}
+		next = c.Offset + c.Length
+	}
+	if zeroGaps {
+		return WriteZeros(dst, next, manifest.SizeBytes)
+	}
+	return nil
+}
+
+func getChunk(ctx context.Context, store Store, c Chunk) ([]byte, error) {
+	compressed, err := store.Get(ctx, chunkKey(c.Offset))
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("corrupted chunk at %d: %w", c.Offset, err)
+	}
+	// one more byte is read to detect the chunk longer than the manifest says.
+	data, err := io.ReadAll(io.LimitReader(r, int64(c.Length)+1))
+	if err != nil {
+		return nil, fmt.Errorf("corrupted chunk at %d: %w", c.Offset, err)
+	}
+	sum := sha256.Sum256(data)
+	if uint64(len(data)) != c.Length || hex.EncodeToString(sum[:]) != c.SHA256 {
+		return nil, fmt.Errorf("checksum mismatch of chunk at %d", c.Offset)
+	}
+	return data, nil
+}
+
+// WriteZeros fills the range [start, end) of dst with zeros.
+func WriteZeros(dst io.WriterAt, start, end uint64) error {
+	if start >= end {
+		return nil
+	}
+	zeros := make([]byte, min(DefaultChunkSize, end-start))
+	for offset := start; offset < end; {
+		n := min(uint64(len(zeros)), end-offset)
+		if _, err := dst.WriteAt(zeros[:n], int64(offset)); err != nil {
+			return fmt.Errorf("failed to write at %d: %w", offset, err)
+		}
+		offset += n
+	}
+	return nil
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// IsNotFound returns true if err means the object does not exist in the store.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// writerAtBuffer is an in-memory io.WriterAt.
+type writerAtBuffer []byte
+
+func (b writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	return copy(b[off:], p), nil
+}
+
+func testVolume() []byte {
+	data := make([]byte, 16384)
+	for i := range 3000 {
+		data[i] = byte(i%251 + 1)
+	}
+	for i := 10000; i < 10100; i++ {
+		data[i] = 0xff
+	}
+	return data
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := testVolume()
+	store := NewFileStore(t.TempDir())
+
+	// the range at 4096 is allocated but zero, so it is skipped.
+	ranges := []Range{{Offset: 0, Length: 8192}, {Offset: 8192, Length: 4096}}
+	manifest, err := Export(ctx, store, bytes.NewReader(src), uint64(len(src)), ranges, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Chunks) != 2 || manifest.Chunks[0].Offset != 0 || manifest.Chunks[1].Offset != 8192 {
+		t.Fatalf("unexpected chunks: %+v", manifest.Chunks)
+	}
+	if manifest.StoredBytes() == 0 || manifest.StoredBytes() >= 8192 {
+		t.Errorf("chunks should be compressed: %d", manifest.StoredBytes())
+	}
+
+	read, err := ReadManifest(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.SizeBytes != uint64(len(src)) || len(read.Chunks) != 2 {
+		t.Fatalf("unexpected manifest: %+v", read)
+	}
+
+	dst := make(writerAtBuffer, len(src))
+	if err := Import(ctx, store, read, dst, false); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst, src) {
+		t.Error("restored data differs")
+	}
+
+	dirty := make(writerAtBuffer, len(src))
+	for i := range dirty {
+		dirty[i] = 0xaa
+	}
+	if err := Import(ctx, store, read, dirty, true); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dirty, src) {
+		t.Error("gaps should be filled with zeros")
+	}
+}
+
+func TestImportCorrupted(t *testing.T) {
+	ctx := context.Background()
+	src := testVolume()
+	store := NewFileStore(t.TempDir())
+	manifest, err := Export(ctx, store, bytes.NewReader(src), uint64(len(src)), []Range{{Offset: 0, Length: 16384}}, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest.Chunks[0].SHA256 = strings.Repeat("0", 64)
+	err = Import(ctx, store, manifest, make(writerAtBuffer, len(src)), false)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("checksum mismatch should be detected: %v", err)
+	}
+
+	if err := store.Put(ctx, chunkKey(0), []byte("broken")); err != nil {
+		t.Fatal(err)
+	}
+	if err := Import(ctx, store, manifest, make(writerAtBuffer, len(src)), false); err == nil {
+		t.Error("corrupted chunk should be detected")
+	}
+}
+
+func TestReadManifestNotFound(t *testing.T) {
+	_, err := ReadManifest(context.Background(), NewFileStore(t.TempDir()))
+	if !IsNotFound(err) {
+		t.Errorf("expected not found: %v", err)
+	}
+}
+
+func TestFileStoreInvalidKey(t *testing.T) {
+	store := NewFileStore(t.TempDir())
+	if err := store.Put(context.Background(), "../escape", []byte("data")); err == nil {
+		t.Error("key escaping the directory should be rejected")
+	}
+}
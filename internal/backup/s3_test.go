@@ -0,0 +1,96 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// s3StandIn is a minimal S3-compatible server keeping the objects in memory.
+type s3StandIn struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.objects[r.URL.Path] = data
+	case http.MethodGet:
+		data, ok := s.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// the SDK requires the metadata of the object.
+		w.Header().Set("Last-Modified", "Mon, 19 Oct 2026 00:00:00 GMT")
+		w.Header().Set("ETag", `"etag"`)
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	standIn := &s3StandIn{objects: map[string][]byte{}}
+	// the payload is not signed in chunks over TLS.
+	server := httptest.NewTLSServer(standIn)
+	defer server.Close()
+
+	store, err := NewS3Store(S3Config{
+		Endpoint:        server.URL,
+		Bucket:          "backups",
+		Prefix:          "/cluster/vol/",
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+	}, server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := testVolume()
+	manifest, err := Export(ctx, store, bytes.NewReader(src), uint64(len(src)), []Range{{Offset: 0, Length: 16384}}, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := standIn.objects["/backups/cluster/vol/manifest.json"]; !ok {
+		t.Errorf("manifest is not stored under the prefix: %v", standIn.objects)
+	}
+
+	dst := make(writerAtBuffer, len(src))
+	if err := Import(ctx, store, manifest, dst, false); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst, src) {
+		t.Error("restored data differs")
+	}
+
+	if _, err := store.Get(ctx, "missing"); !IsNotFound(err) {
+		t.Errorf("expected not found: %v", err)
+	}
+
+	denied, err := NewS3Store(S3Config{Endpoint: server.URL, Bucket: "backups", AccessKeyID: "other"}, server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := denied.Put(ctx, "key", []byte("data")); err == nil || !strings.Contains(err.Error(), "Access Denied") {
+		t.Errorf("expected forbidden: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+// Package backup implements the format of volume backups and the stores to keep them.
+package backup
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned by Store.Get if the object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps the objects of backups by keys like "chunks/0000000000000000.gz".
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// NewFileStore returns a Store keeping the objects as files under dir.
+func NewFileStore(dir string) Store {
+	return &fileStore{baseDir: dir, dir: "."}
+}
+
+// NewFileStoreIn returns a Store keeping the objects as files under dir, which must be an absolute path in baseDir.
+// The files are accessed through baseDir so that symbolic links do not lead out of it.
+func NewFileStoreIn(baseDir, dir string) (Store, error) {
+	if !filepath.IsAbs(dir) {
+		return nil, fmt.Errorf("%s is not an absolute path", dir)
+	}
+	rel, err := filepath.Rel(baseDir, dir)
+	if err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("%s is not in %s", dir, baseDir)
+	}
+	return &fileStore{baseDir: baseDir, dir: rel}, nil
+}
+
+type fileStore struct {
+	baseDir string
+	// dir is the directory of the objects relative to baseDir.
+	dir string
+}
+
+func (s *fileStore) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", fmt.Errorf("invalid key: %s", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+func (s *fileStore) Put(_ context.Context, key string, data []byte) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.baseDir, 0755); err != nil {
+		return err
+	}
+	root, err := os.OpenRoot(s.baseDir)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = root.Close() }()
+	if err := root.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// the object is renamed after written so that a partial object is never read.
+	tmp := filepath.Join(filepath.Dir(p), "."+filepath.Base(p)+"."+rand.Text())
+	f, err := root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	err = errors.Join(err, f.Close())
+	if err == nil {
+		err = root.Rename(tmp, p)
+	}
+	if err != nil {
+		_ = root.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) Get(_ context.Context, key string) ([]byte, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	root, err := os.OpenRoot(s.baseDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = root.Close() }()
+	data, err := root.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+	}
+	return data, err
+}
+
+// joinKey joins the prefix and the key with a slash.
+func joinKey(prefix, key string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return key
+	}
+	return prefix + "/" + key
+}
@@ -0,0 +1,54 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStoreIn(t *testing.T) {
+	ctx := context.Background()
+	base := t.TempDir()
+	outside := t.TempDir()
+
+	for _, dir := range []string{outside, base + "/../escape", "relative/dir"} {
+		if _, err := NewFileStoreIn(base, dir); err == nil {
+			t.Errorf("%s must be rejected", dir)
+		}
+	}
+
+	store, err := NewFileStoreIn(base, filepath.Join(base, "backup"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "chunks/0.gz", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(base, "backup", "chunks", "0.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "data" {
+		t.Errorf("unexpected data: %q", data)
+	}
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, but got %v", err)
+	}
+
+	// a symbolic link in the base directory must not lead out of it.
+	if err := os.Symlink(outside, filepath.Join(base, "link")); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewFileStoreIn(base, filepath.Join(base, "link"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "manifest.json", []byte("{}")); err == nil {
+		t.Error("the object must not be written out of the base directory")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "manifest.json")); !os.IsNotExist(err) {
+		t.Errorf("the object is written out of the base directory: %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/nodeservice"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// defaultAutoResizeIncrease is used when the increase annotation is omitted.
+const defaultAutoResizeIncrease = "10%"
+
+// AutoResizeReconciler expands PVCs whose filesystems are filled over the threshold.
+type AutoResizeReconciler struct {
+	client      client.Client
+	nodeService *nodeservice.NodeService
+	recorder    events.EventRecorder
+}
+
+// NewAutoResizeReconciler returns AutoResizeReconciler.
+func NewAutoResizeReconciler(client client.Client, recorder events.EventRecorder) *AutoResizeReconciler {
+	return &AutoResizeReconciler{
+		client:      client,
+		nodeService: nodeservice.NewNodeService(client),
+		recorder:    recorder,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+// Reconcile expands the PVC of the LogicalVolume if its filesystem usage reaches the threshold.
+func (r *AutoResizeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lv := &topolvmv1.LogicalVolume{}
+	err := r.client.Get(ctx, req.NamespacedName, lv)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	usage := lv.Status.FilesystemUsage
+	if lv.DeletionTimestamp != nil || usage == nil || usage.Total.Value() == 0 {
+		return ctrl.Result{}, nil
+	}
+
+	pvc, sc, err := r.claimOf(ctx, lv)
+	if err != nil || pvc == nil {
+		return ctrl.Result{}, err
+	}
+
+	settings, err := newAutoResizeSettings(pvc, sc)
+	if err != nil {
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "AutoResizeInvalid", "AutoResize", "%s", err.Error())
+		return ctrl.Result{}, nil
+	}
+	if settings == nil || usage.Used.Value()*100 < settings.threshold*usage.Total.Value() {
+		return ctrl.Result{}, nil
+	}
+
+	// Wait for the previous expansion and the usage observed after it.
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+	if !ok || requested.Cmp(capacity) > 0 {
+		return ctrl.Result{}, nil
+	}
+	if at, err := time.Parse(time.RFC3339, pvc.Annotations[topolvm.GetAutoResizedAtKey()]); err == nil && !usage.ObservedAt.After(at) {
+		return ctrl.Result{}, nil
+	}
+
+	newSize := settings.next(capacity.Value())
+	if settings.limit != nil && newSize > settings.limit.Value() {
+		newSize = settings.limit.Value()
+	}
+	if newSize <= capacity.Value() {
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "AutoResizeLimitReached", "AutoResize",
+			"cannot expand beyond the limit %s", settings.limit.String())
+		return ctrl.Result{}, nil
+	}
+	if sc != nil && (sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion) {
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "AutoResizeNotAllowed", "AutoResize",
+			"StorageClass %s does not allow volume expansion", sc.Name)
+		return ctrl.Result{}, nil
+	}
+
+	currentSize := lv.Status.CurrentSize
+	if currentSize == nil {
+		currentSize = &lv.Spec.Size
+	}
+	free, err := r.nodeService.GetCapacityByName(ctx, lv.Spec.NodeName, lv.Spec.DeviceClass)
+	if err != nil {
+		log.Error(err, "failed to get the free capacity", "node", lv.Spec.NodeName, "deviceClass", lv.Spec.DeviceClass)
+		return ctrl.Result{}, err
+	}
+	if newSize-currentSize.Value() > free {
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "AutoResizeNoSpace", "AutoResize",
+			"cannot expand to %d bytes because node %s has only %d bytes free", newSize, lv.Spec.NodeName, free)
+		return ctrl.Result{}, nil
+	}
+
+	patch := client.MergeFrom(pvc.DeepCopy())
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = *resource.NewQuantity(newSize, resource.BinarySI)
+	if pvc.Annotations == nil {
+		pvc.Annotations = make(map[string]string)
+	}
+	pvc.Annotations[topolvm.GetAutoResizedAtKey()] = time.Now().UTC().Format(time.RFC3339)
+	if err := r.client.Patch(ctx, pvc, patch); err != nil {
+		log.Error(err, "failed to expand PVC", "name", pvc.Name, "namespace", pvc.Namespace)
+		return ctrl.Result{}, err
+	}
+	r.recorder.Eventf(pvc, lv, corev1.EventTypeNormal, "AutoResized", "AutoResize",
+		"expanded from %d to %d bytes because %s of %s is used", capacity.Value(), newSize, usage.Used.String(), usage.Total.String())
+	log.Info("expanded PVC", "name", pvc.Name, "namespace", pvc.Namespace, "from", capacity.Value(), "to", newSize)
+	return ctrl.Result{}, nil
+}
+
+// claimOf returns the PVC bound to the LogicalVolume and its StorageClass.
+// The PVC is nil if the LogicalVolume is not provisioned for a PVC.
+func (r *AutoResizeReconciler) claimOf(ctx context.Context, lv *topolvmv1.LogicalVolume) (*corev1.PersistentVolumeClaim, *storagev1.StorageClass, error) {
+	pv := &corev1.PersistentVolume{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: lv.Name}, pv)
+	if apierrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.VolumeHandle != lv.Status.VolumeID || pv.Spec.ClaimRef == nil {
+		return nil, nil, nil
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: pv.Spec.ClaimRef.Namespace, Name: pv.Spec.ClaimRef.Name}, pvc)
+	if apierrors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if pvc.Spec.VolumeName != pv.Name || pvc.DeletionTimestamp != nil {
+		return nil, nil, nil
+	}
+
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return pvc, nil, nil
+	}
+	sc := &storagev1.StorageClass{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc)
+	if apierrors.IsNotFound(err) {
+		return pvc, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return pvc, sc, nil
+}
+
+// autoResizeSettings is the configuration of the automatic expansion of a PVC.
+type autoResizeSettings struct {
+	// threshold is the percentage of the used space to trigger the expansion.
+	threshold int64
+	// increase is the size added by an expansion. increasePercent is used instead if it is nil.
+	increase        *resource.Quantity
+	increasePercent int64
+	// limit is the maximum size of the PVC, or nil if unlimited.
+	limit *resource.Quantity
+}
+
+// newAutoResizeSettings reads the settings from the annotations of the PVC, or of the StorageClass if missing.
+// It returns nil if the automatic expansion is not enabled.
+func newAutoResizeSettings(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass) (*autoResizeSettings, error) {
+	lookup := func(key string) (string, bool) {
+		if v, ok := pvc.Annotations[key]; ok {
+			return v, true
+		}
+		if sc != nil {
+			v, ok := sc.Annotations[key]
+			return v, ok
+		}
+		return "", false
+	}
+
+	threshold, ok := lookup(topolvm.GetAutoResizeThresholdKey())
+	if !ok {
+		return nil, nil
+	}
+	s := &autoResizeSettings{}
+	var err error
+	s.threshold, err = parsePercent(threshold)
+	if err != nil || s.threshold <= 0 || s.threshold > 100 {
+		return nil, fmt.Errorf("invalid %s: %q", topolvm.GetAutoResizeThresholdKey(), threshold)
+	}
+
+	increase, ok := lookup(topolvm.GetAutoResizeIncreaseKey())
+	if !ok {
+		increase = defaultAutoResizeIncrease
+	}
+	if strings.HasSuffix(increase, "%") {
+		s.increasePercent, err = parsePercent(increase)
+		if err != nil || s.increasePercent <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", topolvm.GetAutoResizeIncreaseKey(), increase)
+		}
+	} else {
+		q, err := resource.ParseQuantity(increase)
+		if err != nil || q.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", topolvm.GetAutoResizeIncreaseKey(), increase)
+		}
+		s.increase = &q
+	}
+
+	if limit, ok := lookup(topolvm.GetAutoResizeLimitKey()); ok {
+		q, err := resource.ParseQuantity(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", topolvm.GetAutoResizeLimitKey(), limit)
+		}
+		s.limit = &q
+	}
+	return s, nil
+}
+
+// next returns the size after an expansion from the current size.
+func (s *autoResizeSettings) next(current int64) int64 {
+	if s.increase != nil {
+		return current + s.increase.Value()
+	}
+	// round up to MiB not to create tiny increases.
+	increase := (current*s.increasePercent/100 + (1<<20 - 1)) &^ (1<<20 - 1)
+	return current + increase
+}
+
+func parsePercent(s string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSuffix(s, "%"), 10, 64)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AutoResizeReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	usageChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldLV, ok1 := e.ObjectOld.(*topolvmv1.LogicalVolume)
+			newLV, ok2 := e.ObjectNew.(*topolvmv1.LogicalVolume)
+			if !ok1 || !ok2 {
+				return false
+			}
+			return !equality.Semantic.DeepEqual(oldLV.Status.FilesystemUsage, newLV.Status.FilesystemUsage)
+		},
+		DeleteFunc: func(event.DeleteEvent) bool { return false },
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("autoresize").
+		For(&topolvmv1.LogicalVolume{}, builder.WithPredicates(usageChanged)).
+		Complete(r)
+}
@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+var _ = Describe("AutoResize controller", func() {
+	ctx := context.Background()
+	var stopFunc func()
+	var recorder *events.FakeRecorder
+	errCh := make(chan error)
+
+	BeforeEach(func() {
+		skipNameValidation := true
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme: scheme,
+			Controller: config.Controller{
+				SkipNameValidation: &skipNameValidation,
+			},
+			Metrics: server.Options{
+				BindAddress: "0", // disable metrics
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		recorder = events.NewFakeRecorder(10)
+		reconciler := NewAutoResizeReconciler(mgr.GetClient(), recorder)
+		err = reconciler.SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(ctx)
+		stopFunc = cancel
+		go func() {
+			errCh <- mgr.Start(ctx)
+		}()
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		stopFunc()
+		Expect(<-errCh).NotTo(HaveOccurred())
+	})
+
+	setUsage := func(lv *topolvmv1.LogicalVolume, total, used int64) {
+		lv.Status.FilesystemUsage = &topolvmv1.FilesystemUsage{
+			Total:      *resource.NewQuantity(total, resource.BinarySI),
+			Used:       *resource.NewQuantity(used, resource.BinarySI),
+			Available:  *resource.NewQuantity(total-used, resource.BinarySI),
+			ObservedAt: metav1.NewTime(time.Now().Add(2 * time.Second)),
+		}
+		err := k8sClient.Status().Update(ctx, lv)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should expand a PVC whose filesystem is filled over the threshold", func() {
+		ns := createNamespace()
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "autoresize-node",
+				Annotations: map[string]string{
+					topolvm.GetCapacityKeyPrefix() + "autoresize": "10737418240",
+				},
+			},
+		}
+		err := k8sClient.Create(ctx, node)
+		Expect(err).NotTo(HaveOccurred())
+
+		sc := &storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "autoresize-sc",
+				Annotations: map[string]string{
+					topolvm.GetAutoResizeThresholdKey(): "80%",
+					topolvm.GetAutoResizeIncreaseKey():  "1Gi",
+					topolvm.GetAutoResizeLimitKey():     "4Gi",
+				},
+			},
+			Provisioner:          topolvm.GetPluginName(),
+			AllowVolumeExpansion: ptr.To(true),
+		}
+		err = k8sClient.Create(ctx, sc)
+		Expect(err).NotTo(HaveOccurred())
+
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "autoresize-lv",
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Capacity: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("2Gi"),
+				},
+				StorageClassName: sc.Name,
+				ClaimRef: &corev1.ObjectReference{
+					Namespace: ns,
+					Name:      "autoresize-pvc",
+				},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:       topolvm.GetPluginName(),
+						VolumeHandle: "autoresize-volume",
+					},
+				},
+			},
+		}
+		err = k8sClient.Create(ctx, pv)
+		Expect(err).NotTo(HaveOccurred())
+
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ns,
+				Name:      "autoresize-pvc",
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				StorageClassName: ptr.To(sc.Name),
+				VolumeName:       pv.Name,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse("2Gi"),
+					},
+				},
+			},
+		}
+		err = k8sClient.Create(ctx, pvc)
+		Expect(err).NotTo(HaveOccurred())
+		pvc.Status.Phase = corev1.ClaimBound
+		pvc.Status.Capacity = pv.Spec.Capacity
+		err = k8sClient.Status().Update(ctx, pvc)
+		Expect(err).NotTo(HaveOccurred())
+
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: pv.Name,
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        pv.Name,
+				NodeName:    node.Name,
+				DeviceClass: "autoresize",
+				Size:        resource.MustParse("2Gi"),
+			},
+		}
+		err = k8sClient.Create(ctx, lv)
+		Expect(err).NotTo(HaveOccurred())
+		lv.Status.VolumeID = "autoresize-volume"
+		lv.Status.CurrentSize = resource.NewQuantity(2<<30, resource.BinarySI)
+		setUsage(lv, 2<<30, 1<<30)
+
+		By("keeping the PVC while the usage is under the threshold")
+		Consistently(func(g Gomega) {
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: ns, Name: pvc.Name}, pvc)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(pvc.Spec.Resources.Requests.Storage().Value()).To(Equal(int64(2 << 30)))
+		}, time.Second).Should(Succeed())
+
+		By("expanding the PVC when the usage reaches the threshold")
+		setUsage(lv, 2<<30, 2<<30-100<<20)
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: ns, Name: pvc.Name}, pvc)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(pvc.Spec.Resources.Requests.Storage().Value()).To(Equal(int64(3 << 30)))
+			g.Expect(pvc.Annotations).To(HaveKey(topolvm.GetAutoResizedAtKey()))
+		}).Should(Succeed())
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Normal AutoResized")))
+
+		By("stopping at the limit")
+		pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("4Gi")}
+		err = k8sClient.Status().Update(ctx, pvc)
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Get(ctx, types.NamespacedName{Namespace: ns, Name: pvc.Name}, pvc)
+		Expect(err).NotTo(HaveOccurred())
+		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("4Gi")
+		err = k8sClient.Update(ctx, pvc)
+		Expect(err).NotTo(HaveOccurred())
+		lv.Status.CurrentSize = resource.NewQuantity(4<<30, resource.BinarySI)
+		setUsage(lv, 4<<30, 4<<30-100<<20)
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Warning AutoResizeLimitReached")))
+	})
+})
+
+var _ = Describe("autoResizeSettings", func() {
+	It("should read the annotations of the PVC before the StorageClass", func() {
+		pvc := &corev1.PersistentVolumeClaim{}
+		pvc.Annotations = map[string]string{topolvm.GetAutoResizeIncreaseKey(): "20%"}
+		sc := &storagev1.StorageClass{}
+		sc.Annotations = map[string]string{
+			topolvm.GetAutoResizeThresholdKey(): "90%",
+			topolvm.GetAutoResizeIncreaseKey():  "5Gi",
+		}
+
+		s, err := newAutoResizeSettings(pvc, sc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s.threshold).To(Equal(int64(90)))
+		Expect(s.increase).To(BeNil())
+		Expect(s.next(10 << 30)).To(Equal(int64(12 << 30)))
+		Expect(s.limit).To(BeNil())
+	})
+
+	It("should be disabled without the threshold", func() {
+		s, err := newAutoResizeSettings(&corev1.PersistentVolumeClaim{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(s).To(BeNil())
+	})
+
+	It("should reject invalid values", func() {
+		pvc := &corev1.PersistentVolumeClaim{}
+		pvc.Annotations = map[string]string{topolvm.GetAutoResizeThresholdKey(): "120%"}
+		_, err := newAutoResizeSettings(pvc, nil)
+		Expect(err).To(HaveOccurred())
+
+		pvc.Annotations = map[string]string{
+			topolvm.GetAutoResizeThresholdKey(): "80%",
+			topolvm.GetAutoResizeIncreaseKey():  "-1Gi",
+		}
+		_, err = newAutoResizeSettings(pvc, nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
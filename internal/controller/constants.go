@@ -16,4 +16,10 @@ const (
 
 	// requeueIntervalForSimpleUpdate is the requeue interval when updating the manifest during reconciliation and re-execute loop
 	requeueIntervalForSimpleUpdate = 1 * time.Second
+
+	// requeueIntervalForPopulation is the requeue interval when waiting for the population of a LogicalVolume
+	requeueIntervalForPopulation = 5 * time.Second
+
+	// populationProgressInterval is the minimum interval of updating the progress of the population of a LogicalVolume
+	populationProgressInterval = 10 * time.Second
 )
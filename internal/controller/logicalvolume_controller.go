@@ -2,18 +2,33 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/topolvm/topolvm"
 	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
 	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/internal/filesystem"
+	"github.com/topolvm/topolvm/internal/maintenance"
+	"github.com/topolvm/topolvm/internal/seed"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	mountutil "k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -21,23 +36,66 @@ import (
 	crlog "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// SeedConfig restricts the downloads of the data of VolumeSeeds.
+type SeedConfig struct {
+	// AllowedNetworks are the networks that may be downloaded from even if their addresses are
+	// loopback, link-local or private ones, e.g. the network of an in-cluster registry.
+	AllowedNetworks []netip.Prefix
+
+	// PullSecretsNamespace is the namespace of the pull secrets of the images.
+	// The VolumeSeeds with pull secrets are rejected if it is empty.
+	PullSecretsNamespace string
+}
+
 // LogicalVolumeReconciler reconciles a LogicalVolume object
 type LogicalVolumeReconciler struct {
-	client    client.Client
-	nodeName  string
-	vgService proto.VGServiceClient
-	lvService proto.LVServiceClient
+	client          client.Client
+	apiReader       client.Reader
+	nodeName        string
+	vgService       proto.VGServiceClient
+	lvService       proto.LVServiceClient
+	replicationPort int
+	// dialPeer returns a client of the replication API of lvmd on another node and a function to close it.
+	dialPeer func(address string) (proto.ReplicationServiceClient, func() error, error)
+	// httpClient downloads the data of VolumeSeeds.
+	httpClient *http.Client
+	seedConfig SeedConfig
+	// mounter creates and mounts a filesystem to extract the data of VolumeSeeds into.
+	mounter *mountutil.SafeFormatAndMount
+	// populations copies the data of the sources into the LogicalVolumes in the background.
+	populations *populationTracker
+	now         func() time.Time
 }
 
 //+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=volumeseeds,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,namespace=topolvm-system,resources=secrets,verbs=get
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 
-func NewLogicalVolumeReconcilerWithServices(client client.Client, nodeName string, vgService proto.VGServiceClient, lvService proto.LVServiceClient) *LogicalVolumeReconciler {
+// NewLogicalVolumeReconcilerWithServices returns LogicalVolumeReconciler.
+// replicationPort is the port of the replication API of lvmd on the other nodes, which is used to copy volumes from them.
+// If it is zero, LogicalVolumes whose copySource is on another node are not provisioned.
+// replicationCredentials are the mutual TLS credentials to call the replication API.
+// apiReader is used to read the pull secrets of VolumeSeeds without caching all the Secrets.
+func NewLogicalVolumeReconcilerWithServices(client client.Client, apiReader client.Reader, nodeName string, vgService proto.VGServiceClient, lvService proto.LVServiceClient,
+	replicationPort int, replicationCredentials credentials.TransportCredentials, seedConfig SeedConfig) *LogicalVolumeReconciler {
 	return &LogicalVolumeReconciler{
-		client:    client,
-		nodeName:  nodeName,
-		vgService: vgService,
-		lvService: lvService,
+		client:          client,
+		apiReader:       apiReader,
+		nodeName:        nodeName,
+		vgService:       vgService,
+		lvService:       lvService,
+		replicationPort: replicationPort,
+		dialPeer:        replicationDialer(replicationCredentials),
+		httpClient:      seed.NewHTTPClient(seedConfig.AllowedNetworks),
+		seedConfig:      seedConfig,
+		mounter: &mountutil.SafeFormatAndMount{
+			Interface: mountutil.New(""),
+			Exec:      utilexec.New(),
+		},
+		populations: newPopulationTracker(),
+		now:         time.Now,
 	}
 }
 
@@ -74,7 +132,8 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		if !controllerutil.ContainsFinalizer(lv, topolvm.GetLogicalVolumeFinalizer()) {
 			lv2 := lv.DeepCopy()
 			controllerutil.AddFinalizer(lv2, topolvm.GetLogicalVolumeFinalizer())
-			patch := client.MergeFrom(lv)
+			// the node of the copy source may change the finalizers concurrently.
+			patch := client.MergeFromWithOptions(lv, client.MergeFromWithOptimisticLock{})
 			if err := r.client.Patch(ctx, lv2, patch); err != nil {
 				log.Error(err, "failed to add finalizer", "name", lv.Name)
 				return ctrl.Result{}, err
@@ -96,6 +155,22 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
 		}
 
+		if lv.Status.VolumeID == "" && lv.Spec.Seed != nil {
+			result, err := r.seedLV(ctx, log, lv)
+			if err != nil {
+				log.Error(err, "failed to seed LV", "name", lv.Name)
+			}
+			return result, err
+		}
+
+		if lv.Status.VolumeID == "" && lv.Spec.CopySource != "" {
+			result, err := r.populateLV(ctx, log, lv)
+			if err != nil {
+				log.Error(err, "failed to populate LV", "name", lv.Name)
+			}
+			return result, err
+		}
+
 		if lv.Status.VolumeID == "" {
 			err := r.createLV(ctx, log, lv)
 			if err != nil {
@@ -104,6 +179,23 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return ctrl.Result{}, err
 		}
 
+		if requestedAt, ok := lv.Annotations[topolvm.GetVerifyRequestedAtKey()]; ok &&
+			(lv.Status.Verification == nil || lv.Status.Verification.RequestedAt != requestedAt) {
+			err := r.verifyLV(ctx, log, lv, requestedAt)
+			if err != nil {
+				log.Error(err, "failed to verify LV", "name", lv.Name)
+			}
+			return ctrl.Result{}, err
+		}
+
+		if lv.IsReverting() {
+			err := r.revertLV(ctx, log, lv)
+			if err != nil {
+				log.Error(err, "failed to revert LV", "name", lv.Name)
+			}
+			return ctrl.Result{}, err
+		}
+
 		err := r.expandLV(ctx, log, lv)
 		if err != nil {
 			log.Error(err, "failed to expand LV", "name", lv.Name)
@@ -118,6 +210,7 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	}
 
 	log.Info("start finalizing LogicalVolume", "name", lv.Name)
+	r.populations.stop(lv.UID)
 	err := r.removeLVIfExists(ctx, log, lv)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -125,7 +218,7 @@ func (r *LogicalVolumeReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	lv2 := lv.DeepCopy()
 	controllerutil.RemoveFinalizer(lv2, topolvm.GetLogicalVolumeFinalizer())
-	patch := client.MergeFrom(lv)
+	patch := client.MergeFromWithOptions(lv, client.MergeFromWithOptimisticLock{})
 	if err := r.client.Patch(ctx, lv2, patch); err != nil {
 		log.Error(err, "failed to remove finalizer", "name", lv.Name)
 		return ctrl.Result{}, err
@@ -146,8 +239,13 @@ func (r *LogicalVolumeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 
 func (r *LogicalVolumeReconciler) removeLVIfExists(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) error {
 	// Finalizer's process ( RemoveLV then removeString ) is not atomic,
-	// so checking existence of LV to ensure its idempotence
-	_, err := r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{Name: string(lv.UID), DeviceClass: lv.Spec.DeviceClass})
+	// so checking existence of LV to ensure its idempotence.
+	// The snapshots for VolumeSnapshots are not kept in the trash, unlike the volumes restored from them.
+	_, err := r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{
+		Name:        string(lv.UID),
+		DeviceClass: lv.Spec.DeviceClass,
+		SkipTrash:   isSnapshot(lv),
+	})
 	if status.Code(err) == codes.NotFound {
 		log.Info("LV already removed", "name", lv.Name, "uid", lv.UID)
 		return nil
@@ -202,6 +300,19 @@ func (r *LogicalVolumeReconciler) createLV(ctx context.Context, log logr.Logger,
 			return nil
 		}
 
+		underMaintenance, err := r.isUnderMaintenance(ctx, lv.Spec.DeviceClass)
+		if err != nil {
+			lv.Status.Code = codes.Internal
+			lv.Status.Message = "failed to check storage maintenance"
+			return err
+		}
+		if underMaintenance {
+			// ResourceExhausted lets the external-provisioner reschedule the PVC to another node.
+			lv.Status.Code = codes.ResourceExhausted
+			lv.Status.Message = "node is under storage maintenance"
+			return errors.New(lv.Status.Message)
+		}
+
 		var volume *proto.LogicalVolume
 
 		// Create a snapshot LV
@@ -237,6 +348,22 @@ func (r *LogicalVolumeReconciler) createLV(ctx context.Context, log logr.Logger,
 				return err
 			}
 			volume = resp.Snapshot
+		} else if lv.Spec.Undelete != "" {
+			// Restore a removed lv from the trash
+			resp, err := r.lvService.UndeleteLV(ctx, &proto.UndeleteLVRequest{
+				Name:        lv.Spec.Undelete,
+				DeviceClass: lv.Spec.DeviceClass,
+				NewName:     string(lv.UID),
+				SizeBytes:   reqBytes,
+			})
+			if err != nil {
+				code, message := extractFromError(err)
+				log.Error(err, message)
+				lv.Status.Code = code
+				lv.Status.Message = message
+				return err
+			}
+			volume = resp.Volume
 		} else {
 			// Create a regular lv
 			resp, err := r.lvService.CreateLV(ctx, &proto.CreateLVRequest{
@@ -244,6 +371,8 @@ func (r *LogicalVolumeReconciler) createLV(ctx context.Context, log logr.Logger,
 				DeviceClass:         lv.Spec.DeviceClass,
 				LvcreateOptionClass: lv.Spec.LvcreateOptionClass,
 				SizeBytes:           reqBytes,
+				FsType:              lv.Spec.FsType,
+				MkfsOptions:         lv.Spec.MkfsOptions,
 			})
 			if err != nil {
 				code, message := extractFromError(err)
@@ -279,6 +408,478 @@ func (r *LogicalVolumeReconciler) createLV(ctx context.Context, log logr.Logger,
 	return nil
 }
 
+// populateLV creates an LV with the data of the source LogicalVolume, which may be on another node or in another device class.
+// The volume ID is set after the copy completes so that the volume is not used before.
+func (r *LogicalVolumeReconciler) populateLV(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) (ctrl.Result, error) {
+	// When lv.Status.Code is not codes.OK (== 0), the population has already failed.
+	// LogicalVolume CRD will be deleted soon by the controller.
+	if lv.Status.Code != codes.OK {
+		return ctrl.Result{}, nil
+	}
+
+	sourcelv := new(topolvmv1.LogicalVolume)
+	err := r.client.Get(ctx, types.NamespacedName{Name: lv.Spec.CopySource}, sourcelv)
+	local := err == nil && sourcelv.Spec.NodeName == r.nodeName
+	switch {
+	case apierrs.IsNotFound(err):
+		return ctrl.Result{}, r.failPopulation(ctx, lv, codes.NotFound, fmt.Sprintf("source LogicalVolume %s is not found", lv.Spec.CopySource))
+	case err != nil:
+		return ctrl.Result{}, err
+	case !local && r.replicationPort == 0:
+		return ctrl.Result{}, r.failPopulation(ctx, lv, codes.FailedPrecondition, "copying volumes from other nodes is disabled")
+	case sourcelv.Status.VolumeID == "" && sourcelv.Status.Code != codes.OK:
+		return ctrl.Result{}, r.failPopulation(ctx, lv, codes.FailedPrecondition,
+			fmt.Sprintf("source LogicalVolume %s failed: %s", sourcelv.Name, sourcelv.Status.Message))
+	case sourcelv.Status.VolumeID == "":
+		log.Info("waiting for the source LV to be provisioned", "name", lv.Name, "source", sourcelv.Name)
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	job := r.populations.get(lv.UID)
+	if job == nil {
+		var populate populationFunc
+		if local {
+			populate, err = r.prepareCopyOnNode(ctx, lv, sourcelv)
+		} else {
+			populate, err = r.prepareCopyFromNode(ctx, lv, sourcelv)
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		job = r.populations.start(ctx, lv.UID, populate)
+	}
+	if !job.finished() {
+		return ctrl.Result{RequeueAfter: populationProgressInterval}, r.updatePopulationProgress(ctx, lv, job)
+	}
+
+	// the next reconciliation starts a new job if this one has failed.
+	r.populations.stop(lv.UID)
+	volume, copied, err := job.result()
+	switch {
+	case err != nil && (local || status.Code(err) == codes.OutOfRange):
+		code, message := extractFromError(err)
+		return ctrl.Result{}, errors.Join(err, r.failPopulation(ctx, lv, code, message))
+	case err != nil:
+		lv.Status.Population.Message = err.Error()
+		if err2 := r.client.Status().Update(ctx, lv); err2 != nil {
+			// err2 is logged but not returned because err is more important
+			log.Error(err2, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		}
+		return ctrl.Result{}, err
+	}
+
+	lv.Status.Population.Message = ""
+	if err := r.completePopulation(ctx, lv, volume, copied); err != nil {
+		log.Error(err, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		return ctrl.Result{}, err
+	}
+
+	log.Info("populated new LV", "name", lv.Name, "uid", lv.UID, "source", sourcelv.Name,
+		"source_node", sourcelv.Spec.NodeName, "copied", copied)
+	return ctrl.Result{}, nil
+}
+
+// seedLV creates the LV and writes the data of the VolumeSeed into it in the background before setting the volume ID.
+// The errors in fetching the data are retried, and the last one is recorded in the status.
+func (r *LogicalVolumeReconciler) seedLV(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) (ctrl.Result, error) {
+	// When lv.Status.Code is not codes.OK (== 0), the population has already failed.
+	// LogicalVolume CRD will be deleted soon by the controller.
+	if lv.Status.Code != codes.OK {
+		return ctrl.Result{}, nil
+	}
+
+	vs := new(topolvmv1.VolumeSeed)
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: lv.Spec.Seed.Namespace, Name: lv.Spec.Seed.Name}, vs)
+	if apierrs.IsNotFound(err) {
+		return ctrl.Result{}, r.failPopulation(ctx, lv, codes.NotFound,
+			fmt.Sprintf("VolumeSeed %s/%s is not found", lv.Spec.Seed.Namespace, lv.Spec.Seed.Name))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := seed.Validate(&vs.Spec); err != nil {
+		return ctrl.Result{}, r.failPopulation(ctx, lv, codes.InvalidArgument, err.Error())
+	}
+
+	job := r.populations.get(lv.UID)
+	if job == nil {
+		volume, err := r.findOrCreateLV(ctx, lv)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// the data written by a failed attempt must be overwritten.
+		retrying := lv.Status.Population != nil
+		if !retrying {
+			if err := r.startPopulation(ctx, lv); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		fsType, mkfsOptions := lv.Spec.FsType, lv.Spec.MkfsOptions
+		job = r.populations.start(ctx, lv.UID, func(ctx context.Context, progress func(int64)) (*proto.LogicalVolume, int64, error) {
+			var written int64
+			var err error
+			if seed.IsFilesystem(&vs.Spec) {
+				written, err = r.extractSeed(ctx, vs, volume, fsType, mkfsOptions, progress)
+			} else {
+				written, err = r.writeSeed(ctx, vs, volume, retrying || !isThinVolume(volume), progress)
+			}
+			return volume, written, err
+		})
+	}
+	if !job.finished() {
+		return ctrl.Result{RequeueAfter: populationProgressInterval}, r.updatePopulationProgress(ctx, lv, job)
+	}
+
+	// the next reconciliation starts a new job if this one has failed.
+	r.populations.stop(lv.UID)
+	volume, written, err := job.result()
+	if err != nil {
+		var code codes.Code
+		switch {
+		case errors.Is(err, seed.ErrInvalid):
+			code = codes.InvalidArgument
+		case errors.Is(err, seed.ErrChecksumMismatch):
+			code = codes.DataLoss
+		case errors.Is(err, seed.ErrTooLarge):
+			code = codes.OutOfRange
+		default:
+			lv.Status.Population.Message = err.Error()
+			if err2 := r.client.Status().Update(ctx, lv); err2 != nil {
+				// err2 is logged but not returned because err is more important
+				log.Error(err2, "failed to update status", "name", lv.Name, "uid", lv.UID)
+			}
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, errors.Join(err, r.failPopulation(ctx, lv, code, err.Error()))
+	}
+
+	lv.Status.Population.Message = ""
+	if err := r.completePopulation(ctx, lv, volume, written); err != nil {
+		log.Error(err, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		return ctrl.Result{}, err
+	}
+
+	log.Info("seeded new LV", "name", lv.Name, "uid", lv.UID, "seed", vs.Namespace+"/"+vs.Name, "written", written)
+	return ctrl.Result{}, nil
+}
+
+// writeSeed writes the raw image of the VolumeSeed into the volume.
+// If zeroGaps is true, the chunks of zeros are written and the rest of the volume is filled with zeros.
+func (r *LogicalVolumeReconciler) writeSeed(ctx context.Context, vs *topolvmv1.VolumeSeed, volume *proto.LogicalVolume,
+	zeroGaps bool, progress func(int64)) (int64, error) {
+	src, err := seed.OpenHTTP(ctx, r.httpClient, vs.Spec.HTTP)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = src.Close() }()
+
+	f, err := os.OpenFile(volume.GetPath(), os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	written, err := seed.WriteRaw(src, f, uint64(volume.GetSizeBytes()), zeroGaps, progress)
+	if err == nil {
+		err = f.Sync()
+	}
+	return written, errors.Join(err, f.Close())
+}
+
+// extractSeed creates a new filesystem of fsType on the volume with the extra arguments of mkfs, and extracts
+// the tarball or the image of the VolumeSeed into it. fsType defaults to the one of the VolumeSeed.
+func (r *LogicalVolumeReconciler) extractSeed(ctx context.Context, vs *topolvmv1.VolumeSeed, volume *proto.LogicalVolume,
+	fsType string, mkfsOptions []string, progress func(int64)) (_ int64, err error) {
+	if fsType == "" {
+		fsType = seed.FsType(&vs.Spec)
+	}
+	args := filesystem.MkfsArgs(fsType, mkfsOptions, volume.GetPath())
+	out, err := r.mounter.Exec.Command("mkfs."+fsType, args...).CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("mkfs.%s failed: args=%v, output=%s, error=%w", fsType, args, string(out), err)
+	}
+
+	dir, err := os.MkdirTemp("", "topolvm-seed-")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { err = errors.Join(err, os.Remove(dir)) }()
+	if err := r.mounter.Mount(volume.GetPath(), dir, fsType, nil); err != nil {
+		return 0, fmt.Errorf("mount failed: %w", err)
+	}
+	defer func() { err = errors.Join(err, r.mounter.Unmount(dir)) }()
+	// the same as the root of a new filesystem created by NodePublishVolume.
+	if err := os.Chmod(dir, 0777|os.ModeSetgid); err != nil {
+		return 0, err
+	}
+
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = root.Close() }()
+	e := seed.NewExtractor(root, progress)
+	if vs.Spec.Image != nil {
+		var credentials *seed.Credentials
+		credentials, err = r.imageCredentials(ctx, vs.Spec.Image)
+		if err == nil {
+			err = seed.ExtractImage(ctx, r.httpClient, vs.Spec.Image.Reference, credentials, e)
+		}
+	} else {
+		var src io.ReadCloser
+		src, err = seed.OpenHTTP(ctx, r.httpClient, vs.Spec.HTTP)
+		if err == nil {
+			err = errors.Join(e.Extract(src), src.Close())
+		}
+	}
+	return e.Written(), err
+}
+
+// imageCredentials returns the credentials for the registry of the image in its pull secrets,
+// or nil if none of them has the credentials.
+func (r *LogicalVolumeReconciler) imageCredentials(ctx context.Context, image *topolvmv1.ImageSeedSource) (*seed.Credentials, error) {
+	if len(image.PullSecrets) == 0 {
+		return nil, nil
+	}
+	if r.seedConfig.PullSecretsNamespace == "" {
+		return nil, fmt.Errorf("%w: pull secrets are not allowed", seed.ErrInvalid)
+	}
+	ref, err := seed.ParseReference(image.Reference)
+	if err != nil {
+		return nil, err
+	}
+	for _, ps := range image.PullSecrets {
+		secret := &corev1.Secret{}
+		if err := r.apiReader.Get(ctx, types.NamespacedName{Namespace: r.seedConfig.PullSecretsNamespace, Name: ps.Name}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get the pull secret %s: %w", ps.Name, err)
+		}
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			return nil, fmt.Errorf("%w: the type of the pull secret %s is not %s", seed.ErrInvalid, ps.Name, corev1.SecretTypeDockerConfigJson)
+		}
+		credentials, err := seed.FindCredentials(secret.Data[corev1.DockerConfigJsonKey], ref.Registry)
+		if err != nil || credentials != nil {
+			return credentials, err
+		}
+	}
+	return nil, nil
+}
+
+// completePopulation sets the volume ID to provision the LogicalVolume.
+func (r *LogicalVolumeReconciler) completePopulation(ctx context.Context, lv *topolvmv1.LogicalVolume, volume *proto.LogicalVolume, copied int64) error {
+	lv.Status.VolumeID = volume.GetName()
+	lv.Status.CurrentSize = resource.NewQuantity(volume.GetSizeBytes(), resource.BinarySI)
+	lv.Status.Population.Phase = topolvmv1.PopulationCompleted
+	lv.Status.Population.CopiedBytes = copied
+	lv.Status.Population.CompletionTime = &metav1.Time{Time: r.now()}
+	return r.client.Status().Update(ctx, lv)
+}
+
+// prepareCopyOnNode starts the population and returns the function asking lvmd to create the LV
+// with the data of the source LV on this node, which may be in another device class.
+func (r *LogicalVolumeReconciler) prepareCopyOnNode(ctx context.Context, lv, sourcelv *topolvmv1.LogicalVolume) (populationFunc, error) {
+	if lv.Status.Population == nil {
+		underMaintenance, err := r.isUnderMaintenance(ctx, lv.Spec.DeviceClass)
+		if err != nil {
+			return nil, err
+		}
+		if underMaintenance {
+			// ResourceExhausted lets the external-provisioner reschedule the PVC to another node.
+			err := errors.New("node is under storage maintenance")
+			return nil, errors.Join(err, r.failPopulation(ctx, lv, codes.ResourceExhausted, err.Error()))
+		}
+		if err := r.startPopulation(ctx, lv); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &proto.CopyLVRequest{
+		Name:                string(lv.UID),
+		DeviceClass:         lv.Spec.DeviceClass,
+		LvcreateOptionClass: lv.Spec.LvcreateOptionClass,
+		SizeBytes:           lv.Spec.Size.Value(),
+		SourceVolume:        sourcelv.Status.VolumeID,
+		SourceDeviceClass:   sourcelv.Spec.DeviceClass,
+	}
+	return func(ctx context.Context, _ func(int64)) (*proto.LogicalVolume, int64, error) {
+		resp, err := r.lvService.CopyLV(ctx, req)
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.GetVolume(), int64(resp.GetCopiedBytes()), nil
+	}, nil
+}
+
+// prepareCopyFromNode creates the LV, starts the population and returns the function copying the data of the source LV
+// from lvmd on the node of the source.
+func (r *LogicalVolumeReconciler) prepareCopyFromNode(ctx context.Context, lv, sourcelv *topolvmv1.LogicalVolume) (populationFunc, error) {
+	volume, err := r.findOrCreateLV(ctx, lv)
+	if err != nil {
+		return nil, err
+	}
+	if lv.Status.Population == nil {
+		if err := r.startPopulation(ctx, lv); err != nil {
+			return nil, err
+		}
+	}
+
+	address, err := replicationAddress(ctx, r.client, sourcelv.Spec.NodeName, r.replicationPort)
+	if err != nil {
+		return nil, err
+	}
+	sourcelv = sourcelv.DeepCopy()
+	return func(ctx context.Context, progress func(int64)) (*proto.LogicalVolume, int64, error) {
+		copied, err := r.copyFromPeer(ctx, address, sourcelv, volume, progress)
+		return volume, copied, err
+	}, nil
+}
+
+// updatePopulationProgress records the number of bytes copied so far by the running job in the status.
+func (r *LogicalVolumeReconciler) updatePopulationProgress(ctx context.Context, lv *topolvmv1.LogicalVolume, job *populationJob) error {
+	copied := job.copied.Load()
+	if copied == lv.Status.Population.CopiedBytes {
+		return nil
+	}
+	lv2 := lv.DeepCopy()
+	lv2.Status.Population.CopiedBytes = copied
+	return r.client.Status().Patch(ctx, lv2, client.MergeFrom(lv))
+}
+
+// startPopulation records the start of the copy.
+func (r *LogicalVolumeReconciler) startPopulation(ctx context.Context, lv *topolvmv1.LogicalVolume) error {
+	lv.Status.Population = &topolvmv1.PopulationStatus{
+		Phase:     topolvmv1.PopulationPopulating,
+		StartTime: metav1.Time{Time: r.now()},
+	}
+	return r.client.Status().Update(ctx, lv)
+}
+
+// findOrCreateLV returns the LV of lv, creating it if it does not exist yet.
+func (r *LogicalVolumeReconciler) findOrCreateLV(ctx context.Context, lv *topolvmv1.LogicalVolume) (*proto.LogicalVolume, error) {
+	res, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range res.GetVolumes() {
+		if v.GetName() == string(lv.UID) {
+			return v, nil
+		}
+	}
+
+	underMaintenance, err := r.isUnderMaintenance(ctx, lv.Spec.DeviceClass)
+	if err != nil {
+		return nil, err
+	}
+	if underMaintenance {
+		// ResourceExhausted lets the external-provisioner reschedule the PVC to another node.
+		err := errors.New("node is under storage maintenance")
+		return nil, errors.Join(err, r.failPopulation(ctx, lv, codes.ResourceExhausted, err.Error()))
+	}
+	resp, err := r.lvService.CreateLV(ctx, &proto.CreateLVRequest{
+		Name:                string(lv.UID),
+		DeviceClass:         lv.Spec.DeviceClass,
+		LvcreateOptionClass: lv.Spec.LvcreateOptionClass,
+		SizeBytes:           lv.Spec.Size.Value(),
+	})
+	if err != nil {
+		code, message := extractFromError(err)
+		return nil, errors.Join(err, r.failPopulation(ctx, lv, code, message))
+	}
+	return resp.GetVolume(), nil
+}
+
+// copyFromPeer writes the data of the source LV read from lvmd on its node into the volume.
+// It returns the number of bytes copied.
+func (r *LogicalVolumeReconciler) copyFromPeer(ctx context.Context, address string, sourcelv *topolvmv1.LogicalVolume,
+	volume *proto.LogicalVolume, progress func(int64)) (int64, error) {
+	peer, closePeer, err := r.dialPeer(address)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = closePeer() }()
+
+	// the node of the source serves the snapshot taken for the copy to this volume.
+	stream, err := peer.ReadLV(ctx, &proto.ReadLVRequest{
+		Name:        sourcelv.Status.VolumeID,
+		DeviceClass: sourcelv.Spec.DeviceClass,
+		CopyId:      volume.GetName(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	header, err := stream.Recv()
+	if err != nil {
+		return 0, err
+	}
+	size := header.GetSizeBytes()
+	if size > uint64(volume.GetSizeBytes()) {
+		return 0, status.Errorf(codes.OutOfRange, "source LV %s is larger than the volume: %d > %d", sourcelv.Name, size, volume.GetSizeBytes())
+	}
+
+	f, err := os.OpenFile(volume.GetPath(), os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+	copied, err := writeStream(stream, f, size, !isThinVolume(volume), progress)
+	if err == nil {
+		err = f.Sync()
+	}
+	return copied, errors.Join(err, f.Close())
+}
+
+// writeStream writes the data received from stream into dst.
+// If zeroGaps is true, the ranges of the source not received are filled with zeros.
+// The number of bytes written so far is reported to progress.
+func writeStream(stream proto.ReplicationService_ReadLVClient, dst io.WriterAt, size uint64, zeroGaps bool,
+	progress func(int64)) (int64, error) {
+	var next uint64
+	var copied int64
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return copied, err
+		}
+		offset, data := res.GetOffset(), res.GetData()
+		if offset < next || offset+uint64(len(data)) > size {
+			return copied, fmt.Errorf("invalid data: offset=%d, length=%d", offset, len(data))
+		}
+		if zeroGaps {
+			if err := backup.WriteZeros(dst, next, offset); err != nil {
+				return copied, err
+			}
+		}
+		if _, err := dst.WriteAt(data, int64(offset)); err != nil {
+			return copied, fmt.Errorf("failed to write at %d: %w", offset, err)
+		}
+		next = offset + uint64(len(data))
+		copied += int64(len(data))
+		progress(copied)
+	}
+	if zeroGaps {
+		return copied, backup.WriteZeros(dst, next, size)
+	}
+	return copied, nil
+}
+
+// failPopulation records the error that is not retried. The controller deletes the LogicalVolume.
+func (r *LogicalVolumeReconciler) failPopulation(ctx context.Context, lv *topolvmv1.LogicalVolume, code codes.Code, message string) error {
+	crlog.FromContext(ctx).Error(errors.New(message), "failed to populate LV", "name", lv.Name)
+	lv.Status.Code = code
+	lv.Status.Message = message
+	return r.client.Status().Update(ctx, lv)
+}
+
+// isUnderMaintenance returns true if new volumes of the device-class must not be created on this node.
+// Existing volumes are still expanded during maintenance.
+func (r *LogicalVolumeReconciler) isUnderMaintenance(ctx context.Context, deviceClass string) (bool, error) {
+	var node metav1.PartialObjectMetadata
+	node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+	if err := r.client.Get(ctx, types.NamespacedName{Name: r.nodeName}, &node); err != nil {
+		return false, err
+	}
+	return maintenance.IsUnderMaintenance(node.Annotations, deviceClass), nil
+}
+
 func (r *LogicalVolumeReconciler) expandLV(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) error {
 	// We denote unknown size as -1.
 	var origBytes int64 = -1
@@ -333,6 +934,116 @@ func (r *LogicalVolumeReconciler) expandLV(ctx context.Context, log logr.Logger,
 	return nil
 }
 
+// revertLV merges the snapshot given by spec.revertSnapshot into the LVM logical volume.
+// The progress is reported in status.revert.
+func (r *LogicalVolumeReconciler) revertLV(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume) error {
+	snapshotName := lv.Spec.RevertSnapshot
+	prev := lv.Status.Revert
+	lv.Status.Revert = &topolvmv1.RevertStatus{
+		Snapshot:  snapshotName,
+		Phase:     topolvmv1.RevertMerging,
+		StartTime: metav1.Now(),
+	}
+
+	size, err := func() (int64, error) {
+		snapshotlv := new(topolvmv1.LogicalVolume)
+		if err := r.client.Get(ctx, types.NamespacedName{Name: snapshotName}, snapshotlv); err != nil {
+			log.Error(err, "unable to fetch snapshot LogicalVolume", "name", lv.Name, "snapshot", snapshotName)
+			return 0, err
+		}
+		if snapshotlv.Spec.Source != lv.Name || snapshotlv.Spec.NodeName != lv.Spec.NodeName || snapshotlv.Status.VolumeID == "" {
+			return 0, fmt.Errorf("LogicalVolume %s is not a snapshot of %s", snapshotName, lv.Name)
+		}
+
+		// In case the node crashed just after the merge, the snapshot LV has already been consumed.
+		if prev != nil && prev.Snapshot == snapshotName && prev.Phase == topolvmv1.RevertMerging {
+			respList, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+			if err != nil {
+				log.Error(err, "failed to get list of LV")
+				return 0, err
+			}
+			var volume, snapshot *proto.LogicalVolume
+			for _, v := range respList.Volumes {
+				switch v.Name {
+				case string(lv.UID):
+					volume = v
+				case snapshotlv.Status.VolumeID:
+					snapshot = v
+				}
+			}
+			if volume != nil && snapshot == nil {
+				log.Info("snapshot LV has already been merged", "name", lv.Name, "snapshot", snapshotName)
+				lv.Status.Revert.StartTime = prev.StartTime
+				return volume.SizeBytes, nil
+			}
+		}
+
+		// Record the start of the merge before calling lvmd so that the volume is not published during it.
+		if err := r.client.Status().Update(ctx, lv); err != nil {
+			return 0, err
+		}
+
+		resp, err := r.lvService.MergeLVSnapshot(ctx, &proto.MergeLVSnapshotRequest{
+			Name:        string(lv.UID),
+			Snapshot:    snapshotlv.Status.VolumeID,
+			DeviceClass: lv.Spec.DeviceClass,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return resp.Volume.SizeBytes, nil
+	}()
+
+	if err != nil {
+		_, message := extractFromError(err)
+		lv.Status.Revert.Phase = topolvmv1.RevertFailed
+		lv.Status.Revert.Message = message
+		if err2 := r.client.Status().Update(ctx, lv); err2 != nil {
+			// err2 is logged but not returned because err is more important
+			log.Error(err2, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		}
+		return err
+	}
+
+	now := metav1.Now()
+	lv.Status.Revert.Phase = topolvmv1.RevertCompleted
+	lv.Status.Revert.CompletionTime = &now
+	lv.Status.CurrentSize = resource.NewQuantity(size, resource.BinarySI)
+	if err := r.client.Status().Update(ctx, lv); err != nil {
+		log.Error(err, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		return err
+	}
+
+	log.Info("reverted LV", "name", lv.Name, "uid", lv.UID, "snapshot", snapshotName, "status.currentSize", lv.Status.CurrentSize)
+	return nil
+}
+
+// verifyLV reports whether the LVM logical volume exists and its size in status.verification.
+func (r *LogicalVolumeReconciler) verifyLV(ctx context.Context, log logr.Logger, lv *topolvmv1.LogicalVolume, requestedAt string) error {
+	respList, err := r.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+	if err != nil {
+		log.Error(err, "failed to get list of LV")
+		return err
+	}
+
+	lv.Status.Verification = &topolvmv1.VerificationStatus{RequestedAt: requestedAt}
+	for _, v := range respList.Volumes {
+		if v.Name == lv.Status.VolumeID {
+			lv.Status.Verification.Found = true
+			lv.Status.Verification.SizeBytes = v.SizeBytes
+			break
+		}
+	}
+	if err := r.client.Status().Update(ctx, lv); err != nil {
+		log.Error(err, "failed to update status", "name", lv.Name, "uid", lv.UID)
+		return err
+	}
+
+	log.Info("verified LV", "name", lv.Name, "uid", lv.UID,
+		"found", lv.Status.Verification.Found, "size", lv.Status.Verification.SizeBytes)
+	return nil
+}
+
 type logicalVolumeFilter struct {
 	nodeName string
 }
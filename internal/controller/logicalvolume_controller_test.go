@@ -10,12 +10,18 @@ import (
 	topolvmv1 "github.com/topolvm/topolvm/api/v1"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	storegev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -64,6 +70,50 @@ func (MockLVServiceClient) CreateLVSnapshot(ctx context.Context, in *proto.Creat
 	panic("unimplemented")
 }
 
+// MergeLVSnapshot implements proto.LVServiceClient.
+func (MockLVServiceClient) MergeLVSnapshot(ctx context.Context, in *proto.MergeLVSnapshotRequest, opts ...grpc.CallOption) (*proto.MergeLVSnapshotResponse, error) {
+	var merged *proto.LogicalVolume
+	for _, v := range *volumes {
+		if v.Name == in.Name {
+			merged = v
+		}
+	}
+	if merged == nil {
+		return nil, status.Errorf(codes.NotFound, "logical volume %s is not found", in.Name)
+	}
+	return &proto.MergeLVSnapshotResponse{Volume: merged}, nil
+}
+
+// GetLVBlockMetadata implements proto.LVServiceClient.
+func (MockLVServiceClient) GetLVBlockMetadata(ctx context.Context, in *proto.GetLVBlockMetadataRequest, opts ...grpc.CallOption) (proto.LVService_GetLVBlockMetadataClient, error) {
+	panic("unimplemented")
+}
+
+// CopyLV implements proto.LVServiceClient.
+func (MockLVServiceClient) CopyLV(ctx context.Context, in *proto.CopyLVRequest, opts ...grpc.CallOption) (*proto.CopyLVResponse, error) {
+	panic("unimplemented")
+}
+
+// UndeleteLV implements proto.LVServiceClient.
+func (MockLVServiceClient) UndeleteLV(ctx context.Context, in *proto.UndeleteLVRequest, opts ...grpc.CallOption) (*proto.UndeleteLVResponse, error) {
+	panic("unimplemented")
+}
+
+// PrepareLVCopy implements proto.LVServiceClient.
+func (MockLVServiceClient) PrepareLVCopy(ctx context.Context, in *proto.PrepareLVCopyRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
+	panic("unimplemented")
+}
+
+// RemoveLVCopy implements proto.LVServiceClient.
+func (MockLVServiceClient) RemoveLVCopy(ctx context.Context, in *proto.RemoveLVCopyRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
+	panic("unimplemented")
+}
+
+// ReplicateLV implements proto.LVServiceClient.
+func (MockLVServiceClient) ReplicateLV(ctx context.Context, in *proto.ReplicateLVRequest, opts ...grpc.CallOption) (*proto.ReplicateLVResponse, error) {
+	panic("unimplemented")
+}
+
 // RemoveLV implements proto.LVServiceClient.
 func (MockLVServiceClient) RemoveLV(ctx context.Context, in *proto.RemoveLVRequest, opts ...grpc.CallOption) (*proto.Empty, error) {
 	panic("unimplemented")
@@ -74,6 +124,56 @@ func (MockLVServiceClient) ResizeLV(ctx context.Context, in *proto.ResizeLVReque
 	panic("unimplemented")
 }
 
+type removeLVServiceMock struct {
+	MockLVServiceClient
+	removeRequests []*proto.RemoveLVRequest
+}
+
+func (m *removeLVServiceMock) RemoveLV(_ context.Context, in *proto.RemoveLVRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	m.removeRequests = append(m.removeRequests, in)
+	return &proto.Empty{}, nil
+}
+
+var _ = Describe("LogicalVolume controller removing volumes", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "lv"}}
+
+	removeLV := func(spec topolvmv1.LogicalVolumeSpec) *proto.RemoveLVRequest {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		now := metav1.Now()
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "lv",
+				UID:               "lv-uid",
+				Finalizers:        []string{topolvm.GetLogicalVolumeFinalizer()},
+				DeletionTimestamp: &now,
+			},
+			Spec: spec,
+		}
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(lv).Build()
+		lvService := &removeLVServiceMock{}
+		r := NewLogicalVolumeReconcilerWithServices(c, c, "node1", MockVGServiceClient{}, lvService, 0, nil, SeedConfig{})
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lvService.removeRequests).To(HaveLen(1))
+		Expect(lvService.removeRequests[0].GetName()).To(Equal("lv-uid"))
+		return lvService.removeRequests[0]
+	}
+
+	It("should keep the volume restored from a snapshot in the trash", func() {
+		res := removeLV(topolvmv1.LogicalVolumeSpec{NodeName: "node1", DeviceClass: "thin", Source: "snapshot", AccessType: "rw"})
+		Expect(res.GetSkipTrash()).To(BeFalse())
+	})
+
+	It("should remove the snapshot for a VolumeSnapshot without keeping it in the trash", func() {
+		res := removeLV(topolvmv1.LogicalVolumeSpec{NodeName: "node1", DeviceClass: "thin", Source: "source", AccessType: "ro"})
+		Expect(res.GetSkipTrash()).To(BeTrue())
+	})
+})
+
 var _ = Describe("LogicalVolume controller", func() {
 	ctx := context.Background()
 	var stopFunc func()
@@ -97,7 +197,7 @@ var _ = Describe("LogicalVolume controller", func() {
 		vgService = MockVGServiceClient{}
 		lvService = MockLVServiceClient{}
 
-		reconciler := NewLogicalVolumeReconcilerWithServices(mgr.GetClient(), nodeNameBase+suffix, vgService, lvService)
+		reconciler := NewLogicalVolumeReconcilerWithServices(mgr.GetClient(), mgr.GetAPIReader(), nodeNameBase+suffix, vgService, lvService, 0, nil, SeedConfig{})
 		err = reconciler.SetupWithManager(mgr)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -114,10 +214,11 @@ var _ = Describe("LogicalVolume controller", func() {
 		Expect(<-errCh).NotTo(HaveOccurred())
 	})
 
-	setupResources := func(ctx context.Context, suffix string) topolvmv1.LogicalVolume {
+	setupResources := func(ctx context.Context, suffix string, nodeAnnotations map[string]string) topolvmv1.LogicalVolume {
 		node := corev1.Node{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: nodeNameBase + suffix,
+				Name:        nodeNameBase + suffix,
+				Annotations: nodeAnnotations,
 				Finalizers: []string{
 					topolvm.GetNodeFinalizer(),
 				},
@@ -179,7 +280,7 @@ var _ = Describe("LogicalVolume controller", func() {
 		ctx := context.Background()
 
 		// Setup
-		lv := setupResources(ctx, "-add-finalizer")
+		lv := setupResources(ctx, "-add-finalizer", nil)
 
 		// Verify
 		// ensure LV has finalizer
@@ -198,7 +299,7 @@ var _ = Describe("LogicalVolume controller", func() {
 		ctx := context.Background()
 
 		// Setup
-		lv := setupResources(ctx, "-pendingdeletion")
+		lv := setupResources(ctx, "-pendingdeletion", nil)
 
 		// ensure LV gets finalizer
 		Eventually(func(g Gomega) bool {
@@ -229,4 +330,74 @@ var _ = Describe("LogicalVolume controller", func() {
 			return !controllerutil.ContainsFinalizer(&lv, topolvm.GetLogicalVolumeFinalizer())
 		}, "2s").Should(BeTrue())
 	})
+
+	It("should not create LV on the node under storage maintenance", func() {
+		startReconciler("-maintenance")
+
+		ctx := context.Background()
+
+		// Setup
+		lv := setupResources(ctx, "-maintenance", map[string]string{
+			topolvm.GetMaintenanceKey(): "true",
+		})
+
+		// Verify
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lv.Status.Code).To(Equal(codes.ResourceExhausted))
+		}).Should(Succeed())
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+	})
+
+	It("should merge the snapshot into LV when spec.revertSnapshot is set", func() {
+		startReconciler("-revert")
+
+		ctx := context.Background()
+
+		// Setup
+		lv := setupResources(ctx, "-revert", nil)
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lv.Status.VolumeID).NotTo(BeEmpty())
+		}).Should(Succeed())
+
+		// The snapshot is ignored by the reconciler, because CreateLVSnapshot is not implemented by the mock.
+		snapshot := topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: logicalVolumeNameBase + "-revert-snapshot",
+				Annotations: map[string]string{
+					topolvm.GetLVPendingDeletionKey(): "true",
+				},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				NodeName:   lv.Spec.NodeName,
+				Source:     lv.Name,
+				AccessType: "ro",
+			},
+		}
+		err := k8sClient.Create(ctx, &snapshot)
+		Expect(err).NotTo(HaveOccurred())
+		snapshot.Status.VolumeID = string(snapshot.UID)
+		err = k8sClient.Status().Update(ctx, &snapshot)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(lv.IsReverting()).To(BeFalse())
+		lv2 := lv.DeepCopy()
+		lv2.Spec.RevertSnapshot = snapshot.Name
+		err = k8sClient.Patch(ctx, lv2, client.MergeFrom(&lv))
+		Expect(err).NotTo(HaveOccurred())
+
+		// Verify
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lv.Status.Revert).NotTo(BeNil())
+			g.Expect(lv.Status.Revert.Snapshot).To(Equal(snapshot.Name))
+			g.Expect(lv.Status.Revert.Phase).To(Equal(topolvmv1.RevertCompleted))
+			g.Expect(lv.Status.Revert.CompletionTime).NotTo(BeNil())
+		}).Should(Succeed())
+		Expect(lv.IsReverting()).To(BeFalse())
+	})
 })
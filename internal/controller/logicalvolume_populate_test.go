@@ -0,0 +1,412 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	mountutil "k8s.io/mount-utils"
+	utilexec "k8s.io/utils/exec"
+	testingexec "k8s.io/utils/exec/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type populateLVServiceMock struct {
+	MockLVServiceClient
+	vgService      *backupVGServiceMock
+	path           string
+	createRequests []*proto.CreateLVRequest
+	copyRequests   []*proto.CopyLVRequest
+}
+
+func (m *populateLVServiceMock) CreateLV(_ context.Context, in *proto.CreateLVRequest, _ ...grpc.CallOption) (*proto.CreateLVResponse, error) {
+	m.createRequests = append(m.createRequests, in)
+	vol := &proto.LogicalVolume{Name: in.GetName(), SizeBytes: in.GetSizeBytes(), Path: m.path}
+	m.vgService.volumes = append(m.vgService.volumes, vol)
+	return &proto.CreateLVResponse{Volume: vol}, nil
+}
+
+func (m *populateLVServiceMock) CopyLV(_ context.Context, in *proto.CopyLVRequest, _ ...grpc.CallOption) (*proto.CopyLVResponse, error) {
+	m.copyRequests = append(m.copyRequests, in)
+	vol := &proto.LogicalVolume{Name: in.GetName(), SizeBytes: in.GetSizeBytes(), Path: m.path}
+	m.vgService.volumes = append(m.vgService.volumes, vol)
+	return &proto.CopyLVResponse{Volume: vol, CopiedBytes: 4096}, nil
+}
+
+type populateReplicationServiceMock struct {
+	proto.ReplicationServiceClient
+	responses    []*proto.ReadLVResponse
+	readRequests []*proto.ReadLVRequest
+	// if release is not nil, the stream ends after it is closed.
+	release chan struct{}
+}
+
+func (m *populateReplicationServiceMock) ReadLV(_ context.Context, in *proto.ReadLVRequest, _ ...grpc.CallOption) (proto.ReplicationService_ReadLVClient, error) {
+	m.readRequests = append(m.readRequests, in)
+	return &readLVClientMock{responses: m.responses, release: m.release}, nil
+}
+
+type readLVClientMock struct {
+	grpc.ClientStream
+	responses []*proto.ReadLVResponse
+	release   chan struct{}
+}
+
+func (c *readLVClientMock) Recv() (*proto.ReadLVResponse, error) {
+	if len(c.responses) == 0 {
+		if c.release != nil {
+			<-c.release
+		}
+		return nil, io.EOF
+	}
+	res := c.responses[0]
+	c.responses = c.responses[1:]
+	return res, nil
+}
+
+var _ = Describe("LogicalVolume controller populating volumes", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "clone"}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	const size = 64 << 10
+
+	type fixture struct {
+		r         *LogicalVolumeReconciler
+		c         client.Client
+		lvService *populateLVServiceMock
+		peer      *populateReplicationServiceMock
+		addresses []string
+		path      string
+	}
+	newFixture := func(replicationPort int, objs ...client.Object) *fixture {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(objs...).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}).
+			Build()
+
+		// the new LV has garbage, which must be overwritten for a thick volume.
+		path := filepath.Join(GinkgoT().TempDir(), "device")
+		Expect(os.WriteFile(path, bytes.Repeat([]byte{0xff}, size), 0644)).To(Succeed())
+
+		vgService := &backupVGServiceMock{}
+		f := &fixture{
+			c:         c,
+			lvService: &populateLVServiceMock{vgService: vgService, path: path},
+			peer: &populateReplicationServiceMock{responses: []*proto.ReadLVResponse{
+				{SizeBytes: size},
+				{Offset: 0, Data: []byte("head")},
+				{Offset: 40960, Data: []byte("tail")},
+			}},
+			path: path,
+		}
+		f.r = NewLogicalVolumeReconcilerWithServices(c, c, "node1", vgService, f.lvService, replicationPort, nil, SeedConfig{})
+		f.r.now = func() time.Time { return now }
+		f.r.dialPeer = func(address string) (proto.ReplicationServiceClient, func() error, error) {
+			f.addresses = append(f.addresses, address)
+			return f.peer, func() error { return nil }, nil
+		}
+		return f
+	}
+
+	objects := func(withSource bool, sourceNode string) []client.Object {
+		nodes := []client.Object{
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+				Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+					{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+				}},
+			},
+		}
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "clone",
+				UID:        "clone-uid",
+				Finalizers: []string{topolvm.GetLogicalVolumeFinalizer()},
+				Labels:     map[string]string{topolvm.CreatedbyLabelKey: topolvm.CreatedbyLabelValue},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        "clone",
+				NodeName:    "node1",
+				DeviceClass: "thick",
+				Size:        *resource.NewQuantity(size, resource.BinarySI),
+				CopySource:  "source",
+			},
+		}
+		objs := append(nodes, lv)
+		if withSource {
+			objs = append(objs, &topolvmv1.LogicalVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "source"},
+				Spec:       topolvmv1.LogicalVolumeSpec{Name: "source", NodeName: sourceNode, DeviceClass: "thin"},
+				Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "vol"},
+			})
+		}
+		return objs
+	}
+
+	// reconcile reconciles the LogicalVolume until the background job populating it finishes.
+	reconcile := func(f *fixture) error {
+		var err error
+		Eventually(func() time.Duration {
+			var result ctrl.Result
+			result, err = f.r.Reconcile(ctx, req)
+			return result.RequeueAfter
+		}).Should(BeZero())
+		return err
+	}
+
+	getLV := func(c client.Client) *topolvmv1.LogicalVolume {
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, req.NamespacedName, lv)).To(Succeed())
+		return lv
+	}
+
+	It("should copy the source from its node before setting the volume ID", func() {
+		f := newFixture(9445, objects(true, "node2")...)
+
+		err := reconcile(f)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(f.lvService.createRequests).To(HaveLen(1))
+		Expect(f.lvService.createRequests[0].GetName()).To(Equal("clone-uid"))
+		Expect(f.lvService.createRequests[0].GetDeviceClass()).To(Equal("thick"))
+		Expect(f.addresses).To(Equal([]string{"10.0.0.2:9445"}))
+		Expect(f.peer.readRequests).To(HaveLen(1))
+		Expect(f.peer.readRequests[0].GetName()).To(Equal("vol"))
+		Expect(f.peer.readRequests[0].GetDeviceClass()).To(Equal("thin"))
+		Expect(f.peer.readRequests[0].GetCopyId()).To(Equal("clone-uid"))
+
+		expected := make([]byte, size)
+		copy(expected, "head")
+		copy(expected[40960:], "tail")
+		Expect(os.ReadFile(f.path)).To(Equal(expected))
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.OK))
+		Expect(lv.Status.VolumeID).To(Equal("clone-uid"))
+		Expect(lv.Status.CurrentSize.Value()).To(BeEquivalentTo(size))
+		Expect(lv.Status.Population).NotTo(BeNil())
+		Expect(lv.Status.Population.Phase).To(Equal(topolvmv1.PopulationCompleted))
+		Expect(lv.Status.Population.CopiedBytes).To(BeEquivalentTo(8))
+		Expect(lv.Status.Population.StartTime.Time).To(BeTemporally("==", now))
+		Expect(lv.Status.Population.CompletionTime.Time).To(BeTemporally("==", now))
+	})
+
+	It("should record the progress while copying the source in the background", func() {
+		f := newFixture(9445, objects(true, "node2")...)
+		f.peer.release = make(chan struct{})
+
+		result, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(populationProgressInterval))
+
+		Eventually(func() int64 {
+			_, err := f.r.Reconcile(ctx, req)
+			Expect(err).NotTo(HaveOccurred())
+			return getLV(f.c).Status.Population.CopiedBytes
+		}).Should(BeEquivalentTo(8))
+		lv := getLV(f.c)
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+		Expect(lv.Status.Population.Phase).To(Equal(topolvmv1.PopulationPopulating))
+
+		close(f.peer.release)
+		Expect(reconcile(f)).To(Succeed())
+		Expect(f.peer.readRequests).To(HaveLen(1))
+		Expect(getLV(f.c).Status.VolumeID).To(Equal("clone-uid"))
+	})
+
+	It("should fail if the source does not exist", func() {
+		f := newFixture(9445, objects(false, "node2")...)
+
+		err := reconcile(f)
+		Expect(err).NotTo(HaveOccurred())
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.NotFound))
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+		Expect(f.lvService.createRequests).To(BeEmpty())
+	})
+
+	It("should fail if the replication port is not given", func() {
+		f := newFixture(0, objects(true, "node2")...)
+
+		err := reconcile(f)
+		Expect(err).NotTo(HaveOccurred())
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.FailedPrecondition))
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+		Expect(f.peer.readRequests).To(BeEmpty())
+	})
+
+	It("should fail if the source is larger than the volume", func() {
+		f := newFixture(9445, objects(true, "node2")...)
+		f.peer.responses = []*proto.ReadLVResponse{{SizeBytes: 2 * size}}
+
+		err := reconcile(f)
+		Expect(err).To(HaveOccurred())
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.OutOfRange))
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+		Expect(lv.Status.Population.Phase).To(Equal(topolvmv1.PopulationPopulating))
+	})
+
+	It("should copy the source in another device class on the same node by lvmd", func() {
+		f := newFixture(0, objects(true, "node1")...)
+
+		err := reconcile(f)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(f.lvService.createRequests).To(BeEmpty())
+		Expect(f.addresses).To(BeEmpty())
+		Expect(f.lvService.copyRequests).To(HaveLen(1))
+		copyReq := f.lvService.copyRequests[0]
+		Expect(copyReq.GetName()).To(Equal("clone-uid"))
+		Expect(copyReq.GetDeviceClass()).To(Equal("thick"))
+		Expect(copyReq.GetSizeBytes()).To(BeEquivalentTo(size))
+		Expect(copyReq.GetSourceVolume()).To(Equal("vol"))
+		Expect(copyReq.GetSourceDeviceClass()).To(Equal("thin"))
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.OK))
+		Expect(lv.Status.VolumeID).To(Equal("clone-uid"))
+		Expect(lv.Status.Population.Phase).To(Equal(topolvmv1.PopulationCompleted))
+		Expect(lv.Status.Population.CopiedBytes).To(BeEquivalentTo(4096))
+	})
+
+	seedObjects := func(url, sha256 string) []client.Object {
+		objs := objects(false, "")
+		lv := objs[len(objs)-1].(*topolvmv1.LogicalVolume)
+		lv.Spec.CopySource = ""
+		lv.Spec.Seed = &topolvmv1.SeedReference{Namespace: "test", Name: "seed"}
+		return append(objs, &topolvmv1.VolumeSeed{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "seed"},
+			Spec:       topolvmv1.VolumeSeedSpec{HTTP: &topolvmv1.HTTPSeedSource{URL: url, SHA256: sha256}},
+		})
+	}
+
+	It("should write the raw image of the seed before setting the volume ID", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("seed"))
+		}))
+		defer server.Close()
+		f := newFixture(0, seedObjects(server.URL, "")...)
+		f.r.httpClient = server.Client()
+
+		err := reconcile(f)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(f.lvService.createRequests).To(HaveLen(1))
+		expected := make([]byte, size)
+		copy(expected, "seed")
+		Expect(os.ReadFile(f.path)).To(Equal(expected))
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.OK))
+		Expect(lv.Status.VolumeID).To(Equal("clone-uid"))
+		Expect(lv.Status.Population.Phase).To(Equal(topolvmv1.PopulationCompleted))
+		Expect(lv.Status.Population.CopiedBytes).To(BeEquivalentTo(4))
+	})
+
+	It("should retry the errors in fetching the seed", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+		f := newFixture(0, seedObjects(server.URL, "")...)
+		f.r.httpClient = server.Client()
+
+		err := reconcile(f)
+		Expect(err).To(HaveOccurred())
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.OK))
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+		Expect(lv.Status.Population.Phase).To(Equal(topolvmv1.PopulationPopulating))
+		Expect(lv.Status.Population.Message).To(ContainSubstring("503"))
+	})
+
+	It("should fail if the checksum of the seed does not match", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("seed"))
+		}))
+		defer server.Close()
+		f := newFixture(0, seedObjects(server.URL, strings.Repeat("0", 64))...)
+		f.r.httpClient = server.Client()
+
+		err := reconcile(f)
+		Expect(err).To(HaveOccurred())
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.DataLoss))
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+	})
+
+	It("should refuse to download the seed from a loopback address", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("seed"))
+		}))
+		defer server.Close()
+		f := newFixture(0, seedObjects(server.URL, "")...)
+
+		err := reconcile(f)
+		Expect(err).To(HaveOccurred())
+
+		lv := getLV(f.c)
+		Expect(lv.Status.Code).To(Equal(codes.InvalidArgument))
+		Expect(lv.Status.VolumeID).To(BeEmpty())
+	})
+
+	It("should create the filesystem of the seed with the mkfs options of the LogicalVolume", func() {
+		objs := seedObjects("https://example.com/seed.tar", "")
+		lv := objs[len(objs)-2].(*topolvmv1.LogicalVolume)
+		lv.Spec.FsType = "xfs"
+		lv.Spec.MkfsOptions = []string{"-L", "data"}
+		objs[len(objs)-1].(*topolvmv1.VolumeSeed).Spec.Format = topolvmv1.SeedFormatTar
+		f := newFixture(0, objs...)
+
+		var command string
+		var args []string
+		fakeExec := &testingexec.FakeExec{}
+		fakeExec.CommandScript = []testingexec.FakeCommandAction{
+			func(cmd string, a ...string) utilexec.Cmd {
+				command, args = cmd, a
+				return &testingexec.FakeCmd{CombinedOutputScript: []testingexec.FakeAction{
+					func() ([]byte, []byte, error) { return nil, nil, errors.New("mkfs is not run in the test") },
+				}}
+			},
+		}
+		f.r.mounter = &mountutil.SafeFormatAndMount{Interface: mountutil.NewFakeMounter(nil), Exec: fakeExec}
+
+		err := reconcile(f)
+		Expect(err).To(HaveOccurred())
+		Expect(command).To(Equal("mkfs.xfs"))
+		Expect(args).To(Equal([]string{"-f", "-L", "data", f.path}))
+	})
+})
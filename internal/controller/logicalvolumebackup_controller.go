@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// requeueIntervalForBackupError is the requeue interval after a failed backup or restore.
+	requeueIntervalForBackupError = 1 * time.Minute
+
+	// keys of the Secret of S3 credentials.
+	s3AccessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	s3SecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+// BackupConfig restricts the locations of LogicalVolumeBackups and LogicalVolumeRestores.
+type BackupConfig struct {
+	// BaseDir is the directory on the node in which location.path must be.
+	// The locations in paths are rejected if it is empty.
+	BaseDir string
+
+	// CredentialsNamespace is the namespace of the Secrets of S3 credentials.
+	// The locations in S3 are rejected if it is empty.
+	CredentialsNamespace string
+}
+
+// LogicalVolumeBackupReconciler stores the data of the LogicalVolumes on this node as requested by LogicalVolumeBackups.
+type LogicalVolumeBackupReconciler struct {
+	client    client.Client
+	apiReader client.Reader
+	nodeName  string
+	vgService proto.VGServiceClient
+	lvService proto.LVServiceClient
+	config    BackupConfig
+	now       func() time.Time
+}
+
+// NewLogicalVolumeBackupReconciler returns LogicalVolumeBackupReconciler.
+// apiReader is used to read the Secrets of S3 credentials without caching all the Secrets.
+func NewLogicalVolumeBackupReconciler(client client.Client, apiReader client.Reader, nodeName string,
+	vgService proto.VGServiceClient, lvService proto.LVServiceClient, config BackupConfig) *LogicalVolumeBackupReconciler {
+	return &LogicalVolumeBackupReconciler{
+		client:    client,
+		apiReader: apiReader,
+		nodeName:  nodeName,
+		vgService: vgService,
+		lvService: lvService,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumebackups,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumebackups/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,namespace=topolvm-system,resources=secrets,verbs=get
+
+// Reconcile stores the data of the LogicalVolume once. A completed or failed backup is never retried.
+func (r *LogicalVolumeBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lvb := &topolvmv1.LogicalVolumeBackup{}
+	err := r.client.Get(ctx, req.NamespacedName, lvb)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	if lvb.DeletionTimestamp != nil || isBackupFinished(lvb.Status.Phase) {
+		return ctrl.Result{}, nil
+	}
+
+	lv := &topolvmv1.LogicalVolume{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: lvb.Spec.LogicalVolumeName}, lv)
+	if apierrors.IsNotFound(err) {
+		// The node to run the backup is unknown.
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if lv.Spec.NodeName != r.nodeName {
+		return ctrl.Result{}, nil
+	}
+	if lv.Status.VolumeID == "" {
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	if lvb.Status.Phase == "" {
+		lvb.Status.Phase = topolvmv1.BackupRunning
+		lvb.Status.NodeName = r.nodeName
+		lvb.Status.StartTime = &metav1.Time{Time: r.now()}
+		err := validateBackupLocation(&lvb.Spec.Location, r.config)
+		if err == nil && !isSnapshot(lv) {
+			// The data of a volume in use may be changed during the backup.
+			err = fmt.Errorf("LogicalVolume %s is not a snapshot", lv.Name)
+		}
+		if err != nil {
+			lvb.Status.Phase = topolvmv1.BackupFailed
+			lvb.Status.CompletionTime = lvb.Status.StartTime
+			lvb.Status.Message = err.Error()
+		}
+		if err := r.client.Status().Update(ctx, lvb); err != nil {
+			log.Error(err, "failed to update the status", "name", lvb.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	var result ctrl.Result
+	manifest, err := r.backup(ctx, lvb, lv)
+	if err != nil {
+		log.Error(err, "failed to back up volume", "name", lvb.Name)
+		lvb.Status.Message = err.Error()
+		result.RequeueAfter = requeueIntervalForBackupError
+	} else {
+		log.Info("backed up volume", "name", lvb.Name, "size", manifest.SizeBytes, "stored", manifest.StoredBytes())
+		lvb.Status.Phase = topolvmv1.BackupCompleted
+		lvb.Status.SizeBytes = int64(manifest.SizeBytes)
+		lvb.Status.StoredBytes = int64(manifest.StoredBytes())
+		lvb.Status.Chunks = int32(len(manifest.Chunks))
+		lvb.Status.CompletionTime = &metav1.Time{Time: r.now()}
+		lvb.Status.Message = ""
+	}
+	if err := r.client.Status().Update(ctx, lvb); err != nil {
+		log.Error(err, "failed to update the status", "name", lvb.Name)
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// backup stores the allocated ranges of the volume. The whole volume is stored for a thick volume.
+func (r *LogicalVolumeBackupReconciler) backup(ctx context.Context, lvb *topolvmv1.LogicalVolumeBackup,
+	lv *topolvmv1.LogicalVolume) (*backup.Manifest, error) {
+	store, err := backupStore(ctx, r.apiReader, &lvb.Spec.Location, r.config)
+	if err != nil {
+		return nil, err
+	}
+	vol, err := findVolume(ctx, r.vgService, lv.Spec.DeviceClass, lv.Status.VolumeID)
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(vol.GetSizeBytes())
+	ranges, err := r.allocatedRanges(ctx, lv)
+	if status.Code(err) == codes.Unimplemented {
+		ranges = []backup.Range{{Offset: 0, Length: size}}
+	} else if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(vol.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return backup.Export(ctx, store, f, size, ranges, backup.DefaultChunkSize)
+}
+
+func (r *LogicalVolumeBackupReconciler) allocatedRanges(ctx context.Context, lv *topolvmv1.LogicalVolume) ([]backup.Range, error) {
+	stream, err := r.lvService.GetLVBlockMetadata(ctx, &proto.GetLVBlockMetadataRequest{
+		Name:        lv.Status.VolumeID,
+		DeviceClass: lv.Spec.DeviceClass,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ranges []backup.Range
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return ranges, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, br := range res.GetRanges() {
+			ranges = append(ranges, backup.Range{Offset: br.GetOffset(), Length: br.GetLength()})
+		}
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LogicalVolumeBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("logicalvolumebackup").
+		For(&topolvmv1.LogicalVolumeBackup{}).
+		Complete(r)
+}
+
+func isBackupFinished(phase topolvmv1.BackupPhase) bool {
+	return phase == topolvmv1.BackupCompleted || phase == topolvmv1.BackupFailed
+}
+
+// isSnapshot returns true if the LogicalVolume is a read-only snapshot of another LogicalVolume.
+func isSnapshot(lv *topolvmv1.LogicalVolume) bool {
+	return lv.Spec.Source != "" && lv.Spec.AccessType == "ro"
+}
+
+func validateBackupLocation(location *topolvmv1.BackupLocation, config BackupConfig) error {
+	if (location.Path == "") == (location.S3 == nil) {
+		return errors.New("exactly one of location.path and location.s3 must be set")
+	}
+	if location.S3 != nil && config.CredentialsNamespace == "" {
+		return errors.New("location.s3 is disabled because the namespace of the credentials is not configured")
+	}
+	if location.Path != "" {
+		if config.BaseDir == "" {
+			return errors.New("location.path is disabled because the base directory is not configured")
+		}
+		if _, err := backup.NewFileStoreIn(config.BaseDir, location.Path); err != nil {
+			return fmt.Errorf("invalid location.path: %w", err)
+		}
+	}
+	return nil
+}
+
+// backupStore returns the store of the location.
+// The credentials of S3 are read from the Secret in the namespace of the credentials.
+func backupStore(ctx context.Context, reader client.Reader, location *topolvmv1.BackupLocation, config BackupConfig) (backup.Store, error) {
+	if location.S3 == nil {
+		return backup.NewFileStoreIn(config.BaseDir, location.Path)
+	}
+
+	name := types.NamespacedName{Namespace: config.CredentialsNamespace, Name: location.S3.CredentialsSecretRef.Name}
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, name, secret); err != nil {
+		return nil, fmt.Errorf("failed to get the credentials: %w", err)
+	}
+	return backup.NewS3Store(backup.S3Config{
+		Endpoint:        location.S3.Endpoint,
+		Region:          location.S3.Region,
+		Bucket:          location.S3.Bucket,
+		Prefix:          location.S3.Prefix,
+		AccessKeyID:     string(secret.Data[s3AccessKeyIDKey]),
+		SecretAccessKey: string(secret.Data[s3SecretAccessKeyKey]),
+	}, nil)
+}
+
+// findVolume returns the logical volume of the device class reported by lvmd.
+func findVolume(ctx context.Context, vgService proto.VGServiceClient, deviceClass, name string) (*proto.LogicalVolume, error) {
+	res, err := vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: deviceClass})
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range res.GetVolumes() {
+		if v.GetName() == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("logical volume %s is not found", name)
+}
+
+// isThinVolume returns true if the logical volume is a thin volume, which reads zeros where nothing has been written.
+func isThinVolume(vol *proto.LogicalVolume) bool {
+	attr, err := command.ParsedLVAttr(vol.GetAttr())
+	return err == nil && attr.VolumeType == command.VolumeTypeThinVolume
+}
@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type backupVGServiceMock struct {
+	MockVGServiceClient
+	volumes []*proto.LogicalVolume
+}
+
+func (m *backupVGServiceMock) GetLVList(_ context.Context, _ *proto.GetLVListRequest, _ ...grpc.CallOption) (*proto.GetLVListResponse, error) {
+	return &proto.GetLVListResponse{Volumes: m.volumes}, nil
+}
+
+type backupLVServiceMock struct {
+	MockLVServiceClient
+	ranges []*proto.BlockRange
+	// thick makes GetLVBlockMetadata fail as lvmd does for a thick volume.
+	thick bool
+}
+
+func (m *backupLVServiceMock) GetLVBlockMetadata(_ context.Context, in *proto.GetLVBlockMetadataRequest, _ ...grpc.CallOption) (proto.LVService_GetLVBlockMetadataClient, error) {
+	if m.thick {
+		return nil, status.Error(codes.Unimplemented, "device class is not thin")
+	}
+	return &backupBlockMetadataClientMock{responses: []*proto.GetLVBlockMetadataResponse{{Ranges: m.ranges}}}, nil
+}
+
+type backupBlockMetadataClientMock struct {
+	grpc.ClientStream
+	responses []*proto.GetLVBlockMetadataResponse
+}
+
+func (c *backupBlockMetadataClientMock) Recv() (*proto.GetLVBlockMetadataResponse, error) {
+	if len(c.responses) == 0 {
+		return nil, io.EOF
+	}
+	res := c.responses[0]
+	c.responses = c.responses[1:]
+	return res, nil
+}
+
+// writeTestDevice writes a file standing for a volume device of 64 KiB with data at 0 and 40960.
+func writeTestDevice(dir string) (string, []byte) {
+	data := make([]byte, 64<<10)
+	copy(data, "head")
+	copy(data[40960:], "tail")
+	p := filepath.Join(dir, "device")
+	Expect(os.WriteFile(p, data, 0644)).To(Succeed())
+	return p, data
+}
+
+var _ = Describe("LogicalVolumeBackup controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "lvb"}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type fixture struct {
+		r         *LogicalVolumeBackupReconciler
+		c         client.Client
+		lvService *backupLVServiceMock
+		dir       string
+		data      []byte
+	}
+	newFixture := func(location topolvmv1.BackupLocation) *fixture {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "snapshot"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "snapshot", NodeName: "node1", DeviceClass: "thin", Source: "pv", AccessType: "ro"},
+			Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "snap"},
+		}
+		lvb := &topolvmv1.LogicalVolumeBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "lvb"},
+			Spec:       topolvmv1.LogicalVolumeBackupSpec{LogicalVolumeName: "snapshot", Location: location},
+		}
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(lv, lvb).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}, &topolvmv1.LogicalVolumeBackup{}).
+			Build()
+
+		f := &fixture{c: c, dir: GinkgoT().TempDir()}
+		var path string
+		path, f.data = writeTestDevice(f.dir)
+		vgService := &backupVGServiceMock{volumes: []*proto.LogicalVolume{
+			{Name: "snap", SizeBytes: int64(len(f.data)), Path: path},
+		}}
+		f.lvService = &backupLVServiceMock{ranges: []*proto.BlockRange{{Offset: 0, Length: 8192}, {Offset: 32768, Length: 16384}}}
+		f.r = NewLogicalVolumeBackupReconciler(c, c, "node1", vgService, f.lvService,
+			// the temporary directories of the tests are in os.TempDir().
+			BackupConfig{BaseDir: os.TempDir(), CredentialsNamespace: "topolvm-system"})
+		f.r.now = func() time.Time { return now }
+		return f
+	}
+
+	getLVB := func(c client.Client) *topolvmv1.LogicalVolumeBackup {
+		lvb := &topolvmv1.LogicalVolumeBackup{}
+		Expect(c.Get(ctx, req.NamespacedName, lvb)).To(Succeed())
+		return lvb
+	}
+
+	It("should store the allocated ranges of a thin volume", func() {
+		dir := filepath.Join(GinkgoT().TempDir(), "backup")
+		f := newFixture(topolvmv1.BackupLocation{Path: dir})
+
+		By("starting the backup")
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvb := getLVB(f.c)
+		Expect(lvb.Status.Phase).To(Equal(topolvmv1.BackupRunning))
+		Expect(lvb.Status.NodeName).To(Equal("node1"))
+		Expect(lvb.Status.StartTime.Time).To(BeTemporally("==", now))
+
+		By("storing the chunks having data")
+		res, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		lvb = getLVB(f.c)
+		Expect(lvb.Status.Phase).To(Equal(topolvmv1.BackupCompleted))
+		Expect(lvb.Status.SizeBytes).To(Equal(int64(len(f.data))))
+		Expect(lvb.Status.Chunks).To(Equal(int32(2)))
+		Expect(lvb.Status.StoredBytes).To(BeNumerically(">", 0))
+		Expect(lvb.Status.Message).To(BeEmpty())
+
+		manifest, err := backup.ReadManifest(ctx, backup.NewFileStore(dir))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Chunks).To(HaveLen(2))
+		Expect(manifest.Chunks[0].Offset).To(Equal(uint64(0)))
+		Expect(manifest.Chunks[1].Offset).To(Equal(uint64(32768)))
+	})
+
+	It("should store the whole thick volume", func() {
+		dir := filepath.Join(GinkgoT().TempDir(), "backup")
+		f := newFixture(topolvmv1.BackupLocation{Path: dir})
+		f.lvService.thick = true
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getLVB(f.c).Status.Phase).To(Equal(topolvmv1.BackupCompleted))
+
+		manifest, err := backup.ReadManifest(ctx, backup.NewFileStore(dir))
+		Expect(err).NotTo(HaveOccurred())
+		restored, err := os.Create(filepath.Join(f.dir, "restored"))
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = restored.Close() }()
+		Expect(backup.Import(ctx, backup.NewFileStore(dir), manifest, restored, true)).To(Succeed())
+		Expect(os.ReadFile(restored.Name())).To(Equal(f.data))
+	})
+
+	It("should fail with an invalid location", func() {
+		f := newFixture(topolvmv1.BackupLocation{})
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvb := getLVB(f.c)
+		Expect(lvb.Status.Phase).To(Equal(topolvmv1.BackupFailed))
+		Expect(lvb.Status.Message).To(ContainSubstring("exactly one of"))
+		Expect(lvb.Status.CompletionTime).NotTo(BeNil())
+	})
+
+	It("should fail with a path out of the base directory", func() {
+		f := newFixture(topolvmv1.BackupLocation{Path: "/etc/backup"})
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvb := getLVB(f.c)
+		Expect(lvb.Status.Phase).To(Equal(topolvmv1.BackupFailed))
+		Expect(lvb.Status.Message).To(ContainSubstring("invalid location.path"))
+	})
+
+	It("should fail if the volume is not a snapshot", func() {
+		f := newFixture(topolvmv1.BackupLocation{Path: GinkgoT().TempDir()})
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(f.c.Get(ctx, types.NamespacedName{Name: "snapshot"}, lv)).To(Succeed())
+		lv.Spec.AccessType = "rw"
+		Expect(f.c.Update(ctx, lv)).To(Succeed())
+
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvb := getLVB(f.c)
+		Expect(lvb.Status.Phase).To(Equal(topolvmv1.BackupFailed))
+		Expect(lvb.Status.Message).To(ContainSubstring("not a snapshot"))
+	})
+
+	It("should retry when the credentials are not found", func() {
+		f := newFixture(topolvmv1.BackupLocation{S3: &topolvmv1.S3Location{
+			Endpoint:             "http://127.0.0.1:1",
+			Bucket:               "backup",
+			CredentialsSecretRef: corev1.LocalObjectReference{Name: "missing"},
+		}})
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		res, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueIntervalForBackupError))
+		lvb := getLVB(f.c)
+		Expect(lvb.Status.Phase).To(Equal(topolvmv1.BackupRunning))
+		Expect(lvb.Status.Message).To(ContainSubstring("failed to get the credentials"))
+	})
+
+	It("should fail with a location in S3 if the namespace of the credentials is not configured", func() {
+		f := newFixture(topolvmv1.BackupLocation{S3: &topolvmv1.S3Location{
+			Endpoint:             "http://127.0.0.1:1",
+			Bucket:               "backup",
+			CredentialsSecretRef: corev1.LocalObjectReference{Name: "credentials"},
+		}})
+		f.r.config.CredentialsNamespace = ""
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvb := getLVB(f.c)
+		Expect(lvb.Status.Phase).To(Equal(topolvmv1.BackupFailed))
+		Expect(lvb.Status.Message).To(ContainSubstring("namespace of the credentials"))
+	})
+
+	It("should ignore the backups of volumes on other nodes", func() {
+		f := newFixture(topolvmv1.BackupLocation{Path: GinkgoT().TempDir()})
+		f.r.nodeName = "node2"
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getLVB(f.c).Status.Phase).To(BeEmpty())
+	})
+})
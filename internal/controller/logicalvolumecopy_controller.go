@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/topolvm/topolvm"
+	topolvmlegacyv1 "github.com/topolvm/topolvm/api/legacy/v1"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// LogicalVolumeCopyReconciler prepares the LogicalVolumes on this node to be copied to other nodes.
+// For each LogicalVolume on another node whose copySource is on this node, it has lvmd take a snapshot
+// of the source, which is the only volume served to the other node by ReadLV, and removes it after the copy.
+type LogicalVolumeCopyReconciler struct {
+	client    client.Client
+	nodeName  string
+	lvService proto.LVServiceClient
+}
+
+// NewLogicalVolumeCopyReconciler returns LogicalVolumeCopyReconciler.
+func NewLogicalVolumeCopyReconciler(client client.Client, nodeName string, lvService proto.LVServiceClient) *LogicalVolumeCopyReconciler {
+	return &LogicalVolumeCopyReconciler{
+		client:    client,
+		nodeName:  nodeName,
+		lvService: lvService,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;update;patch
+
+// Reconcile takes the snapshot of the source while the LogicalVolume is being populated and removes it afterwards.
+func (r *LogicalVolumeCopyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lv := new(topolvmv1.LogicalVolume)
+	if err := r.client.Get(ctx, req.NamespacedName, lv); err != nil {
+		if !apierrs.IsNotFound(err) {
+			log.Error(err, "unable to fetch LogicalVolume")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if lv.Spec.CopySource == "" || lv.Spec.NodeName == r.nodeName {
+		return ctrl.Result{}, nil
+	}
+
+	// The volume ID is set after the copy completes, and a non-OK code means the copy has failed.
+	if lv.DeletionTimestamp != nil || lv.Status.VolumeID != "" || lv.Status.Code != codes.OK {
+		if !controllerutil.ContainsFinalizer(lv, topolvm.GetCopySourceFinalizer()) {
+			return ctrl.Result{}, nil
+		}
+		_, err := r.lvService.RemoveLVCopy(ctx, &proto.RemoveLVCopyRequest{CopyId: string(lv.UID)})
+		if err != nil && status.Code(err) != codes.NotFound {
+			log.Error(err, "failed to remove the snapshot for the copy", "name", lv.Name)
+			return ctrl.Result{}, err
+		}
+		lv2 := lv.DeepCopy()
+		controllerutil.RemoveFinalizer(lv2, topolvm.GetCopySourceFinalizer())
+		if err := r.client.Patch(ctx, lv2, client.MergeFromWithOptions(lv, client.MergeFromWithOptimisticLock{})); err != nil {
+			log.Error(err, "failed to remove finalizer", "name", lv.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	sourcelv := new(topolvmv1.LogicalVolume)
+	err := r.client.Get(ctx, types.NamespacedName{Name: lv.Spec.CopySource}, sourcelv)
+	switch {
+	case apierrs.IsNotFound(err):
+		// The population fails on the node of the LogicalVolume.
+		return ctrl.Result{}, nil
+	case err != nil:
+		return ctrl.Result{}, err
+	case sourcelv.Spec.NodeName != r.nodeName:
+		return ctrl.Result{}, nil
+	case sourcelv.Status.VolumeID == "":
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(lv, topolvm.GetCopySourceFinalizer()) {
+		lv2 := lv.DeepCopy()
+		controllerutil.AddFinalizer(lv2, topolvm.GetCopySourceFinalizer())
+		// the node of the LogicalVolume may change the finalizers concurrently.
+		if err := r.client.Patch(ctx, lv2, client.MergeFromWithOptions(lv, client.MergeFromWithOptimisticLock{})); err != nil {
+			log.Error(err, "failed to add finalizer", "name", lv.Name)
+			return ctrl.Result{}, err
+		}
+	}
+
+	_, err = r.lvService.PrepareLVCopy(ctx, &proto.PrepareLVCopyRequest{
+		Name:        sourcelv.Status.VolumeID,
+		DeviceClass: sourcelv.Spec.DeviceClass,
+		CopyId:      string(lv.UID),
+	})
+	if err != nil {
+		log.Error(err, "failed to prepare the copy", "name", lv.Name, "source", sourcelv.Name)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LogicalVolumeCopyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	builder := ctrl.NewControllerManagedBy(mgr).Named("logicalvolumecopy")
+	if topolvm.UseLegacy() {
+		builder = builder.For(&topolvmlegacyv1.LogicalVolume{})
+	} else {
+		builder = builder.For(&topolvmv1.LogicalVolume{})
+	}
+	// Only the LogicalVolumes copied from a volume are handled. Their sources may be on this node.
+	return builder.WithEventFilter(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		switch lv := obj.(type) {
+		case *topolvmlegacyv1.LogicalVolume:
+			return lv.Spec.CopySource != "" && lv.Spec.NodeName != r.nodeName
+		case *topolvmv1.LogicalVolume:
+			return lv.Spec.CopySource != "" && lv.Spec.NodeName != r.nodeName
+		}
+		return false
+	})).Complete(r)
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type copyLVServiceMock struct {
+	MockLVServiceClient
+	prepareRequests []*proto.PrepareLVCopyRequest
+	removeRequests  []*proto.RemoveLVCopyRequest
+}
+
+func (m *copyLVServiceMock) PrepareLVCopy(_ context.Context, in *proto.PrepareLVCopyRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	m.prepareRequests = append(m.prepareRequests, in)
+	return &proto.Empty{}, nil
+}
+
+func (m *copyLVServiceMock) RemoveLVCopy(_ context.Context, in *proto.RemoveLVCopyRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	m.removeRequests = append(m.removeRequests, in)
+	return nil, status.Error(codes.NotFound, "not found")
+}
+
+var _ = Describe("LogicalVolumeCopy controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "clone"}}
+
+	newReconciler := func(clone *topolvmv1.LogicalVolume) (*LogicalVolumeCopyReconciler, client.Client, *copyLVServiceMock) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		source := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "source"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "source", NodeName: "node2", DeviceClass: "thick"},
+			Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "vol"},
+		}
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(source, clone).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}).
+			Build()
+		lvService := &copyLVServiceMock{}
+		return NewLogicalVolumeCopyReconciler(c, "node2", lvService), c, lvService
+	}
+
+	clone := func() *topolvmv1.LogicalVolume {
+		return &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", UID: "clone-uid"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "clone", NodeName: "node1", DeviceClass: "thin", CopySource: "source"},
+		}
+	}
+
+	It("should prepare the copy of the source on this node", func() {
+		r, c, lvService := newReconciler(clone())
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(lvService.prepareRequests).To(HaveLen(1))
+		Expect(lvService.prepareRequests[0].GetName()).To(Equal("vol"))
+		Expect(lvService.prepareRequests[0].GetDeviceClass()).To(Equal("thick"))
+		Expect(lvService.prepareRequests[0].GetCopyId()).To(Equal("clone-uid"))
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, req.NamespacedName, lv)).To(Succeed())
+		Expect(lv.Finalizers).To(ContainElement(topolvm.GetCopySourceFinalizer()))
+	})
+
+	It("should remove the copy after the volume is populated", func() {
+		lv := clone()
+		lv.Finalizers = []string{topolvm.GetCopySourceFinalizer()}
+		lv.Status.VolumeID = "clone-uid"
+		r, c, lvService := newReconciler(lv)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(lvService.prepareRequests).To(BeEmpty())
+		Expect(lvService.removeRequests).To(HaveLen(1))
+		Expect(lvService.removeRequests[0].GetCopyId()).To(Equal("clone-uid"))
+		Expect(c.Get(ctx, req.NamespacedName, lv)).To(Succeed())
+		Expect(lv.Finalizers).NotTo(ContainElement(topolvm.GetCopySourceFinalizer()))
+	})
+
+	It("should not prepare the copy of the source on another node", func() {
+		r, _, lvService := newReconciler(clone())
+		r.nodeName = "node3"
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(lvService.prepareRequests).To(BeEmpty())
+	})
+})
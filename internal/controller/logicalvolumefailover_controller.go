@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// LogicalVolumeFailoverReconciler repoints LogicalVolumes and PersistentVolumes to the replicas on the peer nodes
+// when the failover of LogicalVolumeReplications is requested.
+type LogicalVolumeFailoverReconciler struct {
+	client   client.Client
+	recorder events.EventRecorder
+	now      func() metav1.Time
+}
+
+// NewLogicalVolumeFailoverReconciler returns LogicalVolumeFailoverReconciler.
+func NewLogicalVolumeFailoverReconciler(client client.Client, recorder events.EventRecorder) *LogicalVolumeFailoverReconciler {
+	return &LogicalVolumeFailoverReconciler{
+		client:   client,
+		recorder: recorder,
+		now:      metav1.Now,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumereplications,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumereplications/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+// Reconcile moves the LogicalVolume to the peer node, recreates the PersistentVolume with the node affinity
+// of the peer node, and updates the selected node of the PVC.
+func (r *LogicalVolumeFailoverReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lvr := &topolvmv1.LogicalVolumeReplication{}
+	err := r.client.Get(ctx, req.NamespacedName, lvr)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	if !lvr.Spec.Failover || lvr.DeletionTimestamp != nil || lvr.Status.Phase == topolvmv1.LogicalVolumeReplicationFailedOver {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.failover(ctx, lvr); err != nil {
+		log.Error(err, "failed to fail over", "name", lvr.Name)
+		lvr.Status.Message = fmt.Sprintf("failed to fail over: %v", err)
+		if err2 := r.client.Status().Update(ctx, lvr); err2 != nil {
+			// err2 is logged but not returned because err is more important
+			log.Error(err2, "failed to update the status", "name", lvr.Name)
+		}
+		return ctrl.Result{}, err
+	}
+
+	lvr.Status.Phase = topolvmv1.LogicalVolumeReplicationFailedOver
+	now := r.now()
+	lvr.Status.FailoverTime = &now
+	lvr.Status.Message = ""
+	if err := r.client.Status().Update(ctx, lvr); err != nil {
+		log.Error(err, "failed to update the status", "name", lvr.Name)
+		return ctrl.Result{}, err
+	}
+	r.recorder.Eventf(lvr, nil, corev1.EventTypeNormal, "FailedOver", "FailOver",
+		"LogicalVolume %s was repointed to the replica on %s", lvr.Spec.LogicalVolumeName, lvr.Spec.PeerNodeName)
+	log.Info("failed over", "name", lvr.Name, "logicalvolume", lvr.Spec.LogicalVolumeName, "node", lvr.Spec.PeerNodeName)
+	return ctrl.Result{}, nil
+}
+
+func (r *LogicalVolumeFailoverReconciler) failover(ctx context.Context, lvr *topolvmv1.LogicalVolumeReplication) error {
+	if lvr.Status.LastSyncTime == nil || lvr.Status.VolumeID == "" {
+		return fmt.Errorf("the volume has not been replicated yet")
+	}
+	peerDeviceClass := lvr.Spec.PeerDeviceClass
+	if peerDeviceClass == "" {
+		peerDeviceClass = lvr.Status.DeviceClass
+	}
+
+	lv := &topolvmv1.LogicalVolume{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: lvr.Spec.LogicalVolumeName}, lv); err != nil {
+		return err
+	}
+	if lv.Status.VolumeID != lvr.Status.VolumeID {
+		return fmt.Errorf("the volume ID of LogicalVolume %s has changed: %s", lv.Name, lv.Status.VolumeID)
+	}
+	if lv.Spec.NodeName != lvr.Spec.PeerNodeName || lv.Spec.DeviceClass != peerDeviceClass {
+		lv2 := lv.DeepCopy()
+		lv2.Spec.NodeName = lvr.Spec.PeerNodeName
+		lv2.Spec.DeviceClass = peerDeviceClass
+		if err := r.client.Patch(ctx, lv2, client.MergeFrom(lv)); err != nil {
+			return err
+		}
+	}
+
+	// The name of the PV is the same as the LogicalVolume.
+	pv := &corev1.PersistentVolume{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: lv.Name}, pv)
+	if apierrors.IsNotFound(err) {
+		// The LogicalVolume is not provisioned for a PV, e.g. it is used by an ephemeral volume.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return movePV(ctx, r.client, pv, lvr.Spec.PeerNodeName)
+}
+
+// movePV recreates pv with the node affinity of node, and updates the selected node of the PVC bound to it.
+func movePV(ctx context.Context, c client.Client, pv *corev1.PersistentVolume, node string) error {
+	if err := recreatePV(ctx, c, pv, node); err != nil {
+		return err
+	}
+
+	if pv.Spec.ClaimRef == nil {
+		return nil
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := c.Get(ctx, types.NamespacedName{Namespace: pv.Spec.ClaimRef.Namespace, Name: pv.Spec.ClaimRef.Name}, pvc)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// NodeReconciler deletes the PVCs on the deleted node by the selected node.
+	if selected, ok := pvc.Annotations[AnnSelectedNode]; ok && selected != node {
+		pvc2 := pvc.DeepCopy()
+		pvc2.Annotations[AnnSelectedNode] = node
+		if err := c.Patch(ctx, pvc2, client.MergeFrom(pvc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recreatePV replaces pv with the one having the node affinity of node because the node affinity is immutable.
+// The reclaim policy is set to Retain before the deletion so that the volume is not deleted.
+func recreatePV(ctx context.Context, c client.Client, pv *corev1.PersistentVolume, node string) error {
+	newPV, changed := pvForNode(pv, node)
+	if !changed {
+		return nil
+	}
+
+	if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+		pv2 := pv.DeepCopy()
+		pv2.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		if err := c.Patch(ctx, pv2, client.MergeFrom(pv)); err != nil {
+			return err
+		}
+		pv = pv2
+	}
+	if err := c.Delete(ctx, pv); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	// The finalizers are never removed while the PV is bound.
+	if len(pv.Finalizers) > 0 {
+		pv2 := pv.DeepCopy()
+		pv2.Finalizers = nil
+		if err := c.Patch(ctx, pv2, client.MergeFrom(pv)); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return c.Create(ctx, newPV)
+}
+
+// pvForNode returns a copy of pv to be created with the node affinity of node.
+// It returns false if pv already has the node affinity.
+func pvForNode(pv *corev1.PersistentVolume, node string) (*corev1.PersistentVolume, bool) {
+	newPV := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pv.Name,
+			Labels:      pv.Labels,
+			Annotations: pv.Annotations,
+			Finalizers:  pv.Finalizers,
+		},
+		Spec: *pv.Spec.DeepCopy(),
+	}
+
+	changed := false
+	if affinity := newPV.Spec.NodeAffinity; affinity != nil && affinity.Required != nil {
+		for i := range affinity.Required.NodeSelectorTerms {
+			term := &affinity.Required.NodeSelectorTerms[i]
+			for j := range term.MatchExpressions {
+				expr := &term.MatchExpressions[j]
+				if expr.Key != topolvm.GetTopologyNodeKey() || slices.Equal(expr.Values, []string{node}) {
+					continue
+				}
+				expr.Operator = corev1.NodeSelectorOpIn
+				expr.Values = []string{node}
+				changed = true
+			}
+		}
+	}
+	return newPV, changed
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LogicalVolumeFailoverReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("logicalvolumefailover").
+		For(&topolvmv1.LogicalVolumeReplication{}).
+		Complete(r)
+}
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("LogicalVolumeFailover controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "lvr"}}
+
+	newReconciler := func(objs ...client.Object) (*LogicalVolumeFailoverReconciler, client.Client) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(objs...).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}, &topolvmv1.LogicalVolumeReplication{}).
+			Build()
+		return NewLogicalVolumeFailoverReconciler(c, events.NewFakeRecorder(10)), c
+	}
+
+	objects := func(lastSyncTime *metav1.Time) []client.Object {
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "pv", NodeName: "node1", DeviceClass: "thin"},
+			Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "vol"},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "pv",
+				Finalizers: []string{"kubernetes.io/pv-protection"},
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "test", Name: "pvc", UID: "pvc-uid"},
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: topolvm.GetPluginName(), VolumeHandle: "vol"},
+				},
+				NodeAffinity: &corev1.VolumeNodeAffinity{
+					Required: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+							MatchExpressions: []corev1.NodeSelectorRequirement{{
+								Key:      topolvm.GetTopologyNodeKey(),
+								Operator: corev1.NodeSelectorOpIn,
+								Values:   []string{"node1"},
+							}},
+						}},
+					},
+				},
+			},
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "pvc",
+				Annotations: map[string]string{AnnSelectedNode: "node1"},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv"},
+		}
+		lvr := &topolvmv1.LogicalVolumeReplication{
+			ObjectMeta: metav1.ObjectMeta{Name: "lvr"},
+			Spec: topolvmv1.LogicalVolumeReplicationSpec{
+				LogicalVolumeName: "pv",
+				PeerNodeName:      "node2",
+				PeerDeviceClass:   "replica",
+				Failover:          true,
+			},
+			Status: topolvmv1.LogicalVolumeReplicationStatus{
+				Phase:          topolvmv1.LogicalVolumeReplicationReplicating,
+				SourceNodeName: "node1",
+				VolumeID:       "vol",
+				DeviceClass:    "thin",
+				LastSyncTime:   lastSyncTime,
+			},
+		}
+		return []client.Object{lv, pv, pvc, lvr}
+	}
+
+	It("should repoint the LogicalVolume and the PV to the peer node", func() {
+		now := metav1.Now()
+		r, c := newReconciler(objects(&now)...)
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.NodeName).To(Equal("node2"))
+		Expect(lv.Spec.DeviceClass).To(Equal("replica"))
+		Expect(lv.Status.VolumeID).To(Equal("vol"))
+
+		pv := &corev1.PersistentVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, pv)).To(Succeed())
+		Expect(pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values).To(Equal([]string{"node2"}))
+		Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimDelete))
+		Expect(pv.Spec.ClaimRef.UID).To(Equal(types.UID("pvc-uid")))
+		Expect(pv.Spec.CSI.VolumeHandle).To(Equal("vol"))
+
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, types.NamespacedName{Namespace: "test", Name: "pvc"}, pvc)).To(Succeed())
+		Expect(pvc.Annotations[AnnSelectedNode]).To(Equal("node2"))
+
+		lvr := &topolvmv1.LogicalVolumeReplication{}
+		Expect(c.Get(ctx, req.NamespacedName, lvr)).To(Succeed())
+		Expect(lvr.Status.Phase).To(Equal(topolvmv1.LogicalVolumeReplicationFailedOver))
+		Expect(lvr.Status.FailoverTime).NotTo(BeNil())
+		Expect(lvr.Status.SourceNodeName).To(Equal("node1"))
+	})
+
+	It("should refuse to fail over before the first replication", func() {
+		r, c := newReconciler(objects(nil)...)
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).To(HaveOccurred())
+
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.NodeName).To(Equal("node1"))
+		lvr := &topolvmv1.LogicalVolumeReplication{}
+		Expect(c.Get(ctx, req.NamespacedName, lvr)).To(Succeed())
+		Expect(lvr.Status.Phase).To(Equal(topolvmv1.LogicalVolumeReplicationReplicating))
+		Expect(lvr.Status.Message).To(ContainSubstring("not been replicated"))
+	})
+})
@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// defaultReplicationInterval is the interval of the replication if spec.interval is omitted.
+	defaultReplicationInterval = 5 * time.Minute
+
+	// requeueIntervalForReplicationError is the requeue interval after a failed replication.
+	requeueIntervalForReplicationError = 1 * time.Minute
+)
+
+// LogicalVolumeReplicationReconciler replicates the LogicalVolumes on this node to the peer nodes periodically.
+type LogicalVolumeReplicationReconciler struct {
+	client          client.Client
+	nodeName        string
+	lvService       proto.LVServiceClient
+	replicationPort int
+	// dialPeer returns a client of the replication API of lvmd on the peer node and a function to close it.
+	dialPeer func(address string) (proto.ReplicationServiceClient, func() error, error)
+	now      func() time.Time
+}
+
+// NewLogicalVolumeReplicationReconciler returns LogicalVolumeReplicationReconciler.
+// replicationPort is the port of the replication API of lvmd on the peer nodes,
+// and replicationCredentials are the mutual TLS credentials to call it.
+func NewLogicalVolumeReplicationReconciler(client client.Client, nodeName string, lvService proto.LVServiceClient,
+	replicationPort int, replicationCredentials credentials.TransportCredentials) *LogicalVolumeReplicationReconciler {
+	return &LogicalVolumeReplicationReconciler{
+		client:          client,
+		nodeName:        nodeName,
+		lvService:       lvService,
+		replicationPort: replicationPort,
+		dialPeer:        replicationDialer(replicationCredentials),
+		now:             time.Now,
+	}
+}
+
+// replicationDialer returns a function to dial the replication API of lvmd on the peer node with creds.
+func replicationDialer(creds credentials.TransportCredentials) func(address string) (proto.ReplicationServiceClient, func() error, error) {
+	return func(address string) (proto.ReplicationServiceClient, func() error, error) {
+		if creds == nil {
+			return nil, nil, errors.New("TLS is not configured for the replication API")
+		}
+		conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, nil, err
+		}
+		return proto.NewReplicationServiceClient(conn), conn.Close, nil
+	}
+}
+
+// NewNodeReplicationDialer returns a function returning a client of the replication API of lvmd on the node
+// and a function to close it. The API is called on port with the mutual TLS credentials.
+func NewNodeReplicationDialer(reader client.Reader, port int, creds credentials.TransportCredentials) func(ctx context.Context, nodeName string) (proto.ReplicationServiceClient, func() error, error) {
+	dial := replicationDialer(creds)
+	return func(ctx context.Context, nodeName string) (proto.ReplicationServiceClient, func() error, error) {
+		address, err := replicationAddress(ctx, reader, nodeName, port)
+		if err != nil {
+			return nil, nil, err
+		}
+		return dial(address)
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumereplications,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumereplications/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+
+// Reconcile replicates the volume when the interval has passed and updates the lag.
+func (r *LogicalVolumeReplicationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lvr := &topolvmv1.LogicalVolumeReplication{}
+	err := r.client.Get(ctx, req.NamespacedName, lvr)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+
+	lv := &topolvmv1.LogicalVolume{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: lvr.Spec.LogicalVolumeName}, lv)
+	if apierrors.IsNotFound(err) {
+		lv = nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The source node is recorded in the status because the LogicalVolume is moved to the peer by the failover.
+	sourceNode := lvr.Status.SourceNodeName
+	if sourceNode == "" && lv != nil {
+		sourceNode = lv.Spec.NodeName
+	}
+	if sourceNode != r.nodeName {
+		return ctrl.Result{}, nil
+	}
+
+	if lvr.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(lvr, topolvm.GetLogicalVolumeReplicationFinalizer()) {
+			return ctrl.Result{}, nil
+		}
+		if err := r.cleanup(ctx, lvr); err != nil {
+			log.Error(err, "failed to clean up replication", "name", lvr.Name)
+			return ctrl.Result{}, err
+		}
+		lvr2 := lvr.DeepCopy()
+		controllerutil.RemoveFinalizer(lvr2, topolvm.GetLogicalVolumeReplicationFinalizer())
+		if err := r.client.Patch(ctx, lvr2, client.MergeFrom(lvr)); err != nil {
+			log.Error(err, "failed to remove finalizer", "name", lvr.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(lvr, topolvm.GetLogicalVolumeReplicationFinalizer()) {
+		lvr2 := lvr.DeepCopy()
+		controllerutil.AddFinalizer(lvr2, topolvm.GetLogicalVolumeReplicationFinalizer())
+		if err := r.client.Patch(ctx, lvr2, client.MergeFrom(lvr)); err != nil {
+			log.Error(err, "failed to add finalizer", "name", lvr.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	// The failover is handled by topolvm-controller. The replica must not be overwritten any longer.
+	if lvr.Spec.Failover || lvr.Status.Phase == topolvmv1.LogicalVolumeReplicationFailedOver {
+		return ctrl.Result{}, nil
+	}
+
+	now := r.now()
+	st := *lvr.Status.DeepCopy()
+	interval := defaultReplicationInterval
+	if lvr.Spec.Interval != nil && lvr.Spec.Interval.Duration > 0 {
+		interval = lvr.Spec.Interval.Duration
+	}
+
+	var result ctrl.Result
+	switch {
+	case lv == nil || lv.DeletionTimestamp != nil:
+		st.Message = fmt.Sprintf("LogicalVolume %s is not found", lvr.Spec.LogicalVolumeName)
+		result.RequeueAfter = requeueIntervalForReplicationError
+	case lv.Status.VolumeID == "" || lv.Spec.Source != "":
+		st.Message = fmt.Sprintf("LogicalVolume %s is not a provisioned volume", lv.Name)
+		result.RequeueAfter = requeueIntervalForReplicationError
+	case st.LastSyncTime != nil && now.Before(st.LastSyncTime.Add(interval)):
+		result.RequeueAfter = st.LastSyncTime.Add(interval).Sub(now)
+	default:
+		st.SourceNodeName = lv.Spec.NodeName
+		st.VolumeID = lv.Status.VolumeID
+		st.DeviceClass = lv.Spec.DeviceClass
+		if err := r.replicate(ctx, lvr, &st, now); err != nil {
+			log.Error(err, "failed to replicate volume", "name", lvr.Name)
+			st.Message = err.Error()
+			result.RequeueAfter = requeueIntervalForReplicationError
+		} else {
+			st.Message = ""
+			result.RequeueAfter = interval
+		}
+	}
+
+	if st.LastSyncTime != nil {
+		st.Lag = &metav1.Duration{Duration: now.Sub(st.LastSyncTime.Time).Truncate(time.Second)}
+	}
+	if !equality.Semantic.DeepEqual(lvr.Status, st) {
+		lvr.Status = st
+		if err := r.client.Status().Update(ctx, lvr); err != nil {
+			log.Error(err, "failed to update the status", "name", lvr.Name)
+			return ctrl.Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// replicate sends the changes since the last replicated snapshot to the peer and updates st.
+func (r *LogicalVolumeReplicationReconciler) replicate(ctx context.Context, lvr *topolvmv1.LogicalVolumeReplication,
+	st *topolvmv1.LogicalVolumeReplicationStatus, now time.Time) error {
+	address, err := replicationAddress(ctx, r.client, lvr.Spec.PeerNodeName, r.replicationPort)
+	if err != nil {
+		return err
+	}
+	peerDeviceClass := lvr.Spec.PeerDeviceClass
+	if peerDeviceClass == "" {
+		peerDeviceClass = st.DeviceClass
+	}
+
+	snapshot := fmt.Sprintf("%s-replica-%d", st.VolumeID, now.Unix())
+	res, err := r.lvService.ReplicateLV(ctx, &proto.ReplicateLVRequest{
+		Name:            st.VolumeID,
+		DeviceClass:     st.DeviceClass,
+		Snapshot:        snapshot,
+		BaseSnapshot:    st.LastSnapshot,
+		PeerAddress:     address,
+		PeerDeviceClass: peerDeviceClass,
+	})
+	if status.Code(err) == codes.FailedPrecondition {
+		// lvmd has dropped the base snapshot, so the next replication sends the whole volume.
+		st.LastSnapshot = ""
+	}
+	if err != nil {
+		return err
+	}
+
+	st.Phase = topolvmv1.LogicalVolumeReplicationReplicating
+	st.LastSnapshot = snapshot
+	st.LastSyncTime = &metav1.Time{Time: now}
+	st.LastSyncBytes = int64(res.GetTransferredBytes())
+	return nil
+}
+
+// cleanup removes the last replicated snapshot on this node and the replica on the peer node.
+// The replica is kept after the failover because it is the volume in use.
+func (r *LogicalVolumeReplicationReconciler) cleanup(ctx context.Context, lvr *topolvmv1.LogicalVolumeReplication) error {
+	if lvr.Status.LastSnapshot != "" {
+		_, err := r.lvService.RemoveLV(ctx, &proto.RemoveLVRequest{
+			Name:        lvr.Status.LastSnapshot,
+			DeviceClass: lvr.Status.DeviceClass,
+			SkipTrash:   true,
+		})
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+	}
+	if lvr.Status.Phase == topolvmv1.LogicalVolumeReplicationFailedOver || lvr.Spec.Failover || lvr.Status.VolumeID == "" {
+		return nil
+	}
+
+	address, err := replicationAddress(ctx, r.client, lvr.Spec.PeerNodeName, r.replicationPort)
+	if apierrors.IsNotFound(err) {
+		// The replica has gone with the peer node.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	peer, closeFunc, err := r.dialPeer(address)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = closeFunc() }()
+
+	peerDeviceClass := lvr.Spec.PeerDeviceClass
+	if peerDeviceClass == "" {
+		peerDeviceClass = lvr.Status.DeviceClass
+	}
+	_, err = peer.RemoveLVReplica(ctx, &proto.RemoveLVReplicaRequest{
+		Name:        lvr.Status.VolumeID,
+		DeviceClass: peerDeviceClass,
+	})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return err
+	}
+	return nil
+}
+
+// replicationAddress returns the address of the replication API of lvmd on the node.
+func replicationAddress(ctx context.Context, reader client.Reader, nodeName string, port int) (string, error) {
+	node := &corev1.Node{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		return "", err
+	}
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			return net.JoinHostPort(addr.Address, strconv.Itoa(port)), nil
+		}
+	}
+	return "", fmt.Errorf("node %s has no internal IP address", nodeName)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LogicalVolumeReplicationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("logicalvolumereplication").
+		For(&topolvmv1.LogicalVolumeReplication{}).
+		Complete(r)
+}
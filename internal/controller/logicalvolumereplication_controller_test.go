@@ -0,0 +1,240 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type replicationLVServiceMock struct {
+	MockLVServiceClient
+	replicateRequests []*proto.ReplicateLVRequest
+	removeRequests    []*proto.RemoveLVRequest
+	replicateErr      error
+}
+
+func (m *replicationLVServiceMock) ReplicateLV(_ context.Context, in *proto.ReplicateLVRequest, _ ...grpc.CallOption) (*proto.ReplicateLVResponse, error) {
+	m.replicateRequests = append(m.replicateRequests, in)
+	if m.replicateErr != nil {
+		return nil, m.replicateErr
+	}
+	return &proto.ReplicateLVResponse{SizeBytes: 1 << 30, TransferredBytes: 1 << 20}, nil
+}
+
+func (m *replicationLVServiceMock) RemoveLV(_ context.Context, in *proto.RemoveLVRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	m.removeRequests = append(m.removeRequests, in)
+	return &proto.Empty{}, nil
+}
+
+type replicationServiceMock struct {
+	proto.ReplicationServiceClient
+	removeRequests []*proto.RemoveLVReplicaRequest
+}
+
+func (m *replicationServiceMock) RemoveLVReplica(_ context.Context, in *proto.RemoveLVReplicaRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	m.removeRequests = append(m.removeRequests, in)
+	return &proto.Empty{}, nil
+}
+
+var _ = Describe("LogicalVolumeReplication controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "lvr"}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type fixture struct {
+		r         *LogicalVolumeReplicationReconciler
+		c         client.Client
+		lvService *replicationLVServiceMock
+		peer      *replicationServiceMock
+		addresses []string
+	}
+	newFixture := func(objs ...client.Object) *fixture {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(objs...).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}, &topolvmv1.LogicalVolumeReplication{}).
+			Build()
+		f := &fixture{c: c, lvService: &replicationLVServiceMock{}, peer: &replicationServiceMock{}}
+		f.r = NewLogicalVolumeReplicationReconciler(c, "node1", f.lvService, 9445, nil)
+		f.r.now = func() time.Time { return now }
+		f.r.dialPeer = func(address string) (proto.ReplicationServiceClient, func() error, error) {
+			f.addresses = append(f.addresses, address)
+			return f.peer, func() error { return nil }, nil
+		}
+		return f
+	}
+
+	objects := func() []client.Object {
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "pv", NodeName: "node1", DeviceClass: "thin"},
+			Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "vol"},
+		}
+		peer := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+			Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node2"},
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.2"},
+			}},
+		}
+		lvr := &topolvmv1.LogicalVolumeReplication{
+			ObjectMeta: metav1.ObjectMeta{Name: "lvr"},
+			Spec: topolvmv1.LogicalVolumeReplicationSpec{
+				LogicalVolumeName: "pv",
+				PeerNodeName:      "node2",
+				PeerDeviceClass:   "replica",
+				Interval:          &metav1.Duration{Duration: 10 * time.Minute},
+			},
+		}
+		return []client.Object{lv, peer, lvr}
+	}
+
+	getLVR := func(c client.Client) *topolvmv1.LogicalVolumeReplication {
+		lvr := &topolvmv1.LogicalVolumeReplication{}
+		Expect(c.Get(ctx, req.NamespacedName, lvr)).To(Succeed())
+		return lvr
+	}
+
+	It("should replicate incrementally at the interval", func() {
+		f := newFixture(objects()...)
+
+		By("adding the finalizer")
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getLVR(f.c).Finalizers).To(ContainElement(topolvm.GetLogicalVolumeReplicationFinalizer()))
+
+		By("replicating the whole volume first")
+		res, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(10 * time.Minute))
+		Expect(f.lvService.replicateRequests).To(HaveLen(1))
+		first := f.lvService.replicateRequests[0]
+		Expect(first.GetName()).To(Equal("vol"))
+		Expect(first.GetDeviceClass()).To(Equal("thin"))
+		Expect(first.GetBaseSnapshot()).To(BeEmpty())
+		Expect(first.GetPeerAddress()).To(Equal("10.0.0.2:9445"))
+		Expect(first.GetPeerDeviceClass()).To(Equal("replica"))
+
+		lvr := getLVR(f.c)
+		Expect(lvr.Status.Phase).To(Equal(topolvmv1.LogicalVolumeReplicationReplicating))
+		Expect(lvr.Status.SourceNodeName).To(Equal("node1"))
+		Expect(lvr.Status.VolumeID).To(Equal("vol"))
+		Expect(lvr.Status.LastSnapshot).To(Equal(first.GetSnapshot()))
+		Expect(lvr.Status.LastSyncTime.Time).To(BeTemporally("==", now))
+		Expect(lvr.Status.LastSyncBytes).To(Equal(int64(1 << 20)))
+		Expect(lvr.Status.Lag.Duration).To(BeZero())
+
+		By("updating only the lag before the interval passes")
+		now = now.Add(4 * time.Minute)
+		res, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(6 * time.Minute))
+		Expect(f.lvService.replicateRequests).To(HaveLen(1))
+		Expect(getLVR(f.c).Status.Lag.Duration).To(Equal(4 * time.Minute))
+
+		By("replicating the changes since the last snapshot")
+		now = now.Add(6 * time.Minute)
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.lvService.replicateRequests).To(HaveLen(2))
+		second := f.lvService.replicateRequests[1]
+		Expect(second.GetBaseSnapshot()).To(Equal(first.GetSnapshot()))
+		Expect(second.GetSnapshot()).NotTo(Equal(first.GetSnapshot()))
+		Expect(getLVR(f.c).Status.LastSnapshot).To(Equal(second.GetSnapshot()))
+	})
+
+	It("should replicate the whole volume after the base snapshot is lost", func() {
+		f := newFixture(objects()...)
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		now = now.Add(10 * time.Minute)
+		f.lvService.replicateErr = status.Error(codes.FailedPrecondition, "replica is not found")
+		res, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueIntervalForReplicationError))
+		lvr := getLVR(f.c)
+		Expect(lvr.Status.LastSnapshot).To(BeEmpty())
+		Expect(lvr.Status.Message).To(ContainSubstring("replica is not found"))
+		Expect(lvr.Status.LastSyncTime).NotTo(BeNil())
+
+		f.lvService.replicateErr = nil
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.lvService.replicateRequests[2].GetBaseSnapshot()).To(BeEmpty())
+		Expect(getLVR(f.c).Status.Message).To(BeEmpty())
+	})
+
+	It("should ignore the replications of volumes on other nodes", func() {
+		f := newFixture(objects()...)
+		f.r.nodeName = "node2"
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getLVR(f.c).Finalizers).To(BeEmpty())
+		Expect(f.lvService.replicateRequests).To(BeEmpty())
+	})
+
+	It("should remove the snapshot and the replica on deletion", func() {
+		f := newFixture(objects()...)
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		snapshot := getLVR(f.c).Status.LastSnapshot
+
+		Expect(f.c.Delete(ctx, getLVR(f.c))).To(Succeed())
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.lvService.removeRequests).To(HaveLen(1))
+		Expect(f.lvService.removeRequests[0].GetName()).To(Equal(snapshot))
+		Expect(f.lvService.removeRequests[0].GetSkipTrash()).To(BeTrue())
+		Expect(f.addresses).To(Equal([]string{"10.0.0.2:9445"}))
+		Expect(f.peer.removeRequests).To(HaveLen(1))
+		Expect(f.peer.removeRequests[0].GetName()).To(Equal("vol"))
+		Expect(f.peer.removeRequests[0].GetDeviceClass()).To(Equal("replica"))
+		lvr := &topolvmv1.LogicalVolumeReplication{}
+		Expect(f.c.Get(ctx, req.NamespacedName, lvr)).NotTo(Succeed())
+	})
+
+	It("should keep the replica on deletion after the failover", func() {
+		f := newFixture(objects()...)
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(f.c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		lv.Spec.NodeName = "node2"
+		Expect(f.c.Update(ctx, lv)).To(Succeed())
+		lvr := getLVR(f.c)
+		lvr.Status.Phase = topolvmv1.LogicalVolumeReplicationFailedOver
+		Expect(f.c.Status().Update(ctx, lvr)).To(Succeed())
+
+		Expect(f.c.Delete(ctx, getLVR(f.c))).To(Succeed())
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.lvService.removeRequests).To(HaveLen(1))
+		Expect(f.peer.removeRequests).To(BeEmpty())
+	})
+})
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// LogicalVolumeRestoreReconciler creates LogicalVolumes on this node from the backups as requested by LogicalVolumeRestores.
+type LogicalVolumeRestoreReconciler struct {
+	client    client.Client
+	apiReader client.Reader
+	nodeName  string
+	vgService proto.VGServiceClient
+	config    BackupConfig
+	now       func() time.Time
+}
+
+// NewLogicalVolumeRestoreReconciler returns LogicalVolumeRestoreReconciler.
+// apiReader is used to read the Secrets of S3 credentials without caching all the Secrets.
+func NewLogicalVolumeRestoreReconciler(client client.Client, apiReader client.Reader, nodeName string,
+	vgService proto.VGServiceClient, config BackupConfig) *LogicalVolumeRestoreReconciler {
+	return &LogicalVolumeRestoreReconciler{
+		client:    client,
+		apiReader: apiReader,
+		nodeName:  nodeName,
+		vgService: vgService,
+		config:    config,
+		now:       time.Now,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumerestores,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumerestores/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=core,namespace=topolvm-system,resources=secrets,verbs=get
+
+// Reconcile creates the LogicalVolume with the size of the backup, and then writes the backup into it.
+func (r *LogicalVolumeRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	lvr := &topolvmv1.LogicalVolumeRestore{}
+	err := r.client.Get(ctx, req.NamespacedName, lvr)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	if lvr.Spec.NodeName != r.nodeName || lvr.DeletionTimestamp != nil || isBackupFinished(lvr.Status.Phase) {
+		return ctrl.Result{}, nil
+	}
+
+	if err := validateBackupLocation(&lvr.Spec.Location, r.config); err != nil {
+		return r.fail(ctx, lvr, err)
+	}
+	store, err := backupStore(ctx, r.apiReader, &lvr.Spec.Location, r.config)
+	if err != nil {
+		return r.retry(ctx, lvr, err)
+	}
+	manifest, err := backup.ReadManifest(ctx, store)
+	if err != nil {
+		// The backup may not be completed yet.
+		return r.retry(ctx, lvr, err)
+	}
+
+	if lvr.Status.Phase == "" {
+		lvr.Status.Phase = topolvmv1.BackupRunning
+		lvr.Status.LogicalVolumeName = lvr.Name
+		lvr.Status.SizeBytes = int64(manifest.SizeBytes)
+		lvr.Status.StartTime = &metav1.Time{Time: r.now()}
+		lvr.Status.Message = ""
+		if err := r.client.Status().Update(ctx, lvr); err != nil {
+			log.Error(err, "failed to update the status", "name", lvr.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	lv := &topolvmv1.LogicalVolume{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: lvr.Status.LogicalVolumeName}, lv)
+	if apierrors.IsNotFound(err) {
+		if err := r.createLogicalVolume(ctx, lvr, manifest); err != nil {
+			log.Error(err, "failed to create LogicalVolume", "name", lvr.Name)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	switch {
+	case lv.Spec.NodeName != r.nodeName:
+		return r.fail(ctx, lvr, fmt.Errorf("LogicalVolume %s exists on another node %s", lv.Name, lv.Spec.NodeName))
+	case lv.Status.Code != codes.OK:
+		return r.fail(ctx, lvr, fmt.Errorf("failed to create LogicalVolume %s: %s", lv.Name, lv.Status.Message))
+	case lv.Status.VolumeID == "":
+		return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+	}
+
+	if err := r.restore(ctx, store, manifest, lv); err != nil {
+		return r.retry(ctx, lvr, err)
+	}
+	log.Info("restored volume", "name", lvr.Name, "volume_id", lv.Status.VolumeID, "size", manifest.SizeBytes)
+	lvr.Status.Phase = topolvmv1.BackupCompleted
+	lvr.Status.VolumeID = lv.Status.VolumeID
+	lvr.Status.CompletionTime = &metav1.Time{Time: r.now()}
+	lvr.Status.Message = ""
+	if err := r.client.Status().Update(ctx, lvr); err != nil {
+		log.Error(err, "failed to update the status", "name", lvr.Name)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *LogicalVolumeRestoreReconciler) createLogicalVolume(ctx context.Context, lvr *topolvmv1.LogicalVolumeRestore, manifest *backup.Manifest) error {
+	lv := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: lvr.Status.LogicalVolumeName,
+			Labels: map[string]string{
+				topolvm.CreatedbyLabelKey: topolvm.CreatedbyLabelValue,
+			},
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:        lvr.Status.LogicalVolumeName,
+			NodeName:    r.nodeName,
+			DeviceClass: lvr.Spec.DeviceClass,
+			Size:        *resource.NewQuantity(int64(manifest.SizeBytes), resource.BinarySI),
+		},
+	}
+	if err := r.client.Create(ctx, lv); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	crlog.FromContext(ctx).Info("created LogicalVolume", "name", lv.Name, "device_class", lv.Spec.DeviceClass, "size", lv.Spec.Size.String())
+	return nil
+}
+
+// restore writes the backup into the volume. The gaps between the chunks are left for a thin volume, which reads zeros.
+func (r *LogicalVolumeRestoreReconciler) restore(ctx context.Context, store backup.Store, manifest *backup.Manifest,
+	lv *topolvmv1.LogicalVolume) error {
+	vol, err := findVolume(ctx, r.vgService, lv.Spec.DeviceClass, lv.Status.VolumeID)
+	if err != nil {
+		return err
+	}
+	if uint64(vol.GetSizeBytes()) < manifest.SizeBytes {
+		return fmt.Errorf("logical volume %s is smaller than the backup: %d < %d", vol.GetName(), vol.GetSizeBytes(), manifest.SizeBytes)
+	}
+	f, err := os.OpenFile(vol.GetPath(), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	err = backup.Import(ctx, store, manifest, f, !isThinVolume(vol))
+	if err == nil {
+		err = f.Sync()
+	}
+	return errors.Join(err, f.Close())
+}
+
+// retry records err in the status and requeues the restore.
+func (r *LogicalVolumeRestoreReconciler) retry(ctx context.Context, lvr *topolvmv1.LogicalVolumeRestore, err error) (ctrl.Result, error) {
+	crlog.FromContext(ctx).Error(err, "failed to restore volume", "name", lvr.Name)
+	lvr.Status.Message = err.Error()
+	if err := r.client.Status().Update(ctx, lvr); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: requeueIntervalForBackupError}, nil
+}
+
+// fail makes the restore failed. It is not retried.
+func (r *LogicalVolumeRestoreReconciler) fail(ctx context.Context, lvr *topolvmv1.LogicalVolumeRestore, err error) (ctrl.Result, error) {
+	crlog.FromContext(ctx).Error(err, "restore failed", "name", lvr.Name)
+	now := &metav1.Time{Time: r.now()}
+	if lvr.Status.StartTime == nil {
+		lvr.Status.StartTime = now
+	}
+	lvr.Status.Phase = topolvmv1.BackupFailed
+	lvr.Status.CompletionTime = now
+	lvr.Status.Message = err.Error()
+	if err := r.client.Status().Update(ctx, lvr); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *LogicalVolumeRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("logicalvolumerestore").
+		For(&topolvmv1.LogicalVolumeRestore{}).
+		Complete(r)
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("LogicalVolumeRestore controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "restored"}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type fixture struct {
+		r         *LogicalVolumeRestoreReconciler
+		c         client.Client
+		vgService *backupVGServiceMock
+		backupDir string
+		data      []byte
+		device    string
+	}
+	newFixture := func() *fixture {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		f := &fixture{backupDir: filepath.Join(GinkgoT().TempDir(), "backup")}
+
+		By("backing up a volume")
+		dir := GinkgoT().TempDir()
+		var src string
+		src, f.data = writeTestDevice(dir)
+		file, err := os.Open(src)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = file.Close() }()
+		_, err = backup.Export(ctx, backup.NewFileStore(f.backupDir), file, uint64(len(f.data)),
+			[]backup.Range{{Offset: 0, Length: uint64(len(f.data))}}, 8192)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The device of the restored volume has garbage to check that the gaps are zero-filled.
+		f.device = filepath.Join(dir, "restored")
+		garbage := make([]byte, len(f.data))
+		for i := range garbage {
+			garbage[i] = 0xff
+		}
+		Expect(os.WriteFile(f.device, garbage, 0644)).To(Succeed())
+
+		lvr := &topolvmv1.LogicalVolumeRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restored"},
+			Spec: topolvmv1.LogicalVolumeRestoreSpec{
+				Location:    topolvmv1.BackupLocation{Path: f.backupDir},
+				NodeName:    "node2",
+				DeviceClass: "ssd",
+			},
+		}
+		f.c = fake.NewClientBuilder().WithScheme(s).
+			WithObjects(lvr).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}, &topolvmv1.LogicalVolumeRestore{}).
+			Build()
+		f.vgService = &backupVGServiceMock{}
+		f.r = NewLogicalVolumeRestoreReconciler(f.c, f.c, "node2", f.vgService, BackupConfig{BaseDir: os.TempDir()})
+		f.r.now = func() time.Time { return now }
+		return f
+	}
+
+	getLVR := func(c client.Client) *topolvmv1.LogicalVolumeRestore {
+		lvr := &topolvmv1.LogicalVolumeRestore{}
+		Expect(c.Get(ctx, req.NamespacedName, lvr)).To(Succeed())
+		return lvr
+	}
+
+	// provision does what the LogicalVolume controller does on creation.
+	provision := func(f *fixture, code codes.Code) {
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(f.c.Get(ctx, types.NamespacedName{Name: "restored"}, lv)).To(Succeed())
+		if code == codes.OK {
+			lv.Status.VolumeID = "restored-vol"
+		}
+		lv.Status.Code = code
+		lv.Status.Message = code.String()
+		Expect(f.c.Status().Update(ctx, lv)).To(Succeed())
+		f.vgService.volumes = []*proto.LogicalVolume{
+			{Name: "restored-vol", SizeBytes: int64(len(f.data)), Path: f.device, Attr: "-wi-a-----"},
+		}
+	}
+
+	It("should create a LogicalVolume and write the backup into it", func() {
+		f := newFixture()
+
+		By("starting the restore")
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvr := getLVR(f.c)
+		Expect(lvr.Status.Phase).To(Equal(topolvmv1.BackupRunning))
+		Expect(lvr.Status.LogicalVolumeName).To(Equal("restored"))
+		Expect(lvr.Status.SizeBytes).To(Equal(int64(len(f.data))))
+
+		By("creating the LogicalVolume")
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(f.c.Get(ctx, types.NamespacedName{Name: "restored"}, lv)).To(Succeed())
+		Expect(lv.Spec.NodeName).To(Equal("node2"))
+		Expect(lv.Spec.DeviceClass).To(Equal("ssd"))
+		Expect(lv.Spec.Size.Value()).To(Equal(int64(len(f.data))))
+
+		By("waiting for the volume")
+		res, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueIntervalForSimpleUpdate))
+
+		By("writing the backup")
+		provision(f, codes.OK)
+		res, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(BeZero())
+		lvr = getLVR(f.c)
+		Expect(lvr.Status.Phase).To(Equal(topolvmv1.BackupCompleted))
+		Expect(lvr.Status.VolumeID).To(Equal("restored-vol"))
+		Expect(lvr.Status.CompletionTime.Time).To(BeTemporally("==", now))
+		Expect(os.ReadFile(f.device)).To(Equal(f.data))
+	})
+
+	It("should fail when the LogicalVolume cannot be created", func() {
+		f := newFixture()
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		provision(f, codes.ResourceExhausted)
+
+		_, err = f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvr := getLVR(f.c)
+		Expect(lvr.Status.Phase).To(Equal(topolvmv1.BackupFailed))
+		Expect(lvr.Status.Message).To(ContainSubstring("ResourceExhausted"))
+	})
+
+	It("should wait for the backup to complete", func() {
+		f := newFixture()
+		lvr := getLVR(f.c)
+		lvr.Spec.Location.Path = filepath.Join(GinkgoT().TempDir(), "incomplete")
+		Expect(f.c.Update(ctx, lvr)).To(Succeed())
+
+		res, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueIntervalForBackupError))
+		lvr = getLVR(f.c)
+		Expect(lvr.Status.Phase).To(BeEmpty())
+		Expect(lvr.Status.Message).To(ContainSubstring("not found"))
+	})
+
+	It("should ignore the restores to other nodes", func() {
+		f := newFixture()
+		f.r.nodeName = "node1"
+		_, err := f.r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(getLVR(f.c).Status.Phase).To(BeEmpty())
+	})
+})
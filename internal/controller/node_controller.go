@@ -11,6 +11,7 @@ import (
 	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -24,19 +25,26 @@ import (
 type NodeReconciler struct {
 	client           client.Client
 	skipNodeFinalize bool
+	orphanVolumes    bool
 }
 
 // NewNodeReconciler returns NodeReconciler.
-func NewNodeReconciler(client client.Client, skipNodeFinalize bool) *NodeReconciler {
+// If orphanVolumes is true, the volumes on a deleted node are kept orphaned instead of being deleted,
+// and reattached when a node with the same volume groups joins the cluster.
+func NewNodeReconciler(client client.Client, skipNodeFinalize, orphanVolumes bool) *NodeReconciler {
 	return &NodeReconciler{
 		client:           client,
 		skipNodeFinalize: skipNodeFinalize,
+		orphanVolumes:    orphanVolumes,
 	}
 }
 
 //+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;update;patch
-//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;patch;delete
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=nodestorages,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;update;patch;delete
 
 // Reconcile finalize Node
 func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -55,6 +63,9 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	}
 
 	if node.DeletionTimestamp == nil {
+		if r.orphanVolumes {
+			return ctrl.Result{}, r.reattachVolumes(ctx, log, node.Name)
+		}
 		return ctrl.Result{}, nil
 	}
 
@@ -97,6 +108,9 @@ func (r *NodeReconciler) doFinalize(ctx context.Context, log logr.Logger, node c
 		log.Info("skipping node finalize")
 		return nil
 	}
+	if r.orphanVolumes {
+		return r.orphanLogicalVolumes(ctx, log, node)
+	}
 
 	scs, err := r.targetStorageClasses(ctx)
 	if err != nil {
@@ -174,6 +188,112 @@ func (r *NodeReconciler) cleanupLogicalVolume(ctx context.Context, log logr.Logg
 	return nil
 }
 
+// orphanLogicalVolumes marks the LogicalVolumes on the deleted node as orphaned, recording the UUIDs of
+// their volume groups. The PVCs and the LogicalVolumes are kept so that they are reattached when the node re-joins.
+func (r *NodeReconciler) orphanLogicalVolumes(ctx context.Context, log logr.Logger, node client.Object) error {
+	ns := &topolvmv1.NodeStorage{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: node.GetName()}, ns)
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Error(err, "failed to get NodeStorage")
+		return err
+	}
+
+	lvList := &topolvmv1.LogicalVolumeList{}
+	err = r.client.List(ctx, lvList, client.MatchingFields{keyLogicalVolumeNode: node.GetName()})
+	if err != nil {
+		log.Error(err, "failed to get LogicalVolumes")
+		return err
+	}
+	for _, lv := range lvList.Items {
+		if lv.DeletionTimestamp != nil || lv.Annotations[topolvm.GetOrphanedByNodeKey()] != "" {
+			continue
+		}
+		// The volumes are never reattached without the UUID, and must be deleted manually.
+		var uuid string
+		if dc := ns.Status.DeviceClass(lv.Spec.DeviceClass); dc != nil {
+			uuid = dc.VolumeGroupUUID
+		}
+
+		lv2 := lv.DeepCopy()
+		if lv2.Annotations == nil {
+			lv2.Annotations = make(map[string]string)
+		}
+		lv2.Annotations[topolvm.GetOrphanedByNodeKey()] = node.GetName()
+		lv2.Annotations[topolvm.GetVolumeGroupUUIDKey()] = uuid
+		if err := r.client.Patch(ctx, lv2, client.MergeFrom(&lv)); err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, "failed to patch LogicalVolume", "name", lv.Name)
+			return err
+		}
+		log.Info("orphaned LogicalVolume", "name", lv.Name, "volume_group_uuid", uuid)
+	}
+	return nil
+}
+
+// reattachVolumes moves the orphaned LogicalVolumes, whose volume groups are on the node, and their PVs to the node.
+// The PVs are recreated with the node affinity of the node if it re-joined with another name.
+func (r *NodeReconciler) reattachVolumes(ctx context.Context, log logr.Logger, nodeName string) error {
+	ns := &topolvmv1.NodeStorage{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: nodeName}, ns)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	lvList := &topolvmv1.LogicalVolumeList{}
+	if err := r.client.List(ctx, lvList); err != nil {
+		log.Error(err, "failed to get LogicalVolumes")
+		return err
+	}
+	for _, lv := range lvList.Items {
+		if !isReattachable(&lv, ns) {
+			continue
+		}
+		orphanedBy := lv.Annotations[topolvm.GetOrphanedByNodeKey()]
+
+		// The PV is moved on every pass before the LogicalVolume is, so that the move is retried
+		// until the LogicalVolume is reattached even if it fails partway through.
+		// The name of the PV is the same as the LogicalVolume.
+		pv := &corev1.PersistentVolume{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: lv.Name}, pv)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			if err := movePV(ctx, r.client, pv, nodeName); err != nil {
+				log.Error(err, "failed to move PV", "name", pv.Name)
+				return err
+			}
+		}
+
+		lv2 := lv.DeepCopy()
+		lv2.Spec.NodeName = nodeName
+		delete(lv2.Annotations, topolvm.GetOrphanedByNodeKey())
+		delete(lv2.Annotations, topolvm.GetVolumeGroupUUIDKey())
+		if err := r.client.Patch(ctx, lv2, client.MergeFrom(&lv)); err != nil {
+			log.Error(err, "failed to patch LogicalVolume", "name", lv.Name)
+			return err
+		}
+		log.Info("reattached LogicalVolume", "name", lv.Name, "node", nodeName, "orphaned_by", orphanedBy)
+	}
+	return nil
+}
+
+// isReattachable returns true if lv is orphaned and the device-class of the same name in ns has the volume group
+// where lv was.
+func isReattachable(lv *topolvmv1.LogicalVolume, ns *topolvmv1.NodeStorage) bool {
+	if lv.DeletionTimestamp != nil || lv.Annotations[topolvm.GetOrphanedByNodeKey()] == "" {
+		return false
+	}
+	uuid := lv.Annotations[topolvm.GetVolumeGroupUUIDKey()]
+	if uuid == "" {
+		return false
+	}
+	dc := ns.Status.DeviceClass(lv.Spec.DeviceClass)
+	return dc != nil && dc.VolumeGroupUUID == uuid
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	ctx := context.Background()
@@ -207,9 +327,13 @@ func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		GenericFunc: func(event.GenericEvent) bool { return false },
 	}
 
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		WithEventFilter(pred).
 		Named("node-controller").
-		WatchesMetadata(&corev1.Node{}, &handler.EnqueueRequestForObject{}).
-		Complete(r)
+		WatchesMetadata(&corev1.Node{}, &handler.EnqueueRequestForObject{})
+	if r.orphanVolumes {
+		// NodeStorage is named after the node. The volume groups are reported after the node joins.
+		builder = builder.Watches(&topolvmv1.NodeStorage{}, &handler.EnqueueRequestForObject{})
+	}
+	return builder.Complete(r)
 }
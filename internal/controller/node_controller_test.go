@@ -15,8 +15,11 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 )
@@ -26,7 +29,7 @@ var _ = Describe("NodeController controller", func() {
 	var stopFunc func()
 	errCh := make(chan error)
 
-	startReconciler := func(skipNodeFinalize bool) {
+	startReconciler := func(skipNodeFinalize, orphanVolumes bool) {
 		skipNameValidation := true
 		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 			Scheme: scheme,
@@ -39,7 +42,7 @@ var _ = Describe("NodeController controller", func() {
 		})
 		Expect(err).ToNot(HaveOccurred())
 
-		reconciler := NewNodeReconciler(mgr.GetClient(), skipNodeFinalize)
+		reconciler := NewNodeReconciler(mgr.GetClient(), skipNodeFinalize, orphanVolumes)
 		err = reconciler.SetupWithManager(mgr)
 		Expect(err).NotTo(HaveOccurred())
 
@@ -117,7 +120,7 @@ var _ = Describe("NodeController controller", func() {
 	}
 
 	It("should delete PVC and LogicalVolume when the node is deleted if the finalizer is not skipped", func() {
-		startReconciler(false)
+		startReconciler(false, false)
 
 		ctx := context.Background()
 
@@ -155,7 +158,7 @@ var _ = Describe("NodeController controller", func() {
 	})
 
 	It("should not touch PVC and LogicalVolume when the node is deleted if the finalizer is skipped", func() {
-		startReconciler(true)
+		startReconciler(true, false)
 
 		ctx := context.Background()
 
@@ -184,4 +187,146 @@ var _ = Describe("NodeController controller", func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(lv.DeletionTimestamp).To(BeNil())
 	})
+
+	It("should orphan LogicalVolume when the node is deleted and reattach it when the node re-joins", func() {
+		startReconciler(false, true)
+
+		ctx := context.Background()
+
+		// Setup
+		node, pvc, lv := setupResources(ctx, "-orphan")
+		ns := topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: node.Name},
+		}
+		err := k8sClient.Create(ctx, &ns)
+		Expect(err).NotTo(HaveOccurred())
+		ns.Status.DeviceClasses = []topolvmv1.DeviceClassStorageStatus{
+			{Name: "ssd", Default: true, VolumeGroup: "vg1", VolumeGroupUUID: "vg1-uuid"},
+		}
+		err = k8sClient.Status().Update(ctx, &ns)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Exercise
+		err = k8sClient.Delete(ctx, &node)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Verify
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&node), &node)
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		}).Should(Succeed())
+
+		err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&pvc), &pvc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pvc.DeletionTimestamp).To(BeNil())
+
+		err = k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(lv.DeletionTimestamp).To(BeNil())
+		Expect(lv.Annotations).To(HaveKeyWithValue(topolvm.GetOrphanedByNodeKey(), node.Name))
+		Expect(lv.Annotations).To(HaveKeyWithValue(topolvm.GetVolumeGroupUUIDKey(), "vg1-uuid"))
+
+		// Exercise: the node re-joins with another name and the same volume group
+		newNode := corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: node.Name + "-rejoined"},
+		}
+		err = k8sClient.Create(ctx, &newNode)
+		Expect(err).NotTo(HaveOccurred())
+		newNS := topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: newNode.Name},
+		}
+		err = k8sClient.Create(ctx, &newNS)
+		Expect(err).NotTo(HaveOccurred())
+		newNS.Status.DeviceClasses = ns.Status.DeviceClasses
+		err = k8sClient.Status().Update(ctx, &newNS)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Verify
+		Eventually(func(g Gomega) {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&lv), &lv)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lv.Spec.NodeName).To(Equal(newNode.Name))
+			g.Expect(lv.Annotations).NotTo(HaveKey(topolvm.GetOrphanedByNodeKey()))
+		}).Should(Succeed())
+	})
+})
+
+// failingPVCPatchClient fails to patch PVCs until failures runs out.
+type failingPVCPatchClient struct {
+	client.Client
+	failures int
+}
+
+func (c *failingPVCPatchClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if _, ok := obj.(*corev1.PersistentVolumeClaim); ok && c.failures > 0 {
+		c.failures--
+		return errors.New("failed to patch PVC")
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+var _ = Describe("NodeController controller reattaching volumes", func() {
+	ctx := context.Background()
+
+	It("should retry moving the PV until the LogicalVolume is reattached", func() {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		ns := &topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+			Status: topolvmv1.NodeStorageStatus{DeviceClasses: []topolvmv1.DeviceClassStorageStatus{
+				{Name: "ssd", VolumeGroup: "vg1", VolumeGroupUUID: "vg1-uuid"},
+			}},
+		}
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "lv",
+				Annotations: map[string]string{
+					topolvm.GetOrphanedByNodeKey():  "node1",
+					topolvm.GetVolumeGroupUUIDKey(): "vg1-uuid",
+				},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{NodeName: "node1", DeviceClass: "ssd"},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "lv", Finalizers: []string{"kubernetes.io/pv-protection"}},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef: &corev1.ObjectReference{Namespace: "default", Name: "pvc"},
+				NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: topolvm.GetTopologyNodeKey(), Operator: corev1.NodeSelectorOpIn, Values: []string{"node1"}},
+					}}},
+				}},
+			},
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "pvc",
+				Namespace:   "default",
+				Annotations: map[string]string{AnnSelectedNode: "node1"},
+			},
+		}
+		c := &failingPVCPatchClient{
+			Client:   fake.NewClientBuilder().WithScheme(s).WithObjects(ns, lv, pv, pvc).Build(),
+			failures: 1,
+		}
+		r := NewNodeReconciler(c, false, true)
+		log := GinkgoLogr
+
+		// the PV is recreated, but the PVC fails to be updated.
+		Expect(r.reattachVolumes(ctx, log, "node2")).NotTo(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(lv), lv)).To(Succeed())
+		Expect(lv.Spec.NodeName).To(Equal("node1"))
+		Expect(lv.Annotations).To(HaveKeyWithValue(topolvm.GetOrphanedByNodeKey(), "node1"))
+
+		Expect(r.reattachVolumes(ctx, log, "node2")).To(Succeed())
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(lv), lv)).To(Succeed())
+		Expect(lv.Spec.NodeName).To(Equal("node2"))
+		Expect(lv.Annotations).NotTo(HaveKey(topolvm.GetOrphanedByNodeKey()))
+		Expect(lv.Annotations).NotTo(HaveKey(topolvm.GetVolumeGroupUUIDKey()))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(pv), pv)).To(Succeed())
+		Expect(pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values).To(Equal([]string{"node2"}))
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(pvc), pvc)).To(Succeed())
+		Expect(pvc.Annotations).To(HaveKeyWithValue(AnnSelectedNode, "node2"))
+	})
 })
@@ -0,0 +1,264 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// requeueIntervalForReattach is the interval to wait for topolvm-node to check the LVM logical volume
+// and for the PVC to be bound.
+const requeueIntervalForReattach = 10 * time.Second
+
+// verifyFreshness is how long the result of a check of the LVM logical volume is trusted.
+// A new check is requested after it, e.g. when the PVC is annotated again.
+const verifyFreshness = time.Minute
+
+// PersistentVolumeReattachReconciler binds released TopoLVM PVs to the PVCs requesting them.
+type PersistentVolumeReattachReconciler struct {
+	client   client.Client
+	recorder events.EventRecorder
+	now      func() time.Time
+}
+
+// NewPersistentVolumeReattachReconciler returns PersistentVolumeReattachReconciler.
+func NewPersistentVolumeReattachReconciler(client client.Client, recorder events.EventRecorder) *PersistentVolumeReattachReconciler {
+	return &PersistentVolumeReattachReconciler{
+		client:   client,
+		recorder: recorder,
+		now:      time.Now,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+// Reconcile points the claimRef of the released PV named by the annotation of the PVC to the PVC
+// after topolvm-node confirms the LVM logical volume is intact, and removes the annotation when the PVC is bound.
+func (r *PersistentVolumeReattachReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(ctx, req.NamespacedName, pvc)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	target, ok := pvc.Annotations[topolvm.GetReattachVolumeKey()]
+	if !ok || pvc.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	pv, err := r.findVolume(ctx, target)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pv == nil {
+		return ctrl.Result{}, r.reject(ctx, pvc, nil, fmt.Sprintf("TopoLVM PV %s is not found", target))
+	}
+
+	if pvc.Spec.VolumeName != "" {
+		if pvc.Spec.VolumeName != pv.Name {
+			return ctrl.Result{}, r.reject(ctx, pvc, pv, fmt.Sprintf("PVC is already bound to PV %s", pvc.Spec.VolumeName))
+		}
+		r.recorder.Eventf(pvc, pv, corev1.EventTypeNormal, "Reattached", "Reattach", "bound to PV %s", pv.Name)
+		return ctrl.Result{}, r.removeAnnotation(ctx, pvc)
+	}
+	if isClaimedBy(pv, pvc) {
+		// wait for the PV controller to bind them.
+		return ctrl.Result{RequeueAfter: requeueIntervalForReattach}, nil
+	}
+	if err := checkReattachable(pv, pvc); err != nil {
+		return ctrl.Result{}, r.reject(ctx, pvc, pv, err.Error())
+	}
+
+	lv := &topolvmv1.LogicalVolume{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: pv.Name}, lv)
+	if apierrors.IsNotFound(err) {
+		return ctrl.Result{}, r.reject(ctx, pvc, pv, fmt.Sprintf("LogicalVolume of PV %s is not found", pv.Name))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if lv.DeletionTimestamp != nil || lv.Status.VolumeID != pv.Spec.CSI.VolumeHandle {
+		return ctrl.Result{}, r.reject(ctx, pvc, pv, fmt.Sprintf("LogicalVolume of PV %s is being deleted or replaced", pv.Name))
+	}
+
+	verified, err := r.verify(ctx, lv)
+	if err != nil || !verified {
+		return ctrl.Result{RequeueAfter: requeueIntervalForReattach}, err
+	}
+	v := lv.Status.Verification
+	if !v.Found {
+		return ctrl.Result{}, r.reject(ctx, pvc, pv, fmt.Sprintf("LVM logical volume of PV %s is not found on node %s", pv.Name, lv.Spec.NodeName))
+	}
+	if lv.Status.CurrentSize == nil || v.SizeBytes != lv.Status.CurrentSize.Value() {
+		return ctrl.Result{}, r.reject(ctx, pvc, pv,
+			fmt.Sprintf("size of LVM logical volume of PV %s does not match LogicalVolume: %d", pv.Name, v.SizeBytes))
+	}
+
+	// Replace the stale claimRef including its uid and resourceVersion so that the PV controller binds the PVC.
+	pv2 := pv.DeepCopy()
+	pv2.Spec.ClaimRef = &corev1.ObjectReference{
+		Kind:       "PersistentVolumeClaim",
+		APIVersion: "v1",
+		Namespace:  pvc.Namespace,
+		Name:       pvc.Name,
+		UID:        pvc.UID,
+	}
+	if err := r.client.Patch(ctx, pv2, client.MergeFrom(pv)); err != nil {
+		log.Error(err, "failed to update claimRef", "name", pv.Name)
+		return ctrl.Result{}, err
+	}
+	r.recorder.Eventf(pvc, pv, corev1.EventTypeNormal, "Reattaching", "Reattach", "binding to PV %s", pv.Name)
+	log.Info("reattaching the released PV", "name", pvc.Name, "namespace", pvc.Namespace, "pv", pv.Name)
+	return ctrl.Result{RequeueAfter: requeueIntervalForReattach}, nil
+}
+
+// findVolume returns the TopoLVM PV whose name or volume ID is target, or nil if not found.
+func (r *PersistentVolumeReattachReconciler) findVolume(ctx context.Context, target string) (*corev1.PersistentVolume, error) {
+	isTopoLVM := func(pv *corev1.PersistentVolume) bool {
+		return pv.Spec.CSI != nil && pv.Spec.CSI.Driver == topolvm.GetPluginName()
+	}
+
+	pv := &corev1.PersistentVolume{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: target}, pv)
+	if err == nil && isTopoLVM(pv) {
+		return pv, nil
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, err
+	}
+
+	var pvs corev1.PersistentVolumeList
+	if err := r.client.List(ctx, &pvs); err != nil {
+		return nil, err
+	}
+	for i := range pvs.Items {
+		if isTopoLVM(&pvs.Items[i]) && pvs.Items[i].Spec.CSI.VolumeHandle == target {
+			return &pvs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// verify requests topolvm-node to check the LVM logical volume, and returns true when the result of a request
+// made within verifyFreshness is reported in status.verification.
+func (r *PersistentVolumeReattachReconciler) verify(ctx context.Context, lv *topolvmv1.LogicalVolume) (bool, error) {
+	requestedAt, ok := lv.Annotations[topolvm.GetVerifyRequestedAtKey()]
+	if ok {
+		at, err := time.Parse(time.RFC3339, requestedAt)
+		if err == nil && r.now().Sub(at) < verifyFreshness {
+			v := lv.Status.Verification
+			return v != nil && v.RequestedAt == requestedAt, nil
+		}
+	}
+
+	lv2 := lv.DeepCopy()
+	if lv2.Annotations == nil {
+		lv2.Annotations = map[string]string{}
+	}
+	lv2.Annotations[topolvm.GetVerifyRequestedAtKey()] = r.now().UTC().Format(time.RFC3339)
+	if err := r.client.Patch(ctx, lv2, client.MergeFrom(lv)); err != nil {
+		crlog.FromContext(ctx).Error(err, "failed to request verification", "name", lv.Name)
+		return false, err
+	}
+	return false, nil
+}
+
+// isClaimedBy returns true if the claimRef of the PV points to the PVC.
+func isClaimedBy(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) bool {
+	ref := pv.Spec.ClaimRef
+	return ref != nil && ref.Namespace == pvc.Namespace && ref.Name == pvc.Name && ref.UID == pvc.UID
+}
+
+// isReattachAllowed returns true if the PVC may take over the PV.
+// A PV is reattached only within the namespace of its previous PVC unless the PV is annotated with
+// the namespace or the namespace/name of the PVC. PVs are cluster-scoped, so only cluster administrators
+// can grant it.
+func isReattachAllowed(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) bool {
+	if pv.Spec.ClaimRef != nil && pv.Spec.ClaimRef.Namespace == pvc.Namespace {
+		return true
+	}
+	allowed, ok := pv.Annotations[topolvm.GetReattachAllowedToKey()]
+	if !ok {
+		return false
+	}
+	return allowed == pvc.Namespace || allowed == pvc.Namespace+"/"+pvc.Name
+}
+
+// checkReattachable returns an error if the PV cannot be bound to the PVC.
+func checkReattachable(pv *corev1.PersistentVolume, pvc *corev1.PersistentVolumeClaim) error {
+	if pv.DeletionTimestamp != nil || pv.Status.Phase != corev1.VolumeReleased {
+		return fmt.Errorf("PV %s is not released", pv.Name)
+	}
+	if !isReattachAllowed(pv, pvc) {
+		return fmt.Errorf("PV %s was claimed in another namespace and is not allowed to be reattached in namespace %s", pv.Name, pvc.Namespace)
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+		return fmt.Errorf("reclaim policy of PV %s is not Retain", pv.Name)
+	}
+	if pv.Spec.StorageClassName != ptr.Deref(pvc.Spec.StorageClassName, "") {
+		return fmt.Errorf("storage class of PV %s does not match", pv.Name)
+	}
+	if ptr.Deref(pv.Spec.VolumeMode, corev1.PersistentVolumeFilesystem) != ptr.Deref(pvc.Spec.VolumeMode, corev1.PersistentVolumeFilesystem) {
+		return fmt.Errorf("volume mode of PV %s does not match", pv.Name)
+	}
+	for _, mode := range pvc.Spec.AccessModes {
+		if !slices.Contains(pv.Spec.AccessModes, mode) {
+			return fmt.Errorf("PV %s does not support access mode %s", pv.Name, mode)
+		}
+	}
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+	if capacity.Cmp(requested) < 0 {
+		return fmt.Errorf("PV %s is smaller than the request: %s", pv.Name, capacity.String())
+	}
+	return nil
+}
+
+// reject records the reason why the PV cannot be reattached, and removes the annotation.
+func (r *PersistentVolumeReattachReconciler) reject(ctx context.Context, pvc *corev1.PersistentVolumeClaim, pv *corev1.PersistentVolume, message string) error {
+	var related runtime.Object
+	if pv != nil {
+		related = pv
+	}
+	r.recorder.Eventf(pvc, related, corev1.EventTypeWarning, "ReattachInvalid", "Reattach", "%s", message)
+	return r.removeAnnotation(ctx, pvc)
+}
+
+func (r *PersistentVolumeReattachReconciler) removeAnnotation(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	patch := client.MergeFrom(pvc.DeepCopy())
+	delete(pvc.Annotations, topolvm.GetReattachVolumeKey())
+	if err := r.client.Patch(ctx, pvc, patch); err != nil {
+		crlog.FromContext(ctx).Error(err, "failed to remove the annotation", "name", pvc.Name, "namespace", pvc.Namespace)
+		return err
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PersistentVolumeReattachReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("persistentvolumereattach").
+		For(&corev1.PersistentVolumeClaim{}).
+		Complete(r)
+}
@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("PersistentVolumeReattach controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "new-pvc"}}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newReconciler := func(objs ...client.Object) (*PersistentVolumeReattachReconciler, client.Client, *events.FakeRecorder) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(objs...).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}).
+			Build()
+		recorder := events.NewFakeRecorder(10)
+		r := NewPersistentVolumeReattachReconciler(c, recorder)
+		r.now = func() time.Time { return now }
+		return r, c, recorder
+	}
+
+	// objects returns a PVC annotated to reattach target, and the PV released from the old PVC with its LogicalVolume.
+	objects := func(target string, phase corev1.PersistentVolumePhase) []client.Object {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "new-pvc",
+				UID:         "new-uid",
+				Annotations: map[string]string{topolvm.GetReattachVolumeKey(): target},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: ptr.To("topolvm"),
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				},
+			},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName:              "topolvm",
+				AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+				PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+				ClaimRef:                      &corev1.ObjectReference{Namespace: "test", Name: "old-pvc", UID: "old-uid", ResourceVersion: "1"},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: topolvm.GetPluginName(), VolumeHandle: "vol"},
+				},
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "pv", NodeName: "node", Size: resource.MustParse("1Gi")},
+			Status: topolvmv1.LogicalVolumeStatus{
+				VolumeID:    "vol",
+				CurrentSize: resource.NewQuantity(1<<30, resource.BinarySI),
+			},
+		}
+		return []client.Object{pvc, pv, lv}
+	}
+
+	// reportVerification reports the result of the check requested to the LogicalVolume as topolvm-node does.
+	reportVerification := func(c client.Client, found bool, size int64) {
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		requestedAt, ok := lv.Annotations[topolvm.GetVerifyRequestedAtKey()]
+		Expect(ok).To(BeTrue())
+		lv.Status.Verification = &topolvmv1.VerificationStatus{RequestedAt: requestedAt, Found: found, SizeBytes: size}
+		Expect(c.Status().Update(ctx, lv)).To(Succeed())
+	}
+
+	It("should bind the released PV named by the volume ID after the verification", func() {
+		r, c, recorder := newReconciler(objects("vol", corev1.VolumeReleased)...)
+
+		By("requesting the verification")
+		res, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueIntervalForReattach))
+		pv := &corev1.PersistentVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, pv)).To(Succeed())
+		Expect(pv.Spec.ClaimRef.Name).To(Equal("old-pvc"))
+
+		By("replacing the claimRef after the verification")
+		reportVerification(c, true, 1<<30)
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, pv)).To(Succeed())
+		Expect(pv.Spec.ClaimRef.Namespace).To(Equal("test"))
+		Expect(pv.Spec.ClaimRef.Name).To(Equal("new-pvc"))
+		Expect(pv.Spec.ClaimRef.UID).To(BeEquivalentTo("new-uid"))
+		Expect(pv.Spec.ClaimRef.ResourceVersion).To(BeEmpty())
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Normal Reattaching")))
+
+		By("removing the annotation when the PVC is bound")
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		pvc.Spec.VolumeName = "pv"
+		Expect(c.Update(ctx, pvc)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(topolvm.GetReattachVolumeKey()))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Normal Reattached")))
+	})
+
+	It("should reject the PV whose LVM logical volume does not match", func() {
+		r, c, recorder := newReconciler(objects("pv", corev1.VolumeReleased)...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		reportVerification(c, true, 1<<29)
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		pv := &corev1.PersistentVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, pv)).To(Succeed())
+		Expect(pv.Spec.ClaimRef.Name).To(Equal("old-pvc"))
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(topolvm.GetReattachVolumeKey()))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Warning ReattachInvalid")))
+	})
+
+	It("should reject the PV not released", func() {
+		r, c, recorder := newReconciler(objects("pv", corev1.VolumeBound)...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Annotations).NotTo(HaveKey(topolvm.GetVerifyRequestedAtKey()))
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(topolvm.GetReattachVolumeKey()))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Warning ReattachInvalid")))
+	})
+
+	It("should reject the PV claimed in another namespace", func() {
+		objs := objects("pv", corev1.VolumeReleased)
+		objs[1].(*corev1.PersistentVolume).Spec.ClaimRef.Namespace = "victim"
+		r, c, recorder := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		pv := &corev1.PersistentVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, pv)).To(Succeed())
+		Expect(pv.Spec.ClaimRef.Namespace).To(Equal("victim"))
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Annotations).NotTo(HaveKey(topolvm.GetVerifyRequestedAtKey()))
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(topolvm.GetReattachVolumeKey()))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("not allowed to be reattached in namespace test")))
+	})
+
+	It("should reattach the PV claimed in another namespace if an administrator allows it", func() {
+		objs := objects("pv", corev1.VolumeReleased)
+		pv := objs[1].(*corev1.PersistentVolume)
+		pv.Spec.ClaimRef.Namespace = "old"
+		pv.Annotations = map[string]string{topolvm.GetReattachAllowedToKey(): "test/new-pvc"}
+		r, c, _ := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		reportVerification(c, true, 1<<30)
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, pv)).To(Succeed())
+		Expect(pv.Spec.ClaimRef.Namespace).To(Equal("test"))
+		Expect(pv.Spec.ClaimRef.Name).To(Equal("new-pvc"))
+	})
+
+	It("should not allow the grant for another PVC", func() {
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{topolvm.GetReattachAllowedToKey(): "test/other-pvc"}},
+			Spec:       corev1.PersistentVolumeSpec{ClaimRef: &corev1.ObjectReference{Namespace: "old"}},
+		}
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "new-pvc"}}
+		Expect(isReattachAllowed(pv, pvc)).To(BeFalse())
+		pv.Annotations[topolvm.GetReattachAllowedToKey()] = "test"
+		Expect(isReattachAllowed(pv, pvc)).To(BeTrue())
+		pv.Annotations[topolvm.GetReattachAllowedToKey()] = "test2"
+		Expect(isReattachAllowed(pv, pvc)).To(BeFalse())
+	})
+})
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// populationFunc writes the data into a volume. It reports the number of bytes written so far to progress,
+// and returns the volume and the number of bytes written.
+type populationFunc func(ctx context.Context, progress func(int64)) (*proto.LogicalVolume, int64, error)
+
+// populationTracker runs the populations of the LogicalVolumes in the background so that the reconciler
+// is not blocked while they copy the data. Each LogicalVolume is populated by one job at a time.
+type populationTracker struct {
+	mu   sync.Mutex
+	jobs map[types.UID]*populationJob
+}
+
+type populationJob struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	copied atomic.Int64
+	volume *proto.LogicalVolume
+	err    error
+}
+
+func newPopulationTracker() *populationTracker {
+	return &populationTracker{
+		jobs: map[types.UID]*populationJob{},
+	}
+}
+
+// get returns the job populating the LogicalVolume, or nil if no job is started.
+func (t *populationTracker) get(uid types.UID) *populationJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.jobs[uid]
+}
+
+// start starts populate for the LogicalVolume in the background.
+// The job is not canceled when ctx is done but when stop is called.
+func (t *populationTracker) start(ctx context.Context, uid types.UID, populate populationFunc) *populationJob {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	job := &populationJob{cancel: cancel, done: make(chan struct{})}
+	t.mu.Lock()
+	t.jobs[uid] = job
+	t.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		volume, copied, err := populate(ctx, job.copied.Store)
+		job.volume, job.err = volume, err
+		job.copied.Store(copied)
+		close(job.done)
+	}()
+	return job
+}
+
+// stop cancels the job populating the LogicalVolume if it is running, and forgets it.
+func (t *populationTracker) stop(uid types.UID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if job, ok := t.jobs[uid]; ok {
+		job.cancel()
+		delete(t.jobs, uid)
+	}
+}
+
+// finished returns true if the job has finished. Its result is returned by result.
+func (j *populationJob) finished() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// result returns the result of the finished job.
+func (j *populationJob) result() (*proto.LogicalVolume, int64, error) {
+	<-j.done
+	return j.volume, j.copied.Load(), j.err
+}
@@ -0,0 +1,267 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/dcpolicy"
+	"github.com/topolvm/topolvm/internal/dra"
+	"github.com/topolvm/topolvm/internal/quota"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// requeueIntervalForDeniedClaim is the interval to check again a ResourceClaim denied by
+// DeviceClassPolicies or TopoLVMQuotas, which may be changed to allow it.
+const requeueIntervalForDeniedClaim = time.Minute
+
+// ResourceClaimReconciler reconciles a ResourceClaim object
+type ResourceClaimReconciler struct {
+	client   client.Client
+	recorder events.EventRecorder
+}
+
+// NewResourceClaimReconciler returns ResourceClaimReconciler.
+func NewResourceClaimReconciler(client client.Client, recorder events.EventRecorder) *ResourceClaimReconciler {
+	return &ResourceClaimReconciler{
+		client:   client,
+		recorder: recorder,
+	}
+}
+
+//+kubebuilder:rbac:groups=resource.k8s.io,resources=resourceclaims,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=topolvm.io,resources=topolvmquotas,verbs=get;list;watch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+// Reconcile creates LogicalVolumes for the devices allocated to a ResourceClaim from TopoLVM,
+// and deletes them when the claim is deallocated or deleted.
+// The LogicalVolumes are not created while DeviceClassPolicies or TopoLVMQuotas of the namespace deny them,
+// in the same way as the volumes of PVCs.
+func (r *ResourceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	claim := &resourcev1.ResourceClaim{}
+	err := r.client.Get(ctx, req.NamespacedName, claim)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+
+	results := allocatedResults(claim)
+	if claim.DeletionTimestamp != nil || len(results) == 0 {
+		if !controllerutil.ContainsFinalizer(claim, topolvm.GetResourceClaimFinalizer()) {
+			return ctrl.Result{}, nil
+		}
+
+		remaining, err := r.deleteLogicalVolumes(ctx, claim)
+		if err != nil {
+			log.Error(err, "failed to delete LogicalVolumes", "name", claim.Name, "namespace", claim.Namespace)
+			return ctrl.Result{}, err
+		}
+		if remaining {
+			return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+		}
+
+		claim2 := claim.DeepCopy()
+		controllerutil.RemoveFinalizer(claim2, topolvm.GetResourceClaimFinalizer())
+		if err := r.client.Patch(ctx, claim2, client.MergeFrom(claim)); err != nil {
+			log.Error(err, "failed to remove finalizer", "name", claim.Name, "namespace", claim.Namespace)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(claim, topolvm.GetResourceClaimFinalizer()) {
+		claim2 := claim.DeepCopy()
+		controllerutil.AddFinalizer(claim2, topolvm.GetResourceClaimFinalizer())
+		if err := r.client.Patch(ctx, claim2, client.MergeFrom(claim)); err != nil {
+			log.Error(err, "failed to add finalizer", "name", claim.Name, "namespace", claim.Namespace)
+			return ctrl.Result{}, err
+		}
+		claim = claim2
+	}
+
+	var quotas topolvmv1.TopoLVMQuotaList
+	if err := r.client.List(ctx, &quotas, client.InNamespace(claim.Namespace)); err != nil {
+		return ctrl.Result{}, err
+	}
+	used, err := quota.Usage(ctx, r.client, claim.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for _, i := range slices.Sorted(maps.Keys(results)) {
+		result := results[i]
+		err := r.createLogicalVolume(ctx, claim, i, result, quotas.Items, used)
+		var denied deniedError
+		if errors.As(err, &denied) {
+			r.recorder.Eventf(claim, nil, corev1.EventTypeWarning, "VolumeDenied", "CreateVolume", "%s", err.Error())
+			return ctrl.Result{RequeueAfter: requeueIntervalForDeniedClaim}, nil
+		}
+		if err != nil {
+			log.Error(err, "failed to create LogicalVolume", "name", claim.Name, "namespace", claim.Namespace, "request", result.Request)
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// deniedError is returned when a DeviceClassPolicy or a TopoLVMQuota denies the volume of a claim.
+type deniedError struct {
+	error
+}
+
+// allocatedResults returns the allocation results of the claim that TopoLVM is responsible for.
+// They are keyed by the index in the allocation, which is stable because the allocation is immutable.
+func allocatedResults(claim *resourcev1.ResourceClaim) map[int]resourcev1.DeviceRequestAllocationResult {
+	if claim.Status.Allocation == nil {
+		return nil
+	}
+
+	results := make(map[int]resourcev1.DeviceRequestAllocationResult)
+	for i, result := range claim.Status.Allocation.Devices.Results {
+		if result.Driver != topolvm.GetPluginName() {
+			continue
+		}
+		results[i] = result
+	}
+	return results
+}
+
+// createLogicalVolume creates the LogicalVolume for the i-th allocation result of the claim unless it exists.
+// used is the usage of the namespace for the quotas, and is updated with the created volume.
+func (r *ResourceClaimReconciler) createLogicalVolume(ctx context.Context, claim *resourcev1.ResourceClaim, i int,
+	result resourcev1.DeviceRequestAllocationResult, quotas []topolvmv1.TopoLVMQuota, used corev1.ResourceList) error {
+	log := crlog.FromContext(ctx)
+	name := dra.VolumeName(claim.UID, i)
+
+	lv := &topolvmv1.LogicalVolume{}
+	err := r.client.Get(ctx, client.ObjectKey{Name: name}, lv)
+	switch {
+	case err == nil:
+		return nil
+	case apierrors.IsNotFound(err):
+	default:
+		return err
+	}
+
+	deviceClass := dra.DeviceClass(result.Device)
+	size := requestedBytes(claim, result)
+	err = dcpolicy.Check(ctx, r.client, deviceClass, claim.Namespace)
+	if errors.Is(err, dcpolicy.ErrDenied) {
+		return deniedError{err}
+	}
+	if err != nil {
+		return err
+	}
+	key := quota.DeviceClassKey(deviceClass)
+	if err := quota.Check(quotas, used, key, size); err != nil {
+		return deniedError{err}
+	}
+
+	lv = &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				topolvm.CreatedbyLabelKey:     topolvm.CreatedbyLabelValue,
+				topolvm.GetResourceClaimKey(): string(claim.UID),
+				topolvm.GetNamespaceKey():     claim.Namespace,
+			},
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:        name,
+			NodeName:    result.Pool,
+			DeviceClass: deviceClass,
+			Size:        *resource.NewQuantity(size, resource.BinarySI),
+		},
+	}
+	if err := r.client.Create(ctx, lv); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	log.Info("created LogicalVolume", "name", name, "node", lv.Spec.NodeName, "device_class", lv.Spec.DeviceClass, "size", lv.Spec.Size.String())
+	q := used[key]
+	q.Add(lv.Spec.Size)
+	used[key] = q
+	return nil
+}
+
+// requestedBytes returns the size of the volume for the allocation result.
+// The capacity consumed by the allocation takes precedence, and then the capacity in the claim's request is used.
+func requestedBytes(claim *resourcev1.ResourceClaim, result resourcev1.DeviceRequestAllocationResult) int64 {
+	if size, ok := dra.CapacitySizeOf(result.ConsumedCapacity); ok {
+		return size
+	}
+
+	var capacity *resourcev1.CapacityRequirements
+	parent, sub, _ := strings.Cut(result.Request, "/")
+	for _, req := range claim.Spec.Devices.Requests {
+		if req.Name != parent {
+			continue
+		}
+		if req.Exactly != nil {
+			capacity = req.Exactly.Capacity
+		}
+		for _, subReq := range req.FirstAvailable {
+			if subReq.Name == sub {
+				capacity = subReq.Capacity
+			}
+		}
+	}
+	if capacity != nil {
+		if size, ok := dra.CapacitySizeOf(capacity.Requests); ok {
+			return size
+		}
+	}
+	return topolvm.DefaultSize
+}
+
+// deleteLogicalVolumes deletes LogicalVolumes created for the claim.
+// It returns true if some of them still exist.
+func (r *ResourceClaimReconciler) deleteLogicalVolumes(ctx context.Context, claim *resourcev1.ResourceClaim) (bool, error) {
+	log := crlog.FromContext(ctx)
+
+	lvList := &topolvmv1.LogicalVolumeList{}
+	err := r.client.List(ctx, lvList, client.MatchingLabels{topolvm.GetResourceClaimKey(): string(claim.UID)})
+	if err != nil {
+		return false, err
+	}
+
+	for _, lv := range lvList.Items {
+		if lv.DeletionTimestamp != nil {
+			continue
+		}
+		if err := r.client.Delete(ctx, &lv); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		log.Info("deleted LogicalVolume", "name", lv.Name)
+	}
+	return len(lvList.Items) != 0, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ResourceClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&resourcev1.ResourceClaim{}).
+		Complete(r)
+}
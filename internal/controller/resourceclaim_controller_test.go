@@ -0,0 +1,330 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+var _ = Describe("ResourceClaimController controller", func() {
+	ctx := context.Background()
+	var stopFunc func()
+	errCh := make(chan error)
+
+	BeforeEach(func() {
+		skipNameValidation := true
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme: scheme,
+			Controller: config.Controller{
+				SkipNameValidation: &skipNameValidation,
+			},
+			Metrics: server.Options{
+				BindAddress: "0", // disable metrics
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		reconciler := NewResourceClaimReconciler(mgr.GetClient(), mgr.GetEventRecorder("topolvm-controller"))
+		err = reconciler.SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(ctx)
+		stopFunc = cancel
+		go func() {
+			errCh <- mgr.Start(ctx)
+		}()
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		stopFunc()
+		Expect(<-errCh).NotTo(HaveOccurred())
+	})
+
+	createAllocatedClaim := func(ctx context.Context, ns string, results []resourcev1.DeviceRequestAllocationResult) *resourcev1.ResourceClaim {
+		claim := &resourcev1.ResourceClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "claim",
+				Namespace: ns,
+			},
+			Spec: resourcev1.ResourceClaimSpec{
+				Devices: resourcev1.DeviceClaim{
+					Requests: []resourcev1.DeviceRequest{
+						{
+							Name: "volume",
+							Exactly: &resourcev1.ExactDeviceRequest{
+								DeviceClassName: "topolvm",
+								Capacity: &resourcev1.CapacityRequirements{
+									Requests: map[resourcev1.QualifiedName]resource.Quantity{
+										"size": resource.MustParse("3Gi"),
+									},
+								},
+							},
+						},
+						{
+							Name: "other",
+							Exactly: &resourcev1.ExactDeviceRequest{
+								DeviceClassName: "other",
+							},
+						},
+					},
+				},
+			},
+		}
+		err := k8sClient.Create(ctx, claim)
+		Expect(err).NotTo(HaveOccurred())
+
+		claim.Status.Allocation = &resourcev1.AllocationResult{
+			Devices: resourcev1.DeviceAllocationResult{
+				Results: results,
+			},
+		}
+		err = k8sClient.Status().Update(ctx, claim)
+		Expect(err).NotTo(HaveOccurred())
+		return claim
+	}
+
+	It("should create and delete LogicalVolumes for the allocated claim", func() {
+		ctx := context.Background()
+		ns := createNamespace()
+
+		claim := createAllocatedClaim(ctx, ns, []resourcev1.DeviceRequestAllocationResult{
+			{
+				Request: "other",
+				Driver:  "other.example.com",
+				Pool:    "pool",
+				Device:  "device",
+			},
+			{
+				Request: "volume",
+				Driver:  topolvm.GetPluginName(),
+				Pool:    nodeNameBase + "-claim",
+				Device:  "ssd",
+			},
+			{
+				Request: "volume",
+				Driver:  topolvm.GetPluginName(),
+				Pool:    nodeNameBase + "-claim",
+				Device:  "00default",
+			},
+		})
+
+		By("checking the LogicalVolumes are created")
+		var lvList topolvmv1.LogicalVolumeList
+		Eventually(func(g Gomega) {
+			err := k8sClient.List(ctx, &lvList, client.MatchingLabels{topolvm.GetResourceClaimKey(): string(claim.UID)})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lvList.Items).To(HaveLen(2))
+		}).Should(Succeed())
+
+		lvs := map[string]topolvmv1.LogicalVolume{}
+		for _, lv := range lvList.Items {
+			lvs[lv.Name] = lv
+		}
+		Expect(lvs).To(HaveKey(string(claim.UID) + "-1"))
+		Expect(lvs).To(HaveKey(string(claim.UID) + "-2"))
+
+		lv := lvs[string(claim.UID)+"-1"]
+		Expect(lv.Spec.Name).To(Equal(lv.Name))
+		Expect(lv.Spec.NodeName).To(Equal(nodeNameBase + "-claim"))
+		Expect(lv.Spec.DeviceClass).To(Equal("ssd"))
+		Expect(lv.Spec.Size.Value()).To(Equal(int64(3 << 30)))
+
+		lv = lvs[string(claim.UID)+"-2"]
+		Expect(lv.Spec.DeviceClass).To(Equal(topolvm.DefaultDeviceClassName))
+		Expect(lv.Spec.Size.Value()).To(Equal(int64(3 << 30)))
+
+		Eventually(func(g Gomega) {
+			var claim2 resourcev1.ResourceClaim
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), &claim2)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(controllerutil.ContainsFinalizer(&claim2, topolvm.GetResourceClaimFinalizer())).To(BeTrue())
+		}).Should(Succeed())
+
+		By("deleting the claim")
+		err := k8sClient.Delete(ctx, claim)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("checking the LogicalVolumes and the claim are deleted")
+		Eventually(func(g Gomega) {
+			err := k8sClient.List(ctx, &lvList, client.MatchingLabels{topolvm.GetResourceClaimKey(): string(claim.UID)})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lvList.Items).To(BeEmpty())
+
+			var claim2 resourcev1.ResourceClaim
+			err = k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), &claim2)
+			g.Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		}).Should(Succeed())
+	})
+
+	It("should choose the volume size of the allocation results", func() {
+		claim := &resourcev1.ResourceClaim{
+			Spec: resourcev1.ResourceClaimSpec{
+				Devices: resourcev1.DeviceClaim{
+					Requests: []resourcev1.DeviceRequest{
+						{
+							Name: "exactly",
+							Exactly: &resourcev1.ExactDeviceRequest{
+								Capacity: &resourcev1.CapacityRequirements{
+									Requests: map[resourcev1.QualifiedName]resource.Quantity{
+										"size": resource.MustParse("3Gi"),
+									},
+								},
+							},
+						},
+						{
+							Name: "first",
+							FirstAvailable: []resourcev1.DeviceSubRequest{
+								{Name: "small"},
+								{
+									Name: "large",
+									Capacity: &resourcev1.CapacityRequirements{
+										Requests: map[resourcev1.QualifiedName]resource.Quantity{
+											resourcev1.QualifiedName(topolvm.GetPluginName() + "/size"): resource.MustParse("5Gi"),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		Expect(requestedBytes(claim, resourcev1.DeviceRequestAllocationResult{
+			Request: "exactly",
+			ConsumedCapacity: map[resourcev1.QualifiedName]resource.Quantity{
+				"size": resource.MustParse("4Gi"),
+			},
+		})).To(Equal(int64(4 << 30)))
+		Expect(requestedBytes(claim, resourcev1.DeviceRequestAllocationResult{Request: "exactly"})).To(Equal(int64(3 << 30)))
+		Expect(requestedBytes(claim, resourcev1.DeviceRequestAllocationResult{Request: "first/large"})).To(Equal(int64(5 << 30)))
+		Expect(requestedBytes(claim, resourcev1.DeviceRequestAllocationResult{Request: "first/small"})).To(Equal(topolvm.DefaultSize))
+	})
+
+	It("should ignore claims allocated by other drivers", func() {
+		ctx := context.Background()
+		ns := createNamespace()
+
+		claim := createAllocatedClaim(ctx, ns, []resourcev1.DeviceRequestAllocationResult{
+			{
+				Request: "other",
+				Driver:  "other.example.com",
+				Pool:    "pool",
+				Device:  "device",
+			},
+		})
+
+		Consistently(func(g Gomega) {
+			var claim2 resourcev1.ResourceClaim
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(claim), &claim2)
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(claim2.Finalizers).To(BeEmpty())
+
+			var lvList topolvmv1.LogicalVolumeList
+			err = k8sClient.List(ctx, &lvList, client.MatchingLabels{topolvm.GetResourceClaimKey(): string(claim.UID)})
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(lvList.Items).To(BeEmpty())
+		}, 3*time.Second).Should(Succeed())
+	})
+})
+
+var _ = Describe("ResourceClaimController admission", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "claim"}}
+
+	newReconciler := func(objs ...client.Object) (*ResourceClaimReconciler, client.Client, *events.FakeRecorder) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		claim := &resourcev1.ResourceClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "claim", UID: "claim-uid"},
+			Spec: resourcev1.ResourceClaimSpec{
+				Devices: resourcev1.DeviceClaim{
+					Requests: []resourcev1.DeviceRequest{{Name: "volume"}},
+				},
+			},
+			Status: resourcev1.ResourceClaimStatus{
+				Allocation: &resourcev1.AllocationResult{
+					Devices: resourcev1.DeviceAllocationResult{
+						Results: []resourcev1.DeviceRequestAllocationResult{{
+							Request: "volume",
+							Driver:  topolvm.GetPluginName(),
+							Pool:    "node",
+							Device:  "ssd",
+							ConsumedCapacity: map[resourcev1.QualifiedName]resource.Quantity{
+								"size": resource.MustParse("3Gi"),
+							},
+						}},
+					},
+				},
+			},
+		}
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(append(objs, claim)...).Build()
+		recorder := events.NewFakeRecorder(10)
+		return NewResourceClaimReconciler(c, recorder), c, recorder
+	}
+
+	listVolumes := func(c client.Client) []topolvmv1.LogicalVolume {
+		var lvList topolvmv1.LogicalVolumeList
+		Expect(c.List(ctx, &lvList, client.MatchingLabels{topolvm.GetResourceClaimKey(): "claim-uid"})).To(Succeed())
+		return lvList.Items
+	}
+
+	It("should create the LogicalVolume with the namespace label within the quota", func() {
+		r, c, _ := newReconciler(&topolvmv1.TopoLVMQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "quota"},
+			Spec:       topolvmv1.TopoLVMQuotaSpec{Hard: corev1.ResourceList{"ssd": resource.MustParse("5Gi")}},
+		})
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvs := listVolumes(c)
+		Expect(lvs).To(HaveLen(1))
+		Expect(lvs[0].Labels).To(HaveKeyWithValue(topolvm.GetNamespaceKey(), "test"))
+	})
+
+	It("should not create the LogicalVolume exceeding the quota", func() {
+		r, c, recorder := newReconciler(&topolvmv1.TopoLVMQuota{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "quota"},
+			Spec:       topolvmv1.TopoLVMQuotaSpec{Hard: corev1.ResourceList{"ssd": resource.MustParse("2Gi")}},
+		})
+
+		res, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueIntervalForDeniedClaim))
+		Expect(listVolumes(c)).To(BeEmpty())
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Warning VolumeDenied")))
+	})
+
+	It("should not create the LogicalVolume of a device-class denied by DeviceClassPolicies", func() {
+		r, c, recorder := newReconciler(&topolvmv1.DeviceClassPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Spec:       topolvmv1.DeviceClassPolicySpec{DeviceClass: "ssd", Namespaces: []string{"other"}},
+		})
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listVolumes(c)).To(BeEmpty())
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Warning VolumeDenied")))
+	})
+})
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// requeueIntervalForSnapshotRevert is the interval to wait for the VolumeSnapshot to be ready
+// and for the pods using the PVC to stop.
+const requeueIntervalForSnapshotRevert = 10 * time.Second
+
+// SnapshotRevertReconciler reverts TopoLVM PVCs in place to their VolumeSnapshots.
+type SnapshotRevertReconciler struct {
+	client    client.Client
+	apiReader client.Reader
+	recorder  events.EventRecorder
+}
+
+// NewSnapshotRevertReconciler returns SnapshotRevertReconciler.
+func NewSnapshotRevertReconciler(client client.Client, apiReader client.Reader, recorder events.EventRecorder) *SnapshotRevertReconciler {
+	return &SnapshotRevertReconciler{
+		client:    client,
+		apiReader: apiReader,
+		recorder:  recorder,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshotcontents,verbs=get;list;watch
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+// Reconcile requests topolvm-node to merge the snapshot named by the annotation of the PVC into its LogicalVolume
+// while the PVC is not used by any pod, and removes the annotation when the merge finishes.
+func (r *SnapshotRevertReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(ctx, req.NamespacedName, pvc)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	snapshotName, ok := pvc.Annotations[topolvm.GetRevertToSnapshotKey()]
+	if !ok || pvc.DeletionTimestamp != nil || pvc.Spec.VolumeName == "" {
+		return ctrl.Result{}, nil
+	}
+
+	lv, err := r.volumeOf(ctx, pvc)
+	if err != nil || lv == nil {
+		return ctrl.Result{}, err
+	}
+
+	if lv.Spec.RevertSnapshot != "" {
+		return ctrl.Result{}, r.checkProgress(ctx, pvc, lv)
+	}
+
+	snapshot, err := r.snapshotVolume(ctx, pvc.Namespace, snapshotName)
+	if err != nil {
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "RevertInvalid", "Revert", "%s", err.Error())
+		return ctrl.Result{}, r.removeAnnotation(ctx, pvc)
+	}
+	if snapshot == nil {
+		// wait for the VolumeSnapshot to be ready.
+		return ctrl.Result{RequeueAfter: requeueIntervalForSnapshotRevert}, nil
+	}
+	if lv.Status.Revert != nil && lv.Status.Revert.Snapshot == snapshot.Name && lv.Status.Revert.Phase == topolvmv1.RevertCompleted {
+		// the volume has already been reverted, but the annotation was left.
+		return ctrl.Result{}, r.removeAnnotation(ctx, pvc)
+	}
+	if snapshot.Spec.Source != lv.Name {
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "RevertInvalid", "Revert",
+			"VolumeSnapshot %s is not taken from this PVC", snapshotName)
+		return ctrl.Result{}, r.removeAnnotation(ctx, pvc)
+	}
+
+	pod, err := r.podUsing(ctx, pvc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if pod != "" {
+		log.Info("waiting for the pod to stop using the PVC", "name", pvc.Name, "namespace", pvc.Namespace, "pod", pod)
+		return ctrl.Result{RequeueAfter: requeueIntervalForSnapshotRevert}, nil
+	}
+
+	patch := client.MergeFrom(lv.DeepCopy())
+	lv.Spec.RevertSnapshot = snapshot.Name
+	if err := r.client.Patch(ctx, lv, patch); err != nil {
+		log.Error(err, "failed to request the revert", "name", lv.Name)
+		return ctrl.Result{}, err
+	}
+	r.recorder.Eventf(pvc, lv, corev1.EventTypeNormal, "Reverting", "Revert", "reverting to VolumeSnapshot %s", snapshotName)
+	log.Info("requested to revert the volume", "name", pvc.Name, "namespace", pvc.Namespace, "snapshot", snapshotName)
+	return ctrl.Result{}, nil
+}
+
+// checkProgress reports the result of the revert requested to the LogicalVolume, and clears the request when it finishes.
+func (r *SnapshotRevertReconciler) checkProgress(ctx context.Context, pvc *corev1.PersistentVolumeClaim, lv *topolvmv1.LogicalVolume) error {
+	rs := lv.Status.Revert
+	if rs == nil || rs.Snapshot != lv.Spec.RevertSnapshot {
+		return nil
+	}
+	snapshotName := pvc.Annotations[topolvm.GetRevertToSnapshotKey()]
+	switch rs.Phase {
+	case topolvmv1.RevertCompleted:
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeNormal, "Reverted", "Revert", "reverted to VolumeSnapshot %s", snapshotName)
+	case topolvmv1.RevertFailed:
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "RevertFailed", "Revert",
+			"failed to revert to VolumeSnapshot %s: %s", snapshotName, rs.Message)
+	default:
+		return nil
+	}
+
+	// Clear the request first so that the volume can be published even if removing the annotation fails.
+	patch := client.MergeFrom(lv.DeepCopy())
+	lv.Spec.RevertSnapshot = ""
+	if err := r.client.Patch(ctx, lv, patch); err != nil {
+		crlog.FromContext(ctx).Error(err, "failed to clear the revert request", "name", lv.Name)
+		return err
+	}
+	return r.removeAnnotation(ctx, pvc)
+}
+
+func (r *SnapshotRevertReconciler) removeAnnotation(ctx context.Context, pvc *corev1.PersistentVolumeClaim) error {
+	patch := client.MergeFrom(pvc.DeepCopy())
+	delete(pvc.Annotations, topolvm.GetRevertToSnapshotKey())
+	if err := r.client.Patch(ctx, pvc, patch); err != nil {
+		crlog.FromContext(ctx).Error(err, "failed to remove the annotation", "name", pvc.Name, "namespace", pvc.Namespace)
+		return err
+	}
+	return nil
+}
+
+// volumeOf returns the LogicalVolume of the PVC, or nil if the PVC is not provisioned by TopoLVM.
+func (r *SnapshotRevertReconciler) volumeOf(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*topolvmv1.LogicalVolume, error) {
+	pv := &corev1.PersistentVolume{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != topolvm.GetPluginName() {
+		return nil, nil
+	}
+
+	lv := &topolvmv1.LogicalVolume{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: pv.Name}, lv)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if lv.Status.VolumeID != pv.Spec.CSI.VolumeHandle || lv.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	return lv, nil
+}
+
+// snapshotVolume returns the LogicalVolume of the VolumeSnapshot, or nil if the VolumeSnapshot is not ready yet.
+// An error is returned if the VolumeSnapshot cannot be used for the revert.
+func (r *SnapshotRevertReconciler) snapshotVolume(ctx context.Context, namespace, name string) (*topolvmv1.LogicalVolume, error) {
+	var vs snapapi.VolumeSnapshot
+	err := r.client.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &vs)
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("VolumeSnapshot %s is not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if vs.Status == nil || vs.Status.ReadyToUse == nil || !*vs.Status.ReadyToUse || vs.Status.BoundVolumeSnapshotContentName == nil {
+		return nil, nil
+	}
+
+	var vsc snapapi.VolumeSnapshotContent
+	if err := r.client.Get(ctx, types.NamespacedName{Name: *vs.Status.BoundVolumeSnapshotContentName}, &vsc); err != nil {
+		return nil, err
+	}
+	if vsc.Spec.Driver != topolvm.GetPluginName() || vsc.Status == nil || vsc.Status.SnapshotHandle == nil {
+		return nil, fmt.Errorf("VolumeSnapshot %s is not taken by TopoLVM", name)
+	}
+
+	var lvs topolvmv1.LogicalVolumeList
+	if err := r.client.List(ctx, &lvs); err != nil {
+		return nil, err
+	}
+	for i := range lvs.Items {
+		if lvs.Items[i].Status.VolumeID == *vsc.Status.SnapshotHandle {
+			return &lvs.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("LogicalVolume of VolumeSnapshot %s is not found", name)
+}
+
+// podUsing returns the name of a pod using the PVC, or an empty string if none.
+func (r *SnapshotRevertReconciler) podUsing(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	var pods corev1.PodList
+	// query directly to API server not to miss pods just created
+	if err := r.apiReader.List(ctx, &pods, client.InNamespace(pvc.Namespace)); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvc.Name {
+				return pod.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SnapshotRevertReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("snapshotrevert").
+		For(&corev1.PersistentVolumeClaim{}).
+		Watches(&topolvmv1.LogicalVolume{}, handler.EnqueueRequestsFromMapFunc(r.claimForLogicalVolume)).
+		Complete(r)
+}
+
+// claimForLogicalVolume enqueues the PVC of the LogicalVolume being reverted to report the progress.
+func (r *SnapshotRevertReconciler) claimForLogicalVolume(ctx context.Context, obj client.Object) []reconcile.Request {
+	lv, ok := obj.(*topolvmv1.LogicalVolume)
+	if !ok || lv.Spec.RevertSnapshot == "" {
+		return nil
+	}
+	pv := &corev1.PersistentVolume{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: lv.Name}, pv); err != nil {
+		return nil
+	}
+	if pv.Spec.ClaimRef == nil {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Namespace: pv.Spec.ClaimRef.Namespace, Name: pv.Spec.ClaimRef.Name},
+	}}
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"context"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// The tests use the fake client because envtest does not install the CRDs of VolumeSnapshot.
+var _ = Describe("SnapshotRevert controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "pvc"}}
+
+	newReconciler := func(objs ...client.Object) (*SnapshotRevertReconciler, client.Client, *events.FakeRecorder) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		Expect(snapapi.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(objs...).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}).
+			Build()
+		recorder := events.NewFakeRecorder(10)
+		r := NewSnapshotRevertReconciler(c, c, recorder)
+		return r, c, recorder
+	}
+
+	// objects returns a PVC annotated to revert to the VolumeSnapshot "snap" and the related resources.
+	objects := func(snapshotSource string) []client.Object {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "pvc",
+				Annotations: map[string]string{topolvm.GetRevertToSnapshotKey(): "snap"},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{VolumeName: "pv"},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec: corev1.PersistentVolumeSpec{
+				ClaimRef: &corev1.ObjectReference{Namespace: "test", Name: "pvc"},
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: topolvm.GetPluginName(), VolumeHandle: "vol"},
+				},
+			},
+		}
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "pv", NodeName: "node", DeviceClass: "thin"},
+			Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "vol"},
+		}
+		snapshotLV := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "snapshot-lv"},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name: "snapshot-lv", NodeName: "node", DeviceClass: "thin", Source: snapshotSource, AccessType: "ro",
+			},
+			Status: topolvmv1.LogicalVolumeStatus{VolumeID: "snap-vol"},
+		}
+		vs := &snapapi.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "snap"},
+			Spec: snapapi.VolumeSnapshotSpec{
+				Source: snapapi.VolumeSnapshotSource{PersistentVolumeClaimName: ptr.To("pvc")},
+			},
+			Status: &snapapi.VolumeSnapshotStatus{
+				BoundVolumeSnapshotContentName: ptr.To("snapcontent"),
+				ReadyToUse:                     ptr.To(true),
+			},
+		}
+		vsc := &snapapi.VolumeSnapshotContent{
+			ObjectMeta: metav1.ObjectMeta{Name: "snapcontent"},
+			Spec:       snapapi.VolumeSnapshotContentSpec{Driver: topolvm.GetPluginName()},
+			Status:     &snapapi.VolumeSnapshotContentStatus{SnapshotHandle: ptr.To("snap-vol")},
+		}
+		return []client.Object{pvc, pv, lv, snapshotLV, vs, vsc}
+	}
+
+	It("should request the revert and clear it when completed", func() {
+		r, c, recorder := newReconciler(objects("pv")...)
+
+		By("waiting for the pod using the PVC")
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "pod"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc"},
+					},
+				}},
+			},
+		}
+		Expect(c.Create(ctx, pod)).To(Succeed())
+		res, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(requeueIntervalForSnapshotRevert))
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.RevertSnapshot).To(BeEmpty())
+
+		By("requesting the revert after the pod is deleted")
+		Expect(c.Delete(ctx, pod)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.RevertSnapshot).To(Equal("snapshot-lv"))
+		Expect(lv.IsReverting()).To(BeTrue())
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Normal Reverting")))
+
+		By("keeping the request while merging")
+		lv.Status.Revert = &topolvmv1.RevertStatus{Snapshot: "snapshot-lv", Phase: topolvmv1.RevertMerging, StartTime: metav1.Now()}
+		Expect(c.Status().Update(ctx, lv)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.RevertSnapshot).To(Equal("snapshot-lv"))
+
+		By("clearing the request when completed")
+		lv.Status.Revert.Phase = topolvmv1.RevertCompleted
+		Expect(c.Status().Update(ctx, lv)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.RevertSnapshot).To(BeEmpty())
+		Expect(lv.IsReverting()).To(BeFalse())
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(topolvm.GetRevertToSnapshotKey()))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Normal Reverted")))
+	})
+
+	It("should report the failure and clear the request", func() {
+		r, c, recorder := newReconciler(objects("pv")...)
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		lv.Spec.RevertSnapshot = "snapshot-lv"
+		Expect(c.Update(ctx, lv)).To(Succeed())
+		lv.Status.Revert = &topolvmv1.RevertStatus{
+			Snapshot: "snapshot-lv", Phase: topolvmv1.RevertFailed, Message: "in use", StartTime: metav1.Now(),
+		}
+		Expect(c.Status().Update(ctx, lv)).To(Succeed())
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.RevertSnapshot).To(BeEmpty())
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(topolvm.GetRevertToSnapshotKey()))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Warning RevertFailed")))
+	})
+
+	It("should reject a snapshot of another volume", func() {
+		r, c, recorder := newReconciler(objects("other-pv")...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pv"}, lv)).To(Succeed())
+		Expect(lv.Spec.RevertSnapshot).To(BeEmpty())
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		Expect(pvc.Annotations).NotTo(HaveKey(topolvm.GetRevertToSnapshotKey()))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring("Warning RevertInvalid")))
+	})
+})
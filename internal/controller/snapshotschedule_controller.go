@@ -0,0 +1,316 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/cron"
+	"github.com/topolvm/topolvm/internal/nodeservice"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// snapshotTimeFormat is the format of the schedule time in the names of VolumeSnapshots.
+const snapshotTimeFormat = "200601021504"
+
+// SnapshotScheduleReconciler takes VolumeSnapshots of TopoLVM PVCs periodically and prunes the old ones.
+type SnapshotScheduleReconciler struct {
+	client      client.Client
+	nodeService *nodeservice.NodeService
+	recorder    events.EventRecorder
+	now         func() time.Time
+}
+
+// NewSnapshotScheduleReconciler returns SnapshotScheduleReconciler.
+func NewSnapshotScheduleReconciler(client client.Client, recorder events.EventRecorder) *SnapshotScheduleReconciler {
+	return &SnapshotScheduleReconciler{
+		client:      client,
+		nodeService: nodeservice.NewNodeService(client),
+		recorder:    recorder,
+		now:         time.Now,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=snapshotschedules,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=snapshotschedules/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=nodestorages,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+// Reconcile takes the snapshots when the schedule is due, prunes the expired ones and requeues for the next run.
+func (r *SnapshotScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	ss := &topolvmv1.SnapshotSchedule{}
+	err := r.client.Get(ctx, req.NamespacedName, ss)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	if ss.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	status := *ss.Status.DeepCopy()
+	status.Message = ""
+	sched, err := cron.Parse(ss.Spec.Schedule)
+	if err != nil {
+		status.Message = fmt.Sprintf("invalid schedule: %v", err)
+		return ctrl.Result{}, r.updateStatus(ctx, ss, status)
+	}
+
+	now := r.now().UTC()
+	last := ss.CreationTimestamp.Time
+	if ss.Status.LastScheduleTime != nil {
+		last = ss.Status.LastScheduleTime.Time
+	}
+	// Missed runs are not caught up. Only the latest one is taken.
+	if next := sched.Next(last.UTC()); !next.IsZero() && !now.Before(next) {
+		if err := r.takeSnapshots(ctx, ss, now); err != nil {
+			log.Error(err, "failed to take snapshots", "name", ss.Name, "namespace", ss.Namespace)
+			status.Message = err.Error()
+		}
+		status.LastScheduleTime = &metav1.Time{Time: now.Truncate(time.Minute)}
+	}
+
+	if err := r.pruneSnapshots(ctx, ss, now); err != nil {
+		log.Error(err, "failed to prune snapshots", "name", ss.Name, "namespace", ss.Namespace)
+		return ctrl.Result{}, err
+	}
+	if err := r.updateStatus(ctx, ss, status); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	next := sched.Next(now)
+	if next.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+}
+
+func (r *SnapshotScheduleReconciler) updateStatus(ctx context.Context, ss *topolvmv1.SnapshotSchedule, status topolvmv1.SnapshotScheduleStatus) error {
+	if equality.Semantic.DeepEqual(ss.Status, status) {
+		return nil
+	}
+	ss.Status = status
+	if err := r.client.Status().Update(ctx, ss); err != nil {
+		crlog.FromContext(ctx).Error(err, "failed to update the status", "name", ss.Name, "namespace", ss.Namespace)
+		return err
+	}
+	return nil
+}
+
+// takeSnapshots creates a VolumeSnapshot for each selected TopoLVM PVC.
+// PVCs whose thin pools lack headroom are skipped with warning events.
+func (r *SnapshotScheduleReconciler) takeSnapshots(ctx context.Context, ss *topolvmv1.SnapshotSchedule, now time.Time) error {
+	selector, err := metav1.LabelSelectorAsSelector(&ss.Spec.PVCSelector)
+	if err != nil {
+		return fmt.Errorf("invalid pvcSelector: %w", err)
+	}
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := r.client.List(ctx, &pvcs, client.InNamespace(ss.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if pvc.DeletionTimestamp != nil || pvc.Status.Phase != corev1.ClaimBound {
+			continue
+		}
+		lv, err := r.logicalVolumeOf(ctx, pvc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if lv == nil {
+			continue
+		}
+
+		reason, err := r.checkHeadroom(ctx, ss, lv)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if reason != "" {
+			r.recorder.Eventf(pvc, ss, corev1.EventTypeWarning, "SnapshotSkipped", "SnapshotSchedule", "%s", reason)
+			continue
+		}
+
+		vs := &snapapi.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: ss.Namespace,
+				Name:      fmt.Sprintf("%s-%s-%s", ss.Name, pvc.Name, now.Format(snapshotTimeFormat)),
+				Labels:    map[string]string{topolvm.GetSnapshotScheduleKey(): ss.Name},
+			},
+			Spec: snapapi.VolumeSnapshotSpec{
+				Source: snapapi.VolumeSnapshotSource{
+					PersistentVolumeClaimName: &pvc.Name,
+				},
+				VolumeSnapshotClassName: ss.Spec.VolumeSnapshotClassName,
+			},
+		}
+		err = r.client.Create(ctx, vs)
+		if apierrors.IsAlreadyExists(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create VolumeSnapshot %s: %w", vs.Name, err))
+			continue
+		}
+		r.recorder.Eventf(pvc, ss, corev1.EventTypeNormal, "SnapshotCreated", "SnapshotSchedule",
+			"created VolumeSnapshot %s", vs.Name)
+	}
+	return errors.Join(errs...)
+}
+
+// logicalVolumeOf returns the LogicalVolume of the PVC, or nil if the PVC is not provisioned by TopoLVM.
+func (r *SnapshotScheduleReconciler) logicalVolumeOf(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*topolvmv1.LogicalVolume, error) {
+	pv := &corev1.PersistentVolume{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != topolvm.GetPluginName() {
+		return nil, nil
+	}
+
+	var lvs topolvmv1.LogicalVolumeList
+	if err := r.client.List(ctx, &lvs); err != nil {
+		return nil, err
+	}
+	for i := range lvs.Items {
+		if lvs.Items[i].Status.VolumeID == pv.Spec.CSI.VolumeHandle {
+			return &lvs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// checkHeadroom returns the reason to skip the snapshot of the LogicalVolume, or an empty string.
+// The snapshot is skipped if the free capacity of the device-class cannot hold the volume,
+// or if the data usage of the thin pool exceeds MaxDataPercent.
+func (r *SnapshotScheduleReconciler) checkHeadroom(ctx context.Context, ss *topolvmv1.SnapshotSchedule, lv *topolvmv1.LogicalVolume) (string, error) {
+	free, err := r.nodeService.GetCapacityByName(ctx, lv.Spec.NodeName, lv.Spec.DeviceClass)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the free capacity of node %s: %w", lv.Spec.NodeName, err)
+	}
+	if free < lv.Spec.Size.Value() {
+		return fmt.Sprintf("node %s has only %d bytes free for a snapshot of %d bytes", lv.Spec.NodeName, free, lv.Spec.Size.Value()), nil
+	}
+
+	if ss.Spec.MaxDataPercent == nil {
+		return "", nil
+	}
+	ns := &topolvmv1.NodeStorage{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: lv.Spec.NodeName}, ns)
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	dc := ns.Status.DeviceClass(lv.Spec.DeviceClass)
+	if dc == nil || dc.ThinPool == nil {
+		return "", nil
+	}
+	dataPercent, err := strconv.ParseFloat(dc.ThinPool.DataPercent, 64)
+	if err != nil {
+		return "", nil
+	}
+	if dataPercent > float64(*ss.Spec.MaxDataPercent) {
+		return fmt.Sprintf("thin pool of device-class %q on node %s is %s%% full, exceeding %d%%",
+			lv.Spec.DeviceClass, lv.Spec.NodeName, dc.ThinPool.DataPercent, *ss.Spec.MaxDataPercent), nil
+	}
+	return "", nil
+}
+
+// pruneSnapshots deletes the VolumeSnapshots of the schedule beyond its retention.
+func (r *SnapshotScheduleReconciler) pruneSnapshots(ctx context.Context, ss *topolvmv1.SnapshotSchedule, now time.Time) error {
+	var vsList snapapi.VolumeSnapshotList
+	err := r.client.List(ctx, &vsList, client.InNamespace(ss.Namespace), client.MatchingLabels{topolvm.GetSnapshotScheduleKey(): ss.Name})
+	if err != nil {
+		return err
+	}
+	for _, vs := range snapshotsToPrune(vsList.Items, ss.Spec.Retention, now) {
+		err := r.client.Delete(ctx, vs)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		crlog.FromContext(ctx).Info("pruned VolumeSnapshot", "name", vs.Name, "namespace", vs.Namespace)
+	}
+	return nil
+}
+
+// snapshotsToPrune returns the snapshots exceeding the retention.
+// The retention is applied to the snapshots of each PVC separately.
+// Only the snapshots ready to use are counted and pruned, and the newest ready one is always kept,
+// so that failed or in-progress snapshots never cause the last restorable one to be deleted.
+func snapshotsToPrune(snapshots []snapapi.VolumeSnapshot, retention topolvmv1.SnapshotRetention, now time.Time) []*snapapi.VolumeSnapshot {
+	byPVC := make(map[string][]*snapapi.VolumeSnapshot)
+	for i := range snapshots {
+		vs := &snapshots[i]
+		if vs.DeletionTimestamp != nil || vs.Spec.Source.PersistentVolumeClaimName == nil {
+			continue
+		}
+		if vs.Status == nil || vs.Status.ReadyToUse == nil || !*vs.Status.ReadyToUse {
+			continue
+		}
+		pvc := *vs.Spec.Source.PersistentVolumeClaimName
+		byPVC[pvc] = append(byPVC[pvc], vs)
+	}
+
+	var pruned []*snapapi.VolumeSnapshot
+	for _, list := range byPVC {
+		// newest first
+		sort.Slice(list, func(i, j int) bool {
+			return list[j].CreationTimestamp.Before(&list[i].CreationTimestamp)
+		})
+		for i, vs := range list {
+			switch {
+			case i == 0:
+				continue
+			case retention.MaxCount != nil && i >= int(*retention.MaxCount):
+			case retention.MaxAge != nil && now.Sub(vs.CreationTimestamp.Time) > retention.MaxAge.Duration:
+			default:
+				continue
+			}
+			pruned = append(pruned, vs)
+		}
+	}
+	return pruned
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SnapshotScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&topolvmv1.SnapshotSchedule{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// The tests use the fake client because envtest does not install the CRDs of VolumeSnapshot.
+var _ = Describe("SnapshotSchedule controller", func() {
+	ctx := context.Background()
+	base := time.Date(2024, time.January, 31, 2, 0, 0, 0, time.UTC)
+
+	newReconciler := func(objs ...client.Object) (*SnapshotScheduleReconciler, client.Client, *events.FakeRecorder) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		Expect(snapapi.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(objs...).
+			WithStatusSubresource(&topolvmv1.SnapshotSchedule{}).
+			Build()
+		recorder := events.NewFakeRecorder(10)
+		r := NewSnapshotScheduleReconciler(c, recorder)
+		return r, c, recorder
+	}
+
+	volume := func(name, dataPercent string) []client.Object {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name, Labels: map[string]string{"backup": "true"}},
+			Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-" + name},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		}
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-" + name},
+			Spec: corev1.PersistentVolumeSpec{
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{Driver: topolvm.GetPluginName(), VolumeHandle: "vol-" + name},
+				},
+			},
+		}
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-" + name},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        "pv-" + name,
+				NodeName:    "node-" + name,
+				DeviceClass: "thin",
+				Size:        resource.MustParse("1Gi"),
+			},
+			Status: topolvmv1.LogicalVolumeStatus{VolumeID: "vol-" + name},
+		}
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "node-" + name,
+				Annotations: map[string]string{topolvm.GetCapacityKeyPrefix() + "thin": "10737418240"},
+			},
+		}
+		ns := &topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-" + name},
+			Status: topolvmv1.NodeStorageStatus{
+				DeviceClasses: []topolvmv1.DeviceClassStorageStatus{{
+					Name:     "thin",
+					ThinPool: &topolvmv1.ThinPoolStatus{DataPercent: dataPercent},
+				}},
+			},
+		}
+		return []client.Object{pvc, pv, lv, node, ns}
+	}
+
+	schedule := func() *topolvmv1.SnapshotSchedule {
+		return &topolvmv1.SnapshotSchedule{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "nightly"},
+			Spec: topolvmv1.SnapshotScheduleSpec{
+				Schedule:       "0 3 * * *",
+				PVCSelector:    metav1.LabelSelector{MatchLabels: map[string]string{"backup": "true"}},
+				MaxDataPercent: ptr.To[int32](80),
+			},
+			Status: topolvmv1.SnapshotScheduleStatus{
+				LastScheduleTime: &metav1.Time{Time: base},
+			},
+		}
+	}
+
+	It("should wait for the next schedule", func() {
+		objs := append(volume("pvc1", "10.00"), schedule())
+		r, c, _ := newReconciler(objs...)
+		r.now = func() time.Time { return base.Add(30 * time.Minute) }
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "test", Name: "nightly"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(30 * time.Minute))
+
+		var vsList snapapi.VolumeSnapshotList
+		Expect(c.List(ctx, &vsList)).To(Succeed())
+		Expect(vsList.Items).To(BeEmpty())
+	})
+
+	It("should take snapshots of the selected PVCs with enough headroom", func() {
+		objs := append(volume("pvc1", "10.00"), volume("pvc2", "90.00")...)
+		objs = append(objs, schedule())
+		r, c, recorder := newReconciler(objs...)
+		now := base.Add(time.Hour + 10*time.Second)
+		r.now = func() time.Time { return now }
+
+		res, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "test", Name: "nightly"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(24*time.Hour - 10*time.Second))
+
+		var vsList snapapi.VolumeSnapshotList
+		Expect(c.List(ctx, &vsList)).To(Succeed())
+		Expect(vsList.Items).To(HaveLen(1))
+		vs := vsList.Items[0]
+		Expect(vs.Name).To(Equal("nightly-pvc1-202401310300"))
+		Expect(vs.Labels).To(HaveKeyWithValue(topolvm.GetSnapshotScheduleKey(), "nightly"))
+		Expect(vs.Spec.Source.PersistentVolumeClaimName).To(Equal(ptr.To("pvc1")))
+
+		Expect(recorder.Events).To(HaveLen(2))
+		var msgs []string
+		for range 2 {
+			msgs = append(msgs, <-recorder.Events)
+		}
+		Expect(msgs).To(ContainElement(ContainSubstring("SnapshotSkipped")))
+
+		ss := &topolvmv1.SnapshotSchedule{}
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "test", Name: "nightly"}, ss)).To(Succeed())
+		Expect(ss.Status.LastScheduleTime.Time).To(BeTemporally("==", base.Add(time.Hour)))
+		Expect(ss.Status.Message).To(BeEmpty())
+	})
+
+	It("should report an invalid schedule", func() {
+		ss := schedule()
+		ss.Spec.Schedule = "0 3 * *"
+		r, c, _ := newReconciler(ss)
+
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Namespace: "test", Name: "nightly"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, client.ObjectKey{Namespace: "test", Name: "nightly"}, ss)).To(Succeed())
+		Expect(ss.Status.Message).To(ContainSubstring("invalid schedule"))
+	})
+
+	snapshot := func(name, pvc string, age time.Duration) snapapi.VolumeSnapshot {
+		return snapapi.VolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: base.Add(-age)}},
+			Spec: snapapi.VolumeSnapshotSpec{
+				Source: snapapi.VolumeSnapshotSource{PersistentVolumeClaimName: ptr.To(pvc)},
+			},
+			Status: &snapapi.VolumeSnapshotStatus{ReadyToUse: ptr.To(true)},
+		}
+	}
+	names := func(list []*snapapi.VolumeSnapshot) []string {
+		var ret []string
+		for _, vs := range list {
+			ret = append(ret, vs.Name)
+		}
+		return ret
+	}
+
+	It("should select the snapshots to prune by count and age per PVC", func() {
+		snapshots := []snapapi.VolumeSnapshot{
+			snapshot("a1", "a", 3*time.Hour),
+			snapshot("a2", "a", 2*time.Hour),
+			snapshot("a3", "a", time.Hour),
+			snapshot("b1", "b", 50*time.Hour),
+			snapshot("b2", "b", time.Hour),
+		}
+
+		Expect(snapshotsToPrune(snapshots, topolvmv1.SnapshotRetention{}, base)).To(BeEmpty())
+		Expect(names(snapshotsToPrune(snapshots, topolvmv1.SnapshotRetention{
+			MaxCount: ptr.To[int32](2),
+		}, base))).To(ConsistOf("a1"))
+		Expect(names(snapshotsToPrune(snapshots, topolvmv1.SnapshotRetention{
+			MaxAge: &metav1.Duration{Duration: 48 * time.Hour},
+		}, base))).To(ConsistOf("b1"))
+		Expect(names(snapshotsToPrune(snapshots, topolvmv1.SnapshotRetention{
+			MaxCount: ptr.To[int32](1),
+			MaxAge:   &metav1.Duration{Duration: 48 * time.Hour},
+		}, base))).To(ConsistOf("a1", "a2", "b1"))
+	})
+
+	It("should count only ready snapshots and keep the newest ready one", func() {
+		failed := snapshot("a3", "a", time.Hour)
+		failed.Status = &snapapi.VolumeSnapshotStatus{ReadyToUse: ptr.To(false), Error: &snapapi.VolumeSnapshotError{Message: ptr.To("failed")}}
+		inProgress := snapshot("a4", "a", 0)
+		inProgress.Status = nil
+		snapshots := []snapapi.VolumeSnapshot{
+			snapshot("a1", "a", 3*time.Hour),
+			snapshot("a2", "a", 2*time.Hour),
+			failed,
+			inProgress,
+			snapshot("b1", "b", 100*time.Hour),
+		}
+
+		Expect(names(snapshotsToPrune(snapshots, topolvmv1.SnapshotRetention{
+			MaxCount: ptr.To[int32](2),
+		}, base))).To(BeEmpty())
+		Expect(names(snapshotsToPrune(snapshots, topolvmv1.SnapshotRetention{
+			MaxCount: ptr.To[int32](1),
+		}, base))).To(ConsistOf("a1"))
+		Expect(names(snapshotsToPrune(snapshots, topolvmv1.SnapshotRetention{
+			MaxAge: &metav1.Duration{Duration: time.Minute},
+		}, base))).To(ConsistOf("a1"))
+	})
+})
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/quota"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TopoLVMQuotaReconciler reports the usage of TopoLVMQuota.
+type TopoLVMQuotaReconciler struct {
+	client client.Client
+}
+
+// NewTopoLVMQuotaReconciler returns TopoLVMQuotaReconciler.
+func NewTopoLVMQuotaReconciler(client client.Client) *TopoLVMQuotaReconciler {
+	return &TopoLVMQuotaReconciler{
+		client: client,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=topolvmquotas,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=topolvmquotas/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+
+// Reconcile updates the usage in the status of TopoLVMQuota.
+func (r *TopoLVMQuotaReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	q := &topolvmv1.TopoLVMQuota{}
+	err := r.client.Get(ctx, req.NamespacedName, q)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	if q.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	used, err := quota.Usage(ctx, r.client, q.Namespace)
+	if err != nil {
+		log.Error(err, "failed to get the usage", "namespace", q.Namespace)
+		return ctrl.Result{}, err
+	}
+	// Only the device-classes limited by the quota are reported.
+	status := topolvmv1.TopoLVMQuotaStatus{Used: corev1.ResourceList{}}
+	for key := range q.Spec.Hard {
+		u, ok := used[key]
+		if !ok {
+			u = *resource.NewQuantity(0, resource.BinarySI)
+		}
+		status.Used[key] = u
+	}
+	if equality.Semantic.DeepEqual(q.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	q.Status = status
+	if err := r.client.Status().Update(ctx, q); err != nil {
+		log.Error(err, "failed to update the status", "name", q.Name, "namespace", q.Namespace)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *TopoLVMQuotaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&topolvmv1.TopoLVMQuota{}).
+		Watches(&topolvmv1.LogicalVolume{}, handler.EnqueueRequestsFromMapFunc(r.quotasForLogicalVolume)).
+		Watches(&corev1.PersistentVolumeClaim{}, handler.EnqueueRequestsFromMapFunc(r.quotasForPVC)).
+		Complete(r)
+}
+
+func (r *TopoLVMQuotaReconciler) quotasForLogicalVolume(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.quotasInNamespace(ctx, obj.GetLabels()[topolvm.GetNamespaceKey()])
+}
+
+// quotasForPVC enqueues the quotas because pending and expanding PVCs are counted in the usage.
+func (r *TopoLVMQuotaReconciler) quotasForPVC(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.quotasInNamespace(ctx, obj.GetNamespace())
+}
+
+func (r *TopoLVMQuotaReconciler) quotasInNamespace(ctx context.Context, ns string) []reconcile.Request {
+	if ns == "" {
+		return nil
+	}
+
+	var quotas topolvmv1.TopoLVMQuotaList
+	if err := r.client.List(ctx, &quotas, client.InNamespace(ns)); err != nil {
+		crlog.FromContext(ctx).Error(err, "failed to list TopoLVMQuotas", "namespace", ns)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(quotas.Items))
+	for _, q := range quotas.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: q.Namespace, Name: q.Name},
+		})
+	}
+	return requests
+}
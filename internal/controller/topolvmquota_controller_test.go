@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/config"
+	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+var _ = Describe("TopoLVMQuotaController controller", func() {
+	ctx := context.Background()
+	var stopFunc func()
+	errCh := make(chan error)
+
+	BeforeEach(func() {
+		skipNameValidation := true
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme: scheme,
+			Controller: config.Controller{
+				SkipNameValidation: &skipNameValidation,
+			},
+			Metrics: server.Options{
+				BindAddress: "0", // disable metrics
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		reconciler := NewTopoLVMQuotaReconciler(mgr.GetClient())
+		err = reconciler.SetupWithManager(mgr)
+		Expect(err).NotTo(HaveOccurred())
+
+		ctx, cancel := context.WithCancel(ctx)
+		stopFunc = cancel
+		go func() {
+			errCh <- mgr.Start(ctx)
+		}()
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	AfterEach(func() {
+		stopFunc()
+		Expect(<-errCh).NotTo(HaveOccurred())
+	})
+
+	createLV := func(ctx context.Context, name, ns, size string) {
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{topolvm.GetNamespaceKey(): ns},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        name,
+				NodeName:    "node",
+				DeviceClass: "ssd",
+				Size:        resource.MustParse(size),
+			},
+		}
+		err := k8sClient.Create(ctx, lv)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should report the usage of the namespace", func() {
+		ns := createNamespace()
+		createLV(ctx, "quota-lv1", ns, "1Gi")
+
+		q := &topolvmv1.TopoLVMQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "quota",
+				Namespace: ns,
+			},
+			Spec: topolvmv1.TopoLVMQuotaSpec{
+				Hard: corev1.ResourceList{
+					"ssd": resource.MustParse("10Gi"),
+					"hdd": resource.MustParse("10Gi"),
+				},
+			},
+		}
+		err := k8sClient.Create(ctx, q)
+		Expect(err).NotTo(HaveOccurred())
+
+		usedOf := func(g Gomega, key corev1.ResourceName) int64 {
+			err := k8sClient.Get(ctx, client.ObjectKeyFromObject(q), q)
+			g.Expect(err).NotTo(HaveOccurred())
+			used, ok := q.Status.Used[key]
+			g.Expect(ok).To(BeTrue())
+			return used.Value()
+		}
+		Eventually(func(g Gomega) {
+			g.Expect(usedOf(g, "ssd")).To(Equal(int64(1 << 30)))
+			g.Expect(usedOf(g, "hdd")).To(BeZero())
+		}).Should(Succeed())
+
+		By("creating another LogicalVolume in the namespace")
+		createLV(ctx, "quota-lv2", ns, "2Gi")
+		Eventually(func(g Gomega) {
+			g.Expect(usedOf(g, "ssd")).To(Equal(int64(3 << 30)))
+		}).Should(Succeed())
+	})
+})
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// VolumeSeedReconciler deletes the LogicalVolumes caching the data of VolumeSeeds when they become stale.
+type VolumeSeedReconciler struct {
+	client client.Client
+}
+
+// NewVolumeSeedReconciler returns VolumeSeedReconciler.
+func NewVolumeSeedReconciler(client client.Client) *VolumeSeedReconciler {
+	return &VolumeSeedReconciler{
+		client: client,
+	}
+}
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=volumeseeds,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;create;delete
+
+// Reconcile deletes the caches of a VolumeSeed created for an old spec, all of them when the cache is disabled,
+// and all of them before the VolumeSeed is deleted.
+func (r *VolumeSeedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	vs := &topolvmv1.VolumeSeed{}
+	err := r.client.Get(ctx, req.NamespacedName, vs)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+
+	if vs.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(vs, topolvm.GetVolumeSeedFinalizer()) {
+			return ctrl.Result{}, nil
+		}
+
+		remaining, err := r.deleteCaches(ctx, vs, true)
+		if err != nil {
+			log.Error(err, "failed to delete caches", "name", vs.Name, "namespace", vs.Namespace)
+			return ctrl.Result{}, err
+		}
+		if remaining {
+			return ctrl.Result{RequeueAfter: requeueIntervalForSimpleUpdate}, nil
+		}
+
+		vs2 := vs.DeepCopy()
+		controllerutil.RemoveFinalizer(vs2, topolvm.GetVolumeSeedFinalizer())
+		if err := r.client.Patch(ctx, vs2, client.MergeFrom(vs)); err != nil {
+			log.Error(err, "failed to remove finalizer", "name", vs.Name, "namespace", vs.Namespace)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(vs, topolvm.GetVolumeSeedFinalizer()) {
+		vs2 := vs.DeepCopy()
+		controllerutil.AddFinalizer(vs2, topolvm.GetVolumeSeedFinalizer())
+		if err := r.client.Patch(ctx, vs2, client.MergeFrom(vs)); err != nil {
+			log.Error(err, "failed to add finalizer", "name", vs.Name, "namespace", vs.Namespace)
+			return ctrl.Result{}, err
+		}
+		vs = vs2
+	}
+
+	if _, err := r.deleteCaches(ctx, vs, !vs.Spec.Cache); err != nil {
+		log.Error(err, "failed to delete stale caches", "name", vs.Name, "namespace", vs.Namespace)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// deleteCaches deletes the LogicalVolumes caching the data of the VolumeSeed. Only the stale ones are deleted
+// unless all is true. It returns true if some of the LogicalVolumes to be deleted still exist.
+func (r *VolumeSeedReconciler) deleteCaches(ctx context.Context, vs *topolvmv1.VolumeSeed, all bool) (bool, error) {
+	log := crlog.FromContext(ctx)
+
+	lvList := &topolvmv1.LogicalVolumeList{}
+	err := r.client.List(ctx, lvList, client.MatchingLabels{topolvm.GetSeedKey(): string(vs.UID)})
+	if err != nil {
+		return false, err
+	}
+
+	remaining := false
+	for _, lv := range lvList.Items {
+		if !all && lv.Name == seedCacheVolumeName(vs, lv.Spec.NodeName, lv.Spec.DeviceClass, lv.Spec.FsType, lv.Spec.MkfsOptions) {
+			continue
+		}
+		remaining = true
+		if lv.DeletionTimestamp != nil {
+			continue
+		}
+		if err := r.client.Delete(ctx, &lv); err != nil && !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		log.Info("deleted the cache of VolumeSeed", "name", vs.Name, "namespace", vs.Namespace, "logicalvolume", lv.Name)
+	}
+	return remaining, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VolumeSeedReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&topolvmv1.VolumeSeed{}).
+		Complete(r)
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("VolumeSeed controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "seed"}}
+
+	newReconciler := func(objs ...client.Object) (*VolumeSeedReconciler, client.Client) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+		return NewVolumeSeedReconciler(c), c
+	}
+
+	cacheOf := func(vs *topolvmv1.VolumeSeed, name, node string) *topolvmv1.LogicalVolume {
+		return &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{topolvm.GetSeedKey(): string(vs.UID)},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{Name: name, NodeName: node, DeviceClass: "thin"},
+		}
+	}
+
+	listCaches := func(c client.Client) []string {
+		lvs := &topolvmv1.LogicalVolumeList{}
+		Expect(c.List(ctx, lvs)).To(Succeed())
+		var names []string
+		for _, lv := range lvs.Items {
+			names = append(names, lv.Name)
+		}
+		return names
+	}
+
+	It("should delete the caches of the old spec", func() {
+		vs := &topolvmv1.VolumeSeed{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "seed", UID: "seed-uid", Generation: 2},
+			Spec:       topolvmv1.VolumeSeedSpec{Cache: true},
+		}
+		current := seedCacheVolumeName(vs, "node1", "thin", "", nil)
+		r, c := newReconciler(vs, cacheOf(vs, current, "node1"), cacheOf(vs, "seed-seed-uid-stale", "node1"))
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listCaches(c)).To(Equal([]string{current}))
+
+		Expect(c.Get(ctx, req.NamespacedName, vs)).To(Succeed())
+		Expect(vs.Finalizers).To(ContainElement(topolvm.GetVolumeSeedFinalizer()))
+	})
+
+	It("should delete all the caches before the VolumeSeed is deleted", func() {
+		vs := &topolvmv1.VolumeSeed{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:  "test",
+				Name:       "seed",
+				UID:        "seed-uid",
+				Finalizers: []string{topolvm.GetVolumeSeedFinalizer()},
+			},
+			Spec: topolvmv1.VolumeSeedSpec{Cache: true},
+		}
+		r, c := newReconciler(vs, cacheOf(vs, seedCacheVolumeName(vs, "node1", "thin", "", nil), "node1"))
+		Expect(c.Delete(ctx, vs)).To(Succeed())
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(listCaches(c)).To(BeEmpty())
+
+		// the finalizer is removed after the caches are gone.
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Get(ctx, req.NamespacedName, vs)).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,470 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/filesystem"
+	"github.com/topolvm/topolvm/internal/seed"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	crlog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// annProvisionedBy is the annotation of PersistentVolumes to tell the external-provisioner to delete them.
+const annProvisionedBy = "pv.kubernetes.io/provisioned-by"
+
+// VolumeSeedPopulatorReconciler provisions the PVCs whose dataSourceRef is a VolumeSeed.
+// The external-provisioner leaves them to the populator because it does not know the kind of the data source.
+type VolumeSeedPopulatorReconciler struct {
+	client   client.Client
+	recorder events.EventRecorder
+}
+
+// NewVolumeSeedPopulatorReconciler returns VolumeSeedPopulatorReconciler.
+func NewVolumeSeedPopulatorReconciler(client client.Client, recorder events.EventRecorder) *VolumeSeedPopulatorReconciler {
+	return &VolumeSeedPopulatorReconciler{
+		client:   client,
+		recorder: recorder,
+	}
+}
+
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=volumeseeds,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=nodestorages,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=events.k8s.io,resources=events,verbs=create;patch
+
+// Reconcile creates a LogicalVolume populated from the VolumeSeed on the node selected by the scheduler,
+// and creates the PersistentVolume bound to the PVC after the population finishes.
+func (r *VolumeSeedPopulatorReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := crlog.FromContext(ctx)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(ctx, req.NamespacedName, pvc)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		return ctrl.Result{}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+	if pvc.DeletionTimestamp != nil || pvc.Spec.VolumeName != "" || !refersVolumeSeed(pvc) {
+		return ctrl.Result{}, nil
+	}
+
+	sc, err := r.storageClassOf(ctx, pvc)
+	if err != nil || sc == nil {
+		return ctrl.Result{}, err
+	}
+	node, ok := pvc.Annotations[AnnSelectedNode]
+	if !ok {
+		if sc.VolumeBindingMode == nil || *sc.VolumeBindingMode != storagev1.VolumeBindingWaitForFirstConsumer {
+			r.recorder.Eventf(pvc, nil, corev1.EventTypeWarning, "PopulationInvalid", "Populate",
+				"StorageClass %s must have volumeBindingMode WaitForFirstConsumer to populate from VolumeSeed", sc.Name)
+		}
+		return ctrl.Result{}, nil
+	}
+
+	ref := pvc.Spec.DataSourceRef
+	if ref.Namespace != nil && *ref.Namespace != pvc.Namespace {
+		r.recorder.Eventf(pvc, nil, corev1.EventTypeWarning, "PopulationInvalid", "Populate",
+			"VolumeSeed in another namespace is not supported")
+		return ctrl.Result{}, nil
+	}
+	vs := &topolvmv1.VolumeSeed{}
+	err = r.client.Get(ctx, types.NamespacedName{Namespace: pvc.Namespace, Name: ref.Name}, vs)
+	if apierrors.IsNotFound(err) {
+		// the PVC is reconciled again when the VolumeSeed is created.
+		r.recorder.Eventf(pvc, nil, corev1.EventTypeWarning, "PopulationInvalid", "Populate", "VolumeSeed %s is not found", ref.Name)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := validateSeedClaim(pvc, sc, vs); err != nil {
+		r.recorder.Eventf(pvc, nil, corev1.EventTypeWarning, "PopulationInvalid", "Populate", "%s", err.Error())
+		return ctrl.Result{}, nil
+	}
+
+	lv := &topolvmv1.LogicalVolume{}
+	err = r.client.Get(ctx, types.NamespacedName{Name: seedClaimVolumeName(pvc)}, lv)
+	switch {
+	case apierrors.IsNotFound(err):
+		fsType, mkfsOptions, err := r.seedClaimFilesystem(ctx, sc, vs, node)
+		if err != nil {
+			log.Error(err, "failed to get the filesystem", "name", pvc.Name, "namespace", pvc.Namespace)
+			return ctrl.Result{}, err
+		}
+		if err := validateSeedClaimMkfsOptions(sc, fsType); err != nil {
+			r.recorder.Eventf(pvc, nil, corev1.EventTypeWarning, "PopulationInvalid", "Populate", "%s", err.Error())
+			return ctrl.Result{}, nil
+		}
+		if err := r.createLogicalVolume(ctx, pvc, sc, vs, node, fsType, mkfsOptions); err != nil {
+			log.Error(err, "failed to create LogicalVolume", "name", pvc.Name, "namespace", pvc.Namespace)
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: requeueIntervalForPopulation}, nil
+	case err != nil:
+		return ctrl.Result{}, err
+	}
+
+	if lv.Status.Code != codes.OK {
+		r.recorder.Eventf(pvc, lv, corev1.EventTypeWarning, "PopulationFailed", "Populate",
+			"failed to populate from VolumeSeed %s: %s", vs.Name, lv.Status.Message)
+		// the failed volumes are recreated with the backoff of the returned error.
+		if err := r.deleteFailedVolumes(ctx, lv); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to populate LogicalVolume %s: %s", lv.Name, lv.Status.Message)
+	}
+	if lv.Status.VolumeID == "" {
+		return ctrl.Result{RequeueAfter: requeueIntervalForPopulation}, nil
+	}
+
+	if err := r.client.Create(ctx, seedClaimPV(pvc, sc, vs, lv)); err != nil && !apierrors.IsAlreadyExists(err) {
+		log.Error(err, "failed to create PersistentVolume", "name", pvc.Name, "namespace", pvc.Namespace)
+		return ctrl.Result{}, err
+	}
+	r.recorder.Eventf(pvc, lv, corev1.EventTypeNormal, "Populated", "Populate", "populated from VolumeSeed %s", vs.Name)
+	log.Info("populated PVC", "name", pvc.Name, "namespace", pvc.Namespace, "seed", vs.Name, "node", node)
+	return ctrl.Result{}, nil
+}
+
+// refersVolumeSeed returns true if the dataSourceRef of the PVC is a VolumeSeed.
+func refersVolumeSeed(pvc *corev1.PersistentVolumeClaim) bool {
+	ref := pvc.Spec.DataSourceRef
+	return ref != nil && ref.APIGroup != nil && *ref.APIGroup == topolvmv1.GroupVersion.Group && ref.Kind == "VolumeSeed"
+}
+
+// storageClassOf returns the StorageClass of the PVC, or nil if it is not provisioned by TopoLVM.
+func (r *VolumeSeedPopulatorReconciler) storageClassOf(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*storagev1.StorageClass, error) {
+	if pvc.Spec.StorageClassName == nil {
+		return nil, nil
+	}
+	sc := &storagev1.StorageClass{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc)
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil || sc.Provisioner != topolvm.GetPluginName() {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// validateSeedClaim returns an error if the PVC cannot be populated from the VolumeSeed with the StorageClass.
+func validateSeedClaim(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass, vs *topolvmv1.VolumeSeed) error {
+	if err := seed.Validate(&vs.Spec); err != nil {
+		return err
+	}
+	if isBlockClaim(pvc) && seed.IsFilesystem(&vs.Spec) {
+		return fmt.Errorf("VolumeSeed %s creates a filesystem, which cannot be used by a Block PVC", vs.Name)
+	}
+	if err := filesystem.ValidateFsckPolicy(sc.Parameters[topolvm.GetFsckPolicyKey()]); err != nil {
+		return fmt.Errorf("invalid %s: %v", topolvm.GetFsckPolicyKey(), err)
+	}
+	return nil
+}
+
+// validateSeedClaimMkfsOptions returns an error if the mkfs options of the StorageClass cannot be passed to mkfs
+// of the filesystem.
+func validateSeedClaimMkfsOptions(sc *storagev1.StorageClass, fsType string) error {
+	options := strings.Fields(sc.Parameters[topolvm.GetMkfsOptionsKey()])
+	if fsType == "" || len(options) == 0 {
+		return nil
+	}
+	if err := filesystem.ValidateMkfsOptions(fsType, options); err != nil {
+		return fmt.Errorf("invalid %s: %v", topolvm.GetMkfsOptionsKey(), err)
+	}
+	return nil
+}
+
+func isBlockClaim(pvc *corev1.PersistentVolumeClaim) bool {
+	return pvc.Spec.VolumeMode != nil && *pvc.Spec.VolumeMode == corev1.PersistentVolumeBlock
+}
+
+// seedClaimFsckPolicy returns the policy of the filesystem check given by the StorageClass parameter,
+// as CreateVolume does. It is empty for block volumes.
+func seedClaimFsckPolicy(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass) string {
+	if isBlockClaim(pvc) {
+		return ""
+	}
+	return sc.Parameters[topolvm.GetFsckPolicyKey()]
+}
+
+// seedClaimFilesystem returns the filesystem created on the volume to extract the VolumeSeed into and the extra
+// arguments of mkfs, as CreateVolume does for a new volume. The defaults of the device class are read from the
+// NodeStorage of the node, and the options of the StorageClass follow them so that they take precedence.
+// It returns empty for a raw seed because the filesystem is in the data.
+func (r *VolumeSeedPopulatorReconciler) seedClaimFilesystem(ctx context.Context, sc *storagev1.StorageClass,
+	vs *topolvmv1.VolumeSeed, node string) (string, []string, error) {
+	if !seed.IsFilesystem(&vs.Spec) {
+		return "", nil, nil
+	}
+	defaults := &topolvmv1.DeviceClassStorageStatus{}
+	ns := &topolvmv1.NodeStorage{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: node}, ns)
+	switch {
+	case err == nil:
+		if dc := ns.Status.DeviceClass(sc.Parameters[topolvm.GetDeviceClassKey()]); dc != nil {
+			defaults = dc
+		}
+	case apierrors.IsNotFound(err):
+	default:
+		return "", nil, err
+	}
+
+	fsType := vs.Spec.FsType
+	if fsType == "" {
+		fsType = defaults.FsType
+	}
+	if fsType == "" {
+		fsType = seed.FsType(&vs.Spec)
+	}
+	options := strings.Fields(sc.Parameters[topolvm.GetMkfsOptionsKey()])
+	return fsType, slices.Concat(defaults.MkfsOptions[fsType], options), nil
+}
+
+// seedClaimVolumeName returns the name of the LogicalVolume and the PersistentVolume of the PVC.
+// It is the same as the name given by the external-provisioner.
+func seedClaimVolumeName(pvc *corev1.PersistentVolumeClaim) string {
+	return "pvc-" + string(pvc.UID)
+}
+
+// seedCacheVolumeName returns the name of the LogicalVolume caching the data of the VolumeSeed on the node and
+// device class with the filesystem and the mkfs options. The name changes when the spec of the VolumeSeed changes,
+// so that the stale cache is not used.
+func seedCacheVolumeName(vs *topolvmv1.VolumeSeed, node, deviceClass, fsType string, mkfsOptions []string) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%d/%s/%s/%s/%s", vs.Generation, node, deviceClass, fsType,
+		strings.Join(mkfsOptions, " ")))
+	return fmt.Sprintf("seed-%s-%s", vs.UID, hex.EncodeToString(sum[:])[:10])
+}
+
+func requestedSize(pvc *corev1.PersistentVolumeClaim) resource.Quantity {
+	if size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok && !size.IsZero() {
+		return size
+	}
+	return *resource.NewQuantity(topolvm.DefaultSize, resource.BinarySI)
+}
+
+// createLogicalVolume creates the LogicalVolume of the PVC. It is copied from the cache of the VolumeSeed
+// if the VolumeSeed is cached and the cache fits in the volume. Otherwise, the data is fetched again.
+// The data is extracted into the filesystem of fsType created with mkfsOptions unless the seed is raw.
+func (r *VolumeSeedPopulatorReconciler) createLogicalVolume(ctx context.Context, pvc *corev1.PersistentVolumeClaim,
+	sc *storagev1.StorageClass, vs *topolvmv1.VolumeSeed, node, fsType string, mkfsOptions []string) error {
+	name := seedClaimVolumeName(pvc)
+	size := requestedSize(pvc)
+	lv := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				topolvm.CreatedbyLabelKey: topolvm.CreatedbyLabelValue,
+				topolvm.GetNamespaceKey(): pvc.Namespace,
+			},
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:                name,
+			NodeName:            node,
+			DeviceClass:         sc.Parameters[topolvm.GetDeviceClassKey()],
+			LvcreateOptionClass: sc.Parameters[topolvm.GetLvcreateOptionClassKey()],
+			Size:                size,
+			FsType:              fsType,
+			MkfsOptions:         mkfsOptions,
+			FsckPolicy:          seedClaimFsckPolicy(pvc, sc),
+		},
+	}
+
+	var cache *topolvmv1.LogicalVolume
+	if vs.Spec.Cache {
+		var err error
+		cache, err = r.ensureCache(ctx, vs, lv)
+		if err != nil {
+			return err
+		}
+	}
+	if cache != nil && cache.Spec.Size.Cmp(size) <= 0 {
+		lv.Spec.CopySource = cache.Name
+	} else {
+		lv.Spec.Seed = &topolvmv1.SeedReference{Namespace: vs.Namespace, Name: vs.Name}
+	}
+	if err := r.client.Create(ctx, lv); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	r.recorder.Eventf(pvc, lv, corev1.EventTypeNormal, "Populating", "Populate", "populating from VolumeSeed %s on %s", vs.Name, node)
+	return nil
+}
+
+// ensureCache returns the LogicalVolume caching the data of the VolumeSeed on the node and device class of lv.
+// The cache is created with the size of lv if it does not exist.
+func (r *VolumeSeedPopulatorReconciler) ensureCache(ctx context.Context, vs *topolvmv1.VolumeSeed,
+	lv *topolvmv1.LogicalVolume) (*topolvmv1.LogicalVolume, error) {
+	name := seedCacheVolumeName(vs, lv.Spec.NodeName, lv.Spec.DeviceClass, lv.Spec.FsType, lv.Spec.MkfsOptions)
+	cache := &topolvmv1.LogicalVolume{}
+	err := r.client.Get(ctx, types.NamespacedName{Name: name}, cache)
+	switch {
+	case err == nil:
+		return cache, nil
+	case apierrors.IsNotFound(err):
+	default:
+		return nil, err
+	}
+
+	cache = &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				topolvm.CreatedbyLabelKey: topolvm.CreatedbyLabelValue,
+				topolvm.GetSeedKey():      string(vs.UID),
+			},
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:                name,
+			NodeName:            lv.Spec.NodeName,
+			DeviceClass:         lv.Spec.DeviceClass,
+			LvcreateOptionClass: lv.Spec.LvcreateOptionClass,
+			Size:                lv.Spec.Size,
+			FsType:              lv.Spec.FsType,
+			MkfsOptions:         lv.Spec.MkfsOptions,
+			Seed:                &topolvmv1.SeedReference{Namespace: vs.Namespace, Name: vs.Name},
+		},
+	}
+	if err := r.client.Create(ctx, cache); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	return cache, nil
+}
+
+// deleteFailedVolumes deletes the failed LogicalVolume of a PVC, and the cache it was copied from if the cache failed.
+func (r *VolumeSeedPopulatorReconciler) deleteFailedVolumes(ctx context.Context, lv *topolvmv1.LogicalVolume) error {
+	if lv.Spec.CopySource != "" {
+		cache := &topolvmv1.LogicalVolume{}
+		err := r.client.Get(ctx, types.NamespacedName{Name: lv.Spec.CopySource}, cache)
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return err
+		case cache.Status.Code != codes.OK:
+			if err := r.client.Delete(ctx, cache); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	if err := r.client.Delete(ctx, lv); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// seedClaimPV returns the PersistentVolume bound to the PVC populated in lv.
+func seedClaimPV(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass, vs *topolvmv1.VolumeSeed,
+	lv *topolvmv1.LogicalVolume) *corev1.PersistentVolume {
+	reclaimPolicy := corev1.PersistentVolumeReclaimDelete
+	if sc.ReclaimPolicy != nil {
+		reclaimPolicy = *sc.ReclaimPolicy
+	}
+	volumeMode := corev1.PersistentVolumeFilesystem
+	if pvc.Spec.VolumeMode != nil {
+		volumeMode = *pvc.Spec.VolumeMode
+	}
+	csiSource := &corev1.CSIPersistentVolumeSource{
+		Driver:       topolvm.GetPluginName(),
+		VolumeHandle: lv.Status.VolumeID,
+	}
+	if volumeMode == corev1.PersistentVolumeFilesystem {
+		csiSource.FSType = lv.Spec.FsType
+		if csiSource.FSType == "" {
+			csiSource.FSType = seed.FsType(&vs.Spec)
+		}
+	}
+	capacity := lv.Spec.Size
+	if lv.Status.CurrentSize != nil {
+		capacity = *lv.Status.CurrentSize
+	}
+
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: lv.Name,
+			Annotations: map[string]string{
+				annProvisionedBy: topolvm.GetPluginName(),
+			},
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:                      corev1.ResourceList{corev1.ResourceStorage: capacity},
+			AccessModes:                   pvc.Spec.AccessModes,
+			VolumeMode:                    &volumeMode,
+			StorageClassName:              sc.Name,
+			PersistentVolumeReclaimPolicy: reclaimPolicy,
+			MountOptions:                  sc.MountOptions,
+			ClaimRef: &corev1.ObjectReference{
+				Kind:            "PersistentVolumeClaim",
+				APIVersion:      "v1",
+				Namespace:       pvc.Namespace,
+				Name:            pvc.Name,
+				UID:             pvc.UID,
+				ResourceVersion: pvc.ResourceVersion,
+			},
+			PersistentVolumeSource: corev1.PersistentVolumeSource{CSI: csiSource},
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      topolvm.GetTopologyNodeKey(),
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{lv.Spec.NodeName},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VolumeSeedPopulatorReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("volumeseedpopulator").
+		For(&corev1.PersistentVolumeClaim{}).
+		Watches(&topolvmv1.VolumeSeed{}, handler.EnqueueRequestsFromMapFunc(r.claimsForVolumeSeed)).
+		Complete(r)
+}
+
+// claimsForVolumeSeed enqueues the unbound PVCs waiting for the VolumeSeed to be created.
+func (r *VolumeSeedPopulatorReconciler) claimsForVolumeSeed(ctx context.Context, obj client.Object) []reconcile.Request {
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := r.client.List(ctx, &pvcs, client.InNamespace(obj.GetNamespace())); err != nil {
+		crlog.FromContext(ctx).Error(err, "failed to list PVCs", "namespace", obj.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, pvc := range pvcs.Items {
+		if pvc.Spec.VolumeName != "" || !refersVolumeSeed(&pvc) || pvc.Spec.DataSourceRef.Name != obj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,321 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/filesystem"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/events"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("VolumeSeedPopulator controller", func() {
+	ctx := context.Background()
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "pvc"}}
+
+	newReconciler := func(objs ...client.Object) (*VolumeSeedPopulatorReconciler, client.Client, *events.FakeRecorder) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).
+			WithObjects(objs...).
+			WithStatusSubresource(&topolvmv1.LogicalVolume{}).
+			Build()
+		recorder := events.NewFakeRecorder(10)
+		return NewVolumeSeedPopulatorReconciler(c, recorder), c, recorder
+	}
+
+	objects := func(cache bool, size string) []client.Object {
+		sc := &storagev1.StorageClass{
+			ObjectMeta:        metav1.ObjectMeta{Name: "topolvm"},
+			Provisioner:       topolvm.GetPluginName(),
+			Parameters:        map[string]string{topolvm.GetDeviceClassKey(): "thin"},
+			VolumeBindingMode: ptr.To(storagev1.VolumeBindingWaitForFirstConsumer),
+		}
+		vs := &topolvmv1.VolumeSeed{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "seed", UID: "seed-uid", Generation: 1},
+			Spec: topolvmv1.VolumeSeedSpec{
+				Image: &topolvmv1.ImageSeedSource{Reference: "ghcr.io/example/model:v1"},
+				Cache: cache,
+			},
+		}
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "test",
+				Name:        "pvc",
+				UID:         "uid",
+				Annotations: map[string]string{AnnSelectedNode: "node1"},
+			},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				StorageClassName: ptr.To("topolvm"),
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+				DataSourceRef: &corev1.TypedObjectReference{
+					APIGroup: ptr.To(topolvmv1.GroupVersion.Group),
+					Kind:     "VolumeSeed",
+					Name:     "seed",
+				},
+			},
+		}
+		return []client.Object{sc, vs, pvc}
+	}
+
+	getLV := func(c client.Client, name string) *topolvmv1.LogicalVolume {
+		lv := &topolvmv1.LogicalVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: name}, lv)).To(Succeed())
+		return lv
+	}
+
+	It("should create the LogicalVolume on the selected node and the PV after the population", func() {
+		r, c, _ := newReconciler(objects(false, "1Gi")...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		lv := getLV(c, "pvc-uid")
+		Expect(lv.Labels).To(HaveKeyWithValue(topolvm.GetNamespaceKey(), "test"))
+		Expect(lv.Spec.NodeName).To(Equal("node1"))
+		Expect(lv.Spec.DeviceClass).To(Equal("thin"))
+		Expect(lv.Spec.Size.Value()).To(BeEquivalentTo(1 << 30))
+		Expect(lv.Spec.Seed).To(Equal(&topolvmv1.SeedReference{Namespace: "test", Name: "seed"}))
+		Expect(lv.Spec.CopySource).To(BeEmpty())
+
+		// the PV is not created until the population completes.
+		result, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.RequeueAfter).To(Equal(requeueIntervalForPopulation))
+		pv := &corev1.PersistentVolume{}
+		Expect(apierrors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "pvc-uid"}, pv))).To(BeTrue())
+
+		lv.Status.VolumeID = "vol"
+		lv.Status.CurrentSize = resource.NewQuantity(1<<30, resource.BinarySI)
+		Expect(c.Status().Update(ctx, lv)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pvc-uid"}, pv)).To(Succeed())
+		Expect(pv.Annotations).To(HaveKeyWithValue(annProvisionedBy, topolvm.GetPluginName()))
+		Expect(pv.Spec.ClaimRef.UID).To(BeEquivalentTo("uid"))
+		Expect(pv.Spec.CSI.VolumeHandle).To(Equal("vol"))
+		Expect(pv.Spec.CSI.FSType).To(Equal("ext4"))
+		Expect(pv.Spec.StorageClassName).To(Equal("topolvm"))
+		Expect(pv.Spec.PersistentVolumeReclaimPolicy).To(Equal(corev1.PersistentVolumeReclaimDelete))
+		Expect(pv.Spec.NodeAffinity.Required.NodeSelectorTerms[0].MatchExpressions[0].Values).To(Equal([]string{"node1"}))
+		Expect(pv.Spec.Capacity.Storage().Value()).To(BeEquivalentTo(1 << 30))
+	})
+
+	It("should copy the cache of the seed if it fits in the volume", func() {
+		objs := objects(true, "1Gi")
+		vs := objs[1].(*topolvmv1.VolumeSeed)
+		r, c, _ := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		cacheName := seedCacheVolumeName(vs, "node1", "thin", "ext4", nil)
+		cache := getLV(c, cacheName)
+		Expect(cache.Labels).To(HaveKeyWithValue(topolvm.GetSeedKey(), "seed-uid"))
+		Expect(cache.Labels).NotTo(HaveKey(topolvm.GetNamespaceKey()))
+		Expect(cache.Spec.Seed).To(Equal(&topolvmv1.SeedReference{Namespace: "test", Name: "seed"}))
+		Expect(cache.Spec.Size.Value()).To(BeEquivalentTo(1 << 30))
+
+		lv := getLV(c, "pvc-uid")
+		Expect(lv.Spec.CopySource).To(Equal(cacheName))
+		Expect(lv.Spec.Seed).To(BeNil())
+
+		// a smaller PVC is populated without the cache.
+		pvc := &corev1.PersistentVolumeClaim{}
+		Expect(c.Get(ctx, req.NamespacedName, pvc)).To(Succeed())
+		small := pvc.DeepCopy()
+		small.ObjectMeta = metav1.ObjectMeta{
+			Namespace:   "test",
+			Name:        "small",
+			UID:         "small-uid",
+			Annotations: pvc.Annotations,
+		}
+		small.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse("512Mi")
+		Expect(c.Create(ctx, small)).To(Succeed())
+		_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "test", Name: "small"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		lv = getLV(c, "pvc-small-uid")
+		Expect(lv.Spec.CopySource).To(BeEmpty())
+		Expect(lv.Spec.Seed).NotTo(BeNil())
+	})
+
+	It("should delete the failed volumes to retry", func() {
+		objs := objects(true, "1Gi")
+		vs := objs[1].(*topolvmv1.VolumeSeed)
+		r, c, recorder := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		<-recorder.Events
+
+		cacheName := seedCacheVolumeName(vs, "node1", "thin", "ext4", nil)
+		for _, name := range []string{cacheName, "pvc-uid"} {
+			lv := getLV(c, name)
+			lv.Status.Code = codes.DataLoss
+			lv.Status.Message = "checksum mismatch"
+			Expect(c.Status().Update(ctx, lv)).To(Succeed())
+		}
+
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).To(HaveOccurred())
+		Expect(<-recorder.Events).To(ContainSubstring("PopulationFailed"))
+		for _, name := range []string{cacheName, "pvc-uid"} {
+			err := c.Get(ctx, types.NamespacedName{Name: name}, &topolvmv1.LogicalVolume{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		}
+	})
+
+	It("should reject a filesystem seed for a Block PVC", func() {
+		objs := objects(false, "1Gi")
+		objs[2].(*corev1.PersistentVolumeClaim).Spec.VolumeMode = ptr.To(corev1.PersistentVolumeBlock)
+		r, c, recorder := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(<-recorder.Events).To(ContainSubstring("PopulationInvalid"))
+		err = c.Get(ctx, types.NamespacedName{Name: "pvc-uid"}, &topolvmv1.LogicalVolume{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should set the fsck policy of the StorageClass to the LogicalVolume", func() {
+		objs := objects(false, "1Gi")
+		objs[0].(*storagev1.StorageClass).Parameters[topolvm.GetFsckPolicyKey()] = filesystem.FsckCheckOnly
+		r, c, _ := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lv := getLV(c, "pvc-uid")
+		Expect(lv.Spec.FsckPolicy).To(Equal(filesystem.FsckCheckOnly))
+	})
+
+	It("should reject an unknown fsck policy of the StorageClass", func() {
+		objs := objects(false, "1Gi")
+		objs[0].(*storagev1.StorageClass).Parameters[topolvm.GetFsckPolicyKey()] = "unknown"
+		r, c, recorder := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(<-recorder.Events).To(ContainSubstring(topolvm.GetFsckPolicyKey()))
+		err = c.Get(ctx, types.NamespacedName{Name: "pvc-uid"}, &topolvmv1.LogicalVolume{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should not set the fsck policy for a Block PVC", func() {
+		objs := objects(false, "1Gi")
+		objs[0].(*storagev1.StorageClass).Parameters[topolvm.GetFsckPolicyKey()] = filesystem.FsckCheckOnly
+		objs[1].(*topolvmv1.VolumeSeed).Spec = topolvmv1.VolumeSeedSpec{
+			HTTP:   &topolvmv1.HTTPSeedSource{URL: "https://example.com/disk.img"},
+			Format: topolvmv1.SeedFormatRaw,
+		}
+		objs[2].(*corev1.PersistentVolumeClaim).Spec.VolumeMode = ptr.To(corev1.PersistentVolumeBlock)
+		r, c, _ := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lv := getLV(c, "pvc-uid")
+		Expect(lv.Spec.FsckPolicy).To(BeEmpty())
+	})
+
+	It("should set the filesystem and the mkfs options of the device class and the StorageClass", func() {
+		objs := objects(true, "1Gi")
+		objs[0].(*storagev1.StorageClass).Parameters[topolvm.GetMkfsOptionsKey()] = "-L data"
+		vs := objs[1].(*topolvmv1.VolumeSeed)
+		ns := &topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Status: topolvmv1.NodeStorageStatus{DeviceClasses: []topolvmv1.DeviceClassStorageStatus{{
+				Name:        "thin",
+				FsType:      "xfs",
+				MkfsOptions: map[string][]string{"xfs": {"-d", "su=64k,sw=2"}},
+			}}},
+		}
+		r, c, _ := newReconciler(append(objs, ns)...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+
+		options := []string{"-d", "su=64k,sw=2", "-L", "data"}
+		lv := getLV(c, "pvc-uid")
+		Expect(lv.Spec.FsType).To(Equal("xfs"))
+		Expect(lv.Spec.MkfsOptions).To(Equal(options))
+		Expect(lv.Spec.CopySource).To(Equal(seedCacheVolumeName(vs, "node1", "thin", "xfs", options)))
+		cache := getLV(c, lv.Spec.CopySource)
+		Expect(cache.Spec.FsType).To(Equal("xfs"))
+		Expect(cache.Spec.MkfsOptions).To(Equal(options))
+
+		lv.Status.VolumeID = "vol"
+		Expect(c.Status().Update(ctx, lv)).To(Succeed())
+		_, err = r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		pv := &corev1.PersistentVolume{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "pvc-uid"}, pv)).To(Succeed())
+		Expect(pv.Spec.CSI.FSType).To(Equal("xfs"))
+	})
+
+	It("should prefer the filesystem of the VolumeSeed to the one of the device class", func() {
+		objs := objects(false, "1Gi")
+		objs[1].(*topolvmv1.VolumeSeed).Spec.FsType = "btrfs"
+		ns := &topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Status: topolvmv1.NodeStorageStatus{DeviceClasses: []topolvmv1.DeviceClassStorageStatus{{
+				Name:        "thin",
+				FsType:      "xfs",
+				MkfsOptions: map[string][]string{"xfs": {"-d", "su=64k,sw=2"}},
+			}}},
+		}
+		r, c, _ := newReconciler(append(objs, ns)...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lv := getLV(c, "pvc-uid")
+		Expect(lv.Spec.FsType).To(Equal("btrfs"))
+		Expect(lv.Spec.MkfsOptions).To(BeEmpty())
+	})
+
+	It("should reject the mkfs options of the StorageClass not supported by the filesystem", func() {
+		objs := objects(false, "1Gi")
+		objs[0].(*storagev1.StorageClass).Parameters[topolvm.GetMkfsOptionsKey()] = "-n"
+		r, c, recorder := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(<-recorder.Events).To(ContainSubstring(topolvm.GetMkfsOptionsKey()))
+		err = c.Get(ctx, types.NamespacedName{Name: "pvc-uid"}, &topolvmv1.LogicalVolume{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("should ignore PVCs of other data sources", func() {
+		objs := objects(false, "1Gi")
+		objs[2].(*corev1.PersistentVolumeClaim).Spec.DataSourceRef = &corev1.TypedObjectReference{
+			Kind: "PersistentVolumeClaim",
+			Name: "source",
+		}
+		r, c, _ := newReconciler(objs...)
+
+		_, err := r.Reconcile(ctx, req)
+		Expect(err).NotTo(HaveOccurred())
+		lvs := &topolvmv1.LogicalVolumeList{}
+		Expect(c.List(ctx, lvs)).To(Succeed())
+		Expect(lvs.Items).To(BeEmpty())
+	})
+})
@@ -0,0 +1,84 @@
+package dcpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=deviceclasspolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// ErrDenied is returned when a namespace is not allowed to use a device-class.
+var ErrDenied = errors.New("denied by DeviceClassPolicy")
+
+// policyKey returns the device-class name used in DeviceClassPolicies.
+func policyKey(deviceClass string) string {
+	if deviceClass == topolvm.DefaultDeviceClassName {
+		return topolvm.DefaultDeviceClassAnnotationName
+	}
+	return deviceClass
+}
+
+// Check returns an error wrapping ErrDenied if the namespace is not allowed to use the device-class.
+// A device-class without DeviceClassPolicies can be used from any namespace.
+// An empty namespace is denied for restricted device-classes because it cannot be verified.
+func Check(ctx context.Context, r client.Reader, deviceClass, namespace string) error {
+	var policies topolvmv1.DeviceClassPolicyList
+	err := r.List(ctx, &policies)
+	if meta.IsNoMatchError(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	key := policyKey(deviceClass)
+	var matched []topolvmv1.DeviceClassPolicy
+	for _, p := range policies.Items {
+		if p.Spec.DeviceClass == key {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+	if namespace == "" {
+		return fmt.Errorf("%w: device-class %s is restricted to some namespaces, but the namespace of the volume is unknown; "+
+			"external-provisioner must run with --extra-create-metadata", ErrDenied, key)
+	}
+
+	var ns *corev1.Namespace
+	for _, p := range matched {
+		if slices.Contains(p.Spec.Namespaces, namespace) {
+			return nil
+		}
+		if p.Spec.NamespaceSelector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(p.Spec.NamespaceSelector)
+		if err != nil {
+			return fmt.Errorf("invalid namespaceSelector in DeviceClassPolicy %s: %w", p.Name, err)
+		}
+		if ns == nil {
+			ns = &corev1.Namespace{}
+			if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+				return err
+			}
+		}
+		if selector.Matches(labels.Set(ns.Labels)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: namespace %s is not allowed to use device-class %s", ErrDenied, namespace, key)
+}
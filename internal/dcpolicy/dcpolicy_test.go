@@ -0,0 +1,88 @@
+package dcpolicy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		testNamespace("team-a", nil),
+		testNamespace("team-b", map[string]string{"tier": "gold"}),
+		testNamespace("team-c", map[string]string{"tier": "silver"}),
+		&topolvmv1.DeviceClassPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "ssd-team-a"},
+			Spec: topolvmv1.DeviceClassPolicySpec{
+				DeviceClass: "ssd",
+				Namespaces:  []string{"team-a"},
+			},
+		},
+		&topolvmv1.DeviceClassPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "ssd-gold"},
+			Spec: topolvmv1.DeviceClassPolicySpec{
+				DeviceClass: "ssd",
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"tier": "gold"},
+				},
+			},
+		},
+		&topolvmv1.DeviceClassPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "default-team-c"},
+			Spec: topolvmv1.DeviceClassPolicySpec{
+				DeviceClass: "00default",
+				Namespaces:  []string{"team-c"},
+			},
+		},
+	).Build()
+
+	testCases := []struct {
+		deviceClass string
+		namespace   string
+		denied      bool
+	}{
+		{deviceClass: "ssd", namespace: "team-a"},
+		{deviceClass: "ssd", namespace: "team-b"},
+		{deviceClass: "ssd", namespace: "team-c", denied: true},
+		{deviceClass: "ssd", namespace: "", denied: true},
+		{deviceClass: "", namespace: "team-c"},
+		{deviceClass: "", namespace: "team-a", denied: true},
+		{deviceClass: "hdd", namespace: "team-a"},
+		{deviceClass: "hdd", namespace: ""},
+	}
+	for _, tc := range testCases {
+		err := Check(context.Background(), c, tc.deviceClass, tc.namespace)
+		if tc.denied {
+			if !errors.Is(err, ErrDenied) {
+				t.Errorf("%q in %q should be denied: %v", tc.deviceClass, tc.namespace, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q in %q should be allowed: %v", tc.deviceClass, tc.namespace, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package dra
+
+import (
+	"fmt"
+
+	"github.com/topolvm/topolvm"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// AttributeDeviceClass is the device attribute that holds the device-class name.
+	AttributeDeviceClass resourcev1.QualifiedName = "deviceClass"
+
+	// AttributeType is the device attribute that holds the type of the device-class, "thick" or "thin".
+	AttributeType resourcev1.QualifiedName = "type"
+
+	// CapacitySize is the consumable capacity of a device in bytes.
+	CapacitySize resourcev1.QualifiedName = "size"
+)
+
+// CapacitySizeOf returns the value of CapacitySize in the capacity map.
+// The name may be qualified by the driver name.
+func CapacitySizeOf(capacity map[resourcev1.QualifiedName]resource.Quantity) (int64, bool) {
+	for _, name := range []resourcev1.QualifiedName{
+		CapacitySize,
+		resourcev1.QualifiedName(topolvm.GetPluginName() + "/" + string(CapacitySize)),
+	} {
+		if q, ok := capacity[name]; ok {
+			return q.Value(), true
+		}
+	}
+	return 0, false
+}
+
+// VolumeName returns the name of the LogicalVolume created for the i-th allocation result of the claim.
+func VolumeName(claimUID types.UID, i int) string {
+	return fmt.Sprintf("%s-%d", claimUID, i)
+}
+
+// SliceName returns the name of the ResourceSlice published for the node.
+func SliceName(nodeName string) string {
+	return fmt.Sprintf("%s-%s", nodeName, topolvm.GetPluginName())
+}
+
+// DeviceName returns the name of the device representing the device-class.
+// The second return value is false if the device-class name cannot be used as a device name,
+// which must be a DNS label.
+func DeviceName(deviceClass string) (string, bool) {
+	if deviceClass == topolvm.DefaultDeviceClassName {
+		return topolvm.DefaultDeviceClassAnnotationName, true
+	}
+	if len(validation.IsDNS1123Label(deviceClass)) != 0 {
+		return "", false
+	}
+	return deviceClass, true
+}
+
+// DeviceClass returns the device-class name represented by the device.
+func DeviceClass(deviceName string) string {
+	if deviceName == topolvm.DefaultDeviceClassAnnotationName {
+		return topolvm.DefaultDeviceClassName
+	}
+	return deviceName
+}
@@ -0,0 +1,49 @@
+package dra
+
+import (
+	"testing"
+
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestDeviceName(t *testing.T) {
+	testCases := []struct {
+		deviceClass string
+		expected    string
+		ok          bool
+	}{
+		{deviceClass: "", expected: "00default", ok: true},
+		{deviceClass: "ssd", expected: "ssd", ok: true},
+		{deviceClass: "ssd-thin", expected: "ssd-thin", ok: true},
+		{deviceClass: "SSD", ok: false},
+		{deviceClass: "ssd.thin", ok: false},
+		{deviceClass: "ssd_thin", ok: false},
+	}
+
+	for _, tc := range testCases {
+		name, ok := DeviceName(tc.deviceClass)
+		if ok != tc.ok || name != tc.expected {
+			t.Errorf("DeviceName(%q) = (%q, %v), expected (%q, %v)", tc.deviceClass, name, ok, tc.expected, tc.ok)
+		}
+		if ok && DeviceClass(name) != tc.deviceClass {
+			t.Errorf("DeviceClass(%q) = %q, expected %q", name, DeviceClass(name), tc.deviceClass)
+		}
+	}
+}
+
+func TestCapacitySizeOf(t *testing.T) {
+	if _, ok := CapacitySizeOf(nil); ok {
+		t.Error("CapacitySizeOf(nil) should not be found")
+	}
+
+	size, ok := CapacitySizeOf(map[resourcev1.QualifiedName]resource.Quantity{"size": resource.MustParse("1Gi")})
+	if !ok || size != 1<<30 {
+		t.Errorf("unexpected size: %d, %v", size, ok)
+	}
+
+	size, ok = CapacitySizeOf(map[resourcev1.QualifiedName]resource.Quantity{"topolvm.io/size": resource.MustParse("2Gi")})
+	if !ok || size != 2<<30 {
+		t.Errorf("unexpected size: %d, %v", size, ok)
+	}
+}
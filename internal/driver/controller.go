@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,15 +13,28 @@ import (
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/topolvm/topolvm"
 	v1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/dcpolicy"
 	"github.com/topolvm/topolvm/internal/driver/internal/k8s"
+	"github.com/topolvm/topolvm/internal/filesystem"
+	"github.com/topolvm/topolvm/internal/nodeservice"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 var ctrlLogger = ctrl.Log.WithName("driver").WithName("controller")
 
+const (
+	// pvcNamespaceKey is the CreateVolume parameter added by external-provisioner with --extra-create-metadata.
+	pvcNamespaceKey = "csi.storage.k8s.io/pvc/namespace"
+
+	// snapshotNamespaceKey is the CreateSnapshot parameter added by external-snapshotter with --extra-create-metadata.
+	snapshotNamespaceKey = "csi.storage.k8s.io/volumesnapshot/namespace"
+)
+
 var (
 	ErrNoNegativeRequestBytes = errors.New("required capacity must not be negative")
 	ErrNoNegativeLimitBytes   = errors.New("capacity limit must not be negative")
@@ -44,7 +59,8 @@ func NewControllerServer(mgr manager.Manager, settings ControllerServerSettings)
 		lockByVolumeID: NewLockWithID(),
 		server: &controllerServerNoLocked{
 			lvService:   lvService,
-			nodeService: k8s.NewNodeService(mgr.GetClient()),
+			nodeService: nodeservice.NewNodeService(mgr.GetClient()),
+			reader:      mgr.GetClient(),
 			settings:    settings,
 		},
 	}, nil
@@ -143,7 +159,8 @@ type controllerServerNoLocked struct {
 	csi.UnimplementedControllerServer
 
 	lvService   *k8s.LogicalVolumeService
-	nodeService *k8s.NodeService
+	nodeService *nodeservice.NodeService
+	reader      client.Reader
 
 	settings ControllerServerSettings
 }
@@ -168,6 +185,8 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 	var (
 		// sourceID   string
 		sourceName string
+		// copySource is set if the data of the source is copied from another node or device class.
+		copySource string
 		sourceVol  *v1.LogicalVolume
 		err        error
 	)
@@ -225,13 +244,21 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 		if requestCapacityBytes < sourceSizeBytes {
 			return nil, status.Error(codes.OutOfRange, "requested size is smaller than the size of the source")
 		}
-		// If a volume has a source, it has to provisioned on the same node and device class as the source volume.
-
+		// A volume in another device class than the source is created by copying the data of the source.
 		if deviceClass != sourceVol.Spec.DeviceClass {
-			return nil, status.Error(codes.InvalidArgument, "device class mismatch. Snapshots should be created with the same device class as the source.")
+			copySource = sourceVol.Name
+		} else {
+			sourceName = sourceVol.Spec.Name
 		}
-		deviceClass = sourceVol.Spec.DeviceClass
-		sourceName = sourceVol.Spec.Name
+	}
+
+	// The webhook may be disabled or bypassed, so DeviceClassPolicies are checked again here.
+	err = dcpolicy.Check(ctx, s.reader, deviceClass, req.GetParameters()[pvcNamespaceKey])
+	if errors.Is(err, dcpolicy.ErrDenied) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	// process topology
@@ -239,10 +266,18 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 	requirements := req.GetAccessibilityRequirements()
 
 	if source != nil {
-		// the snapshot must be created on the same node as the source
+		// the snapshot must be created on the same node as the source unless the data is allowed to be copied.
 		node = sourceVol.Spec.NodeName
 		if requirements != nil && !isRequirementsContaining(requirements, node) {
-			return nil, status.Errorf(codes.InvalidArgument, "cannot find source volume's node '%s' in accessibility_requirements", node)
+			allowCopy, _ := strconv.ParseBool(req.GetParameters()[topolvm.GetAllowCrossNodeCopyKey()])
+			if !allowCopy {
+				return nil, status.Errorf(codes.InvalidArgument, "cannot find source volume's node '%s' in accessibility_requirements", node)
+			}
+			node = findNodeHavingTopologyNodeKey(requirements)
+			if node == "" {
+				return nil, status.Errorf(codes.InvalidArgument, "cannot find key '%s' in accessibility_requirements", topolvm.GetTopologyNodeKey())
+			}
+			copySource, sourceName = sourceVol.Name, ""
 		}
 	} else {
 		if requirements == nil {
@@ -277,7 +312,23 @@ func (s controllerServerNoLocked) CreateVolume(ctx context.Context, req *csi.Cre
 	}
 	name = strings.ToLower(name)
 
-	volume, err := s.lvService.CreateVolume(ctx, node, deviceClass, lvcreateOptionClass, name, sourceName, requestCapacityBytes)
+	var volume *v1.LogicalVolume
+	if copySource != "" {
+		volume, err = s.lvService.CopyVolume(ctx, node, deviceClass, lvcreateOptionClass, name, req.GetParameters()[pvcNamespaceKey], copySource, requestCapacityBytes)
+	} else {
+		var fsType, fsckPolicy string
+		var mkfsOptions []string
+		fsType, mkfsOptions, err = s.volumeFilesystem(ctx, capabilities, node, deviceClass, req.GetParameters())
+		if err != nil {
+			return nil, err
+		}
+		fsckPolicy, err = volumeFsckPolicy(capabilities, req.GetParameters())
+		if err != nil {
+			return nil, err
+		}
+		volume, err = s.lvService.CreateVolume(ctx, node, deviceClass, lvcreateOptionClass, name, req.GetParameters()[pvcNamespaceKey], sourceName,
+			fsType, mkfsOptions, fsckPolicy, requestCapacityBytes)
+	}
 	if err != nil {
 		_, ok := status.FromError(err)
 		if !ok {
@@ -375,7 +426,7 @@ func (s controllerServerNoLocked) CreateSnapshot(ctx context.Context, req *csi.C
 	deviceClass := sourceVol.Spec.DeviceClass
 	sourceVolName := sourceVol.Spec.Name
 	currentSize := sourceVol.Status.CurrentSize
-	snapshot, err := s.lvService.CreateSnapshot(ctx, node, deviceClass, sourceVolName, name, accessType, *currentSize)
+	snapshot, err := s.lvService.CreateSnapshot(ctx, node, deviceClass, sourceVolName, name, req.GetParameters()[snapshotNamespaceKey], accessType, *currentSize)
 	if err != nil {
 		_, ok := status.FromError(err)
 		if !ok {
@@ -417,6 +468,72 @@ func (s controllerServerNoLocked) DeleteSnapshot(ctx context.Context, req *csi.D
 	return &csi.DeleteSnapshotResponse{}, nil
 }
 
+// volumeFilesystem returns the filesystem the new volume is formatted with and the extra arguments of mkfs.
+// The defaults of the device class are read from the NodeStorage of the node, and the options of the StorageClass
+// follow them so that they take precedence.
+func (s controllerServerNoLocked) volumeFilesystem(ctx context.Context, capabilities []*csi.VolumeCapability, node, deviceClass string,
+	parameters map[string]string) (string, []string, error) {
+	var defaults *v1.DeviceClassStorageStatus
+	ns := &v1.NodeStorage{}
+	err := s.reader.Get(ctx, client.ObjectKey{Name: node}, ns)
+	switch {
+	case err == nil:
+		defaults = ns.Status.DeviceClass(deviceClass)
+	case apierrors.IsNotFound(err):
+	default:
+		return "", nil, status.Errorf(codes.Internal, "failed to get NodeStorage: %v", err)
+	}
+	if defaults == nil {
+		defaults = &v1.DeviceClassStorageStatus{}
+	}
+
+	fsType := volumeFsType(capabilities, defaults.FsType)
+	if fsType == "" {
+		// the options are ignored for block volumes so that a StorageClass can be used for both.
+		return "", nil, nil
+	}
+	options := strings.Fields(parameters[topolvm.GetMkfsOptionsKey()])
+	if len(options) > 0 {
+		if err := filesystem.ValidateMkfsOptions(fsType, options); err != nil {
+			return "", nil, status.Errorf(codes.InvalidArgument, "invalid %s: %v", topolvm.GetMkfsOptionsKey(), err)
+		}
+	}
+	return fsType, slices.Concat(defaults.MkfsOptions[fsType], options), nil
+}
+
+// volumeFsckPolicy returns the policy of the filesystem check given by the StorageClass parameter.
+// It is empty for block volumes.
+func volumeFsckPolicy(capabilities []*csi.VolumeCapability, parameters map[string]string) (string, error) {
+	policy := parameters[topolvm.GetFsckPolicyKey()]
+	if err := filesystem.ValidateFsckPolicy(policy); err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "invalid %s: %v", topolvm.GetFsckPolicyKey(), err)
+	}
+	for _, capability := range capabilities {
+		if capability.GetBlock() != nil {
+			return "", nil
+		}
+	}
+	return policy, nil
+}
+
+// volumeFsType returns the filesystem the volume is formatted with when it is published, or empty for a block volume.
+// It defaults to defaultFsType of the device class, or ext4 as topolvm-node does.
+func volumeFsType(capabilities []*csi.VolumeCapability, defaultFsType string) string {
+	fsType := defaultFsType
+	if fsType == "" {
+		fsType = "ext4"
+	}
+	for _, capability := range capabilities {
+		if capability.GetBlock() != nil {
+			return ""
+		}
+		if t := capability.GetMount().GetFsType(); t != "" {
+			fsType = t
+		}
+	}
+	return fsType
+}
+
 // convertRequestCapacityBytes converts requestBytes and limitBytes to a valid capacity.
 func convertRequestCapacityBytes(requestBytes, limitBytes int64) (int64, error) {
 	if requestBytes < 0 {
@@ -561,10 +678,10 @@ func (s controllerServerNoLocked) GetCapacity(ctx context.Context, req *csi.GetC
 		var err error
 		capacity, err = s.nodeService.GetCapacityByTopologyLabel(ctx, v, deviceClass)
 		switch err {
-		case k8s.ErrNodeNotFound:
+		case nodeservice.ErrNodeNotFound:
 			ctrlLogger.Info("target is not found", "accessible_topology", req.AccessibleTopology)
 			return &csi.GetCapacityResponse{AvailableCapacity: 0}, nil
-		case k8s.ErrDeviceClassNotFound:
+		case nodeservice.ErrDeviceClassNotFound:
 			ctrlLogger.Info("target device class is not found on the specified node", "accessible_topology", req.AccessibleTopology, "device-class", deviceClass)
 			return &csi.GetCapacityResponse{AvailableCapacity: 0}, nil
 		case nil:
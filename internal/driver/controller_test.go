@@ -1,11 +1,20 @@
 package driver
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"testing"
 
+	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/topolvm/topolvm"
+	v1 "github.com/topolvm/topolvm/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func Test_convertRequestCapacityBytes(t *testing.T) {
@@ -114,3 +123,118 @@ func Test_roundUp(t *testing.T) {
 		})
 	}
 }
+
+func Test_volumeFsType(t *testing.T) {
+	mount := func(fsType string) *csi.VolumeCapability {
+		return &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: fsType}}}
+	}
+	block := &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}}
+
+	testCases := []struct {
+		name          string
+		capabilities  []*csi.VolumeCapability
+		defaultFsType string
+		expected      string
+	}{
+		{"default", []*csi.VolumeCapability{mount("")}, "", "ext4"},
+		{"xfs", []*csi.VolumeCapability{mount("xfs")}, "", "xfs"},
+		{"device class default", []*csi.VolumeCapability{mount("")}, "xfs", "xfs"},
+		{"override device class default", []*csi.VolumeCapability{mount("btrfs")}, "xfs", "btrfs"},
+		{"block", []*csi.VolumeCapability{block}, "", ""},
+		{"block and mount", []*csi.VolumeCapability{mount("xfs"), block}, "xfs", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := volumeFsType(tc.capabilities, tc.defaultFsType); actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_volumeFsckPolicy(t *testing.T) {
+	mount := &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{}}}
+	block := &csi.VolumeCapability{AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}}
+
+	testCases := []struct {
+		name         string
+		capabilities []*csi.VolumeCapability
+		policy       string
+		expected     string
+		code         codes.Code
+	}{
+		{"default", []*csi.VolumeCapability{mount}, "", "", codes.OK},
+		{"auto-repair-safe", []*csi.VolumeCapability{mount}, "auto-repair-safe", "auto-repair-safe", codes.OK},
+		{"block", []*csi.VolumeCapability{block}, "force", "", codes.OK},
+		{"invalid", []*csi.VolumeCapability{mount}, "repair", "", codes.InvalidArgument},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parameters := map[string]string{topolvm.GetFsckPolicyKey(): tc.policy}
+			actual, err := volumeFsckPolicy(tc.capabilities, parameters)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("expected code %s, but got %v", tc.code, err)
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %q, but got %q", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func Test_volumeFilesystem(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	ns := &v1.NodeStorage{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1.NodeStorageStatus{
+			DeviceClasses: []v1.DeviceClassStorageStatus{{
+				Name:        "ssd",
+				Default:     true,
+				FsType:      "xfs",
+				MkfsOptions: map[string][]string{"xfs": {"-d", "su=64k,sw=4"}},
+			}},
+		},
+	}
+	s := controllerServerNoLocked{
+		reader: fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns).Build(),
+	}
+	mount := func(fsType string) []*csi.VolumeCapability {
+		return []*csi.VolumeCapability{{AccessType: &csi.VolumeCapability_Mount{Mount: &csi.VolumeCapability_MountVolume{FsType: fsType}}}}
+	}
+	block := []*csi.VolumeCapability{{AccessType: &csi.VolumeCapability_Block{Block: &csi.VolumeCapability_BlockVolume{}}}}
+
+	testCases := []struct {
+		name         string
+		capabilities []*csi.VolumeCapability
+		node         string
+		mkfsOptions  string
+		fsType       string
+		options      []string
+		code         codes.Code
+	}{
+		{"device class defaults", mount(""), "node1", "", "xfs", []string{"-d", "su=64k,sw=4"}, codes.OK},
+		{"storage class options", mount(""), "node1", "-m reflink=1", "xfs", []string{"-d", "su=64k,sw=4", "-m", "reflink=1"}, codes.OK},
+		{"other filesystem", mount("ext4"), "node1", "-E lazy_itable_init=1", "ext4", []string{"-E", "lazy_itable_init=1"}, codes.OK},
+		{"no NodeStorage", mount(""), "node2", "", "ext4", nil, codes.OK},
+		{"block", block, "node1", "-m reflink=1", "", nil, codes.OK},
+		{"invalid options", mount(""), "node1", "-m", "", nil, codes.InvalidArgument},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			parameters := map[string]string{topolvm.GetMkfsOptionsKey(): tc.mkfsOptions}
+			fsType, options, err := s.volumeFilesystem(context.Background(), tc.capabilities, tc.node, "", parameters)
+			if code := status.Code(err); code != tc.code {
+				t.Fatalf("expected code %s, but got %v", tc.code, err)
+			}
+			if fsType != tc.fsType || !slices.Equal(options, tc.options) {
+				t.Errorf("expected %q %v, but got %q %v", tc.fsType, tc.options, fsType, options)
+			}
+		})
+	}
+}
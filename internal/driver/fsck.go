@@ -0,0 +1,89 @@
+package driver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// fsckTimeout is the longest time a filesystem check may take.
+	fsckTimeout = time.Hour
+
+	// fsckWait is how long NodePublishVolume waits for a check to finish before returning Unavailable.
+	fsckWait = 3 * time.Second
+
+	// fsckResultTTL is how long the result of a finished check is kept for the retry of NodePublishVolume.
+	// An older result is discarded and the volume is checked again.
+	fsckResultTTL = 10 * time.Minute
+)
+
+// fsckTracker runs the filesystem checks in the background so that the global lock of nodeServer is not held
+// while they run. Each volume is checked by one check at a time.
+type fsckTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*fsckJob
+	now  func() time.Time
+}
+
+type fsckJob struct {
+	done       chan struct{}
+	err        error
+	finishedAt time.Time
+}
+
+func newFsckTracker() *fsckTracker {
+	return &fsckTracker{
+		jobs: map[string]*fsckJob{},
+		now:  time.Now,
+	}
+}
+
+// run starts check of the volume in the background unless it is running, and waits for it for a while.
+// It returns Unavailable while the check is running, so that NodePublishVolume is retried.
+// The result of a finished check is returned once, and the next call starts a new check.
+func (t *fsckTracker) run(volumeID string, check func(ctx context.Context) error) error {
+	t.mu.Lock()
+	job, ok := t.jobs[volumeID]
+	if ok && job.expired(t.now()) {
+		delete(t.jobs, volumeID)
+		ok = false
+	}
+	if !ok {
+		job = &fsckJob{done: make(chan struct{})}
+		t.jobs[volumeID] = job
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), fsckTimeout)
+			defer cancel()
+			err := check(ctx)
+
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			job.err = err
+			job.finishedAt = t.now()
+			close(job.done)
+		}()
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-job.done:
+	case <-time.After(fsckWait):
+		return status.Errorf(codes.Unavailable, "filesystem check is in progress: volume=%s", volumeID)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.jobs[volumeID] == job {
+		delete(t.jobs, volumeID)
+	}
+	return job.err
+}
+
+// expired returns true if the job has finished long ago. It must be called with the lock held.
+func (j *fsckJob) expired(now time.Time) bool {
+	return !j.finishedAt.IsZero() && now.Sub(j.finishedAt) > fsckResultTTL
+}
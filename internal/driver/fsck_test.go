@@ -0,0 +1,61 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFsckTrackerReturnsQuickResult(t *testing.T) {
+	tr := newFsckTracker()
+	expected := errors.New("corrupted")
+
+	err := tr.run("vol", func(ctx context.Context) error { return expected })
+	if !errors.Is(err, expected) {
+		t.Errorf("expected %v, but got %v", expected, err)
+	}
+	if len(tr.jobs) != 0 {
+		t.Error("the result should be consumed")
+	}
+}
+
+func TestFsckTrackerReturnsUnavailableWhileRunning(t *testing.T) {
+	tr := newFsckTracker()
+	release := make(chan struct{})
+	calls := 0
+	check := func(ctx context.Context) error {
+		calls++
+		<-release
+		return nil
+	}
+
+	err := tr.run("vol", check)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable, but got %v", err)
+	}
+
+	close(release)
+	<-tr.jobs["vol"].done
+	if err := tr.run("vol", check); err != nil {
+		t.Errorf("expected the result of the finished check, but got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("the check should run once, but ran %d times", calls)
+	}
+}
+
+func TestFsckTrackerDiscardsExpiredResult(t *testing.T) {
+	now := time.Now()
+	tr := newFsckTracker()
+	tr.now = func() time.Time { return now }
+	tr.jobs["vol"] = &fsckJob{done: make(chan struct{}), err: errors.New("stale"), finishedAt: now.Add(-fsckResultTTL - time.Second)}
+	close(tr.jobs["vol"].done)
+
+	if err := tr.run("vol", func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("the volume should be checked again, but got %v", err)
+	}
+}
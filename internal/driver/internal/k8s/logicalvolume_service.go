@@ -153,15 +153,21 @@ func NewLogicalVolumeService(mgr manager.Manager) (*LogicalVolumeService, error)
 	}, nil
 }
 
-// CreateVolume creates volume
-func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, name, sourceName string, requestBytes int64) (*topolvmv1.LogicalVolume, error) {
-	logger.Info("k8s.CreateVolume called", "name", name, "node", node, "size", requestBytes, "sourceName", sourceName)
+// CreateVolume creates volume.
+// fsType is the filesystem the volume is formatted with when it is published, and is empty for a block volume.
+// mkfsOptions are the extra arguments of mkfs for the first format.
+// fsckPolicy is the policy of the filesystem check before the volume is mounted.
+func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, name, namespace, sourceName, fsType string, mkfsOptions []string,
+	fsckPolicy string, requestBytes int64) (*topolvmv1.LogicalVolume, error) {
+	logger.Info("k8s.CreateVolume called", "name", name, "namespace", namespace, "node", node, "size", requestBytes, "sourceName", sourceName,
+		"fsType", fsType, "mkfsOptions", mkfsOptions, "fsckPolicy", fsckPolicy)
 	var lv *topolvmv1.LogicalVolume
 	// if the create volume request has no source, proceed with regular lv creation.
 	if sourceName == "" {
 		lv = &topolvmv1.LogicalVolume{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: name,
+				Name:   name,
+				Labels: namespaceLabels(namespace),
 			},
 			Spec: topolvmv1.LogicalVolumeSpec{
 				Name:                name,
@@ -169,6 +175,9 @@ func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, n
 				DeviceClass:         dc,
 				LvcreateOptionClass: oc,
 				Size:                *resource.NewQuantity(requestBytes, resource.BinarySI),
+				FsType:              fsType,
+				MkfsOptions:         mkfsOptions,
+				FsckPolicy:          fsckPolicy,
 			},
 		}
 
@@ -176,7 +185,8 @@ func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, n
 		// On the other hand, if a volume has a datasource, create a thin snapshot of the source volume with READ-WRITE access for volume cloning.
 		lv = &topolvmv1.LogicalVolume{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: name,
+				Name:   name,
+				Labels: namespaceLabels(namespace),
 			},
 			Spec: topolvmv1.LogicalVolumeSpec{
 				Name:                name,
@@ -186,6 +196,7 @@ func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, n
 				Size:                *resource.NewQuantity(requestBytes, resource.BinarySI),
 				Source:              sourceName,
 				AccessType:          "rw",
+				FsckPolicy:          fsckPolicy,
 			},
 		}
 	}
@@ -193,6 +204,37 @@ func (s *LogicalVolumeService) CreateVolume(ctx context.Context, node, dc, oc, n
 	return s.createAndWait(ctx, lv)
 }
 
+// CopyVolume creates a volume on the node and copies the data of the source LogicalVolume, which may be on another node, into it.
+// It returns after the copy completes.
+func (s *LogicalVolumeService) CopyVolume(ctx context.Context, node, dc, oc, name, namespace, copySource string, requestBytes int64) (*topolvmv1.LogicalVolume, error) {
+	logger.Info("k8s.CopyVolume called", "name", name, "namespace", namespace, "node", node, "size", requestBytes, "copySource", copySource)
+	lv := &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: namespaceLabels(namespace),
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:                name,
+			NodeName:            node,
+			DeviceClass:         dc,
+			LvcreateOptionClass: oc,
+			Size:                *resource.NewQuantity(requestBytes, resource.BinarySI),
+			CopySource:          copySource,
+		},
+	}
+
+	return s.createAndWait(ctx, lv)
+}
+
+// namespaceLabels returns the labels of a LogicalVolume created for an object in the namespace.
+// The namespace is empty if external-provisioner or external-snapshotter does not run with --extra-create-metadata.
+func namespaceLabels(namespace string) map[string]string {
+	if namespace == "" {
+		return nil
+	}
+	return map[string]string{topolvm.GetNamespaceKey(): namespace}
+}
+
 // DeleteVolume deletes volume
 func (s *LogicalVolumeService) DeleteVolume(ctx context.Context, volumeID string) error {
 	logger.Info("k8s.DeleteVolume called", "volumeID", volumeID)
@@ -235,11 +277,12 @@ func (s *LogicalVolumeService) DeleteVolume(ctx context.Context, volumeID string
 }
 
 // CreateSnapshot creates a snapshot of existing volume.
-func (s *LogicalVolumeService) CreateSnapshot(ctx context.Context, node, dc, sourceVol, sname, accessType string, snapSize resource.Quantity) (*topolvmv1.LogicalVolume, error) {
-	logger.Info("CreateSnapshot called", "name", sname)
+func (s *LogicalVolumeService) CreateSnapshot(ctx context.Context, node, dc, sourceVol, sname, namespace, accessType string, snapSize resource.Quantity) (*topolvmv1.LogicalVolume, error) {
+	logger.Info("CreateSnapshot called", "name", sname, "namespace", namespace)
 	snapshotLV := &topolvmv1.LogicalVolume{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: sname,
+			Name:   sname,
+			Labels: namespaceLabels(namespace),
 		},
 		Spec: topolvmv1.LogicalVolumeSpec{
 			Name:        sname,
@@ -315,6 +358,32 @@ func (s *LogicalVolumeService) GetVolume(ctx context.Context, volumeID string) (
 	return s.volumeGetter.Get(ctx, volumeID)
 }
 
+// UpdateFilesystemUsage records the usage of the filesystem in the status of LogicalVolume.
+// To reduce API calls, the status is kept if the total size is unchanged and
+// the used size differs less than 1% of the total size.
+func (s *LogicalVolumeService) UpdateFilesystemUsage(ctx context.Context, lv *topolvmv1.LogicalVolume, usage *topolvmv1.FilesystemUsage) error {
+	if old := lv.Status.FilesystemUsage; old != nil && old.Total.Cmp(usage.Total) == 0 {
+		diff := usage.Used.Value() - old.Used.Value()
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff*100 < usage.Total.Value() {
+			return nil
+		}
+	}
+
+	patch := client.MergeFrom(lv.DeepCopy())
+	lv.Status.FilesystemUsage = usage
+	return s.writer.Status().Patch(ctx, lv, patch)
+}
+
+// UpdateFsckStatus records the result of the filesystem check in the status of LogicalVolume.
+func (s *LogicalVolumeService) UpdateFsckStatus(ctx context.Context, lv *topolvmv1.LogicalVolume, fsck *topolvmv1.FsckStatus) error {
+	patch := client.MergeFrom(lv.DeepCopy())
+	lv.Status.Fsck = fsck
+	return s.writer.Status().Patch(ctx, lv, patch)
+}
+
 // updateSpecSize updates .Spec.Size of LogicalVolume.
 func (s *LogicalVolumeService) updateSpecSize(ctx context.Context, volumeID string, size *resource.Quantity) error {
 	return wait.ExponentialBackoffWithContext(ctx,
@@ -9,9 +9,11 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
 	"github.com/topolvm/topolvm/internal/driver/internal/k8s"
 	"github.com/topolvm/topolvm/internal/filesystem"
 	"github.com/topolvm/topolvm/internal/lvmd/command"
@@ -19,6 +21,10 @@ import (
 	"golang.org/x/sys/unix"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/events"
 	mountutil "k8s.io/mount-utils"
 	utilexec "k8s.io/utils/exec"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -44,6 +50,8 @@ func NewNodeServer(nodeName string, vgServiceClient proto.VGServiceClient, lvSer
 			client:       vgServiceClient,
 			lvService:    lvServiceClient,
 			k8sLVService: lvService,
+			recorder:     mgr.GetEventRecorder("topolvm-node"),
+			fsck:         newFsckTracker(),
 			mounter: mountutil.SafeFormatAndMount{
 				Interface: mountutil.New(""),
 				Exec:      utilexec.New(),
@@ -111,6 +119,8 @@ type nodeServerNoLocked struct {
 	client       proto.VGServiceClient
 	lvService    proto.LVServiceClient
 	k8sLVService *k8s.LogicalVolumeService
+	recorder     events.EventRecorder
+	fsck         *fsckTracker
 	mounter      mountutil.SafeFormatAndMount
 }
 
@@ -157,6 +167,9 @@ func (s *nodeServerNoLocked) NodePublishVolume(ctx context.Context, req *csi.Nod
 	if err != nil {
 		return nil, err
 	}
+	if lvr.IsReverting() {
+		return nil, status.Errorf(codes.Unavailable, "volume is being reverted to snapshot %s: volume=%s", lvr.Spec.RevertSnapshot, volumeID)
+	}
 	lv, err = s.getLvFromContext(ctx, lvr.Spec.DeviceClass, volumeID)
 	if err != nil {
 		return nil, err
@@ -168,7 +181,7 @@ func (s *nodeServerNoLocked) NodePublishVolume(ctx context.Context, req *csi.Nod
 	if isBlockVol {
 		err = s.nodePublishBlockVolume(req, lv)
 	} else if isFsVol {
-		err = s.nodePublishFilesystemVolume(req, lv)
+		err = s.nodePublishFilesystemVolume(ctx, req, lv, lvr)
 	}
 	if err != nil {
 		return nil, err
@@ -199,9 +212,14 @@ func toReadOnlyMountOption(readOnly bool) []string {
 	return map[bool][]string{true: {"ro"}, false: nil}[readOnly]
 }
 
-func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVolumeRequest, lv *proto.LogicalVolume) error {
+func (s *nodeServerNoLocked) nodePublishFilesystemVolume(ctx context.Context, req *csi.NodePublishVolumeRequest, lv *proto.LogicalVolume,
+	lvr *topolvmv1.LogicalVolume) error {
 	// Check request
 	mountOption := req.GetVolumeCapability().GetMount()
+	if mountOption.FsType == "" {
+		// the default filesystem of the device class is recorded in the LogicalVolume.
+		mountOption.FsType = lvr.Spec.FsType
+	}
 	if mountOption.FsType == "" {
 		mountOption.FsType = "ext4"
 	}
@@ -243,6 +261,15 @@ func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVol
 				"volume", req.GetVolumeId(),
 				"output", string(out))
 			mountFunc = s.mounter.FormatAndMount
+			if len(lvr.Spec.MkfsOptions) > 0 {
+				// mount-utils puts the format options before its own ones, which would override them.
+				if err := s.format(req.GetVolumeId(), lv.GetPath(), mountOption.FsType, lvr.Spec.MkfsOptions); err != nil {
+					return err
+				}
+				mountFunc = s.mounter.Mount
+			}
+		} else if err := s.checkFilesystem(ctx, req.GetVolumeId(), lv.GetPath(), fsType, lvr); err != nil {
+			return err
 		}
 		if err := mountFunc(lv.GetPath(), req.GetTargetPath(), mountOption.FsType, mountOptions); err != nil {
 			return status.Errorf(codes.Internal, "mount failed: volume=%s, error=%v", req.GetVolumeId(), err)
@@ -269,6 +296,147 @@ func (s *nodeServerNoLocked) nodePublishFilesystemVolume(req *csi.NodePublishVol
 	return nil
 }
 
+// format creates the filesystem on the device with the extra arguments of mkfs.
+func (s *nodeServerNoLocked) format(volumeID, device, fsType string, options []string) error {
+	args := filesystem.MkfsArgs(fsType, options, device)
+	out, err := s.mounter.Exec.Command("mkfs."+fsType, args...).CombinedOutput()
+	if err != nil {
+		return status.Errorf(codes.Internal, "mkfs failed: volume=%s, fstype=%s, args=%v, output=%s, error=%v",
+			volumeID, fsType, args, string(out), err)
+	}
+	nodeLogger.Info("mkfs succeeded",
+		"volume", volumeID,
+		"fstype", fsType,
+		"args", args)
+	return nil
+}
+
+// checkFilesystem checks the filesystem on the device by the fsck policy of the volume unless the device is mounted.
+// The check runs in the background, and Unavailable is returned until it finishes.
+// It returns an error if the filesystem should not be mounted, i.e. the policy repairs the filesystem but it is
+// still corrupted or the check failed.
+func (s *nodeServerNoLocked) checkFilesystem(ctx context.Context, volumeID, device, fsType string, lvr *topolvmv1.LogicalVolume) error {
+	policy := lvr.Spec.FsckPolicy
+	if policy == "" || policy == filesystem.FsckNever {
+		return nil
+	}
+	mounted, err := s.isDeviceMounted(device)
+	if err != nil {
+		return status.Errorf(codes.Internal, "mount check failed: volume=%s, error=%v", volumeID, err)
+	}
+	if mounted {
+		return nil
+	}
+
+	command, args, err := filesystem.FsckCommand(fsType, policy, device)
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "fsck failed: volume=%s, error=%v", volumeID, err)
+	}
+	lvr = lvr.DeepCopy()
+	return s.fsck.run(volumeID, func(ctx context.Context) error {
+		return s.runFsck(ctx, volumeID, device, fsType, policy, command, args, lvr)
+	})
+}
+
+// runFsck runs the command of the filesystem check, and records the result in the status of LogicalVolume
+// and as an Event.
+func (s *nodeServerNoLocked) runFsck(ctx context.Context, volumeID, device, fsType, policy, command string, args []string,
+	lvr *topolvmv1.LogicalVolume) error {
+	var out []byte
+	var err error
+	result := filesystem.FsckClean
+	if filesystem.NeedsLogReplay(fsType) {
+		if err := s.replayLog(device, fsType); err != nil {
+			result = filesystem.FsckFailed
+			out = []byte(err.Error())
+		}
+	}
+	if result == filesystem.FsckClean {
+		out, err = s.mounter.Exec.CommandContext(ctx, command, args...).CombinedOutput()
+		var exitErr utilexec.ExitError
+		switch {
+		case err == nil:
+		case errors.As(err, &exitErr):
+			result = filesystem.FsckResult(fsType, policy, exitErr.ExitStatus())
+		default:
+			result = filesystem.FsckFailed
+			out = []byte(err.Error())
+		}
+	}
+	message := lastLine(string(out))
+	nodeLogger.Info("fsck finished",
+		"volume", volumeID,
+		"command", command,
+		"args", args,
+		"result", result,
+		"output", string(out))
+
+	fsck := &topolvmv1.FsckStatus{
+		Policy:    policy,
+		Result:    result,
+		Message:   message,
+		CheckedAt: metav1.Now(),
+	}
+	if err := s.k8sLVService.UpdateFsckStatus(ctx, lvr, fsck); err != nil {
+		nodeLogger.Error(err, "failed to update the fsck status", "volume", volumeID)
+	}
+	eventType := corev1.EventTypeNormal
+	if result == filesystem.FsckCorrupted || result == filesystem.FsckFailed {
+		eventType = corev1.EventTypeWarning
+	}
+	s.recorder.Eventf(lvr, nil, eventType, "Fsck"+result, "Fsck", "%s check with policy %s: %s", fsType, policy, message)
+
+	if eventType == corev1.EventTypeWarning && policy != filesystem.FsckCheckOnly {
+		return status.Errorf(codes.FailedPrecondition, "filesystem is not mounted because fsck result is %s: volume=%s, output=%s",
+			result, volumeID, message)
+	}
+	return nil
+}
+
+// replayLog mounts the filesystem on a temporary directory and unmounts it to replay its log.
+func (s *nodeServerNoLocked) replayLog(device, fsType string) error {
+	dir, err := os.MkdirTemp("", "topolvm-fsck-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(dir) }()
+
+	if err := s.mounter.Mount(device, dir, fsType, []string{"nouuid"}); err != nil {
+		return fmt.Errorf("failed to mount to replay the log: %w", err)
+	}
+	if err := s.mounter.Unmount(dir); err != nil {
+		return fmt.Errorf("failed to unmount after replaying the log: %w", err)
+	}
+	return nil
+}
+
+// isDeviceMounted returns true if the device is mounted anywhere on the node.
+func (s *nodeServerNoLocked) isDeviceMounted(device string) (bool, error) {
+	target, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return false, err
+	}
+	mountPoints, err := s.mounter.List()
+	if err != nil {
+		return false, err
+	}
+	for _, mp := range mountPoints {
+		if !strings.HasPrefix(mp.Device, "/") {
+			continue
+		}
+		if resolved, err := filepath.EvalSymlinks(mp.Device); err == nil && resolved == target {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lastLine returns the last non-empty line of the output, which summarizes the result of fsck.
+func lastLine(out string) string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
 func (s *nodeServerNoLocked) nodePublishBlockVolume(req *csi.NodePublishVolumeRequest, lv *proto.LogicalVolume) error {
 	// Find lv and create a block device with it
 	// We mount via bind mount so that we can also respect the readonly flag
@@ -452,6 +620,28 @@ func (s *nodeServerNoLocked) NodeGetVolumeStats(ctx context.Context, req *csi.No
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	if fsck := lvr.Status.Fsck; !volumeCondition.Abnormal && fsck != nil &&
+		(fsck.Result == filesystem.FsckCorrupted || fsck.Result == filesystem.FsckFailed) {
+		volumeCondition = &csi.VolumeCondition{
+			Abnormal: true,
+			Message:  fmt.Sprintf("fsck result is %s at %s: %s", fsck.Result, fsck.CheckedAt.UTC().Format(time.RFC3339), fsck.Message),
+		}
+	}
+
+	// Report the usage for the automatic expansion by topolvm-controller.
+	// A failure is only logged not to break the volume stats of kubelet.
+	if sfs.Blocks > 0 {
+		bytes := usage[0]
+		fsUsage := &topolvmv1.FilesystemUsage{
+			Total:      *resource.NewQuantity(bytes.Total, resource.BinarySI),
+			Used:       *resource.NewQuantity(bytes.Used, resource.BinarySI),
+			Available:  *resource.NewQuantity(bytes.Available, resource.BinarySI),
+			ObservedAt: metav1.Now(),
+		}
+		if err := s.k8sLVService.UpdateFilesystemUsage(ctx, lvr, fsUsage); err != nil {
+			nodeLogger.Error(err, "failed to update the filesystem usage", "volume_id", volumeID)
+		}
+	}
 
 	return &csi.NodeGetVolumeStatsResponse{Usage: usage, VolumeCondition: volumeCondition}, nil
 }
@@ -0,0 +1,181 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	clientwrapper "github.com/topolvm/topolvm/internal/client"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var snapshotMetadataLogger = ctrl.Log.WithName("driver").WithName("snapshot-metadata")
+
+// NodeDialer returns a client of the replication API of lvmd on the node and a function to close it.
+type NodeDialer func(ctx context.Context, nodeName string) (proto.ReplicationServiceClient, func() error, error)
+
+// NewSnapshotMetadataServer returns a new SnapshotMetadataServer.
+// It is served by topolvm-controller because the external-snapshot-metadata sidecar connects to
+// only one endpoint of the driver. It finds the node of the snapshots and relays the block ranges
+// streamed by lvmd on the node through the replication API, which is called by dialNode.
+func NewSnapshotMetadataServer(mgr manager.Manager, dialNode NodeDialer) csi.SnapshotMetadataServer {
+	return &snapshotMetadataServer{
+		client:   clientwrapper.NewWrappedClient(mgr.GetClient()),
+		dialNode: dialNode,
+	}
+}
+
+type snapshotMetadataServer struct {
+	csi.UnimplementedSnapshotMetadataServer
+
+	client   client.Reader
+	dialNode NodeDialer
+}
+
+func (s *snapshotMetadataServer) GetMetadataAllocated(req *csi.GetMetadataAllocatedRequest, server csi.SnapshotMetadata_GetMetadataAllocatedServer) error {
+	ctx := server.Context()
+	snapshotMetadataLogger.Info("GetMetadataAllocated called",
+		"snapshot_id", req.GetSnapshotId(),
+		"starting_offset", req.GetStartingOffset(),
+		"max_results", req.GetMaxResults())
+
+	if len(req.GetSnapshotId()) == 0 {
+		return status.Error(codes.InvalidArgument, "no snapshot_id is provided")
+	}
+	if err := validateMetadataRange(req.GetStartingOffset(), req.GetMaxResults()); err != nil {
+		return err
+	}
+	snapshot, err := s.findSnapshot(ctx, req.GetSnapshotId())
+	if err != nil {
+		return err
+	}
+
+	return s.stream(ctx, snapshot.Spec.NodeName, &proto.GetLVBlockMetadataRequest{
+		Name:           req.GetSnapshotId(),
+		DeviceClass:    snapshot.Spec.DeviceClass,
+		StartingOffset: uint64(req.GetStartingOffset()),
+		MaxResults:     req.GetMaxResults(),
+	}, func(capacity int64, metadata []*csi.BlockMetadata) error {
+		return server.Send(&csi.GetMetadataAllocatedResponse{
+			BlockMetadataType:   csi.BlockMetadataType_VARIABLE_LENGTH,
+			VolumeCapacityBytes: capacity,
+			BlockMetadata:       metadata,
+		})
+	})
+}
+
+func (s *snapshotMetadataServer) GetMetadataDelta(req *csi.GetMetadataDeltaRequest, server csi.SnapshotMetadata_GetMetadataDeltaServer) error {
+	ctx := server.Context()
+	snapshotMetadataLogger.Info("GetMetadataDelta called",
+		"base_snapshot_id", req.GetBaseSnapshotId(),
+		"target_snapshot_id", req.GetTargetSnapshotId(),
+		"starting_offset", req.GetStartingOffset(),
+		"max_results", req.GetMaxResults())
+
+	if len(req.GetBaseSnapshotId()) == 0 {
+		return status.Error(codes.InvalidArgument, "no base_snapshot_id is provided")
+	}
+	if len(req.GetTargetSnapshotId()) == 0 {
+		return status.Error(codes.InvalidArgument, "no target_snapshot_id is provided")
+	}
+	if err := validateMetadataRange(req.GetStartingOffset(), req.GetMaxResults()); err != nil {
+		return err
+	}
+	base, err := s.findSnapshot(ctx, req.GetBaseSnapshotId())
+	if err != nil {
+		return err
+	}
+	target, err := s.findSnapshot(ctx, req.GetTargetSnapshotId())
+	if err != nil {
+		return err
+	}
+	if base.Spec.Source != target.Spec.Source || base.Spec.DeviceClass != target.Spec.DeviceClass ||
+		base.Spec.NodeName != target.Spec.NodeName {
+		return status.Errorf(codes.InvalidArgument, "snapshots are not taken from the same volume: base=%s, target=%s",
+			req.GetBaseSnapshotId(), req.GetTargetSnapshotId())
+	}
+
+	return s.stream(ctx, target.Spec.NodeName, &proto.GetLVBlockMetadataRequest{
+		Name:           req.GetTargetSnapshotId(),
+		BaseName:       req.GetBaseSnapshotId(),
+		DeviceClass:    target.Spec.DeviceClass,
+		StartingOffset: uint64(req.GetStartingOffset()),
+		MaxResults:     req.GetMaxResults(),
+	}, func(capacity int64, metadata []*csi.BlockMetadata) error {
+		return server.Send(&csi.GetMetadataDeltaResponse{
+			BlockMetadataType:   csi.BlockMetadataType_VARIABLE_LENGTH,
+			VolumeCapacityBytes: capacity,
+			BlockMetadata:       metadata,
+		})
+	})
+}
+
+func validateMetadataRange(startingOffset int64, maxResults int32) error {
+	if startingOffset < 0 {
+		return status.Errorf(codes.InvalidArgument, "starting_offset must not be negative: %d", startingOffset)
+	}
+	if maxResults < 0 {
+		return status.Errorf(codes.InvalidArgument, "max_results must not be negative: %d", maxResults)
+	}
+	return nil
+}
+
+// findSnapshot returns the LogicalVolume of the snapshot.
+func (s *snapshotMetadataServer) findSnapshot(ctx context.Context, snapshotID string) (*topolvmv1.LogicalVolume, error) {
+	lvList := new(topolvmv1.LogicalVolumeList)
+	if err := s.client.List(ctx, lvList); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	for i := range lvList.Items {
+		lv := &lvList.Items[i]
+		if lv.Status.VolumeID != snapshotID {
+			continue
+		}
+		if lv.Spec.Source == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "volume is not a snapshot: %s", snapshotID)
+		}
+		return lv, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "snapshot is not found: %s", snapshotID)
+}
+
+// stream relays the responses of GetLVBlockMetadata of lvmd on the node to send.
+func (s *snapshotMetadataServer) stream(ctx context.Context, nodeName string, req *proto.GetLVBlockMetadataRequest,
+	send func(capacity int64, metadata []*csi.BlockMetadata) error) error {
+	replicationClient, closeFunc, err := s.dialNode(ctx, nodeName)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to connect to lvmd on node %s: %v", nodeName, err)
+	}
+	defer func() { _ = closeFunc() }()
+	stream, err := replicationClient.GetLVBlockMetadata(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		res, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		metadata := make([]*csi.BlockMetadata, 0, len(res.GetRanges()))
+		for _, r := range res.GetRanges() {
+			metadata = append(metadata, &csi.BlockMetadata{
+				ByteOffset: int64(r.GetOffset()),
+				SizeBytes:  int64(r.GetLength()),
+			})
+		}
+		if err := send(int64(res.GetSizeBytes()), metadata); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package driver
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type replicationClientMock struct {
+	proto.ReplicationServiceClient
+	nodes     []string
+	requests  []*proto.GetLVBlockMetadataRequest
+	responses []*proto.GetLVBlockMetadataResponse
+}
+
+func (c *replicationClientMock) GetLVBlockMetadata(_ context.Context, in *proto.GetLVBlockMetadataRequest, _ ...grpc.CallOption) (proto.ReplicationService_GetLVBlockMetadataClient, error) {
+	c.requests = append(c.requests, in)
+	return &blockMetadataClientMock{responses: c.responses}, nil
+}
+
+type blockMetadataClientMock struct {
+	grpc.ClientStream
+	responses []*proto.GetLVBlockMetadataResponse
+}
+
+func (c *blockMetadataClientMock) Recv() (*proto.GetLVBlockMetadataResponse, error) {
+	if len(c.responses) == 0 {
+		return nil, io.EOF
+	}
+	res := c.responses[0]
+	c.responses = c.responses[1:]
+	return res, nil
+}
+
+type metadataDeltaServerMock struct {
+	grpc.ServerStream
+	responses []*csi.GetMetadataDeltaResponse
+}
+
+func (s *metadataDeltaServerMock) Context() context.Context {
+	return context.Background()
+}
+
+func (s *metadataDeltaServerMock) Send(res *csi.GetMetadataDeltaResponse) error {
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func newSnapshotMetadataServerForTest(t *testing.T, replicationClient *replicationClientMock) *snapshotMetadataServer {
+	scheme := runtime.NewScheme()
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := func(name, node, source string) *topolvmv1.LogicalVolume {
+		return &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: name, NodeName: node, DeviceClass: "thin", Source: source},
+			Status:     topolvmv1.LogicalVolumeStatus{VolumeID: name + "-id"},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		snapshot("snap1", "node1", "vol"),
+		snapshot("snap2", "node1", "vol"),
+		snapshot("snap3", "node1", "other-vol"),
+		snapshot("snap4", "node2", "vol"),
+		snapshot("snap5", "node2", "vol2"),
+		snapshot("snap6", "node2", "vol2"),
+		snapshot("vol", "node1", ""),
+	).WithStatusSubresource(&topolvmv1.LogicalVolume{}).Build()
+	dialNode := func(_ context.Context, nodeName string) (proto.ReplicationServiceClient, func() error, error) {
+		replicationClient.nodes = append(replicationClient.nodes, nodeName)
+		return replicationClient, func() error { return nil }, nil
+	}
+	return &snapshotMetadataServer{client: c, dialNode: dialNode}
+}
+
+func TestGetMetadataDelta(t *testing.T) {
+	replicationClient := &replicationClientMock{
+		responses: []*proto.GetLVBlockMetadataResponse{
+			{SizeBytes: 1 << 30, Ranges: []*proto.BlockRange{{Offset: 0, Length: 65536}, {Offset: 1 << 20, Length: 65536}}},
+			{SizeBytes: 1 << 30, Ranges: []*proto.BlockRange{{Offset: 2 << 20, Length: 131072}}},
+		},
+	}
+	s := newSnapshotMetadataServerForTest(t, replicationClient)
+
+	server := &metadataDeltaServerMock{}
+	err := s.GetMetadataDelta(&csi.GetMetadataDeltaRequest{
+		BaseSnapshotId:   "snap5-id",
+		TargetSnapshotId: "snap6-id",
+		StartingOffset:   4096,
+		MaxResults:       2,
+	}, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(replicationClient.nodes) != 1 || replicationClient.nodes[0] != "node2" {
+		t.Errorf("lvmd on node2 should be called: %v", replicationClient.nodes)
+	}
+	if len(replicationClient.requests) != 1 {
+		t.Fatalf("unexpected requests: %v", replicationClient.requests)
+	}
+	req := replicationClient.requests[0]
+	if req.GetName() != "snap6-id" || req.GetBaseName() != "snap5-id" || req.GetDeviceClass() != "thin" ||
+		req.GetStartingOffset() != 4096 || req.GetMaxResults() != 2 {
+		t.Errorf("unexpected request: %v", req)
+	}
+
+	if len(server.responses) != 2 {
+		t.Fatalf("expected 2 responses: %v", server.responses)
+	}
+	res := server.responses[1]
+	if res.GetBlockMetadataType() != csi.BlockMetadataType_VARIABLE_LENGTH {
+		t.Errorf("unexpected type: %v", res.GetBlockMetadataType())
+	}
+	if res.GetVolumeCapacityBytes() != 1<<30 {
+		t.Errorf("unexpected capacity: %d", res.GetVolumeCapacityBytes())
+	}
+	if len(res.GetBlockMetadata()) != 1 || res.GetBlockMetadata()[0].GetByteOffset() != 2<<20 ||
+		res.GetBlockMetadata()[0].GetSizeBytes() != 131072 {
+		t.Errorf("unexpected metadata: %v", res.GetBlockMetadata())
+	}
+}
+
+func TestGetMetadataDeltaInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		base   string
+		target string
+		offset int64
+		code   codes.Code
+	}{
+		{name: "no base", target: "snap2-id", code: codes.InvalidArgument},
+		{name: "negative offset", base: "snap1-id", target: "snap2-id", offset: -1, code: codes.InvalidArgument},
+		{name: "unknown snapshot", base: "unknown", target: "snap2-id", code: codes.NotFound},
+		{name: "not a snapshot", base: "vol-id", target: "snap2-id", code: codes.InvalidArgument},
+		{name: "another node", base: "snap1-id", target: "snap4-id", code: codes.InvalidArgument},
+		{name: "another source", base: "snap1-id", target: "snap3-id", code: codes.InvalidArgument},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			replicationClient := &replicationClientMock{}
+			s := newSnapshotMetadataServerForTest(t, replicationClient)
+			err := s.GetMetadataDelta(&csi.GetMetadataDeltaRequest{
+				BaseSnapshotId:   tc.base,
+				TargetSnapshotId: tc.target,
+				StartingOffset:   tc.offset,
+			}, &metadataDeltaServerMock{})
+			if status.Code(err) != tc.code {
+				t.Errorf("expected %s: %v", tc.code, err)
+			}
+			if len(replicationClient.nodes) != 0 {
+				t.Errorf("lvmd should not be called: %v", replicationClient.nodes)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package filesystem
+
+import (
+	"fmt"
+)
+
+// Policies of the filesystem check before a volume is mounted.
+const (
+	// FsckNever does not check the filesystem.
+	FsckNever = "never"
+	// FsckCheckOnly checks the filesystem without modifying it.
+	FsckCheckOnly = "check-only"
+	// FsckAutoRepairSafe repairs the problems which can be fixed without human intervention.
+	FsckAutoRepairSafe = "auto-repair-safe"
+	// FsckForce checks the whole filesystem even if it is marked clean, and repairs all the problems.
+	FsckForce = "force"
+)
+
+// Results of the filesystem check.
+const (
+	// FsckClean means no problem was found.
+	FsckClean = "Clean"
+	// FsckRepaired means the problems were found and repaired.
+	FsckRepaired = "Repaired"
+	// FsckCorrupted means the problems were found and left unrepaired.
+	FsckCorrupted = "Corrupted"
+	// FsckFailed means the check itself failed.
+	FsckFailed = "Failed"
+)
+
+// ValidateFsckPolicy returns an error if the policy is unknown.
+// An empty policy is the same as FsckNever.
+func ValidateFsckPolicy(policy string) error {
+	switch policy {
+	case "", FsckNever, FsckCheckOnly, FsckAutoRepairSafe, FsckForce:
+		return nil
+	}
+	return fmt.Errorf("unsupported fsck policy: %q", policy)
+}
+
+// NeedsLogReplay returns true if the log of the filesystem must be replayed by mounting it before it is checked.
+// xfs_repair refuses a dirty log without -n, and reports spurious corruption ignoring it with -n.
+func NeedsLogReplay(fsType string) bool {
+	return fsType == "xfs"
+}
+
+// FsckCommand returns the command and its arguments to check the filesystem on the device by the policy.
+// btrfs is only checked read-only because `btrfs check --repair` may damage the filesystem further.
+// xfs is not repaired by auto-repair-safe because xfs_repair may discard the metadata it cannot fix.
+// For force, -e makes xfs_repair exit with 4 when it has repaired the filesystem.
+func FsckCommand(fsType, policy, device string) (string, []string, error) {
+	switch fsType {
+	case "ext4":
+		switch policy {
+		case FsckCheckOnly:
+			return "e2fsck", []string{"-n", device}, nil
+		case FsckAutoRepairSafe:
+			return "e2fsck", []string{"-p", device}, nil
+		case FsckForce:
+			return "e2fsck", []string{"-f", "-y", device}, nil
+		}
+	case "xfs":
+		switch policy {
+		case FsckCheckOnly, FsckAutoRepairSafe:
+			return "xfs_repair", []string{"-n", device}, nil
+		case FsckForce:
+			return "xfs_repair", []string{"-e", device}, nil
+		}
+	case "btrfs":
+		switch policy {
+		case FsckCheckOnly, FsckAutoRepairSafe, FsckForce:
+			return "btrfs", []string{"check", "--readonly", device}, nil
+		}
+	default:
+		return "", nil, fmt.Errorf("fsck is not supported for fsType %q", fsType)
+	}
+	return "", nil, fmt.Errorf("unsupported fsck policy: %q", policy)
+}
+
+// FsckResult interprets the exit code of the command returned by FsckCommand for the policy.
+func FsckResult(fsType, policy string, exitCode int) string {
+	if exitCode == 0 {
+		return FsckClean
+	}
+	switch fsType {
+	case "xfs":
+		if policy != FsckForce {
+			// xfs_repair -n exits with 1 when it finds problems.
+			if exitCode == 1 {
+				return FsckCorrupted
+			}
+			return FsckFailed
+		}
+		// xfs_repair -e exits with 4 when it has repaired the filesystem, 2 when the log is dirty,
+		// and 1 when it fails.
+		if exitCode == 4 {
+			return FsckRepaired
+		}
+		return FsckFailed
+	case "btrfs":
+		// btrfs check exits with 1 when it finds problems.
+		if exitCode == 1 {
+			return FsckCorrupted
+		}
+		return FsckFailed
+	}
+
+	// The exit code of e2fsck is the sum of the following conditions.
+	// 1: errors corrected, 2: errors corrected and the system should be rebooted,
+	// 4: errors left uncorrected, 8: operational error, 16: usage or syntax error,
+	// 32: canceled by user request, 128: shared library error
+	switch {
+	case exitCode&(8|16|32|128) != 0:
+		return FsckFailed
+	case exitCode&4 != 0:
+		return FsckCorrupted
+	default:
+		return FsckRepaired
+	}
+}
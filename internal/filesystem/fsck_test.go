@@ -0,0 +1,84 @@
+package filesystem
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFsckCommand(t *testing.T) {
+	cases := []struct {
+		fsType  string
+		policy  string
+		command string
+		args    []string
+		valid   bool
+	}{
+		{"ext4", FsckCheckOnly, "e2fsck", []string{"-n", "/dev/vg/lv"}, true},
+		{"ext4", FsckAutoRepairSafe, "e2fsck", []string{"-p", "/dev/vg/lv"}, true},
+		{"ext4", FsckForce, "e2fsck", []string{"-f", "-y", "/dev/vg/lv"}, true},
+		{"xfs", FsckAutoRepairSafe, "xfs_repair", []string{"-n", "/dev/vg/lv"}, true},
+		{"xfs", FsckForce, "xfs_repair", []string{"-e", "/dev/vg/lv"}, true},
+		{"btrfs", FsckForce, "btrfs", []string{"check", "--readonly", "/dev/vg/lv"}, true},
+		{"ext4", FsckNever, "", nil, false},
+		{"ext4", "repair", "", nil, false},
+		{"vfat", FsckCheckOnly, "", nil, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.fsType+"/"+c.policy, func(t *testing.T) {
+			command, args, err := FsckCommand(c.fsType, c.policy, "/dev/vg/lv")
+			if !c.valid {
+				if err == nil {
+					t.Error("should be invalid")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("should be valid: %v", err)
+			}
+			if command != c.command || !slices.Equal(args, c.args) {
+				t.Errorf("expected %s %v, but got %s %v", c.command, c.args, command, args)
+			}
+		})
+	}
+}
+
+func TestNeedsLogReplay(t *testing.T) {
+	if !NeedsLogReplay("xfs") {
+		t.Error("xfs should replay the log")
+	}
+	if NeedsLogReplay("ext4") {
+		t.Error("ext4 should not replay the log")
+	}
+}
+
+func TestFsckResult(t *testing.T) {
+	cases := []struct {
+		fsType   string
+		policy   string
+		exitCode int
+		expected string
+	}{
+		{"ext4", FsckAutoRepairSafe, 0, FsckClean},
+		{"ext4", FsckAutoRepairSafe, 1, FsckRepaired},
+		{"ext4", FsckForce, 3, FsckRepaired},
+		{"ext4", FsckCheckOnly, 4, FsckCorrupted},
+		{"ext4", FsckForce, 5, FsckCorrupted},
+		{"ext4", FsckForce, 8, FsckFailed},
+		{"ext4", FsckForce, 12, FsckFailed},
+		{"xfs", FsckCheckOnly, 0, FsckClean},
+		{"xfs", FsckAutoRepairSafe, 1, FsckCorrupted},
+		{"xfs", FsckCheckOnly, 2, FsckFailed},
+		{"xfs", FsckForce, 0, FsckClean},
+		{"xfs", FsckForce, 1, FsckFailed},
+		{"xfs", FsckForce, 2, FsckFailed},
+		{"xfs", FsckForce, 4, FsckRepaired},
+		{"btrfs", FsckForce, 1, FsckCorrupted},
+	}
+
+	for _, c := range cases {
+		if actual := FsckResult(c.fsType, c.policy, c.exitCode); actual != c.expected {
+			t.Errorf("%s %s exit code %d: expected %s, but got %s", c.fsType, c.policy, c.exitCode, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package filesystem
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// baseMkfsOptions are the options of mkfs that mount-utils uses when it formats a volume.
+// They overwrite stray signatures on the device.
+var baseMkfsOptions = map[string][]string{
+	"ext4":  {"-F", "-m0"},
+	"xfs":   {"-f"},
+	"btrfs": {"-f"},
+}
+
+// mkfsFlags are the flags of mkfs allowed in the extra options for each filesystem.
+// The value is true if the flag takes an argument as the next option.
+// The flags making mkfs not create a filesystem, e.g. -n of mke2fs, or taking a file, e.g. -d of mke2fs, are excluded.
+// So is -r of mkfs.xfs because the realtime section is useless without an external device.
+var mkfsFlags = map[string]map[string]bool{
+	"ext4": {
+		"-b": true, "-C": true, "-E": true, "-g": true, "-G": true, "-i": true, "-I": true, "-j": false,
+		"-J": true, "-L": true, "-m": true, "-N": true, "-O": true, "-T": true,
+	},
+	"xfs": {
+		"-b": true, "-d": true, "-i": true, "-K": false, "-l": true, "-L": true, "-m": true, "-n": true,
+		"-s": true,
+	},
+	"btrfs": {
+		"-d": true, "-K": false, "-L": true, "-m": true, "-n": true, "-O": true, "-R": true, "-s": true,
+	},
+}
+
+// mkfsSubOptions are the sub-options allowed in the arguments of the flags which can also name a device or a file,
+// e.g. -J device= of mke2fs and -d name= or -l logdev= of mkfs.xfs. Only the geometry and the features are allowed.
+var mkfsSubOptions = map[string]map[string][]string{
+	"ext4": {
+		"-J": {"size", "location"},
+	},
+	"xfs": {
+		"-d": {
+			"agcount", "agsize", "cowextsize", "daxinherit", "extszinherit", "noalign", "projinherit",
+			"rtinherit", "size", "su", "sunit", "sw", "swidth", "concurrency",
+		},
+		"-l": {"internal", "lazy-count", "size", "su", "sunit", "version", "concurrency"},
+	},
+}
+
+// IsSupported returns true if the volumes can be formatted with the filesystem by TopoLVM.
+func IsSupported(fsType string) bool {
+	_, ok := baseMkfsOptions[fsType]
+	return ok
+}
+
+// ValidateMkfsOptions returns an error if the options cannot be passed to mkfs of the filesystem.
+// Each flag and its argument must be separate options.
+func ValidateMkfsOptions(fsType string, options []string) error {
+	flags, ok := mkfsFlags[fsType]
+	if !ok {
+		return fmt.Errorf("mkfs options are not supported for fsType %q", fsType)
+	}
+	for i := 0; i < len(options); i++ {
+		hasArg, ok := flags[options[i]]
+		if !ok {
+			return fmt.Errorf("unsupported mkfs option for %s: %q", fsType, options[i])
+		}
+		if !hasArg {
+			continue
+		}
+		i++
+		if i == len(options) || options[i] == "" || strings.HasPrefix(options[i], "-") {
+			return fmt.Errorf("mkfs option %s for %s requires an argument", options[i-1], fsType)
+		}
+		if err := validateSubOptions(fsType, options[i-1], options[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSubOptions returns an error if the comma-separated argument of the flag has a sub-option not allowed.
+func validateSubOptions(fsType, flag, arg string) error {
+	allowed, ok := mkfsSubOptions[fsType][flag]
+	if !ok {
+		return nil
+	}
+	for _, opt := range strings.Split(arg, ",") {
+		name, _, _ := strings.Cut(opt, "=")
+		if !slices.Contains(allowed, name) {
+			return fmt.Errorf("unsupported sub-option of mkfs option %s for %s: %q", flag, fsType, name)
+		}
+	}
+	return nil
+}
+
+// MkfsArgs returns the arguments of mkfs.<fsType> to format the device with the extra options.
+// The extra options come after the ones mount-utils uses so that they take precedence.
+func MkfsArgs(fsType string, options []string, device string) []string {
+	return slices.Concat(baseMkfsOptions[fsType], options, []string{device})
+}
@@ -0,0 +1,51 @@
+package filesystem
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValidateMkfsOptions(t *testing.T) {
+	cases := []struct {
+		name    string
+		fsType  string
+		options []string
+		valid   bool
+	}{
+		{"ext4", "ext4", []string{"-E", "lazy_itable_init=1", "-i", "16384", "-m", "1", "-j"}, true},
+		{"xfs", "xfs", []string{"-m", "reflink=1", "-d", "su=64k,sw=4", "-K"}, true},
+		{"btrfs", "btrfs", []string{"-O", "quota"}, true},
+		{"empty", "ext4", nil, true},
+		{"unsupported fsType", "vfat", []string{"-F", "32"}, false},
+		{"unsupported flag", "ext4", []string{"-n"}, false},
+		{"attached argument", "ext4", []string{"-m1"}, false},
+		{"missing argument", "xfs", []string{"-m"}, false},
+		{"flag as argument", "ext4", []string{"-E", "-j"}, false},
+		{"device", "ext4", []string{"/dev/sda"}, false},
+		{"journal size", "ext4", []string{"-J", "size=64"}, true},
+		{"external journal", "ext4", []string{"-J", "device=/dev/sda"}, false},
+		{"data file", "xfs", []string{"-d", "su=64k,file,name=/etc/passwd"}, false},
+		{"log size", "xfs", []string{"-l", "size=64m,lazy-count=1"}, true},
+		{"external log", "xfs", []string{"-l", "logdev=/dev/sda,size=64m"}, false},
+		{"realtime device", "xfs", []string{"-r", "rtdev=/dev/sda"}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateMkfsOptions(c.fsType, c.options)
+			if c.valid && err != nil {
+				t.Errorf("should be valid: %v", err)
+			} else if !c.valid && err == nil {
+				t.Error("should be invalid")
+			}
+		})
+	}
+}
+
+func TestMkfsArgs(t *testing.T) {
+	actual := MkfsArgs("ext4", []string{"-m", "1"}, "/dev/vg/lv")
+	expected := []string{"-F", "-m0", "-m", "1", "/dev/vg/lv"}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
@@ -20,6 +21,7 @@ const (
 	deviceClass1       = "dc1"
 	deviceClass2       = "dc2"
 	deviceClass3       = "dc3"
+	sourceNodeName     = "source-node"
 )
 
 func pvcSource(name string) *corev1.PersistentVolumeClaimVolumeSource {
@@ -117,6 +119,57 @@ func setupMutatePodResources() {
 	err = k8sClient.Create(testCtx, pvc5)
 	Expect(err).ShouldNot(HaveOccurred())
 
+	sourceLV := &topolvmv1.LogicalVolume{}
+	sourceLV.Name = "source-pv"
+	sourceLV.Spec.Name = "source-pv"
+	sourceLV.Spec.NodeName = sourceNodeName
+	sourceLV.Spec.DeviceClass = deviceClass1
+	sourceLV.Spec.Size = *resource.NewQuantity(1<<30, resource.BinarySI)
+	err = k8sClient.Create(testCtx, sourceLV)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	sourcePVC := &corev1.PersistentVolumeClaim{}
+	sourcePVC.Namespace = mutatePodNamespace
+	sourcePVC.Name = "source-pvc"
+	sourcePVC.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	sourcePVC.Spec.StorageClassName = ptr.To(topolvmProvisionerStorageClassName)
+	sourcePVC.Spec.VolumeName = sourceLV.Name
+	sourcePVC.Spec.Resources.Requests = corev1.ResourceList{
+		"storage": *resource.NewQuantity(1<<30, resource.BinarySI),
+	}
+	err = k8sClient.Create(testCtx, sourcePVC)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	clonePVC := &corev1.PersistentVolumeClaim{}
+	clonePVC.Namespace = mutatePodNamespace
+	clonePVC.Name = "clone-pvc"
+	clonePVC.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	clonePVC.Spec.StorageClassName = ptr.To(topolvmProvisionerStorageClassName)
+	clonePVC.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		Kind: "PersistentVolumeClaim",
+		Name: sourcePVC.Name,
+	}
+	clonePVC.Spec.Resources.Requests = corev1.ResourceList{
+		"storage": *resource.NewQuantity(1<<30, resource.BinarySI),
+	}
+	err = k8sClient.Create(testCtx, clonePVC)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	copyPVC := &corev1.PersistentVolumeClaim{}
+	copyPVC.Namespace = mutatePodNamespace
+	copyPVC.Name = "copy-pvc"
+	copyPVC.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	copyPVC.Spec.StorageClassName = ptr.To(topolvmProvisionerCopyStorageClassName)
+	copyPVC.Spec.DataSource = &corev1.TypedLocalObjectReference{
+		Kind: "PersistentVolumeClaim",
+		Name: sourcePVC.Name,
+	}
+	copyPVC.Spec.Resources.Requests = corev1.ResourceList{
+		"storage": *resource.NewQuantity(1<<30, resource.BinarySI),
+	}
+	err = k8sClient.Create(testCtx, copyPVC)
+	Expect(err).ShouldNot(HaveOccurred())
+
 	defaultPVC := &corev1.PersistentVolumeClaim{}
 	defaultPVC.Namespace = mutatePodNamespace
 	defaultPVC.Name = "default-pvc"
@@ -424,4 +477,59 @@ var _ = Describe("pod mutation webhook", func() {
 		Expect(limit.Value()).Should(Equal(int64(1)))
 		Expect(capacity).Should(Equal(strconv.Itoa(500 * mebibyte)))
 	})
+
+	It("should pin pod w/ cloned TopoLVM PVC to the source node", func() {
+		pod := testPod()
+		pod.Spec.Volumes = []corev1.Volume{
+			{
+				Name: "vol1",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: pvcSource("clone-pvc"),
+				},
+			},
+		}
+		err := k8sClient.Create(testCtx, pod)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pod = getPod()
+		capacity := pod.Annotations[topolvm.GetCapacityKeyPrefix()+deviceClass1]
+		Expect(capacity).Should(Equal(strconv.Itoa(1 << 30)))
+		Expect(pod.Annotations).Should(HaveKeyWithValue(topolvm.GetSourceNodeKey(), sourceNodeName))
+	})
+
+	It("should not pin pod w/ cloned TopoLVM PVC allowed to be copied across nodes", func() {
+		pod := testPod()
+		pod.Spec.Volumes = []corev1.Volume{
+			{
+				Name: "vol1",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: pvcSource("copy-pvc"),
+				},
+			},
+		}
+		err := k8sClient.Create(testCtx, pod)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pod = getPod()
+		capacity := pod.Annotations[topolvm.GetCapacityKeyPrefix()+deviceClass1]
+		Expect(capacity).Should(Equal(strconv.Itoa(1 << 30)))
+		Expect(pod.Annotations).ShouldNot(HaveKey(topolvm.GetSourceNodeKey()))
+	})
+
+	It("should not pin pod w/o PVC data source", func() {
+		pod := testPod()
+		pod.Spec.Volumes = []corev1.Volume{
+			{
+				Name: "vol1",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: pvcSource("pvc1"),
+				},
+			},
+		}
+		err := k8sClient.Create(testCtx, pod)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pod = getPod()
+		Expect(pod.Annotations).ShouldNot(HaveKey(topolvm.GetSourceNodeKey()))
+	})
 })
@@ -38,6 +38,10 @@ func run(ctx context.Context, cfg *rest.Config, scheme *runtime.Scheme, opts *en
 	dec := admission.NewDecoder(scheme)
 	wh := mgr.GetWebhookServer()
 	wh.Register(podMutatingWebhookPath, PodMutator(mgr.GetClient(), mgr.GetAPIReader(), dec))
+	wh.Register(storageClassValidatingWebhookPath, StorageClassValidator(mgr.GetClient(), dec))
+	wh.Register(quotaValidatingWebhookPath, QuotaValidator(mgr.GetClient(), mgr.GetAPIReader(), dec))
+	wh.Register(deviceClassPolicyValidatingWebhookPath, DeviceClassPolicyValidator(mgr.GetClient(), mgr.GetAPIReader(), dec))
+	wh.Register(capacityValidatingWebhookPath, CapacityValidator(mgr.GetClient(), mgr.GetAPIReader(), dec))
 
 	if err := mgr.Start(ctx); err != nil {
 		return err
@@ -0,0 +1,188 @@
+package hook
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+//+kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots;volumesnapshotcontents,verbs=get
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+
+// sourceNodes returns the names of the nodes where the source volumes of
+// the pod's unbound PVCs reside. A PVC restored from a VolumeSnapshot or
+// cloned from another PVC can only be provisioned on the node of its source
+// unless its StorageClass allows copying the source to another node.
+func (m *podMutator) sourceNodes(ctx context.Context, pod *corev1.Pod) ([]string, error) {
+	var nodes []string
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		var pvc corev1.PersistentVolumeClaim
+		name := types.NamespacedName{Namespace: pod.Namespace, Name: vol.PersistentVolumeClaim.ClaimName}
+		if err := m.getter.Get(ctx, name, &pvc); err != nil {
+			if apierrs.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if pvc.Status.Phase != corev1.ClaimPending {
+			continue
+		}
+
+		node, err := m.sourceNode(ctx, &pvc)
+		if err != nil {
+			return nil, err
+		}
+		if node != "" && !slices.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	slices.Sort(nodes)
+	return nodes, nil
+}
+
+func (m *podMutator) sourceNode(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	copyAllowed, err := m.crossNodeCopyAllowed(ctx, pvc)
+	if err != nil || copyAllowed {
+		return "", err
+	}
+
+	var group, kind, name, namespace string
+	switch {
+	case pvc.Spec.DataSourceRef != nil:
+		ref := pvc.Spec.DataSourceRef
+		if ref.APIGroup != nil {
+			group = *ref.APIGroup
+		}
+		kind = ref.Kind
+		name = ref.Name
+		if ref.Namespace != nil {
+			namespace = *ref.Namespace
+		}
+	case pvc.Spec.DataSource != nil:
+		ref := pvc.Spec.DataSource
+		if ref.APIGroup != nil {
+			group = *ref.APIGroup
+		}
+		kind = ref.Kind
+		name = ref.Name
+	default:
+		return "", nil
+	}
+	if namespace == "" {
+		namespace = pvc.Namespace
+	}
+
+	var lv *topolvmv1.LogicalVolume
+	switch {
+	case group == "" && kind == "PersistentVolumeClaim":
+		lv, err = m.clonedVolume(ctx, types.NamespacedName{Namespace: namespace, Name: name})
+	case group == snapapi.GroupName && kind == "VolumeSnapshot":
+		lv, err = m.snapshotVolume(ctx, types.NamespacedName{Namespace: namespace, Name: name})
+	default:
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if lv == nil {
+		return "", nil
+	}
+	return lv.Spec.NodeName, nil
+}
+
+// crossNodeCopyAllowed returns true if the StorageClass of the PVC allows copying the source to another node.
+func (m *podMutator) crossNodeCopyAllowed(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	var sc storagev1.StorageClass
+	if err := m.getter.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, &sc); err != nil {
+		if apierrs.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	allowed, _ := strconv.ParseBool(sc.Parameters[topolvm.GetAllowCrossNodeCopyKey()])
+	return allowed, nil
+}
+
+// clonedVolume returns the LogicalVolume of the source PVC.
+// The name of a LogicalVolume is the same as its PersistentVolume.
+func (m *podMutator) clonedVolume(ctx context.Context, name types.NamespacedName) (*topolvmv1.LogicalVolume, error) {
+	var src corev1.PersistentVolumeClaim
+	if err := m.getter.Get(ctx, name, &src); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if src.Spec.VolumeName == "" {
+		return nil, nil
+	}
+
+	var lv topolvmv1.LogicalVolume
+	if err := m.getter.Get(ctx, types.NamespacedName{Name: src.Spec.VolumeName}, &lv); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lv, nil
+}
+
+// snapshotVolume returns the LogicalVolume of the snapshot.
+// VolumeSnapshots are read from the API server directly because
+// their CRDs may not be installed.
+func (m *podMutator) snapshotVolume(ctx context.Context, name types.NamespacedName) (*topolvmv1.LogicalVolume, error) {
+	var vs snapapi.VolumeSnapshot
+	if err := m.apiReader.Get(ctx, name, &vs); err != nil {
+		if apierrs.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if vs.Status == nil || vs.Status.BoundVolumeSnapshotContentName == nil {
+		return nil, nil
+	}
+
+	var vsc snapapi.VolumeSnapshotContent
+	if err := m.apiReader.Get(ctx, types.NamespacedName{Name: *vs.Status.BoundVolumeSnapshotContentName}, &vsc); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var handle string
+	switch {
+	case vsc.Status != nil && vsc.Status.SnapshotHandle != nil:
+		handle = *vsc.Status.SnapshotHandle
+	case vsc.Spec.Source.SnapshotHandle != nil:
+		handle = *vsc.Spec.Source.SnapshotHandle
+	default:
+		return nil, nil
+	}
+
+	var lvs topolvmv1.LogicalVolumeList
+	if err := m.reader.List(ctx, &lvs); err != nil {
+		return nil, err
+	}
+	for i := range lvs.Items {
+		if lvs.Items[i].Status.VolumeID == handle {
+			return &lvs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
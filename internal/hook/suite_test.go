@@ -13,6 +13,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
 
 	admissionv1 "k8s.io/api/admissionregistration/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -37,15 +38,21 @@ var testEnv *envtest.Environment
 var testCtx, testCancel = context.WithCancel(context.Background())
 
 const (
-	emptyStorageClassName                       = ""
-	topolvmProvisionerStorageClassName          = "topolvm-provisioner"
-	topolvmProvisioner2StorageClassName         = "topolvm-provisioner2"
-	topolvmProvisioner3StorageClassName         = "topolvm-provisioner3"
-	topolvmProvisionerImmediateStorageClassName = "topolvm-provisioner-immediate"
-	hostLocalStorageClassName                   = "host-local"
-	missingStorageClassName                     = "missing-storageclass"
-
-	podMutatingWebhookPath = "/pod/mutate"
+	emptyStorageClassName                        = ""
+	topolvmProvisionerStorageClassName           = "topolvm-provisioner"
+	topolvmProvisioner2StorageClassName          = "topolvm-provisioner2"
+	topolvmProvisioner3StorageClassName          = "topolvm-provisioner3"
+	topolvmProvisionerImmediateStorageClassName  = "topolvm-provisioner-immediate"
+	topolvmProvisionerRestrictedStorageClassName = "topolvm-provisioner-restricted"
+	topolvmProvisionerCopyStorageClassName       = "topolvm-provisioner-copy"
+	hostLocalStorageClassName                    = "host-local"
+	missingStorageClassName                      = "missing-storageclass"
+
+	podMutatingWebhookPath                 = "/pod/mutate"
+	storageClassValidatingWebhookPath      = "/storageclass/validate"
+	quotaValidatingWebhookPath             = "/quota/validate"
+	deviceClassPolicyValidatingWebhookPath = "/deviceclasspolicy/validate"
+	capacityValidatingWebhookPath          = "/capacity/validate"
 )
 
 func setupCommonResources() {
@@ -102,6 +109,33 @@ func setupCommonResources() {
 	err = k8sClient.Create(testCtx, sc)
 	Expect(err).ShouldNot(HaveOccurred())
 
+	sc = &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: topolvmProvisionerRestrictedStorageClassName,
+		},
+		Provisioner:       "topolvm.io",
+		VolumeBindingMode: ptr.To(storagev1.VolumeBindingWaitForFirstConsumer),
+		Parameters: map[string]string{
+			topolvm.GetDeviceClassKey(): "restricted",
+		},
+	}
+	err = k8sClient.Create(testCtx, sc)
+	Expect(err).ShouldNot(HaveOccurred())
+
+	sc = &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: topolvmProvisionerCopyStorageClassName,
+		},
+		Provisioner:       "topolvm.io",
+		VolumeBindingMode: ptr.To(storagev1.VolumeBindingWaitForFirstConsumer),
+		Parameters: map[string]string{
+			topolvm.GetDeviceClassKey():        "dc1",
+			topolvm.GetAllowCrossNodeCopyKey(): "true",
+		},
+	}
+	err = k8sClient.Create(testCtx, sc)
+	Expect(err).ShouldNot(HaveOccurred())
+
 	sc = &storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: hostLocalStorageClassName,
@@ -164,6 +198,113 @@ var _ = BeforeSuite(func() {
 				},
 			},
 		},
+		ValidatingWebhooks: []*admissionv1.ValidatingWebhookConfiguration{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "topolvm-validating-hook",
+				},
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "ValidatingWebhookConfiguration",
+					APIVersion: "admissionregistration.k8s.io/v1",
+				},
+				Webhooks: []admissionv1.ValidatingWebhook{
+					{
+						Name:                    "storageclass-hook.topolvm.io",
+						AdmissionReviewVersions: []string{"v1", "v1beta1"},
+						FailurePolicy:           &failPolicy,
+						ClientConfig: admissionv1.WebhookClientConfig{
+							Service: &admissionv1.ServiceReference{
+								Path: ptr.To(storageClassValidatingWebhookPath),
+							},
+						},
+						Rules: []admissionv1.RuleWithOperations{
+							{
+								Operations: []admissionv1.OperationType{
+									admissionv1.Create,
+								},
+								Rule: admissionv1.Rule{
+									APIGroups:   []string{"storage.k8s.io"},
+									APIVersions: []string{"v1"},
+									Resources:   []string{"storageclasses"},
+								},
+							},
+						},
+						SideEffects: &sideEffects,
+					},
+					{
+						Name:                    "pvc-quota-hook.topolvm.io",
+						AdmissionReviewVersions: []string{"v1", "v1beta1"},
+						FailurePolicy:           &failPolicy,
+						ClientConfig: admissionv1.WebhookClientConfig{
+							Service: &admissionv1.ServiceReference{
+								Path: ptr.To(quotaValidatingWebhookPath),
+							},
+						},
+						Rules: []admissionv1.RuleWithOperations{
+							{
+								Operations: []admissionv1.OperationType{
+									admissionv1.Create,
+									admissionv1.Update,
+								},
+								Rule: admissionv1.Rule{
+									APIGroups:   []string{""},
+									APIVersions: []string{"v1"},
+									Resources:   []string{"persistentvolumeclaims"},
+								},
+							},
+						},
+						SideEffects: &sideEffects,
+					},
+					{
+						Name:                    "pvc-deviceclasspolicy-hook.topolvm.io",
+						AdmissionReviewVersions: []string{"v1", "v1beta1"},
+						FailurePolicy:           &failPolicy,
+						ClientConfig: admissionv1.WebhookClientConfig{
+							Service: &admissionv1.ServiceReference{
+								Path: ptr.To(deviceClassPolicyValidatingWebhookPath),
+							},
+						},
+						Rules: []admissionv1.RuleWithOperations{
+							{
+								Operations: []admissionv1.OperationType{
+									admissionv1.Create,
+								},
+								Rule: admissionv1.Rule{
+									APIGroups:   []string{""},
+									APIVersions: []string{"v1"},
+									Resources:   []string{"persistentvolumeclaims"},
+								},
+							},
+						},
+						SideEffects: &sideEffects,
+					},
+					{
+						Name:                    "pvc-capacity-hook.topolvm.io",
+						AdmissionReviewVersions: []string{"v1", "v1beta1"},
+						FailurePolicy:           &failPolicy,
+						ClientConfig: admissionv1.WebhookClientConfig{
+							Service: &admissionv1.ServiceReference{
+								Path: ptr.To(capacityValidatingWebhookPath),
+							},
+						},
+						Rules: []admissionv1.RuleWithOperations{
+							{
+								Operations: []admissionv1.OperationType{
+									admissionv1.Create,
+									admissionv1.Update,
+								},
+								Rule: admissionv1.Rule{
+									APIGroups:   []string{""},
+									APIVersions: []string{"v1"},
+									Resources:   []string{"persistentvolumeclaims"},
+								},
+							},
+						},
+						SideEffects: &sideEffects,
+					},
+				},
+			},
+		},
 	}
 
 	testEnv = &envtest.Environment{
@@ -183,6 +324,8 @@ var _ = BeforeSuite(func() {
 	scheme := runtime.NewScheme()
 	err = clientgoscheme.AddToScheme(scheme)
 	Expect(err).ToNot(HaveOccurred())
+	err = topolvmv1.AddToScheme(scheme)
+	Expect(err).ToNot(HaveOccurred())
 
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme})
 	Expect(err).ToNot(HaveOccurred())
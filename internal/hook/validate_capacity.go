@@ -0,0 +1,164 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/getter"
+	"github.com/topolvm/topolvm/internal/nodeservice"
+	"github.com/topolvm/topolvm/internal/quota"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var cvLogger = ctrl.Log.WithName("capacity-validator")
+
+//+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=core,resources=persistentvolumeclaims,verbs=create;update,versions=v1,name=pvc-capacity-hook.topolvm.io,path=/capacity/validate,mutating=false,sideEffects=none,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:rbac:groups=core,resources=persistentvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+
+// capacityValidator denies PVCs and PVC expansions that no node can satisfy.
+type capacityValidator struct {
+	reader      client.Reader
+	getter      *getter.RetryMissingGetter
+	nodeService *nodeservice.NodeService
+	decoder     admission.Decoder
+}
+
+// CapacityValidator creates a validating webhook for PVCs checking the free capacity of nodes.
+func CapacityValidator(r client.Reader, apiReader client.Reader, dec admission.Decoder) http.Handler {
+	return &webhook.Admission{
+		Handler: &capacityValidator{
+			reader:      r,
+			getter:      getter.NewRetryMissingGetter(r, apiReader),
+			nodeService: nodeservice.NewNodeService(r),
+			decoder:     dec,
+		},
+	}
+}
+
+// Handle implements admission.Handler interface.
+func (v *capacityValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := v.decoder.Decode(req, pvc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	sc, err := topolvmStorageClass(ctx, v.getter, pvc)
+	if err != nil {
+		cvLogger.Error(err, "failed to get the StorageClass", "namespace", req.Namespace, "name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if sc == nil {
+		return admission.Allowed("not a PVC for TopoLVM")
+	}
+
+	var reason string
+	switch req.Operation {
+	case admissionv1.Create:
+		reason, err = v.validateCreate(ctx, pvc, sc.Parameters[topolvm.GetDeviceClassKey()])
+	case admissionv1.Update:
+		old := &corev1.PersistentVolumeClaim{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if quota.RequestedSize(pvc) <= quota.RequestedSize(old) {
+			return admission.Allowed("")
+		}
+		reason, err = v.validateExpand(ctx, pvc)
+	}
+	if err != nil {
+		cvLogger.Error(err, "failed to validate the capacity", "namespace", req.Namespace, "name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if reason != "" {
+		return admission.Denied(reason)
+	}
+	return admission.Allowed("")
+}
+
+// validateCreate returns the reason to deny the PVC if it is larger than the free capacity of every node.
+func (v *capacityValidator) validateCreate(ctx context.Context, pvc *corev1.PersistentVolumeClaim, deviceClass string) (string, error) {
+	node, maxCapacity, err := v.nodeService.GetMaxCapacity(ctx, deviceClass)
+	if err != nil {
+		return "", err
+	}
+	// No node reports free space of the device-class, e.g. before topolvm-node starts.
+	if node == "" {
+		return "", nil
+	}
+
+	size := quota.RequestedSize(pvc)
+	if size > maxCapacity {
+		return fmt.Sprintf("requested size %d exceeds the largest free capacity %d of device-class %q among nodes",
+			size, maxCapacity, deviceClass), nil
+	}
+	return "", nil
+}
+
+// validateExpand returns the reason to deny the expansion if the node of the bound volume cannot hold the increase.
+func (v *capacityValidator) validateExpand(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	lv, err := v.boundLogicalVolume(ctx, pvc)
+	if err != nil || lv == nil {
+		return "", err
+	}
+
+	currentSize := lv.Status.CurrentSize
+	if currentSize == nil {
+		currentSize = &lv.Spec.Size
+	}
+	delta := quota.RequestedSize(pvc) - currentSize.Value()
+	if delta <= 0 {
+		return "", nil
+	}
+
+	capacity, err := v.nodeService.GetCapacityByName(ctx, lv.Spec.NodeName, lv.Spec.DeviceClass)
+	if apierrs.IsNotFound(err) || errors.Is(err, nodeservice.ErrDeviceClassNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if delta > capacity {
+		return fmt.Sprintf("not enough space to expand the volume on node %s: device-class %q has %d bytes free, but %d bytes are required",
+			lv.Spec.NodeName, lv.Spec.DeviceClass, capacity, delta), nil
+	}
+	return "", nil
+}
+
+// boundLogicalVolume returns the LogicalVolume of the PV bound to the PVC, or nil if it is not found.
+func (v *capacityValidator) boundLogicalVolume(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*topolvmv1.LogicalVolume, error) {
+	if pvc.Spec.VolumeName == "" {
+		return nil, nil
+	}
+	pv := &corev1.PersistentVolume{}
+	if err := v.getter.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != topolvm.GetPluginName() {
+		return nil, nil
+	}
+
+	var lvs topolvmv1.LogicalVolumeList
+	if err := v.reader.List(ctx, &lvs); err != nil {
+		return nil, err
+	}
+	for i := range lvs.Items {
+		if lvs.Items[i].Status.VolumeID == pv.Spec.CSI.VolumeHandle {
+			return &lvs.Items[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,148 @@
+package hook
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	capacityNamespace        = "test-capacity"
+	capacityDeviceClass      = "capacity-dc"
+	capacityStorageClassName = "topolvm-provisioner-capacity"
+)
+
+func capacityPVC(name string, size int64) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Namespace = capacityNamespace
+	pvc.Name = name
+	pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	pvc.Spec.StorageClassName = ptr.To(capacityStorageClassName)
+	pvc.Spec.Resources.Requests = corev1.ResourceList{
+		corev1.ResourceStorage: *resource.NewQuantity(size, resource.BinarySI),
+	}
+	return pvc
+}
+
+func resizePVC(name string, size int64, opts ...client.UpdateOption) error {
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := k8sClient.Get(testCtx, types.NamespacedName{Namespace: capacityNamespace, Name: name}, pvc)
+	if err != nil {
+		return err
+	}
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = *resource.NewQuantity(size, resource.BinarySI)
+	return k8sClient.Update(testCtx, pvc, opts...)
+}
+
+var _ = Describe("Capacity validating webhook", Ordered, func() {
+	BeforeAll(func() {
+		ns := &corev1.Namespace{}
+		ns.Name = capacityNamespace
+		err := k8sClient.Create(testCtx, ns)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "validate-capacity-node",
+				Annotations: map[string]string{
+					topolvm.GetCapacityKeyPrefix() + capacityDeviceClass: "10737418240",
+				},
+			},
+		}
+		err = k8sClient.Create(testCtx, node)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// the StorageClass webhook may deny the device-class until it sees the node.
+		Eventually(func() error {
+			return k8sClient.Create(testCtx, &storagev1.StorageClass{
+				ObjectMeta:           metav1.ObjectMeta{Name: capacityStorageClassName},
+				Provisioner:          topolvm.GetPluginName(),
+				AllowVolumeExpansion: ptr.To(true),
+				Parameters: map[string]string{
+					topolvm.GetDeviceClassKey(): capacityDeviceClass,
+				},
+			})
+		}).Should(Succeed())
+
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "capacity-lv",
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        "capacity-lv",
+				NodeName:    node.Name,
+				DeviceClass: capacityDeviceClass,
+				Size:        resource.MustParse("5Gi"),
+			},
+		}
+		err = k8sClient.Create(testCtx, lv)
+		Expect(err).ShouldNot(HaveOccurred())
+		lv.Status.VolumeID = "capacity-volume"
+		lv.Status.CurrentSize = resource.NewQuantity(5<<30, resource.BinarySI)
+		err = k8sClient.Status().Update(testCtx, lv)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pv := &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "capacity-pv",
+			},
+			Spec: corev1.PersistentVolumeSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Capacity: corev1.ResourceList{
+					corev1.ResourceStorage: *resource.NewQuantity(5<<30, resource.BinarySI),
+				},
+				StorageClassName: capacityStorageClassName,
+				PersistentVolumeSource: corev1.PersistentVolumeSource{
+					CSI: &corev1.CSIPersistentVolumeSource{
+						Driver:       topolvm.GetPluginName(),
+						VolumeHandle: "capacity-volume",
+					},
+				},
+			},
+		}
+		err = k8sClient.Create(testCtx, pv)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		pvc := capacityPVC("capacity-bound", 5<<30)
+		pvc.Spec.VolumeName = pv.Name
+		Eventually(func() error {
+			return k8sClient.Create(testCtx, pvc)
+		}).Should(Succeed())
+		pvc.Status.Phase = corev1.ClaimBound
+		pvc.Status.Capacity = pv.Spec.Capacity
+		err = k8sClient.Status().Update(testCtx, pvc)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should deny a PVC larger than the free capacity of any node", func() {
+		// dry-run until the webhook sees the node
+		Eventually(func() error {
+			return k8sClient.Create(testCtx, capacityPVC("capacity-large", 20<<30), client.DryRunAll)
+		}).Should(MatchError(ContainSubstring("exceeds the largest free capacity 10737418240")))
+	})
+
+	It("should allow a PVC within the free capacity", func() {
+		err := k8sClient.Create(testCtx, capacityPVC("capacity-small", 4<<30))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should deny an expansion exceeding the free capacity of the node", func() {
+		// dry-run until the webhook sees the PV and the LogicalVolume
+		Eventually(func() error {
+			return resizePVC("capacity-bound", 16<<30, client.DryRunAll)
+		}).Should(MatchError(ContainSubstring("not enough space to expand the volume on node validate-capacity-node")))
+	})
+
+	It("should allow an expansion within the free capacity of the node", func() {
+		err := resizePVC("capacity-bound", 15<<30)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
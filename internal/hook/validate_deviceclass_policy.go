@@ -0,0 +1,64 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/internal/dcpolicy"
+	"github.com/topolvm/topolvm/internal/getter"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var dcpvLogger = ctrl.Log.WithName("deviceclasspolicy-validator")
+
+//+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=core,resources=persistentvolumeclaims,verbs=create,versions=v1,name=pvc-deviceclasspolicy-hook.topolvm.io,path=/deviceclasspolicy/validate,mutating=false,sideEffects=none,admissionReviewVersions={v1,v1beta1}
+
+// deviceClassPolicyValidator denies PVCs using device-classes not allowed in their namespaces.
+type deviceClassPolicyValidator struct {
+	reader  client.Reader
+	getter  *getter.RetryMissingGetter
+	decoder admission.Decoder
+}
+
+// DeviceClassPolicyValidator creates a validating webhook for PVCs enforcing DeviceClassPolicies.
+func DeviceClassPolicyValidator(r client.Reader, apiReader client.Reader, dec admission.Decoder) http.Handler {
+	return &webhook.Admission{
+		Handler: &deviceClassPolicyValidator{
+			reader:  r,
+			getter:  getter.NewRetryMissingGetter(r, apiReader),
+			decoder: dec,
+		},
+	}
+}
+
+// Handle implements admission.Handler interface.
+func (v *deviceClassPolicyValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := v.decoder.Decode(req, pvc); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	sc, err := topolvmStorageClass(ctx, v.getter, pvc)
+	if err != nil {
+		dcpvLogger.Error(err, "failed to get the StorageClass", "namespace", req.Namespace, "name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if sc == nil {
+		return admission.Allowed("not a PVC for TopoLVM")
+	}
+
+	err = dcpolicy.Check(ctx, v.reader, sc.Parameters[topolvm.GetDeviceClassKey()], req.Namespace)
+	if errors.Is(err, dcpolicy.ErrDenied) {
+		return admission.Denied(err.Error())
+	}
+	if err != nil {
+		dcpvLogger.Error(err, "failed to check DeviceClassPolicies", "namespace", req.Namespace, "name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.Allowed("")
+}
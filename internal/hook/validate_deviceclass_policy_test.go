@@ -0,0 +1,83 @@
+package hook
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func policyPVC(namespace, name, scName string) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Namespace = namespace
+	pvc.Name = name
+	pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	pvc.Spec.StorageClassName = ptr.To(scName)
+	pvc.Spec.Resources.Requests = corev1.ResourceList{
+		corev1.ResourceStorage: resource.MustParse("1Gi"),
+	}
+	return pvc
+}
+
+var _ = Describe("DeviceClassPolicy validating webhook", Ordered, func() {
+	BeforeAll(func() {
+		for name, labels := range map[string]map[string]string{
+			"test-dcpolicy-allowed":  nil,
+			"test-dcpolicy-selected": {"tier": "gold"},
+			"test-dcpolicy-denied":   {"tier": "silver"},
+		} {
+			ns := &corev1.Namespace{}
+			ns.Name = name
+			ns.Labels = labels
+			err := k8sClient.Create(testCtx, ns)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		for _, spec := range []topolvmv1.DeviceClassPolicySpec{
+			{
+				DeviceClass: "restricted",
+				Namespaces:  []string{"test-dcpolicy-allowed"},
+			},
+			{
+				DeviceClass: "restricted",
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"tier": "gold"},
+				},
+			},
+		} {
+			p := &topolvmv1.DeviceClassPolicy{Spec: spec}
+			p.GenerateName = "restricted-"
+			err := k8sClient.Create(testCtx, p)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+	})
+
+	It("should deny PVCs in namespaces not allowed to use the device-class", func() {
+		// dry-run until the webhook sees the policies
+		Eventually(func() error {
+			return k8sClient.Create(testCtx, policyPVC("test-dcpolicy-denied", "pvc", topolvmProvisionerRestrictedStorageClassName), client.DryRunAll)
+		}).Should(MatchError(ContainSubstring("namespace test-dcpolicy-denied is not allowed to use device-class restricted")))
+	})
+
+	It("should allow PVCs in the listed namespaces", func() {
+		err := k8sClient.Create(testCtx, policyPVC("test-dcpolicy-allowed", "pvc", topolvmProvisionerRestrictedStorageClassName))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should allow PVCs in the selected namespaces", func() {
+		err := k8sClient.Create(testCtx, policyPVC("test-dcpolicy-selected", "pvc", topolvmProvisionerRestrictedStorageClassName))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should allow PVCs of unrestricted device-classes", func() {
+		err := k8sClient.Create(testCtx, policyPVC("test-dcpolicy-denied", "pvc-dc2", topolvmProvisioner2StorageClassName))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = k8sClient.Create(testCtx, policyPVC("test-dcpolicy-denied", "pvc-local", hostLocalStorageClassName))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
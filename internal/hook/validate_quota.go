@@ -0,0 +1,164 @@
+package hook
+
+import (
+	"context"
+	"net/http"
+
+	snapapi "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/getter"
+	"github.com/topolvm/topolvm/internal/quota"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var qvLogger = ctrl.Log.WithName("quota-validator")
+
+//+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=core,resources=persistentvolumeclaims,verbs=create;update,versions=v1,name=pvc-quota-hook.topolvm.io,path=/quota/validate,mutating=false,sideEffects=none,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=create,versions=v1,name=volumesnapshot-quota-hook.topolvm.io,path=/quota/validate,mutating=false,sideEffects=none,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:rbac:groups=topolvm.io,resources=topolvmquotas,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=logicalvolumes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+
+// quotaValidator enforces TopoLVMQuotas on PVCs and VolumeSnapshots.
+type quotaValidator struct {
+	reader  client.Reader
+	getter  *getter.RetryMissingGetter
+	decoder admission.Decoder
+}
+
+// QuotaValidator creates a validating webhook for PVCs and VolumeSnapshots.
+func QuotaValidator(r client.Reader, apiReader client.Reader, dec admission.Decoder) http.Handler {
+	return &webhook.Admission{
+		Handler: &quotaValidator{
+			reader:  r,
+			getter:  getter.NewRetryMissingGetter(r, apiReader),
+			decoder: dec,
+		},
+	}
+}
+
+// Handle implements admission.Handler interface.
+func (v *quotaValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	var quotas topolvmv1.TopoLVMQuotaList
+	if err := v.reader.List(ctx, &quotas, client.InNamespace(req.Namespace)); err != nil {
+		qvLogger.Error(err, "failed to list TopoLVMQuotas", "namespace", req.Namespace)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(quotas.Items) == 0 {
+		return admission.Allowed("no quota")
+	}
+
+	var key corev1.ResourceName
+	var size int64
+	var err error
+	switch req.Kind.Kind {
+	case "PersistentVolumeClaim":
+		key, size, err = v.pvcRequest(ctx, req)
+	case "VolumeSnapshot":
+		key, size, err = v.snapshotRequest(ctx, req)
+	default:
+		return admission.Allowed("")
+	}
+	if err != nil {
+		qvLogger.Error(err, "failed to get the requested size", "kind", req.Kind.Kind, "namespace", req.Namespace, "name", req.Name)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if size <= 0 {
+		return admission.Allowed("")
+	}
+
+	used, err := quota.Usage(ctx, v.reader, req.Namespace)
+	if err != nil {
+		qvLogger.Error(err, "failed to get the usage", "namespace", req.Namespace)
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err := quota.Check(quotas.Items, used, key, size); err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
+
+// pvcRequest returns the device-class key and the size requested by a PVC creation or resize.
+func (v *quotaValidator) pvcRequest(ctx context.Context, req admission.Request) (corev1.ResourceName, int64, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := v.decoder.Decode(req, pvc); err != nil {
+		return "", 0, err
+	}
+	sc, err := topolvmStorageClass(ctx, v.getter, pvc)
+	if err != nil || sc == nil {
+		return "", 0, err
+	}
+
+	size := quota.RequestedSize(pvc)
+	if req.Operation == admissionv1.Update {
+		old := &corev1.PersistentVolumeClaim{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return "", 0, err
+		}
+		size -= quota.RequestedSize(old)
+	}
+	return deviceClassKey(sc), size, nil
+}
+
+// snapshotRequest returns the device-class key and the size of a VolumeSnapshot of a PVC.
+// The snapshot has the same size as the source volume.
+func (v *quotaValidator) snapshotRequest(ctx context.Context, req admission.Request) (corev1.ResourceName, int64, error) {
+	vs := &snapapi.VolumeSnapshot{}
+	if err := v.decoder.Decode(req, vs); err != nil {
+		return "", 0, err
+	}
+	if vs.Spec.Source.PersistentVolumeClaimName == nil {
+		return "", 0, nil
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	name := types.NamespacedName{Namespace: req.Namespace, Name: *vs.Spec.Source.PersistentVolumeClaimName}
+	if err := v.getter.Get(ctx, name, pvc); err != nil {
+		if apierrs.IsNotFound(err) {
+			return "", 0, nil
+		}
+		return "", 0, err
+	}
+	sc, err := topolvmStorageClass(ctx, v.getter, pvc)
+	if err != nil || sc == nil {
+		return "", 0, err
+	}
+
+	capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+	if !ok {
+		return deviceClassKey(sc), quota.RequestedSize(pvc), nil
+	}
+	return deviceClassKey(sc), capacity.Value(), nil
+}
+
+// topolvmStorageClass returns the StorageClass of the PVC, or nil if it is not for TopoLVM.
+func topolvmStorageClass(ctx context.Context, g *getter.RetryMissingGetter, pvc *corev1.PersistentVolumeClaim) (*storagev1.StorageClass, error) {
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return nil, nil
+	}
+	sc := &storagev1.StorageClass{}
+	if err := g.Get(ctx, types.NamespacedName{Name: *pvc.Spec.StorageClassName}, sc); err != nil {
+		if apierrs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if sc.Provisioner != topolvm.GetPluginName() {
+		return nil, nil
+	}
+	return sc, nil
+}
+
+func deviceClassKey(sc *storagev1.StorageClass) corev1.ResourceName {
+	return quota.DeviceClassKey(sc.Parameters[topolvm.GetDeviceClassKey()])
+}
@@ -0,0 +1,85 @@
+package hook
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const quotaNamespace = "test-quota"
+
+func quotaPVC(name, scName string, size int64) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{}
+	pvc.Namespace = quotaNamespace
+	pvc.Name = name
+	pvc.Spec.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	pvc.Spec.StorageClassName = ptr.To(scName)
+	pvc.Spec.Resources.Requests = corev1.ResourceList{
+		corev1.ResourceStorage: *resource.NewQuantity(size, resource.BinarySI),
+	}
+	return pvc
+}
+
+var _ = Describe("Quota validating webhook", Ordered, func() {
+	BeforeAll(func() {
+		ns := &corev1.Namespace{}
+		ns.Name = quotaNamespace
+		err := k8sClient.Create(testCtx, ns)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "quota-lv",
+				Labels: map[string]string{topolvm.GetNamespaceKey(): quotaNamespace},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        "quota-lv",
+				NodeName:    "node",
+				DeviceClass: deviceClass1,
+				Size:        resource.MustParse("6Gi"),
+			},
+		}
+		err = k8sClient.Create(testCtx, lv)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		q := &topolvmv1.TopoLVMQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "team-quota",
+				Namespace: quotaNamespace,
+			},
+			Spec: topolvmv1.TopoLVMQuotaSpec{
+				Hard: corev1.ResourceList{
+					deviceClass1: resource.MustParse("10Gi"),
+				},
+			},
+		}
+		err = k8sClient.Create(testCtx, q)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should deny a PVC exceeding the quota", func() {
+		// dry-run until the webhook sees the quota and the LogicalVolume
+		Eventually(func() error {
+			return k8sClient.Create(testCtx, quotaPVC("quota-pvc-large", topolvmProvisionerStorageClassName, 5<<30), client.DryRunAll)
+		}).Should(MatchError(ContainSubstring("exceeded quota team-quota for device-class dc1")))
+	})
+
+	It("should allow a PVC within the quota", func() {
+		err := k8sClient.Create(testCtx, quotaPVC("quota-pvc-small", topolvmProvisionerStorageClassName, 4<<30))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should allow PVCs of device-classes without quota", func() {
+		err := k8sClient.Create(testCtx, quotaPVC("quota-pvc-dc2", topolvmProvisioner2StorageClassName, 100<<30))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = k8sClient.Create(testCtx, quotaPVC("quota-pvc-local", hostLocalStorageClassName, 100<<30))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,217 @@
+package hook
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/filesystem"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var scvLogger = ctrl.Log.WithName("storageclass-validator")
+
+//+kubebuilder:webhook:failurePolicy=fail,matchPolicy=equivalent,groups=storage.k8s.io,resources=storageclasses,verbs=create,versions=v1,name=storageclass-hook.topolvm.io,path=/storageclass/validate,mutating=false,sideEffects=none,admissionReviewVersions={v1,v1beta1}
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=topolvm.io,resources=nodestorages,verbs=get;list;watch
+
+const (
+	// fsTypeKey is the StorageClass parameter for the filesystem type.
+	fsTypeKey = "csi.storage.k8s.io/fstype"
+
+	// provisionerParameterPrefix is the prefix of the parameters reserved by external-provisioner.
+	// They are removed before CreateVolume is called.
+	provisionerParameterPrefix = "csi.storage.k8s.io/"
+)
+
+// supportedFsTypes are the filesystem types TopoLVM can create. An empty type means ext4.
+var supportedFsTypes = []string{"", "ext4", "xfs", "btrfs"}
+
+// storageClassValidator validates StorageClasses for TopoLVM.
+type storageClassValidator struct {
+	reader  client.Reader
+	decoder admission.Decoder
+}
+
+// StorageClassValidator creates a validating webhook for StorageClasses.
+func StorageClassValidator(r client.Reader, dec admission.Decoder) http.Handler {
+	return &webhook.Admission{
+		Handler: &storageClassValidator{
+			reader:  r,
+			decoder: dec,
+		},
+	}
+}
+
+// Handle implements admission.Handler interface.
+func (v *storageClassValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	sc := &storagev1.StorageClass{}
+	err := v.decoder.Decode(req, sc)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if sc.Provisioner != topolvm.GetPluginName() {
+		return admission.Allowed("not a StorageClass for TopoLVM")
+	}
+
+	known, err := v.knownClasses(ctx)
+	if err != nil {
+		scvLogger.Error(err, "knownClasses failed")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	if errs := validateParameters(sc.Parameters, known); len(errs) != 0 {
+		return admission.Denied(strings.Join(errs, "; "))
+	}
+	return admission.Allowed("")
+}
+
+// classes holds the device-classes and lvcreate-option-classes available in the cluster.
+// The default device-class is stored as an empty name.
+type classes struct {
+	deviceClasses map[string]bool
+	optionClasses map[string]bool
+	// fsTypes are the default filesystems of the device-classes reported by NodeStorages.
+	fsTypes map[string]map[string]bool
+}
+
+// knownClasses aggregates the classes from the capacity annotations of Nodes and NodeStorages.
+func (v *storageClassValidator) knownClasses(ctx context.Context) (*classes, error) {
+	known := &classes{
+		deviceClasses: make(map[string]bool),
+		optionClasses: make(map[string]bool),
+		fsTypes:       make(map[string]map[string]bool),
+	}
+
+	var nodes corev1.NodeList
+	if err := v.reader.List(ctx, &nodes); err != nil {
+		return nil, err
+	}
+	for _, node := range nodes.Items {
+		for key := range node.Annotations {
+			dc, ok := strings.CutPrefix(key, topolvm.GetCapacityKeyPrefix())
+			if !ok {
+				continue
+			}
+			if dc == topolvm.DefaultDeviceClassAnnotationName {
+				dc = topolvm.DefaultDeviceClassName
+			}
+			known.deviceClasses[dc] = true
+		}
+	}
+
+	var nss topolvmv1.NodeStorageList
+	err := v.reader.List(ctx, &nss)
+	if meta.IsNoMatchError(err) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range nss.Items {
+		for _, dc := range ns.Status.DeviceClasses {
+			known.deviceClasses[dc.Name] = true
+			known.addFsType(dc.Name, dc.FsType)
+			if dc.Default {
+				known.deviceClasses[topolvm.DefaultDeviceClassName] = true
+				known.addFsType(topolvm.DefaultDeviceClassName, dc.FsType)
+			}
+		}
+		for _, oc := range ns.Status.LvcreateOptionClasses {
+			known.optionClasses[oc] = true
+		}
+	}
+	return known, nil
+}
+
+func (c *classes) addFsType(deviceClass, fsType string) {
+	if fsType == "" {
+		fsType = "ext4"
+	}
+	if c.fsTypes[deviceClass] == nil {
+		c.fsTypes[deviceClass] = make(map[string]bool)
+	}
+	c.fsTypes[deviceClass][fsType] = true
+}
+
+// volumeFsTypes returns the filesystems the volumes of the StorageClass may be formatted with.
+// Without fsType, it is the default of the device-class, which may differ between nodes.
+func (c *classes) volumeFsTypes(fsType, deviceClass string) []string {
+	if fsType != "" {
+		return []string{fsType}
+	}
+	if fsTypes := c.fsTypes[deviceClass]; len(fsTypes) != 0 {
+		return slices.Sorted(maps.Keys(fsTypes))
+	}
+	return []string{"ext4"}
+}
+
+// validateParameters returns the problems of the StorageClass parameters.
+// Device-classes and lvcreate-option-classes are checked only when some nodes report them,
+// so that StorageClasses can be created before TopoLVM starts on the nodes.
+func validateParameters(params map[string]string, known *classes) []string {
+	var errs []string
+	for key := range params {
+		switch {
+		case key == topolvm.GetDeviceClassKey(),
+			key == topolvm.GetLvcreateOptionClassKey(),
+			key == topolvm.GetAllowCrossNodeCopyKey(),
+			key == topolvm.GetMkfsOptionsKey(),
+			key == topolvm.GetFsckPolicyKey(),
+			strings.HasPrefix(key, provisionerParameterPrefix):
+		default:
+			errs = append(errs, fmt.Sprintf("unknown parameter %q", key))
+		}
+	}
+
+	if fsType := params[fsTypeKey]; !slices.Contains(supportedFsTypes, fsType) {
+		errs = append(errs, fmt.Sprintf("unsupported fsType %q", fsType))
+	}
+
+	if v, ok := params[topolvm.GetAllowCrossNodeCopyKey()]; ok {
+		if _, err := strconv.ParseBool(v); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid value of %s: %q", topolvm.GetAllowCrossNodeCopyKey(), v))
+		}
+	}
+
+	if err := filesystem.ValidateFsckPolicy(params[topolvm.GetFsckPolicyKey()]); err != nil {
+		errs = append(errs, fmt.Sprintf("invalid %s: %v", topolvm.GetFsckPolicyKey(), err))
+	}
+
+	dc := params[topolvm.GetDeviceClassKey()]
+	if options := strings.Fields(params[topolvm.GetMkfsOptionsKey()]); len(options) != 0 {
+		for _, fsType := range known.volumeFsTypes(params[fsTypeKey], dc) {
+			if err := filesystem.ValidateMkfsOptions(fsType, options); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid %s: %v", topolvm.GetMkfsOptionsKey(), err))
+			}
+		}
+	}
+
+	if len(known.deviceClasses) != 0 && !known.deviceClasses[dc] {
+		if dc == topolvm.DefaultDeviceClassName {
+			errs = append(errs, "no default device-class is available")
+		} else {
+			errs = append(errs, fmt.Sprintf("unknown device-class %q", dc))
+		}
+	}
+
+	oc := params[topolvm.GetLvcreateOptionClassKey()]
+	if oc != "" && len(known.optionClasses) != 0 && !known.optionClasses[oc] {
+		errs = append(errs, fmt.Sprintf("unknown lvcreate-option-class %q", oc))
+	}
+
+	slices.Sort(errs)
+	return errs
+}
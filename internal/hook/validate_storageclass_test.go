@@ -0,0 +1,153 @@
+package hook
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testStorageClass(name string, params map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Provisioner: topolvm.GetPluginName(),
+		Parameters:  params,
+	}
+}
+
+var _ = Describe("StorageClass validating webhook", Ordered, func() {
+	BeforeAll(func() {
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "validate-sc-node",
+				Annotations: map[string]string{
+					topolvm.GetCapacityKeyPrefix() + topolvm.DefaultDeviceClassAnnotationName: "1073741824",
+					topolvm.GetCapacityKeyPrefix() + "ssd":                                    "1073741824",
+				},
+			},
+		}
+		err := k8sClient.Create(testCtx, node)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		ns := &topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "validate-sc-node2",
+			},
+		}
+		err = k8sClient.Create(testCtx, ns)
+		Expect(err).ShouldNot(HaveOccurred())
+		ns.Status = topolvmv1.NodeStorageStatus{
+			DeviceClasses: []topolvmv1.DeviceClassStorageStatus{
+				{Name: "hdd", VolumeGroup: "vg", Health: topolvmv1.StorageHealthy},
+			},
+			LvcreateOptionClasses: []string{"raid1"},
+		}
+		err = k8sClient.Status().Update(testCtx, ns)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// wait for the webhook to see the NodeStorage and then the Node.
+		// The invalid fsType makes sure the first StorageClass is never created.
+		Eventually(func() error {
+			return k8sClient.Create(testCtx, testStorageClass("validate-sc-sync", map[string]string{
+				topolvm.GetLvcreateOptionClassKey(): "raid0",
+				"csi.storage.k8s.io/fstype":         "ntfs",
+			}))
+		}).Should(MatchError(ContainSubstring("lvcreate-option-class")))
+		Eventually(func() error {
+			return k8sClient.Create(testCtx, testStorageClass("validate-sc-sync", map[string]string{
+				topolvm.GetDeviceClassKey():         "ssd",
+				topolvm.GetLvcreateOptionClassKey(): "raid1",
+			}))
+		}).Should(Succeed())
+	})
+
+	It("should allow valid StorageClasses", func() {
+		err := k8sClient.Create(testCtx, testStorageClass("validate-sc-default", nil))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = k8sClient.Create(testCtx, testStorageClass("validate-sc-ssd", map[string]string{
+			topolvm.GetDeviceClassKey():                       "ssd",
+			"csi.storage.k8s.io/fstype":                       "xfs",
+			"csi.storage.k8s.io/provisioner-secret-name":      "secret",
+			"csi.storage.k8s.io/provisioner-secret-namespace": "default",
+			topolvm.GetAllowCrossNodeCopyKey():                "true",
+		}))
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should allow StorageClasses of other provisioners", func() {
+		sc := testStorageClass("validate-sc-other", map[string]string{"foo": "bar"})
+		sc.Provisioner = "example.com/other"
+		err := k8sClient.Create(testCtx, sc)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("should deny unknown device-classes", func() {
+		err := k8sClient.Create(testCtx, testStorageClass("validate-sc-nvme", map[string]string{
+			topolvm.GetDeviceClassKey(): "nvme",
+		}))
+		Expect(err).Should(MatchError(ContainSubstring(`unknown device-class "nvme"`)))
+	})
+
+	It("should deny unknown lvcreate-option-classes", func() {
+		err := k8sClient.Create(testCtx, testStorageClass("validate-sc-raid0", map[string]string{
+			topolvm.GetDeviceClassKey():         "ssd",
+			topolvm.GetLvcreateOptionClassKey(): "raid0",
+		}))
+		Expect(err).Should(MatchError(ContainSubstring(`unknown lvcreate-option-class "raid0"`)))
+	})
+
+	It("should deny unknown parameters and unsupported fsTypes", func() {
+		err := k8sClient.Create(testCtx, testStorageClass("validate-sc-typo", map[string]string{
+			topolvm.GetPluginName() + "/deviceclass": "ssd",
+			"csi.storage.k8s.io/fstype":              "ntfs",
+		}))
+		Expect(err).Should(MatchError(ContainSubstring(`unknown parameter "topolvm.io/deviceclass"`)))
+		Expect(err).Should(MatchError(ContainSubstring(`unsupported fsType "ntfs"`)))
+	})
+
+	It("should deny invalid values of allow-cross-node-copy", func() {
+		err := k8sClient.Create(testCtx, testStorageClass("validate-sc-copy", map[string]string{
+			topolvm.GetAllowCrossNodeCopyKey(): "yes please",
+		}))
+		Expect(err).Should(MatchError(ContainSubstring(`invalid value of topolvm.io/allow-cross-node-copy: "yes please"`)))
+	})
+
+	It("should validate mkfs-options against fsType", func() {
+		err := k8sClient.Create(testCtx, testStorageClass("validate-sc-mkfs", map[string]string{
+			"csi.storage.k8s.io/fstype": "xfs",
+			topolvm.GetMkfsOptionsKey(): "-m reflink=1 -l size=64m",
+		}))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = k8sClient.Create(testCtx, testStorageClass("validate-sc-mkfs-logdev", map[string]string{
+			"csi.storage.k8s.io/fstype": "xfs",
+			topolvm.GetMkfsOptionsKey(): "-l logdev=/dev/sda",
+		}))
+		Expect(err).Should(MatchError(ContainSubstring(`unsupported sub-option of mkfs option -l for xfs: "logdev"`)))
+
+		// the volumes of hdd are formatted with ext4 by default.
+		err = k8sClient.Create(testCtx, testStorageClass("validate-sc-mkfs-ext4", map[string]string{
+			topolvm.GetDeviceClassKey(): "hdd",
+			topolvm.GetMkfsOptionsKey(): "-m reflink=1 -K",
+		}))
+		Expect(err).Should(MatchError(ContainSubstring(`invalid topolvm.io/mkfs-options: unsupported mkfs option for ext4: "-K"`)))
+	})
+
+	It("should validate fsck-policy", func() {
+		err := k8sClient.Create(testCtx, testStorageClass("validate-sc-fsck", map[string]string{
+			topolvm.GetFsckPolicyKey(): "auto-repair-safe",
+		}))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		err = k8sClient.Create(testCtx, testStorageClass("validate-sc-fsck-invalid", map[string]string{
+			topolvm.GetFsckPolicyKey(): "repair",
+		}))
+		Expect(err).Should(MatchError(ContainSubstring(`invalid topolvm.io/fsck-policy: unsupported fsck policy: "repair"`)))
+	})
+})
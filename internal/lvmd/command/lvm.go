@@ -66,6 +66,11 @@ func (vg *VolumeGroup) Name() string {
 	return vg.state.name
 }
 
+// UUID returns the UUID of the volume group.
+func (vg *VolumeGroup) UUID() string {
+	return vg.state.uuid
+}
+
 // Size returns the capacity of the volume group in bytes.
 func (vg *VolumeGroup) Size() (uint64, error) {
 	return vg.state.size, nil
@@ -325,6 +330,11 @@ func (t *ThinPool) VG() *VolumeGroup {
 	return t.vg
 }
 
+// Attr returns lv_attr of the thin pool.
+func (t *ThinPool) Attr() string {
+	return t.state.attr
+}
+
 // Size returns a size of the thin pool.
 func (t *ThinPool) Size() uint64 {
 	return t.state.size
@@ -511,6 +521,37 @@ func (l *LogicalVolume) Tags() []string {
 	return l.tags
 }
 
+// AddTags adds the tags to the logical volume.
+func (l *LogicalVolume) AddTags(ctx context.Context, tags ...string) error {
+	return l.changeTags(ctx, "--addtag", tags)
+}
+
+// DeleteTags deletes the tags from the logical volume.
+func (l *LogicalVolume) DeleteTags(ctx context.Context, tags ...string) error {
+	return l.changeTags(ctx, "--deltag", tags)
+}
+
+func (l *LogicalVolume) changeTags(ctx context.Context, option string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	lvchangeArgs := []string{"lvchange"}
+	for _, tag := range tags {
+		lvchangeArgs = append(lvchangeArgs, option, tag)
+	}
+	lvchangeArgs = append(lvchangeArgs, l.fullname)
+	if err := callLVM(ctx, lvchangeArgs...); err != nil {
+		return err
+	}
+
+	vol, err := l.vg.FindVolume(ctx, l.name)
+	if err != nil {
+		return err
+	}
+	l.tags = vol.tags
+	return nil
+}
+
 // Attr returns the attr flag field of the logical volume.
 func (l *LogicalVolume) Attr() string {
 	return l.attr
@@ -534,6 +575,23 @@ func (l *LogicalVolume) ThinSnapshot(ctx context.Context, name string, tags []st
 	return callLVM(ctx, lvcreateArgs...)
 }
 
+// Snapshot takes a thick snapshot of a volume with cowSize bytes to store the changes of the volume.
+// The volume must not be thinly-provisioned.
+func (l *LogicalVolume) Snapshot(ctx context.Context, name string, cowSize uint64, tags []string) error {
+	if l.IsThin() {
+		return fmt.Errorf("cannot take thick snapshot of thin volume: %s", l.fullname)
+	}
+
+	lvcreateArgs := []string{"lvcreate", "-s", "-n", name, "-L", fmt.Sprintf("%vb", cowSize), l.fullname}
+
+	for _, tag := range tags {
+		lvcreateArgs = append(lvcreateArgs, "--addtag")
+		lvcreateArgs = append(lvcreateArgs, tag)
+	}
+
+	return callLVM(ctx, lvcreateArgs...)
+}
+
 // Activate activates the logical volume for desired access.
 func (l *LogicalVolume) Activate(ctx context.Context, access string) error {
 	var lvchangeArgs []string
@@ -549,6 +607,37 @@ func (l *LogicalVolume) Activate(ctx context.Context, access string) error {
 	return callLVM(ctx, lvchangeArgs...)
 }
 
+// MergeThinSnapshot merges this thin snapshot into its origin volume.
+// The snapshot is removed after the merge, and the origin is activated writable.
+// If the origin is open, LVM defers the merge until the origin is activated next time.
+func (l *LogicalVolume) MergeThinSnapshot(ctx context.Context) error {
+	if !l.IsThin() || !l.IsSnapshot() {
+		return fmt.Errorf("cannot merge non-thin-snapshot volume: %s", l.fullname)
+	}
+
+	if err := callLVM(ctx, "lvconvert", "--merge", l.fullname); err != nil {
+		return err
+	}
+
+	origin, err := l.vg.FindVolume(ctx, *l.origin)
+	if err != nil {
+		return err
+	}
+	// the origin inherits the permission of the snapshot, which is read-only for VolumeSnapshots.
+	return origin.MakeWritable(ctx)
+}
+
+// MakeWritable changes the permission of the logical volume to read-write if it is read-only,
+// and activates it for write.
+func (l *LogicalVolume) MakeWritable(ctx context.Context) error {
+	if Permissions(l.attr[1]) != PermissionsWriteable {
+		if err := callLVM(ctx, "lvchange", "-p", "rw", l.fullname); err != nil {
+			return err
+		}
+	}
+	return l.Activate(ctx, "rw")
+}
+
 // Resize this volume.
 // newSize is a new size of this volume in bytes.
 func (l *LogicalVolume) Resize(ctx context.Context, newSize uint64) error {
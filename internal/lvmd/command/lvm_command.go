@@ -83,6 +83,18 @@ func callLVMStreamed(ctx context.Context, logVerbosity int, args ...string) (io.
 	return runCommand(ctx, logVerbosity, cmd)
 }
 
+// callThinToolStreamed calls a device-mapper or thin-provisioning-tools command such as dmsetup or thin_delta,
+// and returns the output as a ReadCloser in the same way as callLVMStreamed.
+// The command is run with the lvm command prefix except the lvm path itself, so that it runs in the same namespaces.
+func callThinToolStreamed(ctx context.Context, args ...string) (io.ReadCloser, error) {
+	ctx = log.IntoContext(ctx, log.FromContext(ctx).WithCallDepth(1))
+	wholeCommand := slices.Concat(lvmCommandPrefix[:len(lvmCommandPrefix)-1], args)
+	cmd := exec.CommandContext(ctx, wholeCommand[0], wholeCommand[1:]...)
+	cmd.Env = os.Environ()
+	cmd.Env = append(cmd.Env, "LC_ALL=C")
+	return runCommand(ctx, verbosityLVMStateNoUpdate, cmd)
+}
+
 // runCommand runs the command and returns the stdout as a ReadCloser that also Waits for the command to finish.
 // After the Close command is called the cmd is closed and the resources are released.
 // Not calling close on this method will result in a resource leak.
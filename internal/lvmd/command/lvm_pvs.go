@@ -0,0 +1,100 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+)
+
+// PhysicalVolume represents a physical volume.
+type PhysicalVolume struct {
+	name   string
+	vgName string
+	size   uint64
+	free   uint64
+	attr   string
+}
+
+func (p *PhysicalVolume) UnmarshalJSON(data []byte) error {
+	type pvInternal struct {
+		Name   string `json:"pv_name"`
+		VGName string `json:"vg_name"`
+		Size   string `json:"pv_size"`
+		Free   string `json:"pv_free"`
+		Attr   string `json:"pv_attr"`
+	}
+
+	var temp pvInternal
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+
+	p.name = temp.Name
+	p.vgName = temp.VGName
+	p.attr = temp.Attr
+
+	var convErr error
+	p.size, convErr = strconv.ParseUint(temp.Size, 10, 64)
+	if convErr != nil {
+		return convErr
+	}
+	p.free, convErr = strconv.ParseUint(temp.Free, 10, 64)
+	if convErr != nil {
+		return convErr
+	}
+
+	return nil
+}
+
+// Name returns the device path of the physical volume.
+func (p *PhysicalVolume) Name() string {
+	return p.name
+}
+
+// VGName returns the name of the volume group to which the physical volume belongs.
+func (p *PhysicalVolume) VGName() string {
+	return p.vgName
+}
+
+// Size returns the size of the physical volume.
+func (p *PhysicalVolume) Size() uint64 {
+	return p.size
+}
+
+// Free returns the free space of the physical volume.
+func (p *PhysicalVolume) Free() uint64 {
+	return p.free
+}
+
+// Missing returns true if the device of the physical volume is missing.
+// The third character of pv_attr is 'm' for a missing physical volume.
+func (p *PhysicalVolume) Missing() bool {
+	return len(p.attr) > 2 && p.attr[2] == 'm'
+}
+
+// ListPhysicalVolumes lists all physical volumes that belong to volume groups.
+func ListPhysicalVolumes(ctx context.Context) ([]*PhysicalVolume, error) {
+	type pvReport struct {
+		Report []struct {
+			PV []*PhysicalVolume `json:"pv"`
+		} `json:"report"`
+	}
+	res := new(pvReport)
+	args := []string{
+		"pvs", "-o", "pv_name,vg_name,pv_size,pv_free,pv_attr", "--units", "b", "--nosuffix", "--reportformat", "json",
+	}
+	if err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate, args...); err != nil {
+		return nil, err
+	}
+
+	var pvs []*PhysicalVolume
+	for _, report := range res.Report {
+		for _, pv := range report.PV {
+			if pv.vgName == "" {
+				continue
+			}
+			pvs = append(pvs, pv)
+		}
+	}
+	return pvs, nil
+}
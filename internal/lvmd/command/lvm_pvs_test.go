@@ -0,0 +1,52 @@
+package command
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPhysicalVolumeJSON(t *testing.T) {
+	data := `
+	  [
+		{
+		  "pv_name": "/dev/loop0",
+		  "vg_name": "myvg1",
+		  "pv_size": "4290772992",
+		  "pv_free": "3221225472",
+		  "pv_attr": "a--"
+		},
+		{
+		  "pv_name": "[unknown]",
+		  "vg_name": "myvg1",
+		  "pv_size": "4290772992",
+		  "pv_free": "4290772992",
+		  "pv_attr": "a-m"
+		}
+	  ]`
+
+	var pvs []*PhysicalVolume
+	if err := json.Unmarshal([]byte(data), &pvs); err != nil {
+		t.Fatal(err)
+	}
+	if len(pvs) != 2 {
+		t.Fatalf("unexpected number of physical volumes: %d", len(pvs))
+	}
+
+	pv := pvs[0]
+	if pv.Name() != "/dev/loop0" || pv.VGName() != "myvg1" {
+		t.Errorf("unexpected name: %s, %s", pv.Name(), pv.VGName())
+	}
+	if pv.Size() != 4290772992 || pv.Free() != 3221225472 {
+		t.Errorf("unexpected size: %d, %d", pv.Size(), pv.Free())
+	}
+	if pv.Missing() {
+		t.Error("/dev/loop0 should not be missing")
+	}
+	if !pvs[1].Missing() {
+		t.Error("[unknown] should be missing")
+	}
+
+	if err := json.Unmarshal([]byte(`{"pv_size": "invalid", "pv_free": "0"}`), &PhysicalVolume{}); err == nil {
+		t.Error("invalid size should be rejected")
+	}
+}
@@ -0,0 +1,252 @@
+package command
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// sectorSize is the unit of data_block_size in the thin pool metadata.
+const sectorSize = 512
+
+// thinMetadataMu serializes the use of metadata snapshots because a thin pool can reserve only one at a time.
+var thinMetadataMu sync.Mutex
+
+// BlockRange represents a range of a logical volume in bytes.
+type BlockRange struct {
+	Offset uint64
+	Length uint64
+}
+
+// AllocatedRanges returns the ranges of this thin volume which are mapped to the thin pool.
+func (l *LogicalVolume) AllocatedRanges(ctx context.Context) ([]BlockRange, error) {
+	if !l.IsThin() {
+		return nil, fmt.Errorf("cannot get allocated ranges of non-thin volume: %s", l.fullname)
+	}
+	id, err := l.thinID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []BlockRange
+	err = l.withMetadataSnapshot(ctx, func(tmeta string) error {
+		out, err := callThinToolStreamed(ctx, "thin_dump", "--metadata-snap", "--dev-id", id, tmeta)
+		if err != nil {
+			return err
+		}
+		var parseErr error
+		ranges, parseErr = parseThinDump(out)
+		return errors.Join(parseErr, out.Close())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clipRanges(ranges, l.size), nil
+}
+
+// ChangedRanges returns the ranges of this thin volume which differ from the base thin volume.
+// Ranges which are unmapped in this volume but mapped in the base are also included.
+func (l *LogicalVolume) ChangedRanges(ctx context.Context, base *LogicalVolume) ([]BlockRange, error) {
+	if !l.IsThin() || !base.IsThin() {
+		return nil, fmt.Errorf("cannot compare non-thin volumes: %s, %s", base.fullname, l.fullname)
+	}
+	if l.pool == nil || base.pool == nil || *l.pool != *base.pool {
+		return nil, fmt.Errorf("cannot compare volumes in different thin pools: %s, %s", base.fullname, l.fullname)
+	}
+	baseID, err := base.thinID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := l.thinID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []BlockRange
+	err = l.withMetadataSnapshot(ctx, func(tmeta string) error {
+		out, err := callThinToolStreamed(ctx, "thin_delta", "--metadata-snap", "--snap1", baseID, "--snap2", id, tmeta)
+		if err != nil {
+			return err
+		}
+		var parseErr error
+		ranges, parseErr = parseThinDelta(out)
+		return errors.Join(parseErr, out.Close())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clipRanges(ranges, l.size), nil
+}
+
+// thinID returns the device ID of this thin volume in the thin pool.
+func (l *LogicalVolume) thinID(ctx context.Context) (string, error) {
+	type thinIDReport struct {
+		Report []struct {
+			LV []struct {
+				ThinID string `json:"thin_id"`
+			} `json:"lv"`
+		} `json:"report"`
+	}
+
+	res := new(thinIDReport)
+	err := callLVMInto(ctx, res, verbosityLVMStateNoUpdate, "lvs", l.fullname, "-o", "thin_id", "--reportformat", "json")
+	if IsLVMNotFound(err) {
+		return "", errors.Join(ErrNotFound, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(res.Report) == 0 || len(res.Report[0].LV) == 0 || res.Report[0].LV[0].ThinID == "" {
+		return "", fmt.Errorf("failed to get thin device id: %s", l.fullname)
+	}
+	return res.Report[0].LV[0].ThinID, nil
+}
+
+// withMetadataSnapshot reserves a metadata snapshot of the thin pool of this volume while calling fn.
+// fn receives the path to the metadata device, which must be read with the --metadata-snap option.
+func (l *LogicalVolume) withMetadataSnapshot(ctx context.Context, fn func(tmeta string) error) error {
+	if l.pool == nil {
+		return fmt.Errorf("volume is not in a thin pool: %s", l.fullname)
+	}
+	dmName := dmEscape(l.vg.Name()) + "-" + dmEscape(*l.pool)
+	tpool := "/dev/mapper/" + dmName + "-tpool"
+	tmeta := "/dev/mapper/" + dmName + "_tmeta"
+
+	thinMetadataMu.Lock()
+	defer thinMetadataMu.Unlock()
+
+	if err := callThinTool(ctx, "dmsetup", "message", tpool, "0", "reserve_metadata_snap"); err != nil {
+		return err
+	}
+	defer func() {
+		// release the metadata snapshot even if ctx is canceled, otherwise the next reservation fails.
+		if err := callThinTool(context.WithoutCancel(ctx), "dmsetup", "message", tpool, "0", "release_metadata_snap"); err != nil {
+			log.FromContext(ctx).Error(err, "failed to release metadata snapshot", "pool", tpool)
+		}
+	}()
+	return fn(tmeta)
+}
+
+func callThinTool(ctx context.Context, args ...string) error {
+	out, err := callThinToolStreamed(ctx, args...)
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// dmEscape escapes a VG or LV name for a device-mapper name.
+func dmEscape(name string) string {
+	return strings.ReplaceAll(name, "-", "--")
+}
+
+// parseThinDump parses the XML output of `thin_dump --dev-id` into ranges mapped to the thin pool.
+func parseThinDump(data io.Reader) ([]BlockRange, error) {
+	type thinDumpResult struct {
+		DataBlockSize uint64 `xml:"data_block_size,attr"`
+		Devices       []struct {
+			RangeMappings []struct {
+				OriginBegin uint64 `xml:"origin_begin,attr"`
+				Length      uint64 `xml:"length,attr"`
+			} `xml:"range_mapping"`
+			SingleMappings []struct {
+				OriginBlock uint64 `xml:"origin_block,attr"`
+			} `xml:"single_mapping"`
+		} `xml:"device"`
+	}
+
+	var result thinDumpResult
+	if err := xml.NewDecoder(data).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.DataBlockSize == 0 {
+		return nil, errors.New("data_block_size is not found in thin_dump output")
+	}
+
+	blockSize := result.DataBlockSize * sectorSize
+	var ranges []BlockRange
+	for _, dev := range result.Devices {
+		for _, m := range dev.RangeMappings {
+			ranges = append(ranges, BlockRange{Offset: m.OriginBegin * blockSize, Length: m.Length * blockSize})
+		}
+		for _, m := range dev.SingleMappings {
+			ranges = append(ranges, BlockRange{Offset: m.OriginBlock * blockSize, Length: blockSize})
+		}
+	}
+	return mergeRanges(ranges), nil
+}
+
+// parseThinDelta parses the XML output of `thin_delta` into ranges which are not the same in both devices.
+func parseThinDelta(data io.Reader) ([]BlockRange, error) {
+	type thinDeltaResult struct {
+		DataBlockSize uint64 `xml:"data_block_size,attr"`
+		Diff          struct {
+			Entries []struct {
+				XMLName xml.Name
+				Begin   uint64 `xml:"begin,attr"`
+				Length  uint64 `xml:"length,attr"`
+			} `xml:",any"`
+		} `xml:"diff"`
+	}
+
+	var result thinDeltaResult
+	if err := xml.NewDecoder(data).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.DataBlockSize == 0 {
+		return nil, errors.New("data_block_size is not found in thin_delta output")
+	}
+
+	blockSize := result.DataBlockSize * sectorSize
+	var ranges []BlockRange
+	for _, e := range result.Diff.Entries {
+		// the other entries are left_only, right_only and different.
+		if e.XMLName.Local == "same" {
+			continue
+		}
+		ranges = append(ranges, BlockRange{Offset: e.Begin * blockSize, Length: e.Length * blockSize})
+	}
+	return mergeRanges(ranges), nil
+}
+
+// mergeRanges sorts ranges and merges overlapping or adjacent ones.
+func mergeRanges(ranges []BlockRange) []BlockRange {
+	slices.SortFunc(ranges, func(a, b BlockRange) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+
+	merged := make([]BlockRange, 0, len(ranges))
+	for _, r := range ranges {
+		if r.Length == 0 {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].Offset+merged[n-1].Length >= r.Offset {
+			last := &merged[n-1]
+			last.Length = max(last.Offset+last.Length, r.Offset+r.Length) - last.Offset
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// clipRanges drops or shortens ranges exceeding size because the last block of the pool may be partially used.
+func clipRanges(ranges []BlockRange, size uint64) []BlockRange {
+	clipped := ranges[:0]
+	for _, r := range ranges {
+		if r.Offset >= size {
+			break
+		}
+		r.Length = min(r.Length, size-r.Offset)
+		clipped = append(clipped, r)
+	}
+	return clipped
+}
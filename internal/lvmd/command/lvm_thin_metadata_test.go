@@ -0,0 +1,81 @@
+package command
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseThinDump(t *testing.T) {
+	// data_block_size is 128 sectors, i.e. 64KiB.
+	output := `<superblock uuid="" time="1" transaction="2" version="2" data_block_size="128" nr_data_blocks="1600">
+  <device dev_id="1" mapped_blocks="7" transaction="0" creation_time="0" snap_time="1">
+    <range_mapping origin_begin="0" data_begin="10" length="2" time="0"/>
+    <single_mapping origin_block="2" data_block="20" time="1"/>
+    <single_mapping origin_block="8" data_block="21" time="1"/>
+    <range_mapping origin_begin="4" data_begin="30" length="3" time="0"/>
+  </device>
+</superblock>
+`
+	ranges, err := parseThinDump(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []BlockRange{
+		{Offset: 0, Length: 3 * 65536},
+		{Offset: 4 * 65536, Length: 3 * 65536},
+		{Offset: 8 * 65536, Length: 65536},
+	}
+	if !slices.Equal(ranges, expected) {
+		t.Errorf("unexpected ranges: expected=%v, actual=%v", expected, ranges)
+	}
+
+	if _, err := parseThinDump(strings.NewReader(`<superblock uuid=""></superblock>`)); err == nil {
+		t.Error("error should happen without data_block_size")
+	}
+}
+
+func TestParseThinDelta(t *testing.T) {
+	output := `<superblock uuid="" time="2" transaction="3" data_block_size="128" nr_data_blocks="1600">
+  <diff left="1" right="2">
+    <same begin="0" length="2"/>
+    <different begin="2" length="1"/>
+    <right_only begin="3" length="2"/>
+    <same begin="5" length="5"/>
+    <left_only begin="10" length="1"/>
+  </diff>
+</superblock>
+`
+	ranges, err := parseThinDelta(strings.NewReader(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []BlockRange{
+		{Offset: 2 * 65536, Length: 3 * 65536},
+		{Offset: 10 * 65536, Length: 65536},
+	}
+	if !slices.Equal(ranges, expected) {
+		t.Errorf("unexpected ranges: expected=%v, actual=%v", expected, ranges)
+	}
+}
+
+func TestClipRanges(t *testing.T) {
+	ranges := []BlockRange{
+		{Offset: 0, Length: 100},
+		{Offset: 200, Length: 100},
+		{Offset: 400, Length: 100},
+	}
+	expected := []BlockRange{
+		{Offset: 0, Length: 100},
+		{Offset: 200, Length: 50},
+	}
+	if actual := clipRanges(ranges, 250); !slices.Equal(actual, expected) {
+		t.Errorf("unexpected ranges: expected=%v, actual=%v", expected, actual)
+	}
+}
+
+func TestDMEscape(t *testing.T) {
+	if actual := dmEscape("my-vg"); actual != "my--vg" {
+		t.Errorf("unexpected name: %s", actual)
+	}
+}
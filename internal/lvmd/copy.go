@@ -0,0 +1,206 @@
+package lvmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func (s *lvService) CopyLV(ctx context.Context, req *proto.CopyLVRequest) (*proto.CopyLVResponse, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName(), "source", req.GetSourceVolume())
+	sourceDC, err := s.dcmapper.DeviceClass(req.GetSourceDeviceClass())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.GetSourceDeviceClass())
+	}
+	dc, err := s.dcmapper.DeviceClass(req.GetDeviceClass())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.GetDeviceClass())
+	}
+
+	sourceVG, err := command.FindVolumeGroup(ctx, sourceDC.VolumeGroup)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	sourceLV, err := sourceVG.FindVolume(ctx, req.GetSourceVolume())
+	if errors.Is(err, command.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "source logical volume %s is not found", req.GetSourceVolume())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find source volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if sourceLV.Size() > uint64(req.GetSizeBytes()) {
+		return nil, status.Errorf(codes.OutOfRange, "requested size %d is smaller than source logical volume: %d", req.GetSizeBytes(), sourceLV.Size())
+	}
+
+	// a thin snapshot is as good as a copy in the same thin pool, and much faster.
+	if sourceLV.IsThin() && isSameThinPool(sourceDC, dc) {
+		vol, err := s.findVolume(ctx, dc, req.GetName())
+		if err != nil || vol != nil {
+			return &proto.CopyLVResponse{Volume: vol}, err
+		}
+		res, err := s.CreateLVSnapshot(ctx, &proto.CreateLVSnapshotRequest{
+			Name:         req.GetName(),
+			DeviceClass:  req.GetDeviceClass(),
+			SourceVolume: req.GetSourceVolume(),
+			SizeBytes:    req.GetSizeBytes(),
+			AccessType:   "rw",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &proto.CopyLVResponse{Volume: res.GetSnapshot()}, nil
+	}
+
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get pool from device class: %v", err)
+	}
+	// the volume may be left by a failed copy. The data in it must be overwritten entirely.
+	zeroGaps := true
+	lv, err := pool.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		if _, err := s.CreateLV(ctx, &proto.CreateLVRequest{
+			Name:                req.GetName(),
+			DeviceClass:         req.GetDeviceClass(),
+			LvcreateOptionClass: req.GetLvcreateOptionClass(),
+			SizeBytes:           req.GetSizeBytes(),
+		}); err != nil {
+			return nil, err
+		}
+		lv, err = pool.FindVolume(ctx, req.GetName())
+		// a new thin volume reads zeros.
+		zeroGaps = err != nil || !lv.IsThin()
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	copied, err := s.copyVolume(ctx, sourceVG, sourceLV, lv, zeroGaps)
+	if err != nil {
+		logger.Error(err, "failed to copy volume", "copied", copied)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Info("copied a LV", "size", lv.Size(), "copied", copied)
+	return &proto.CopyLVResponse{
+		Volume: &proto.LogicalVolume{
+			Name:      lv.Name(),
+			SizeBytes: int64(lv.Size()),
+			DevMajor:  lv.MajorNumber(),
+			DevMinor:  lv.MinorNumber(),
+		},
+		CopiedBytes: copied,
+	}, nil
+}
+
+// copyVolume writes the data of src into dst. If zeroGaps is true, the ranges of src not copied are filled with zeros.
+func (s *lvService) copyVolume(ctx context.Context, srcVG *command.VolumeGroup, src, dst *command.LogicalVolume,
+	zeroGaps bool) (uint64, error) {
+	src, ranges, cleanup, err := snapshotForRead(ctx, srcVG, src, s.notify)
+	if err != nil {
+		return 0, err
+	}
+	defer cleanup()
+
+	in, err := os.Open(src.Path())
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst.Path(), os.O_WRONLY, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var next uint64
+	copied, err := sendRanges(func(req *proto.ApplyLVDeltaRequest) error {
+		if zeroGaps {
+			if err := backup.WriteZeros(out, next, req.GetOffset()); err != nil {
+				return err
+			}
+		}
+		next = req.GetOffset() + uint64(len(req.GetData()))
+		_, err := out.WriteAt(req.GetData(), int64(req.GetOffset()))
+		return err
+	}, in, ranges)
+	if err == nil && zeroGaps {
+		err = backup.WriteZeros(out, next, src.Size())
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+	return copied, errors.Join(err, out.Close())
+}
+
+// findVolume returns the volume in the device class, or nil if it does not exist.
+func (s *lvService) findVolume(ctx context.Context, dc *lvmdTypes.DeviceClass, name string) (*proto.LogicalVolume, error) {
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	lv, err := vg.FindVolume(ctx, name)
+	if errors.Is(err, command.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &proto.LogicalVolume{
+		Name:      lv.Name(),
+		SizeBytes: int64(lv.Size()),
+		DevMajor:  lv.MajorNumber(),
+		DevMinor:  lv.MinorNumber(),
+	}, nil
+}
+
+// isSameThinPool returns true if both device classes use the same thin pool.
+func isSameThinPool(a, b *lvmdTypes.DeviceClass) bool {
+	return a.Type == lvmdTypes.TypeThin && b.Type == lvmdTypes.TypeThin &&
+		a.VolumeGroup == b.VolumeGroup && a.ThinPoolConfig.Name == b.ThinPoolConfig.Name
+}
+
+// snapshotForRead returns the volume to read and its ranges to be copied.
+// A thin volume is read from a snapshot so that the copy is consistent even if the volume is in use,
+// and cleanup removes the snapshot. A thick volume is read entirely.
+func snapshotForRead(ctx context.Context, vg *command.VolumeGroup, lv *command.LogicalVolume,
+	notify func()) (*command.LogicalVolume, []command.BlockRange, func(), error) {
+	if !lv.IsThin() {
+		return lv, []command.BlockRange{{Offset: 0, Length: lv.Size()}}, func() {}, nil
+	}
+
+	snapshotName := fmt.Sprintf("%s-copy-%d", lv.Name(), time.Now().UnixNano())
+	if err := lv.ThinSnapshot(ctx, snapshotName, nil); err != nil {
+		return nil, nil, nil, err
+	}
+	notify()
+	cleanup := func() {
+		// the snapshot is removed even if the client has gone.
+		if err := vg.RemoveVolume(context.WithoutCancel(ctx), snapshotName); err != nil {
+			log.FromContext(ctx).Error(err, "failed to remove snapshot", "snapshot", snapshotName)
+		}
+		notify()
+	}
+	snapshot, err := vg.FindVolume(ctx, snapshotName)
+	if err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	ranges, err := snapshot.AllocatedRanges(ctx)
+	if err != nil {
+		cleanup()
+		return nil, nil, nil, err
+	}
+	return snapshot, ranges, cleanup, nil
+}
@@ -0,0 +1,38 @@
+package lvmd
+
+import (
+	"testing"
+
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+func TestIsSameThinPool(t *testing.T) {
+	thin := func(vg, pool string) *lvmdTypes.DeviceClass {
+		return &lvmdTypes.DeviceClass{
+			VolumeGroup:    vg,
+			Type:           lvmdTypes.TypeThin,
+			ThinPoolConfig: &lvmdTypes.ThinPoolConfig{Name: pool},
+		}
+	}
+	thick := &lvmdTypes.DeviceClass{VolumeGroup: "vg1", Type: lvmdTypes.TypeThick}
+
+	cases := []struct {
+		name     string
+		a, b     *lvmdTypes.DeviceClass
+		expected bool
+	}{
+		{"same pool", thin("vg1", "pool1"), thin("vg1", "pool1"), true},
+		{"another pool", thin("vg1", "pool1"), thin("vg1", "pool2"), false},
+		{"another VG", thin("vg1", "pool1"), thin("vg2", "pool1"), false},
+		{"thin to thick", thin("vg1", "pool1"), thick, false},
+		{"thick to thin", thick, thin("vg1", "pool1"), false},
+		{"thick to thick", thick, thick, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := isSameThinPool(tc.a, tc.b); actual != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
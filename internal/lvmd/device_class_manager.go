@@ -4,8 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/internal/filesystem"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 )
 
@@ -32,6 +37,35 @@ func GetSpare(dc *lvmdTypes.DeviceClass) uint64 {
 	return *dc.SpareGB << 30
 }
 
+// GetTrashRetention returns how long removed volumes are kept in the trash of the device-class.
+// Zero means the volumes are removed immediately.
+func GetTrashRetention(dc *lvmdTypes.DeviceClass) time.Duration {
+	if dc.TrashRetention == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(dc.TrashRetention)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// GetMkfsOptions returns the extra arguments of mkfs to format volumes of the device-class with the filesystem.
+// For xfs on striped volumes, the stripe unit and width are derived from stripe and stripe-size
+// unless the data section options are given by mkfs-options.
+func GetMkfsOptions(dc *lvmdTypes.DeviceClass, fsType string) []string {
+	options := slices.Clone(dc.MkfsOptions[fsType])
+	if fsType == "xfs" && dc.Stripe != nil && *dc.Stripe > 1 && dc.StripeSize != "" && !slices.Contains(options, "-d") {
+		su := strings.ToLower(dc.StripeSize)
+		if _, err := strconv.ParseUint(su, 10, 64); err == nil {
+			// the unit of lvcreate --stripesize defaults to KiB.
+			su += "k"
+		}
+		options = append(options, "-d", fmt.Sprintf("su=%s,sw=%d", su, *dc.Stripe))
+	}
+	return options
+}
+
 // ValidateDeviceClasses validates device-classes
 func ValidateDeviceClasses(deviceClasses []*lvmdTypes.DeviceClass) error {
 	if len(deviceClasses) < 1 {
@@ -96,6 +130,32 @@ func ValidateDeviceClasses(deviceClasses []*lvmdTypes.DeviceClass) error {
 		if dc.StripeSize != "" && !stripeSizeRegexp.MatchString(dc.StripeSize) {
 			return fmt.Errorf("stripe-size format is \"Size[k|UNIT]\": %s", dc.Name)
 		}
+		if err := validateWarmPool(dc); err != nil {
+			return err
+		}
+		switch dc.WipePolicy {
+		case "", lvmdTypes.WipeNone, lvmdTypes.WipeDiscard, lvmdTypes.WipeZero, lvmdTypes.WipeRandom:
+		default:
+			return fmt.Errorf("unsupported wipe-policy: %s, %s", dc.Name, dc.WipePolicy)
+		}
+		// Overwriting a thin volume allocates all of its blocks in the thin pool, which may exhaust the pool.
+		if dc.Type == lvmdTypes.TypeThin && (dc.WipePolicy == lvmdTypes.WipeZero || dc.WipePolicy == lvmdTypes.WipeRandom) {
+			return fmt.Errorf("wipe-policy %s is not supported for thin device-class, use %s: %s", dc.WipePolicy, lvmdTypes.WipeDiscard, dc.Name)
+		}
+		if dc.FsType != "" && !filesystem.IsSupported(dc.FsType) {
+			return fmt.Errorf("unsupported fs-type: %s, %s", dc.Name, dc.FsType)
+		}
+		for fsType, options := range dc.MkfsOptions {
+			if err := filesystem.ValidateMkfsOptions(fsType, options); err != nil {
+				return fmt.Errorf("invalid mkfs-options: %s, %w", dc.Name, err)
+			}
+		}
+		if dc.TrashRetention != "" {
+			d, err := time.ParseDuration(dc.TrashRetention)
+			if err != nil || d < 0 {
+				return fmt.Errorf("trash-retention should be a non-negative duration: %s, %s", dc.Name, dc.TrashRetention)
+			}
+		}
 	}
 	if countDefault > 1 {
 		return errors.New("should not have multiple default device-class")
@@ -103,6 +163,27 @@ func ValidateDeviceClasses(deviceClasses []*lvmdTypes.DeviceClass) error {
 	return nil
 }
 
+func validateWarmPool(dc *lvmdTypes.DeviceClass) error {
+	seen := make(map[lvmdTypes.WarmPoolConfig]bool)
+	for _, wp := range dc.WarmPool {
+		if wp.SizeGB == 0 {
+			return fmt.Errorf("size-gb of warm-pool should be positive: %s", dc.Name)
+		}
+		if wp.Count < 1 {
+			return fmt.Errorf("count of warm-pool should be positive: %s", dc.Name)
+		}
+		if wp.FsType != "" && !filesystem.IsSupported(wp.FsType) {
+			return fmt.Errorf("unsupported fs-type of warm-pool: %s, %s", dc.Name, wp.FsType)
+		}
+		key := lvmdTypes.WarmPoolConfig{SizeGB: wp.SizeGB, FsType: wp.FsType}
+		if seen[key] {
+			return fmt.Errorf("duplicate warm-pool of %dGiB %s volumes: %s", wp.SizeGB, wp.FsType, dc.Name)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
 // DeviceClassManager maps between device-classes and volume groups.
 type DeviceClassManager struct {
 	defaultDeviceClass        *lvmdTypes.DeviceClass
@@ -151,6 +232,18 @@ func (m DeviceClassManager) DeviceClass(dcName string) (*lvmdTypes.DeviceClass,
 	return nil, ErrDeviceClassNotFound
 }
 
+// VolumeGroups returns the names of the volume groups of all the device-classes
+func (m DeviceClassManager) VolumeGroups() []string {
+	var names []string
+	for _, dc := range m.deviceClassByName {
+		if !slices.Contains(names, dc.VolumeGroup) {
+			names = append(names, dc.VolumeGroup)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
 // FindDeviceClassByVGName returns the device-class with the volume group name
 func (m DeviceClassManager) FindDeviceClassByVGName(vgName string) (*lvmdTypes.DeviceClass, error) {
 	if v, ok := m.deviceClassByVGName[vgName]; ok {
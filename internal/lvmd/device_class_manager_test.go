@@ -1,6 +1,7 @@
 package lvmd
 
 import (
+	"slices"
 	"strconv"
 	"testing"
 
@@ -372,6 +373,192 @@ func TestValidateDeviceClasses(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "warm-pool",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					WarmPool: []*lvmdTypes.WarmPoolConfig{
+						{SizeGB: 10, Count: 2, FsType: "ext4"},
+						{SizeGB: 10, Count: 1, FsType: "xfs"},
+						{SizeGB: 10, Count: 1},
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "warm-pool-zero-size",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					WarmPool:    []*lvmdTypes.WarmPoolConfig{{Count: 1}},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "warm-pool-zero-count",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					WarmPool:    []*lvmdTypes.WarmPoolConfig{{SizeGB: 10}},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "warm-pool-unsupported-fs",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					WarmPool:    []*lvmdTypes.WarmPoolConfig{{SizeGB: 10, Count: 1, FsType: "vfat"}},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "warm-pool-duplicate",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					WarmPool: []*lvmdTypes.WarmPoolConfig{
+						{SizeGB: 10, Count: 1, FsType: "ext4"},
+						{SizeGB: 10, Count: 2, FsType: "ext4"},
+					},
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "wipe-policy",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					WipePolicy:  lvmdTypes.WipeZero,
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "wipe-policy-unsupported",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					WipePolicy:  "shred",
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "wipe-policy-thin",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: 5.0,
+					},
+					WipePolicy: lvmdTypes.WipeZero,
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "wipe-policy-thin-discard",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					Type:        lvmdTypes.TypeThin,
+					ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+						Name:               "pool0",
+						OverprovisionRatio: 5.0,
+					},
+					WipePolicy: lvmdTypes.WipeDiscard,
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:           "trash-retention",
+					VolumeGroup:    "node1-myvg1",
+					Default:        true,
+					TrashRetention: "72h",
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:           "trash-retention-invalid",
+					VolumeGroup:    "node1-myvg1",
+					Default:        true,
+					TrashRetention: "3days",
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:           "trash-retention-negative",
+					VolumeGroup:    "node1-myvg1",
+					Default:        true,
+					TrashRetention: "-1h",
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "filesystem",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					FsType:      "xfs",
+					MkfsOptions: map[string][]string{
+						"xfs":  {"-m", "reflink=1"},
+						"ext4": {"-E", "lazy_itable_init=1", "-i", "16384", "-m", "1"},
+					},
+				},
+			},
+			valid: true,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "fs-type-unsupported",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					FsType:      "vfat",
+				},
+			},
+			valid: false,
+		},
+		{
+			deviceClasses: []*lvmdTypes.DeviceClass{
+				{
+					Name:        "mkfs-options-invalid",
+					VolumeGroup: "node1-myvg1",
+					Default:     true,
+					MkfsOptions: map[string][]string{"ext4": {"-n"}},
+				},
+			},
+			valid: false,
+		},
 	}
 
 	for i, c := range cases {
@@ -479,3 +666,63 @@ func TestDeviceClassManager(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGetMkfsOptions(t *testing.T) {
+	stripe := uint(4)
+	one := uint(1)
+	cases := []struct {
+		name     string
+		dc       *lvmdTypes.DeviceClass
+		fsType   string
+		expected []string
+	}{
+		{
+			name:   "none",
+			dc:     &lvmdTypes.DeviceClass{},
+			fsType: "xfs",
+		},
+		{
+			name:     "configured",
+			dc:       &lvmdTypes.DeviceClass{MkfsOptions: map[string][]string{"ext4": {"-m", "1"}}},
+			fsType:   "ext4",
+			expected: []string{"-m", "1"},
+		},
+		{
+			name:     "xfs stripe in KiB",
+			dc:       &lvmdTypes.DeviceClass{Stripe: &stripe, StripeSize: "64"},
+			fsType:   "xfs",
+			expected: []string{"-d", "su=64k,sw=4"},
+		},
+		{
+			name:     "xfs stripe with unit",
+			dc:       &lvmdTypes.DeviceClass{Stripe: &stripe, StripeSize: "1M", MkfsOptions: map[string][]string{"xfs": {"-m", "reflink=1"}}},
+			fsType:   "xfs",
+			expected: []string{"-m", "reflink=1", "-d", "su=1m,sw=4"},
+		},
+		{
+			name:     "xfs data section configured",
+			dc:       &lvmdTypes.DeviceClass{Stripe: &stripe, StripeSize: "64", MkfsOptions: map[string][]string{"xfs": {"-d", "agcount=4"}}},
+			fsType:   "xfs",
+			expected: []string{"-d", "agcount=4"},
+		},
+		{
+			name:   "single stripe",
+			dc:     &lvmdTypes.DeviceClass{Stripe: &one, StripeSize: "64"},
+			fsType: "xfs",
+		},
+		{
+			name:   "ext4 stripe",
+			dc:     &lvmdTypes.DeviceClass{Stripe: &stripe, StripeSize: "64"},
+			fsType: "ext4",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := GetMkfsOptions(c.dc, c.fsType)
+			if !slices.Equal(actual, c.expected) {
+				t.Errorf("expected %v, but got %v", c.expected, actual)
+			}
+		})
+	}
+}
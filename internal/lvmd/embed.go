@@ -2,11 +2,13 @@ package lvmd
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	gproto "google.golang.org/protobuf/proto"
@@ -14,12 +16,22 @@ import (
 )
 
 // NewEmbeddedServiceClients creates clients locally calling instead of using gRPC.
-func NewEmbeddedServiceClients(ctx context.Context, dcmapper *DeviceClassManager, ocmapper *LvcreateOptionClassManager) (
+// It also returns the server of the replication API sharing the notifier with them.
+// replicationCredentials are used to call the replication API of the peers.
+func NewEmbeddedServiceClients(ctx context.Context, dcmapper *DeviceClassManager, ocmapper *LvcreateOptionClassManager,
+	replicationCredentials credentials.TransportCredentials) (
 	proto.LVServiceClient,
 	proto.VGServiceClient,
+	proto.ReplicationServiceServer,
 ) {
-	vgServiceServerInstance, notifier := NewVGService(dcmapper)
-	lvServiceServerInstance := NewLVService(dcmapper, ocmapper, notifier)
+	wiper := NewWiper(dcmapper)
+	vgServiceServerInstance, notifier := NewVGService(dcmapper, ocmapper, wiper)
+	warmPool := NewWarmPool(dcmapper, notifier)
+	recycleBin := NewRecycleBin(dcmapper, wiper)
+	lvServiceServerInstance := NewLVService(dcmapper, ocmapper, warmPool, wiper, recycleBin, notifier, replicationCredentials)
+	go warmPool.Run(ctx)
+	go wiper.Run(ctx, notifier)
+	go recycleBin.Run(ctx, notifier)
 
 	caller := &embeddedServiceClients{
 		lvServiceServer: lvServiceServerInstance,
@@ -45,7 +57,7 @@ func NewEmbeddedServiceClients(ctx context.Context, dcmapper *DeviceClassManager
 		}
 	}()
 
-	return caller, caller
+	return caller, caller, NewReplicationService(dcmapper, notifier)
 }
 
 // embeddedServiceClients is a struct holding indirections to the local lvmd server.
@@ -151,6 +163,40 @@ func (l *embeddedServiceClients) CreateLVSnapshot(ctx context.Context, in *proto
 	return l.lvServiceServer.CreateLVSnapshot(ctx, in)
 }
 
+func (l *embeddedServiceClients) MergeLVSnapshot(ctx context.Context, in *proto.MergeLVSnapshotRequest, _ ...grpc.CallOption) (*proto.MergeLVSnapshotResponse, error) {
+	return l.lvServiceServer.MergeLVSnapshot(ctx, in)
+}
+
+// GetLVBlockMetadata calls the local server in a goroutine and returns a stream receiving its responses via channel.
+func (l *embeddedServiceClients) GetLVBlockMetadata(ctx context.Context, in *proto.GetLVBlockMetadataRequest, _ ...grpc.CallOption) (proto.LVService_GetLVBlockMetadataClient, error) {
+	stream := &embeddedBlockMetadataStream{ctx: ctx, ch: make(chan *proto.GetLVBlockMetadataResponse)}
+	go func() {
+		stream.err = l.lvServiceServer.GetLVBlockMetadata(in, stream)
+		close(stream.ch)
+	}()
+	return stream, nil
+}
+
+func (l *embeddedServiceClients) ReplicateLV(ctx context.Context, in *proto.ReplicateLVRequest, _ ...grpc.CallOption) (*proto.ReplicateLVResponse, error) {
+	return l.lvServiceServer.ReplicateLV(ctx, in)
+}
+
+func (l *embeddedServiceClients) CopyLV(ctx context.Context, in *proto.CopyLVRequest, _ ...grpc.CallOption) (*proto.CopyLVResponse, error) {
+	return l.lvServiceServer.CopyLV(ctx, in)
+}
+
+func (l *embeddedServiceClients) UndeleteLV(ctx context.Context, in *proto.UndeleteLVRequest, _ ...grpc.CallOption) (*proto.UndeleteLVResponse, error) {
+	return l.lvServiceServer.UndeleteLV(ctx, in)
+}
+
+func (l *embeddedServiceClients) PrepareLVCopy(ctx context.Context, in *proto.PrepareLVCopyRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	return l.lvServiceServer.PrepareLVCopy(ctx, in)
+}
+
+func (l *embeddedServiceClients) RemoveLVCopy(ctx context.Context, in *proto.RemoveLVCopyRequest, _ ...grpc.CallOption) (*proto.Empty, error) {
+	return l.lvServiceServer.RemoveLVCopy(ctx, in)
+}
+
 func (l *embeddedServiceClients) GetLVList(ctx context.Context, in *proto.GetLVListRequest, _ ...grpc.CallOption) (*proto.GetLVListResponse, error) {
 	return l.vgServiceServer.GetLVList(ctx, in)
 }
@@ -158,3 +204,68 @@ func (l *embeddedServiceClients) GetLVList(ctx context.Context, in *proto.GetLVL
 func (l *embeddedServiceClients) GetFreeBytes(ctx context.Context, in *proto.GetFreeBytesRequest, _ ...grpc.CallOption) (*proto.GetFreeBytesResponse, error) {
 	return l.vgServiceServer.GetFreeBytes(ctx, in)
 }
+
+// embeddedBlockMetadataStream is a local implementation of the LVService_GetLVBlockMetadataClient and
+// LVService_GetLVBlockMetadataServer that is used by GetLVBlockMetadata.
+// Unlike embeddedChannelWatch, the channel is closed when the server returns, and err holds the returned error.
+type embeddedBlockMetadataStream struct {
+	ctx context.Context
+	ch  chan *proto.GetLVBlockMetadataResponse
+	err error
+}
+
+// SetHeader is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedBlockMetadataStream) SetHeader(md metadata.MD) error { return nil }
+
+// SendHeader is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedBlockMetadataStream) SendHeader(md metadata.MD) error { return nil }
+
+// SetTrailer is stubbed out to satisfy the grpc.ServerStream interface.
+func (s *embeddedBlockMetadataStream) SetTrailer(md metadata.MD) {}
+
+// Header is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedBlockMetadataStream) Header() (metadata.MD, error) { return nil, nil }
+
+// Trailer is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedBlockMetadataStream) Trailer() metadata.MD { return nil }
+
+// CloseSend is stubbed out to satisfy the grpc.ClientStream interface.
+func (s *embeddedBlockMetadataStream) CloseSend() error { return nil }
+
+// Context returns the client context, which is also used by the server.
+func (s *embeddedBlockMetadataStream) Context() context.Context { return s.ctx }
+
+// SendMsg is stubbed out to satisfy the grpc.ClientStream and grpc.ServerStream interface.
+// The server sends responses with Send.
+func (s *embeddedBlockMetadataStream) SendMsg(m any) error {
+	return status.Error(codes.Unimplemented, "SendMsg is not supported")
+}
+
+// RecvMsg is stubbed out to satisfy the grpc.ClientStream and grpc.ServerStream interface.
+// The client receives responses with Recv.
+func (s *embeddedBlockMetadataStream) RecvMsg(m any) error {
+	return status.Error(codes.Unimplemented, "RecvMsg is not supported")
+}
+
+// Send is used to send a GetLVBlockMetadataResponse as a LVService_GetLVBlockMetadataServer.
+func (s *embeddedBlockMetadataStream) Send(m *proto.GetLVBlockMetadataResponse) error {
+	select {
+	case s.ch <- m:
+		return nil
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// Recv is used to receive a GetLVBlockMetadataResponse as a LVService_GetLVBlockMetadataClient.
+// It returns io.EOF after the server returned successfully.
+func (s *embeddedBlockMetadataStream) Recv() (*proto.GetLVBlockMetadataResponse, error) {
+	m, ok := <-s.ch
+	if ok {
+		return m, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return nil, io.EOF
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewEmbeddedServiceClients(t *testing.T) {
@@ -38,7 +40,7 @@ func TestNewEmbeddedServiceClients(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
-			_, vgclient := NewEmbeddedServiceClients(ctx, NewDeviceClassManager(tt.deviceClasses), NewLvcreateOptionClassManager(nil))
+			_, vgclient, _ := NewEmbeddedServiceClients(ctx, NewDeviceClassManager(tt.deviceClasses), NewLvcreateOptionClassManager(nil), nil)
 
 			watchClient, err := vgclient.Watch(ctx, &proto.Empty{}, nil)
 			if err != nil {
@@ -65,3 +67,21 @@ func TestNewEmbeddedServiceClients(t *testing.T) {
 		})
 	}
 }
+
+func TestEmbeddedGetLVBlockMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	lvclient, _, _ := NewEmbeddedServiceClients(ctx, NewDeviceClassManager(nil), NewLvcreateOptionClassManager(nil), nil)
+
+	stream, err := lvclient.GetLVBlockMetadata(ctx, &proto.GetLVBlockMetadataRequest{
+		Name:        "snap",
+		DeviceClass: "unknown",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound from the server: %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package lvmd
 
 import (
+	"maps"
+	"slices"
+
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 )
 
@@ -22,3 +25,8 @@ func NewLvcreateOptionClassManager(LvcreateOptionClasses []*lvmdTypes.LvcreateOp
 func (m LvcreateOptionClassManager) LvcreateOptionClass(name string) *lvmdTypes.LvcreateOptionClass {
 	return m.LvcreateOptionClassByName[name]
 }
+
+// Names returns the sorted names of the lvcreate-option-classes.
+func (m LvcreateOptionClassManager) Names() []string {
+	return slices.Sorted(maps.Keys(m.LvcreateOptionClassByName))
+}
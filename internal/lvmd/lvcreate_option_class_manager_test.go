@@ -1,6 +1,7 @@
 package lvmd
 
 import (
+	"slices"
 	"strconv"
 	"testing"
 
@@ -43,3 +44,13 @@ func TestLvcreateOptionClassManager(t *testing.T) {
 		}
 	}
 }
+
+func TestLvcreateOptionClassManagerNames(t *testing.T) {
+	ocm := NewLvcreateOptionClassManager([]*lvmdTypes.LvcreateOptionClass{
+		{Name: "raid1"},
+		{Name: "mirror"},
+	})
+	if names := ocm.Names(); !slices.Equal(names, []string{"mirror", "raid1"}) {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
@@ -4,29 +4,43 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/topolvm/topolvm/internal/lvmd/command"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-// NewLVService creates a new LVServiceServer
-func NewLVService(dcmapper *DeviceClassManager, ocmapper *LvcreateOptionClassManager, notifyFunc func()) proto.LVServiceServer {
+// NewLVService creates a new LVServiceServer.
+// warmPool may be nil if no volume is claimed from warm pools, wiper may be nil if no volume is wiped,
+// and recycleBin may be nil if no volume is kept in the trash.
+// replicationCredentials are used to call the replication API of the peers. ReplicateLV fails if it is nil.
+func NewLVService(dcmapper *DeviceClassManager, ocmapper *LvcreateOptionClassManager, warmPool *WarmPool, wiper *Wiper,
+	recycleBin *RecycleBin, notifyFunc func(), replicationCredentials credentials.TransportCredentials) proto.LVServiceServer {
 	return &lvService{
-		dcmapper:   dcmapper,
-		ocmapper:   ocmapper,
-		notifyFunc: notifyFunc,
+		dcmapper:               dcmapper,
+		ocmapper:               ocmapper,
+		warmPool:               warmPool,
+		wiper:                  wiper,
+		recycleBin:             recycleBin,
+		notifyFunc:             notifyFunc,
+		replicationCredentials: replicationCredentials,
 	}
 }
 
 type lvService struct {
 	proto.UnimplementedLVServiceServer
-	dcmapper   *DeviceClassManager
-	ocmapper   *LvcreateOptionClassManager
-	notifyFunc func()
+	dcmapper               *DeviceClassManager
+	ocmapper               *LvcreateOptionClassManager
+	warmPool               *WarmPool
+	wiper                  *Wiper
+	recycleBin             *RecycleBin
+	notifyFunc             func()
+	replicationCredentials credentials.TransportCredentials
 }
 
 func (s *lvService) notify() {
@@ -49,10 +63,52 @@ func (s *lvService) CreateLV(ctx context.Context, req *proto.CreateLVRequest) (*
 	oc := s.ocmapper.LvcreateOptionClass(req.LvcreateOptionClass)
 
 	requested := uint64(req.GetSizeBytes())
+	warm := s.warmPool.enabled(dc)
+	if warm {
+		s.warmPool.mu.Lock()
+		defer s.warmPool.mu.Unlock()
+		defer s.warmPool.triggerRefill()
+	}
+	// the volumes in warm pools are created with the options of the device class and without tags.
+	if warm && req.LvcreateOptionClass == "" && len(req.GetTags()) == 0 {
+		// a pre-formatted volume cannot be claimed if it is to be formatted with other mkfs options.
+		fsType := req.GetFsType()
+		if !slices.Equal(req.GetMkfsOptions(), GetMkfsOptions(dc, fsType)) {
+			fsType = ""
+		}
+		lv, err := s.warmPool.claim(ctx, dc, pool, req.GetName(), requested, fsType)
+		if err != nil {
+			logger.Error(err, "failed to claim a volume from the warm pool", "requested", requested)
+		} else if lv != nil {
+			s.notify()
+			logger.Info("claimed a LV from the warm pool", "size", requested, "fsType", req.GetFsType())
+			return &proto.CreateLVResponse{
+				Volume: &proto.LogicalVolume{
+					Name:      lv.Name(),
+					SizeBytes: int64(lv.Size()),
+					DevMajor:  lv.MajorNumber(),
+					DevMinor:  lv.MinorNumber(),
+				},
+			}, nil
+		}
+	}
+
 	free, err := pool.Free(ctx)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get free bytes: %v", err)
 	}
+	if free < requested && warm {
+		free, err = s.warmPool.release(ctx, dc, pool, requested)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to release volumes in the warm pool: %v", err)
+		}
+	}
+	if free < requested && s.recycleBin.enabled(dc) {
+		free, err = s.recycleBin.release(ctx, dc, pool, requested, s.notify)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to purge volumes in the trash: %v", err)
+		}
+	}
 	if free < requested {
 		logger.Error(err, "not enough space left on VG", "free", free, "requested", requested)
 		return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, requested=%d", free, requested)
@@ -123,7 +179,20 @@ func (s *lvService) RemoveLV(ctx context.Context, req *proto.RemoveLVRequest) (*
 		return nil, err
 	}
 
-	if err := vg.RemoveVolume(ctx, req.GetName()); errors.Is(err, command.ErrNotFound) {
+	// the volume is kept in the trash until the retention expires, or renamed and removed after it is wiped
+	// in the background. The volumes requested to skip the trash and those managed by lvmd itself are not kept
+	// in the trash. The restored and cloned volumes are kept even though they are thin snapshots.
+	trash := s.recycleBin.enabled(dc) && !req.GetSkipTrash() && !isInternalVolume(req.GetName())
+	wipe := s.wiper.enabled(dc)
+	switch {
+	case trash:
+		err = s.recycleBin.trash(ctx, vg, req.GetName())
+	case wipe:
+		err = s.wiper.queue(ctx, dc, vg, req.GetName())
+	default:
+		err = vg.RemoveVolume(ctx, req.GetName())
+	}
+	if errors.Is(err, command.ErrNotFound) {
 		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
 	} else if err != nil {
 		logger.Error(err, "failed to remove volume", "name", req.GetName())
@@ -132,7 +201,14 @@ func (s *lvService) RemoveLV(ctx context.Context, req *proto.RemoveLVRequest) (*
 
 	s.notify()
 
-	logger.Info("removed a LV", "name", req.GetName())
+	switch {
+	case trash:
+		logger.Info("moved a LV to the trash", "name", req.GetName(), "retention", dc.TrashRetention)
+	case wipe:
+		logger.Info("queued a LV to be wiped", "name", req.GetName(), "policy", dc.WipePolicy)
+	default:
+		logger.Info("removed a LV", "name", req.GetName())
+	}
 
 	return &proto.Empty{}, nil
 }
@@ -194,14 +270,31 @@ func (s *lvService) CreateLVSnapshot(ctx context.Context, req *proto.CreateLVSna
 		logger.Error(err, "failed to get thinpool")
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	poolUsage, err := pool.Usage(ctx)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get pool usage: %v", err)
+	thinPool := &thinPoolAdapter{pool, dc.ThinPoolConfig.OverprovisionRatio}
+
+	// the volumes in the warm pool are counted as free, so they are released for the snapshot as well.
+	warm := s.warmPool.enabled(dc)
+	if warm {
+		s.warmPool.mu.Lock()
+		defer s.warmPool.mu.Unlock()
+		defer s.warmPool.triggerRefill()
 	}
-	free, err := poolUsage.FreeBytes(dc.ThinPoolConfig.OverprovisionRatio)
+	free, err := thinPool.Free(ctx)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get free bytes: %v", err)
 	}
+	if free < desiredSize && warm {
+		free, err = s.warmPool.release(ctx, dc, thinPool, desiredSize)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to release volumes in the warm pool: %v", err)
+		}
+	}
+	if free < desiredSize && s.recycleBin.enabled(dc) {
+		free, err = s.recycleBin.release(ctx, dc, thinPool, desiredSize, s.notify)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to purge volumes in the trash: %v", err)
+		}
+	}
 	if free < desiredSize {
 		logger.Error(err, "not enough space left on VG", "free", free, "desiredSize", desiredSize)
 		return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, desiredSize=%d", free, desiredSize)
@@ -331,3 +424,177 @@ func (s *lvService) ResizeLV(ctx context.Context, req *proto.ResizeLVRequest) (*
 
 	return &proto.ResizeLVResponse{SizeBytes: int64(lv.Size())}, nil
 }
+
+func (s *lvService) MergeLVSnapshot(ctx context.Context, req *proto.MergeLVSnapshotRequest) (*proto.MergeLVSnapshotResponse, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName(), "snapshot", req.GetSnapshot())
+	dc, err := s.dcmapper.DeviceClass(req.DeviceClass)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+	}
+	if dc.Type != lvmdTypes.TypeThin {
+		return nil, status.Error(codes.Unimplemented, "device class is not thin. Merging thick snapshots is not implemented yet")
+	}
+
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	lv, err := vg.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "logical volume is not found")
+		return nil, status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	snapLV, err := vg.FindVolume(ctx, req.GetSnapshot())
+	if errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "snapshot logical volume is not found")
+		return nil, status.Errorf(codes.NotFound, "snapshot logical volume %s is not found", req.GetSnapshot())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find snapshot volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	origin, err := snapLV.Origin(ctx)
+	if err != nil && !errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "failed to find origin of snapshot volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !snapLV.IsThin() || origin == nil || origin.Name() != lv.Name() {
+		return nil, status.Errorf(codes.FailedPrecondition, "logical volume %s is not a thin snapshot of %s", req.GetSnapshot(), req.GetName())
+	}
+
+	attr, err := command.ParsedLVAttr(lv.Attr())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if attr.Open == command.OpenTrue {
+		return nil, status.Errorf(codes.FailedPrecondition, "logical volume %s is in use", req.GetName())
+	}
+
+	// Grow the snapshot if the volume has been expanded after the snapshot was taken
+	// so that the volume is not shrunk by the merge.
+	current := lv.Size()
+	if err := snapLV.Resize(ctx, max(current, snapLV.Size())); err != nil {
+		logger.Error(err, "failed to resize snapshot volume", "current", current)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Info("lvservice request - MergeLVSnapshot", "current", current, "snapshotSize", snapLV.Size())
+
+	if err := snapLV.MergeThinSnapshot(ctx); err != nil {
+		logger.Error(err, "failed to merge snapshot volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	lv, err = vg.FindVolume(ctx, req.GetName())
+	if err != nil {
+		logger.Error(err, "failed to get volume after merge")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.notify()
+
+	logger.Info("merged a snapshot LV", "size", lv.Size())
+
+	return &proto.MergeLVSnapshotResponse{
+		Volume: &proto.LogicalVolume{
+			Name:      lv.Name(),
+			SizeBytes: int64(lv.Size()),
+			DevMajor:  lv.MajorNumber(),
+			DevMinor:  lv.MinorNumber(),
+		},
+	}, nil
+}
+
+// defaultBlockMetadataMaxResults is the number of ranges in a response of GetLVBlockMetadata
+// when the request does not specify it.
+const defaultBlockMetadataMaxResults = 1024
+
+func (s *lvService) GetLVBlockMetadata(req *proto.GetLVBlockMetadataRequest, server proto.LVService_GetLVBlockMetadataServer) error {
+	return getLVBlockMetadata(s.dcmapper, req, server)
+}
+
+// getLVBlockMetadata implements GetLVBlockMetadata of LVService and ReplicationService.
+func getLVBlockMetadata(dcmapper *DeviceClassManager, req *proto.GetLVBlockMetadataRequest, server proto.LVService_GetLVBlockMetadataServer) error {
+	ctx := server.Context()
+	logger := log.FromContext(ctx).WithValues("name", req.GetName(), "base", req.GetBaseName())
+	dc, err := dcmapper.DeviceClass(req.DeviceClass)
+	if err != nil {
+		return status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+	}
+	if dc.Type != lvmdTypes.TypeThin {
+		return status.Error(codes.Unimplemented, "device class is not thin. Block metadata of thick volumes is not implemented yet")
+	}
+	if req.GetMaxResults() < 0 {
+		return status.Errorf(codes.InvalidArgument, "max results must not be negative: %d", req.GetMaxResults())
+	}
+
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return err
+	}
+	lv, err := vg.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "logical volume is not found")
+		return status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	var ranges []command.BlockRange
+	if req.GetBaseName() == "" {
+		ranges, err = lv.AllocatedRanges(ctx)
+	} else {
+		var base *command.LogicalVolume
+		base, err = vg.FindVolume(ctx, req.GetBaseName())
+		if errors.Is(err, command.ErrNotFound) {
+			logger.Error(err, "base logical volume is not found")
+			return status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetBaseName())
+		}
+		if err != nil {
+			logger.Error(err, "failed to find base volume")
+			return status.Error(codes.Internal, err.Error())
+		}
+		ranges, err = lv.ChangedRanges(ctx, base)
+	}
+	if err != nil {
+		logger.Error(err, "failed to get block metadata")
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Info("lvservice request - GetLVBlockMetadata", "ranges", len(ranges))
+
+	return sendBlockRanges(server, lv.Size(), ranges, req.GetStartingOffset(), int(req.GetMaxResults()))
+}
+
+// sendBlockRanges sends ranges not ending before startingOffset, splitting them into responses of at most maxResults ranges.
+func sendBlockRanges(server proto.LVService_GetLVBlockMetadataServer, size uint64, ranges []command.BlockRange, startingOffset uint64, maxResults int) error {
+	if maxResults == 0 {
+		maxResults = defaultBlockMetadataMaxResults
+	}
+
+	res := &proto.GetLVBlockMetadataResponse{SizeBytes: size}
+	for _, r := range ranges {
+		if r.Offset+r.Length <= startingOffset {
+			continue
+		}
+		res.Ranges = append(res.Ranges, &proto.BlockRange{Offset: r.Offset, Length: r.Length})
+		if len(res.Ranges) == maxResults {
+			if err := server.Send(res); err != nil {
+				return err
+			}
+			res = &proto.GetLVBlockMetadataResponse{SizeBytes: size}
+		}
+	}
+	if len(res.Ranges) != 0 {
+		return server.Send(res)
+	}
+	return nil
+}
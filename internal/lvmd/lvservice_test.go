@@ -5,6 +5,7 @@ import (
 	"errors"
 	"os/exec"
 	"path"
+	"slices"
 	"testing"
 
 	"github.com/go-logr/logr/testr"
@@ -12,6 +13,7 @@ import (
 	"github.com/topolvm/topolvm/internal/lvmd/testutils"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -90,7 +92,11 @@ func setupLVService(ctx context.Context, t *testing.T) (
 			},
 		),
 		NewLvcreateOptionClassManager([]*lvmdTypes.LvcreateOptionClass{}),
+		nil,
+		nil,
+		nil,
 		notifier,
+		nil,
 	)
 
 	return lvService, &count, vg, pool
@@ -415,3 +421,342 @@ func TestLVService_ThinSnapshots(t *testing.T) {
 		t.Errorf(`testsnaptag1 not present on snapshot`)
 	}
 }
+
+func TestLVService_MergeThinSnapshot(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), testr.New(t))
+	lvService, count, vg, _ := setupLVService(ctx, t)
+
+	var originalSizeBytes int64 = 1 << 30 // 1 GiB
+	_, err := lvService.CreateLV(context.Background(), &proto.CreateLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThinDC,
+		SizeBytes:   originalSizeBytes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = lvService.CreateLVSnapshot(context.Background(), &proto.CreateLVSnapshotRequest{
+		Name:         "snap1",
+		DeviceClass:  lvServiceTestThinDC,
+		SourceVolume: "sourceVol",
+		AccessType:   "ro",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = lvService.CreateLV(context.Background(), &proto.CreateLVRequest{
+		Name:        "otherVol",
+		DeviceClass: lvServiceTestThinDC,
+		SizeBytes:   originalSizeBytes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// expand the volume after the snapshot was taken.
+	var expandedSizeBytes int64 = 2 << 30 // 2 GiB
+	_, err = lvService.ResizeLV(context.Background(), &proto.ResizeLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThinDC,
+		SizeBytes:   expandedSizeBytes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	*count = 0
+
+	_, err = lvService.MergeLVSnapshot(context.Background(), &proto.MergeLVSnapshotRequest{
+		Name:        "otherVol",
+		Snapshot:    "snap1",
+		DeviceClass: lvServiceTestThinDC,
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition for a snapshot of another volume: %v", err)
+	}
+	_, err = lvService.MergeLVSnapshot(context.Background(), &proto.MergeLVSnapshotRequest{
+		Name:        "sourceVol",
+		Snapshot:    "snap1",
+		DeviceClass: lvServiceTestThickDC,
+	})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented for a thick device class: %v", err)
+	}
+
+	res, err := lvService.MergeLVSnapshot(context.Background(), &proto.MergeLVSnapshotRequest{
+		Name:        "sourceVol",
+		Snapshot:    "snap1",
+		DeviceClass: lvServiceTestThinDC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *count != 1 {
+		t.Errorf("is not notified: %d", *count)
+	}
+	if res.GetVolume().GetName() != "sourceVol" {
+		t.Errorf(`res.Volume.Name != "sourceVol": %s`, res.GetVolume().GetName())
+	}
+	if res.GetVolume().GetSizeBytes() != expandedSizeBytes {
+		t.Errorf(`res.Volume.SizeBytes != %d: %d`, expandedSizeBytes, res.GetVolume().GetSizeBytes())
+	}
+
+	if err := vg.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vg.FindVolume(ctx, "snap1"); !errors.Is(err, command.ErrNotFound) {
+		t.Error("snapshot is not consumed by the merge: ", err)
+	}
+	lv, err := vg.FindVolume(ctx, "sourceVol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	attr, err := command.ParsedLVAttr(lv.Attr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attr.Permissions != command.PermissionsWriteable {
+		t.Errorf("merged volume is not writable: %s", lv.Attr())
+	}
+
+	_, err = lvService.MergeLVSnapshot(context.Background(), &proto.MergeLVSnapshotRequest{
+		Name:        "sourceVol",
+		Snapshot:    "snap1",
+		DeviceClass: lvServiceTestThinDC,
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for a merged snapshot: %v", err)
+	}
+}
+
+func TestLVService_TrashThinSnapshots(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), testr.New(t))
+	_, _, vg, pool := setupLVService(ctx, t)
+
+	dcmapper := NewDeviceClassManager([]*lvmdTypes.DeviceClass{
+		{
+			Name:           lvServiceTestThinDC,
+			VolumeGroup:    vg.Name(),
+			Type:           lvmdTypes.TypeThin,
+			TrashRetention: "1h",
+			ThinPoolConfig: &lvmdTypes.ThinPoolConfig{
+				Name:               pool.Name(),
+				OverprovisionRatio: 10,
+			},
+		},
+	})
+	lvService := NewLVService(
+		dcmapper,
+		NewLvcreateOptionClassManager([]*lvmdTypes.LvcreateOptionClass{}),
+		nil,
+		nil,
+		NewRecycleBin(dcmapper, nil),
+		func() {},
+		nil,
+	)
+
+	_, err := lvService.CreateLV(ctx, &proto.CreateLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThinDC,
+		SizeBytes:   1 << 30, // 1 GiB
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = lvService.CreateLVSnapshot(ctx, &proto.CreateLVSnapshotRequest{
+		Name:         "snap1",
+		DeviceClass:  lvServiceTestThinDC,
+		SourceVolume: "sourceVol",
+		AccessType:   "ro",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = lvService.CreateLVSnapshot(ctx, &proto.CreateLVSnapshotRequest{
+		Name:         "restoredsnap1",
+		DeviceClass:  lvServiceTestThinDC,
+		SourceVolume: "snap1",
+		AccessType:   "rw",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the restored volume is a thin snapshot, but it is kept in the trash.
+	_, err = lvService.RemoveLV(ctx, &proto.RemoveLVRequest{
+		Name:        "restoredsnap1",
+		DeviceClass: lvServiceTestThinDC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the snapshot for a VolumeSnapshot is removed without being kept in the trash.
+	_, err = lvService.RemoveLV(ctx, &proto.RemoveLVRequest{
+		Name:        "snap1",
+		DeviceClass: lvServiceTestThinDC,
+		SkipTrash:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vg.Update(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vg.FindVolume(ctx, "restoredsnap1"); !errors.Is(err, command.ErrNotFound) {
+		t.Error("restored volume is not removed: ", err)
+	}
+	if _, err := vg.FindVolume(ctx, trashVolumePrefix+"restoredsnap1"); err != nil {
+		t.Error("restored volume is not kept in the trash: ", err)
+	}
+	if _, err := vg.FindVolume(ctx, "snap1"); !errors.Is(err, command.ErrNotFound) {
+		t.Error("snapshot is not removed: ", err)
+	}
+	if _, err := vg.FindVolume(ctx, trashVolumePrefix+"snap1"); !errors.Is(err, command.ErrNotFound) {
+		t.Error("snapshot is kept in the trash: ", err)
+	}
+}
+
+type blockMetadataServerMock struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*proto.GetLVBlockMetadataResponse
+}
+
+func (s *blockMetadataServerMock) Context() context.Context {
+	return s.ctx
+}
+
+func (s *blockMetadataServerMock) Send(res *proto.GetLVBlockMetadataResponse) error {
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func (s *blockMetadataServerMock) ranges() []command.BlockRange {
+	var ranges []command.BlockRange
+	for _, res := range s.responses {
+		for _, r := range res.GetRanges() {
+			ranges = append(ranges, command.BlockRange{Offset: r.GetOffset(), Length: r.GetLength()})
+		}
+	}
+	return ranges
+}
+
+func TestSendBlockRanges(t *testing.T) {
+	ranges := []command.BlockRange{
+		{Offset: 0, Length: 100},
+		{Offset: 200, Length: 100},
+		{Offset: 400, Length: 100},
+		{Offset: 600, Length: 100},
+	}
+
+	server := &blockMetadataServerMock{ctx: context.Background()}
+	if err := sendBlockRanges(server, 1000, ranges, 250, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.responses) != 2 {
+		t.Fatalf("expected 2 responses: %d", len(server.responses))
+	}
+	if len(server.responses[0].GetRanges()) != 2 || len(server.responses[1].GetRanges()) != 1 {
+		t.Errorf("unexpected pagination: %v", server.responses)
+	}
+	if server.responses[1].GetSizeBytes() != 1000 {
+		t.Errorf("unexpected size: %d", server.responses[1].GetSizeBytes())
+	}
+	if actual := server.ranges(); !slices.Equal(actual, ranges[1:]) {
+		t.Errorf("unexpected ranges: %v", actual)
+	}
+
+	server = &blockMetadataServerMock{ctx: context.Background()}
+	if err := sendBlockRanges(server, 1000, ranges, 700, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.responses) != 0 {
+		t.Errorf("expected no response: %v", server.responses)
+	}
+}
+
+func TestLVService_GetLVBlockMetadata(t *testing.T) {
+	ctx := ctrl.LoggerInto(context.Background(), testr.New(t))
+	lvService, _, vg, _ := setupLVService(ctx, t)
+
+	_, err := lvService.CreateLV(ctx, &proto.CreateLVRequest{
+		Name:        "sourceVol",
+		DeviceClass: lvServiceTestThinDC,
+		SizeBytes:   1 << 30,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lv, err := vg.FindVolume(ctx, "sourceVol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(offsetMiB string) {
+		out, err := exec.Command("dd", "if=/dev/urandom", "of="+lv.Path(), "bs=1M", "count=1",
+			"seek="+offsetMiB, "oflag=direct", "conv=notrunc,fsync").CombinedOutput()
+		if err != nil {
+			t.Fatal(err, string(out))
+		}
+	}
+	snapshot := func(name string) {
+		_, err := lvService.CreateLVSnapshot(ctx, &proto.CreateLVSnapshotRequest{
+			Name:         name,
+			DeviceClass:  lvServiceTestThinDC,
+			SourceVolume: "sourceVol",
+			AccessType:   "ro",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("0")
+	snapshot("snap1")
+	write("16")
+	snapshot("snap2")
+
+	server := &blockMetadataServerMock{ctx: ctx}
+	err = lvService.GetLVBlockMetadata(&proto.GetLVBlockMetadataRequest{
+		Name:        "snap2",
+		DeviceClass: lvServiceTestThinDC,
+	}, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []command.BlockRange{{Offset: 0, Length: 1 << 20}, {Offset: 16 << 20, Length: 1 << 20}}
+	if actual := server.ranges(); !slices.Equal(actual, expected) {
+		t.Errorf("unexpected allocated ranges: expected=%v, actual=%v", expected, actual)
+	}
+	if server.responses[0].GetSizeBytes() != 1<<30 {
+		t.Errorf("unexpected size: %d", server.responses[0].GetSizeBytes())
+	}
+
+	server = &blockMetadataServerMock{ctx: ctx}
+	err = lvService.GetLVBlockMetadata(&proto.GetLVBlockMetadataRequest{
+		Name:        "snap2",
+		BaseName:    "snap1",
+		DeviceClass: lvServiceTestThinDC,
+	}, server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []command.BlockRange{{Offset: 16 << 20, Length: 1 << 20}}
+	if actual := server.ranges(); !slices.Equal(actual, expected) {
+		t.Errorf("unexpected changed ranges: expected=%v, actual=%v", expected, actual)
+	}
+
+	err = lvService.GetLVBlockMetadata(&proto.GetLVBlockMetadataRequest{
+		Name:        "snap2",
+		DeviceClass: lvServiceTestThickDC,
+	}, &blockMetadataServerMock{ctx: ctx})
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected Unimplemented for a thick device class: %v", err)
+	}
+	err = lvService.GetLVBlockMetadata(&proto.GetLVBlockMetadataRequest{
+		Name:        "snap2",
+		BaseName:    "unknown",
+		DeviceClass: lvServiceTestThinDC,
+	}, &blockMetadataServerMock{ctx: ctx})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown base: %v", err)
+	}
+}
@@ -0,0 +1,512 @@
+package lvmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// replicationChunkSize is the maximum size of data in a message of ApplyLVDelta.
+	replicationChunkSize = 1 << 20
+
+	// replicaTag is the tag of the replicas created by ApplyLVDelta.
+	// ApplyLVDelta and RemoveLVReplica refuse to touch volumes without it.
+	replicaTag = "topolvm.io/replica"
+
+	// copyVolumePrefix is the prefix of the snapshots taken by PrepareLVCopy, which is followed by the copy ID.
+	copyVolumePrefix = "topolvm-copy-"
+
+	// copyOfTagPrefix is followed by the name of the volume of which the snapshot is taken by PrepareLVCopy.
+	copyOfTagPrefix = "topolvm.io/copy-of="
+)
+
+// isReplica returns true if the volume was created by ApplyLVDelta.
+func isReplica(lv *command.LogicalVolume) bool {
+	return slices.Contains(lv.Tags(), replicaTag)
+}
+
+// NewReplicationService creates a new ReplicationServiceServer.
+// It must be served only with the credentials returned by ReplicationServerCredentials.
+func NewReplicationService(dcmapper *DeviceClassManager, notifyFunc func()) proto.ReplicationServiceServer {
+	return &replicationService{
+		dcmapper:   dcmapper,
+		notifyFunc: notifyFunc,
+	}
+}
+
+type replicationService struct {
+	proto.UnimplementedReplicationServiceServer
+	dcmapper   *DeviceClassManager
+	notifyFunc func()
+}
+
+func (s *replicationService) notify() {
+	if s.notifyFunc != nil {
+		s.notifyFunc()
+	}
+}
+
+func (s *replicationService) ApplyLVDelta(stream proto.ReplicationService_ApplyLVDeltaServer) error {
+	ctx := stream.Context()
+	header, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	logger := log.FromContext(ctx).WithValues("name", header.GetName(), "incremental", header.GetIncremental())
+
+	dc, err := s.dcmapper.DeviceClass(header.GetDeviceClass())
+	if err != nil {
+		return status.Errorf(codes.NotFound, "%s: %s", err.Error(), header.GetDeviceClass())
+	}
+	// a new thin volume reads zeros, so the ranges not sent are consistent with the source.
+	if dc.Type != lvmdTypes.TypeThin {
+		return status.Error(codes.Unimplemented, "device class is not thin. Replicating to thick volumes is not implemented yet")
+	}
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get pool from device class: %v", err)
+	}
+
+	lv, err := pool.FindVolume(ctx, header.GetName())
+	switch {
+	case errors.Is(err, command.ErrNotFound) && header.GetIncremental():
+		return status.Errorf(codes.FailedPrecondition, "replica %s is not found for incremental replication", header.GetName())
+	case errors.Is(err, command.ErrNotFound):
+		if err := pool.CreateVolume(ctx, header.GetName(), header.GetSizeBytes(), []string{replicaTag}, 0, "", nil); err != nil {
+			logger.Error(err, "failed to create replica", "size", header.GetSizeBytes())
+			return status.Error(codes.Internal, err.Error())
+		}
+		lv, err = pool.FindVolume(ctx, header.GetName())
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		s.notify()
+		logger.Info("created a replica LV", "size", lv.Size())
+	case err != nil:
+		logger.Error(err, "failed to find replica")
+		return status.Error(codes.Internal, err.Error())
+	case !isReplica(lv):
+		return status.Errorf(codes.PermissionDenied, "%s is not a replica", header.GetName())
+	}
+
+	attr, err := command.ParsedLVAttr(lv.Attr())
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	// the replica is in use after a failover. It must not be overwritten.
+	if attr.Open == command.OpenTrue {
+		return status.Errorf(codes.FailedPrecondition, "replica %s is in use", header.GetName())
+	}
+	if lv.Size() < header.GetSizeBytes() {
+		if err := lv.Resize(ctx, header.GetSizeBytes()); err != nil {
+			logger.Error(err, "failed to resize replica", "size", header.GetSizeBytes())
+			return status.Error(codes.Internal, err.Error())
+		}
+		s.notify()
+	}
+
+	dev, err := os.OpenFile(lv.Path(), os.O_WRONLY, 0)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	applied, err := receiveRanges(stream.Recv, dev)
+	if err == nil {
+		err = dev.Sync()
+	}
+	err = errors.Join(err, dev.Close())
+	if err != nil {
+		logger.Error(err, "failed to apply delta to replica", "applied", applied)
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Info("applied delta to a replica LV", "applied", applied)
+
+	return stream.SendAndClose(&proto.ApplyLVDeltaResponse{
+		Volume: &proto.LogicalVolume{
+			Name:      lv.Name(),
+			SizeBytes: int64(lv.Size()),
+			DevMajor:  lv.MajorNumber(),
+			DevMinor:  lv.MinorNumber(),
+		},
+		AppliedBytes: applied,
+	})
+}
+
+func (s *replicationService) RemoveLVReplica(ctx context.Context, req *proto.RemoveLVReplicaRequest) (*proto.Empty, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName())
+	dc, err := s.dcmapper.DeviceClass(req.GetDeviceClass())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.GetDeviceClass())
+	}
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	lv, err := vg.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "replica %s is not found", req.GetName())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !isReplica(lv) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s is not a replica", req.GetName())
+	}
+	attr, err := command.ParsedLVAttr(lv.Attr())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if attr.Open == command.OpenTrue {
+		return nil, status.Errorf(codes.FailedPrecondition, "replica %s is in use", req.GetName())
+	}
+	if err := vg.RemoveVolume(ctx, req.GetName()); err != nil {
+		logger.Error(err, "failed to remove replica")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.notify()
+	logger.Info("removed a replica LV")
+	return &proto.Empty{}, nil
+}
+
+func (s *replicationService) GetLVBlockMetadata(req *proto.GetLVBlockMetadataRequest, stream proto.ReplicationService_GetLVBlockMetadataServer) error {
+	return getLVBlockMetadata(s.dcmapper, req, stream)
+}
+
+func (s *replicationService) ReadLV(req *proto.ReadLVRequest, stream proto.ReplicationService_ReadLVServer) error {
+	ctx := stream.Context()
+	logger := log.FromContext(ctx).WithValues("name", req.GetName(), "copy_id", req.GetCopyId())
+	dc, err := s.dcmapper.DeviceClass(req.GetDeviceClass())
+	if err != nil {
+		return status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.GetDeviceClass())
+	}
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	// only the snapshot taken by PrepareLVCopy for the copy is served.
+	snapshot, err := vg.FindVolume(ctx, copyVolumeName(req.GetCopyId()))
+	if errors.Is(err, command.ErrNotFound) || (err == nil && !slices.Contains(snapshot.Tags(), copyOfTag(req.GetName()))) {
+		return status.Errorf(codes.FailedPrecondition, "copy %s of %s is not prepared", req.GetCopyId(), req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return status.Error(codes.Internal, err.Error())
+	}
+	// a thick snapshot has no metadata of the allocated ranges.
+	ranges := []command.BlockRange{{Offset: 0, Length: snapshot.Size()}}
+	if snapshot.IsThin() {
+		ranges, err = snapshot.AllocatedRanges(ctx)
+		if err != nil {
+			logger.Error(err, "failed to get allocated ranges")
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	if err := stream.Send(&proto.ReadLVResponse{SizeBytes: snapshot.Size()}); err != nil {
+		return err
+	}
+	dev, err := os.Open(snapshot.Path())
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer func() { _ = dev.Close() }()
+	sent, err := sendRanges(func(req *proto.ApplyLVDeltaRequest) error {
+		return stream.Send(&proto.ReadLVResponse{Offset: req.GetOffset(), Data: req.GetData()})
+	}, dev, ranges)
+	if err != nil {
+		logger.Error(err, "failed to send volume", "sent", sent)
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	logger.Info("sent a LV", "size", snapshot.Size(), "sent", sent)
+	return nil
+}
+
+// copyVolumeName returns the name of the snapshot taken by PrepareLVCopy for the copy.
+func copyVolumeName(copyID string) string {
+	return copyVolumePrefix + copyID
+}
+
+// copyOfTag returns the tag of the snapshots of the volume taken by PrepareLVCopy.
+func copyOfTag(name string) string {
+	return copyOfTagPrefix + name
+}
+
+func (s *lvService) PrepareLVCopy(ctx context.Context, req *proto.PrepareLVCopyRequest) (*proto.Empty, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName(), "copy_id", req.GetCopyId())
+	if req.GetCopyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "copy ID is not given")
+	}
+	dc, err := s.dcmapper.DeviceClass(req.GetDeviceClass())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.GetDeviceClass())
+	}
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	snapshotName := copyVolumeName(req.GetCopyId())
+	snapshot, err := vg.FindVolume(ctx, snapshotName)
+	if err == nil {
+		if !slices.Contains(snapshot.Tags(), copyOfTag(req.GetName())) {
+			return nil, status.Errorf(codes.AlreadyExists, "copy %s is prepared for another volume", req.GetCopyId())
+		}
+		return &proto.Empty{}, nil
+	}
+	if !errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "failed to find snapshot")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	lv, err := vg.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) || (err == nil && isInternalVolume(lv.Name())) {
+		return nil, status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	tags := []string{copyOfTag(req.GetName())}
+	if lv.IsThin() {
+		err = lv.ThinSnapshot(ctx, snapshotName, tags)
+	} else {
+		// the snapshot does not overflow even if the whole volume is overwritten during the copy.
+		var free uint64
+		free, err = vg.Free()
+		if err == nil && free < lv.Size() {
+			return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, requested=%d", free, lv.Size())
+		}
+		if err == nil {
+			err = lv.Snapshot(ctx, snapshotName, lv.Size(), tags)
+		}
+	}
+	if err != nil {
+		logger.Error(err, "failed to take snapshot")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.notify()
+
+	logger.Info("prepared a LV to be copied")
+	return &proto.Empty{}, nil
+}
+
+func (s *lvService) RemoveLVCopy(ctx context.Context, req *proto.RemoveLVCopyRequest) (*proto.Empty, error) {
+	logger := log.FromContext(ctx).WithValues("copy_id", req.GetCopyId())
+	if req.GetCopyId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "copy ID is not given")
+	}
+
+	// the device class of the source may have been removed with the source.
+	for _, vgName := range s.dcmapper.VolumeGroups() {
+		vg, err := command.FindVolumeGroup(ctx, vgName)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		err = vg.RemoveVolume(ctx, copyVolumeName(req.GetCopyId()))
+		if errors.Is(err, command.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			logger.Error(err, "failed to remove snapshot")
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		s.notify()
+		logger.Info("removed the snapshot of a copy")
+		return &proto.Empty{}, nil
+	}
+	return nil, status.Errorf(codes.NotFound, "copy %s is not found", req.GetCopyId())
+}
+
+func (s *lvService) ReplicateLV(ctx context.Context, req *proto.ReplicateLVRequest) (*proto.ReplicateLVResponse, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetName(), "snapshot", req.GetSnapshot(),
+		"base", req.GetBaseSnapshot(), "peer", req.GetPeerAddress())
+	dc, err := s.dcmapper.DeviceClass(req.DeviceClass)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.DeviceClass)
+	}
+	if dc.Type != lvmdTypes.TypeThin {
+		return nil, status.Error(codes.Unimplemented, "device class is not thin. Replicating thick volumes is not implemented yet")
+	}
+	if req.GetPeerAddress() == "" {
+		return nil, status.Error(codes.InvalidArgument, "peer address is not given")
+	}
+	if s.replicationCredentials == nil {
+		return nil, status.Error(codes.Unavailable, "TLS is not configured for the replication API")
+	}
+
+	vg, err := command.FindVolumeGroup(ctx, dc.VolumeGroup)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	lv, err := vg.FindVolume(ctx, req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "logical volume %s is not found", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var base *command.LogicalVolume
+	if req.GetBaseSnapshot() != "" {
+		base, err = vg.FindVolume(ctx, req.GetBaseSnapshot())
+		if errors.Is(err, command.ErrNotFound) {
+			return nil, status.Errorf(codes.FailedPrecondition, "base snapshot %s is not found", req.GetBaseSnapshot())
+		}
+		if err != nil {
+			logger.Error(err, "failed to find base snapshot")
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	// a snapshot left by an interrupted replication is taken again.
+	if err := vg.RemoveVolume(ctx, req.GetSnapshot()); err != nil && !errors.Is(err, command.ErrNotFound) {
+		logger.Error(err, "failed to remove stale snapshot")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := lv.ThinSnapshot(ctx, req.GetSnapshot(), nil); err != nil {
+		logger.Error(err, "failed to take snapshot")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.notify()
+
+	res, err := s.replicate(ctx, vg, req, base)
+	if err != nil {
+		logger.Error(err, "failed to replicate volume")
+		if err := vg.RemoveVolume(ctx, req.GetSnapshot()); err != nil {
+			logger.Error(err, "failed to remove snapshot")
+		}
+		// the replica needs a full replication, so the base is no longer useful.
+		if base != nil && status.Code(err) == codes.FailedPrecondition {
+			if err := vg.RemoveVolume(ctx, base.Name()); err != nil {
+				logger.Error(err, "failed to remove base snapshot")
+			}
+		}
+		s.notify()
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	if base != nil {
+		if err := vg.RemoveVolume(ctx, base.Name()); err != nil {
+			logger.Error(err, "failed to remove base snapshot")
+		}
+		s.notify()
+	}
+
+	logger.Info("replicated a LV", "size", res.GetSizeBytes(), "transferred", res.GetTransferredBytes())
+	return res, nil
+}
+
+// replicate sends the ranges of the snapshot to the peer.
+func (s *lvService) replicate(ctx context.Context, vg *command.VolumeGroup, req *proto.ReplicateLVRequest,
+	base *command.LogicalVolume) (*proto.ReplicateLVResponse, error) {
+	snapshot, err := vg.FindVolume(ctx, req.GetSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	var ranges []command.BlockRange
+	if base == nil {
+		ranges, err = snapshot.AllocatedRanges(ctx)
+	} else {
+		ranges, err = snapshot.ChangedRanges(ctx, base)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(req.GetPeerAddress(), grpc.WithTransportCredentials(s.replicationCredentials))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+	stream, err := proto.NewReplicationServiceClient(conn).ApplyLVDelta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&proto.ApplyLVDeltaRequest{
+		Name:        req.GetName(),
+		DeviceClass: req.GetPeerDeviceClass(),
+		SizeBytes:   snapshot.Size(),
+		Incremental: base != nil,
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	dev, err := os.Open(snapshot.Path())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = dev.Close() }()
+	// io.EOF means the peer has returned an error, which is received with CloseAndRecv.
+	transferred, err := sendRanges(stream.Send, dev, ranges)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return nil, err
+	}
+	return &proto.ReplicateLVResponse{
+		SizeBytes:        snapshot.Size(),
+		TransferredBytes: transferred,
+	}, nil
+}
+
+// sendRanges reads the ranges from src and sends them in chunks of at most replicationChunkSize.
+func sendRanges(send func(*proto.ApplyLVDeltaRequest) error, src io.ReaderAt, ranges []command.BlockRange) (uint64, error) {
+	var sent uint64
+	buf := make([]byte, replicationChunkSize)
+	for _, r := range ranges {
+		for offset := r.Offset; offset < r.Offset+r.Length; {
+			n := min(uint64(len(buf)), r.Offset+r.Length-offset)
+			if _, err := src.ReadAt(buf[:n], int64(offset)); err != nil {
+				return sent, err
+			}
+			if err := send(&proto.ApplyLVDeltaRequest{Offset: offset, Data: buf[:n]}); err != nil {
+				return sent, err
+			}
+			offset += n
+			sent += n
+		}
+	}
+	return sent, nil
+}
+
+// receiveRanges receives the ranges until the end of the stream and writes them to dst.
+func receiveRanges(recv func() (*proto.ApplyLVDeltaRequest, error), dst io.WriterAt) (uint64, error) {
+	var applied uint64
+	for {
+		req, err := recv()
+		if errors.Is(err, io.EOF) {
+			return applied, nil
+		}
+		if err != nil {
+			return applied, err
+		}
+		if _, err := dst.WriteAt(req.GetData(), int64(req.GetOffset())); err != nil {
+			return applied, err
+		}
+		applied += uint64(len(req.GetData()))
+	}
+}
@@ -0,0 +1,86 @@
+package lvmd
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+)
+
+// deltaStreamMock is used as both ends of ApplyLVDelta by buffering the requests.
+type deltaStreamMock struct {
+	requests []*proto.ApplyLVDeltaRequest
+}
+
+func (s *deltaStreamMock) Send(req *proto.ApplyLVDeltaRequest) error {
+	// the buffer is reused by the sender.
+	s.requests = append(s.requests, &proto.ApplyLVDeltaRequest{
+		Offset: req.GetOffset(),
+		Data:   bytes.Clone(req.GetData()),
+	})
+	return nil
+}
+
+func (s *deltaStreamMock) Recv() (*proto.ApplyLVDeltaRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+// writerAtBuffer is an in-memory io.WriterAt.
+type writerAtBuffer []byte
+
+func (b writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	return copy(b[off:], p), nil
+}
+
+func TestReplicateRanges(t *testing.T) {
+	size := 3*replicationChunkSize + 4096
+	src := make([]byte, size)
+	for i := range src {
+		src[i] = byte(i%251 + 1)
+	}
+	ranges := []command.BlockRange{
+		{Offset: 0, Length: 4096},
+		{Offset: replicationChunkSize / 2, Length: 2*replicationChunkSize + 4096},
+	}
+
+	stream := &deltaStreamMock{}
+	sent, err := sendRanges(stream.Send, bytes.NewReader(src), ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2*replicationChunkSize+8192 {
+		t.Errorf("unexpected sent bytes: %d", sent)
+	}
+	if len(stream.requests) != 4 {
+		t.Errorf("expected 4 chunks: %d", len(stream.requests))
+	}
+	for _, req := range stream.requests {
+		if len(req.GetData()) > replicationChunkSize {
+			t.Errorf("too large chunk: offset=%d, length=%d", req.GetOffset(), len(req.GetData()))
+		}
+	}
+
+	dst := make(writerAtBuffer, size)
+	applied, err := receiveRanges(stream.Recv, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != sent {
+		t.Errorf("unexpected applied bytes: %d", applied)
+	}
+	for _, r := range ranges {
+		if !bytes.Equal(dst[r.Offset:r.Offset+r.Length], src[r.Offset:r.Offset+r.Length]) {
+			t.Errorf("range is not replicated: %v", r)
+		}
+	}
+	if dst[4096] != 0 || dst[replicationChunkSize/2-1] != 0 {
+		t.Error("data out of the ranges should not be written")
+	}
+}
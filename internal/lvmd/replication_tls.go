@@ -0,0 +1,93 @@
+package lvmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc/credentials"
+)
+
+// ValidateReplicationTLS validates the TLS configuration of the replication API.
+func ValidateReplicationTLS(cfg *lvmdTypes.ReplicationTLS) error {
+	if cfg == nil {
+		return errors.New("TLS is not configured for the replication API")
+	}
+	if cfg.CertFile == "" || cfg.KeyFile == "" || cfg.CAFile == "" {
+		return errors.New("cert-file, key-file and ca-file are required for the replication API")
+	}
+	if cfg.PeerName == "" {
+		return errors.New("peer-name is required for the replication API")
+	}
+	return nil
+}
+
+// ReplicationServerCredentials returns the credentials of the replication API server.
+// The clients must present a certificate issued by the CA for the peer name.
+func ReplicationServerCredentials(cfg *lvmdTypes.ReplicationTLS) (credentials.TransportCredentials, error) {
+	tlsConfig, err := replicationTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsConfig.ClientCAs = tlsConfig.RootCAs
+	tlsConfig.RootCAs = nil
+	tlsConfig.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return loadCertificate(cfg)
+	}
+	tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("no client certificate")
+		}
+		return cs.PeerCertificates[0].VerifyHostname(cfg.PeerName)
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// ReplicationClientCredentials returns the credentials to call the replication API of the peers.
+// The servers must present a certificate issued by the CA for the peer name.
+func ReplicationClientCredentials(cfg *lvmdTypes.ReplicationTLS) (credentials.TransportCredentials, error) {
+	tlsConfig, err := replicationTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ServerName = cfg.PeerName
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return loadCertificate(cfg)
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func replicationTLSConfig(cfg *lvmdTypes.ReplicationTLS) (*tls.Config, error) {
+	if err := ValidateReplicationTLS(cfg); err != nil {
+		return nil, err
+	}
+	// the certificate is loaded at each handshake so that it can be renewed without restarting,
+	// but it is loaded here as well to detect misconfigurations at startup.
+	if _, err := loadCertificate(cfg); err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no CA certificate is found in %s", cfg.CAFile)
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}, nil
+}
+
+func loadCertificate(cfg *lvmdTypes.ReplicationTLS) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
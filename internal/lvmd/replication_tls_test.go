@@ -0,0 +1,141 @@
+package lvmd
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// writeCertificate issues a certificate for dnsName signed by the parent and writes it and its key into dir.
+// If parent is nil, a self-signed CA certificate is issued.
+func writeCertificate(t *testing.T, dir, name, dnsName string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (
+	*x509.Certificate, *ecdsa.PrivateKey, *lvmdTypes.ReplicationTLS) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{dnsName},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		template.DNSNames = nil
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &lvmdTypes.ReplicationTLS{
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+	if err := os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return cert, key, cfg
+}
+
+func TestReplicationCredentials(t *testing.T) {
+	dir := t.TempDir()
+	const peerName = "replication.topolvm.io"
+	ca, caKey, caFiles := writeCertificate(t, dir, "ca", "", nil, nil)
+	otherCA, otherCAKey, _ := writeCertificate(t, dir, "other-ca", "", nil, nil)
+	_, _, server := writeCertificate(t, dir, "server", peerName, ca, caKey)
+	_, _, client := writeCertificate(t, dir, "client", peerName, ca, caKey)
+	_, _, wrongName := writeCertificate(t, dir, "wrong-name", "other.example.com", ca, caKey)
+	_, _, wrongCA := writeCertificate(t, dir, "wrong-ca", peerName, otherCA, otherCAKey)
+	for _, cfg := range []*lvmdTypes.ReplicationTLS{server, client, wrongName, wrongCA} {
+		cfg.CAFile = caFiles.CertFile
+		cfg.PeerName = peerName
+	}
+
+	serverCreds, err := ReplicationServerCredentials(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grpcServer := grpc.NewServer(grpc.Creds(serverCreds))
+	proto.RegisterReplicationServiceServer(grpcServer, &proto.UnimplementedReplicationServiceServer{})
+	go func() { _ = grpcServer.Serve(lis) }()
+	defer grpcServer.Stop()
+
+	testCases := []struct {
+		name     string
+		cfg      *lvmdTypes.ReplicationTLS
+		peerName string
+		code     codes.Code
+	}{
+		{name: "trusted client", cfg: client, code: codes.Unimplemented},
+		{name: "client certificate for another name", cfg: wrongName, code: codes.Unavailable},
+		{name: "client certificate issued by another CA", cfg: wrongCA, code: codes.Unavailable},
+		{name: "server certificate for another name", cfg: client, peerName: "other.example.com", code: codes.Unavailable},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := *tc.cfg
+			if tc.peerName != "" {
+				cfg.PeerName = tc.peerName
+			}
+			creds, err := ReplicationClientCredentials(&cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(creds))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = conn.Close() }()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_, err = proto.NewReplicationServiceClient(conn).RemoveLVReplica(ctx, &proto.RemoveLVReplicaRequest{Name: "test"})
+			if status.Code(err) != tc.code {
+				t.Errorf("expected %s, but got %v", tc.code, err)
+			}
+		})
+	}
+
+	if _, err := ReplicationServerCredentials(&lvmdTypes.ReplicationTLS{CertFile: server.CertFile, KeyFile: server.KeyFile,
+		CAFile: caFiles.CertFile}); err == nil {
+		t.Error("credentials without the peer name must be rejected")
+	}
+}
@@ -0,0 +1,364 @@
+package lvmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// trashVolumePrefix is followed by the name of a removed volume kept in the trash.
+	// The volumes in the trash are hidden from GetLVList.
+	trashVolumePrefix = "topolvm-trash-"
+	// trashedAtTagPrefix is followed by the unix time when the volume was moved to the trash.
+	trashedAtTagPrefix = "topolvm.io/trashed-at="
+
+	trashPurgeInterval = time.Minute
+)
+
+// RecycleBin keeps the volumes removed from the device classes with trash-retention, so that they can be
+// undeleted until the retention expires. The expired volumes are purged by Run.
+type RecycleBin struct {
+	dcmapper *DeviceClassManager
+	wiper    *Wiper
+
+	// mu serializes moving volumes into and out of the trash and purging them.
+	mu sync.Mutex
+}
+
+// NewRecycleBin creates a RecycleBin. wiper may be nil if no volume is wiped.
+// Run must be called to purge the expired volumes.
+func NewRecycleBin(dcmapper *DeviceClassManager, wiper *Wiper) *RecycleBin {
+	return &RecycleBin{
+		dcmapper: dcmapper,
+		wiper:    wiper,
+	}
+}
+
+// enabled returns true if the volumes removed from the device class are kept in the trash.
+func (b *RecycleBin) enabled(dc *lvmdTypes.DeviceClass) bool {
+	return b != nil && GetTrashRetention(dc) > 0
+}
+
+func trashedAtTag(t time.Time) string {
+	return trashedAtTagPrefix + strconv.FormatInt(t.Unix(), 10)
+}
+
+// trashedAt returns the time when the volume with the tags was moved to the trash.
+// It returns false if the volume is not tagged yet.
+func trashedAt(tags []string) (time.Time, bool) {
+	for _, tag := range tags {
+		v, ok := strings.CutPrefix(tag, trashedAtTagPrefix)
+		if !ok {
+			continue
+		}
+		sec, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		return time.Unix(sec, 0), true
+	}
+	return time.Time{}, false
+}
+
+// listTrash returns the volumes in the trash of the device class.
+func listTrash(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool) ([]*command.LogicalVolume, error) {
+	lvs, err := pool.ListVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var trash []*command.LogicalVolume
+	for _, lv := range lvs {
+		if dc.Type == lvmdTypes.TypeThick && lv.IsThin() {
+			continue
+		}
+		if strings.HasPrefix(lv.Name(), trashVolumePrefix) {
+			trash = append(trash, lv)
+		}
+	}
+	slices.SortFunc(trash, func(a, b *command.LogicalVolume) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return trash, nil
+}
+
+// trashItems returns the total size of the volumes in the trash of the device class and each of them.
+// The volumes not tagged yet are reported as if they were moved to the trash at now.
+func trashItems(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool, now time.Time) (uint64, []*proto.TrashItem, error) {
+	retention := GetTrashRetention(dc)
+	if retention == 0 {
+		return 0, nil, nil
+	}
+	lvs, err := listTrash(ctx, dc, pool)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var total uint64
+	var items []*proto.TrashItem
+	for _, lv := range lvs {
+		at, ok := trashedAt(lv.Tags())
+		if !ok {
+			at = now
+		}
+		total += lv.Size()
+		items = append(items, &proto.TrashItem{
+			Name:      strings.TrimPrefix(lv.Name(), trashVolumePrefix),
+			SizeBytes: lv.Size(),
+			TrashedAt: at.Unix(),
+			ExpiresAt: at.Add(retention).Unix(),
+		})
+	}
+	return total, items, nil
+}
+
+// isInternalVolume returns true if the volume is managed by lvmd itself and not provisioned for a LogicalVolume.
+func isInternalVolume(name string) bool {
+	return isWarmVolume(name) || strings.HasPrefix(name, wipingVolumePrefix) || strings.HasPrefix(name, trashVolumePrefix) ||
+		strings.HasPrefix(name, copyVolumePrefix)
+}
+
+// oldestTrashFirst sorts the volumes in the trash by the time they were moved to the trash.
+// The volumes not tagged yet are regarded as the newest.
+func oldestTrashFirst(lvs []*command.LogicalVolume, now time.Time) {
+	slices.SortStableFunc(lvs, func(a, b *command.LogicalVolume) int {
+		at, ok := trashedAt(a.Tags())
+		if !ok {
+			at = now
+		}
+		bt, ok := trashedAt(b.Tags())
+		if !ok {
+			bt = now
+		}
+		return at.Compare(bt)
+	})
+}
+
+// trash moves the volume into the trash.
+// It returns an error wrapping command.ErrNotFound if the volume does not exist.
+func (b *RecycleBin) trash(ctx context.Context, vg *command.VolumeGroup, name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lv, err := vg.FindVolume(ctx, name)
+	if err != nil {
+		return err
+	}
+	if err := lv.Rename(ctx, trashVolumePrefix+name); err != nil {
+		return err
+	}
+	// the volume left without the tag is tagged by Run, and the retention starts then.
+	return lv.AddTags(ctx, trashedAtTag(time.Now()))
+}
+
+// restore moves the volume removed as name out of the trash, and renames it to newName.
+// It returns an error wrapping command.ErrNotFound if the volume is not in the trash.
+func (b *RecycleBin) restore(ctx context.Context, pool storagePool, name, newName string) (*command.LogicalVolume, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lv, err := pool.FindVolume(ctx, trashVolumePrefix+name)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for _, tag := range lv.Tags() {
+		if strings.HasPrefix(tag, trashedAtTagPrefix) {
+			tags = append(tags, tag)
+		}
+	}
+	if err := lv.DeleteTags(ctx, tags...); err != nil {
+		return nil, err
+	}
+	if err := lv.Rename(ctx, newName); err != nil {
+		return nil, err
+	}
+	return lv, nil
+}
+
+// release purges the volumes in the trash of the device class from the oldest one until the free space of
+// the pool reaches required bytes, and returns the free space after the purge.
+// If the device class has wipe-policy, the purged volumes are queued to be wiped and their space is not
+// freed until the wipe finishes, so the returned free space is not increased by them.
+func (b *RecycleBin) release(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool, required uint64, notifyFunc func()) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	free, err := pool.Free(ctx)
+	if err != nil {
+		return 0, err
+	}
+	lvs, err := listTrash(ctx, dc, pool)
+	if err != nil {
+		return 0, err
+	}
+	oldestTrashFirst(lvs, time.Now())
+
+	wipe := b.wiper.enabled(dc)
+	reclaimed := free
+	for _, lv := range lvs {
+		if reclaimed >= required {
+			break
+		}
+		name := strings.TrimPrefix(lv.Name(), trashVolumePrefix)
+		if wipe {
+			err = b.wiper.queueVolume(ctx, dc, lv, name)
+		} else {
+			err = lv.VG().RemoveVolume(ctx, lv.Name())
+		}
+		if err != nil {
+			return 0, err
+		}
+		notifyFunc()
+		log.FromContext(ctx).Info("purged a LV from the trash to free space", "name", name, "size", lv.Size())
+		reclaimed += lv.Size()
+		if !wipe {
+			// the free space of a volume group is not updated by removing a volume.
+			free += lv.Size()
+		}
+	}
+	return free, nil
+}
+
+// Run purges the expired volumes in the trash every minute until ctx is done.
+// The volumes are purged immediately if trash-retention of the device class is removed.
+// notifyFunc is called when the free space changes.
+func (b *RecycleBin) Run(ctx context.Context, notifyFunc func()) {
+	logger := log.FromContext(ctx)
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, dc := range b.dcmapper.deviceClassByName {
+			if err := b.purgeDeviceClass(ctx, dc, time.Now(), notifyFunc); err != nil && ctx.Err() == nil {
+				logger.Error(err, "failed to purge the trash", "device-class", dc.Name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeviceClass removes the volumes in the trash of the device class expired at now.
+// They are wiped before being removed if the device class has wipe-policy.
+func (b *RecycleBin) purgeDeviceClass(ctx context.Context, dc *lvmdTypes.DeviceClass, now time.Time, notifyFunc func()) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return err
+	}
+	lvs, err := listTrash(ctx, dc, pool)
+	if err != nil {
+		return err
+	}
+
+	retention := GetTrashRetention(dc)
+	for _, lv := range lvs {
+		name := strings.TrimPrefix(lv.Name(), trashVolumePrefix)
+		logger := log.FromContext(ctx).WithValues("name", name, "device-class", dc.Name)
+		if retention > 0 {
+			at, ok := trashedAt(lv.Tags())
+			if !ok {
+				if err := lv.AddTags(ctx, trashedAtTag(now)); err != nil {
+					return err
+				}
+				continue
+			}
+			if now.Before(at.Add(retention)) {
+				continue
+			}
+		}
+
+		if b.wiper.enabled(dc) {
+			err = b.wiper.queueVolume(ctx, dc, lv, name)
+		} else {
+			err = lv.VG().RemoveVolume(ctx, lv.Name())
+		}
+		if err != nil {
+			return err
+		}
+		notifyFunc()
+		logger.Info("purged an expired LV from the trash", "size", lv.Size())
+	}
+	return nil
+}
+
+func (s *lvService) UndeleteLV(ctx context.Context, req *proto.UndeleteLVRequest) (*proto.UndeleteLVResponse, error) {
+	logger := log.FromContext(ctx).WithValues("name", req.GetNewName(), "removed", req.GetName())
+	dc, err := s.dcmapper.DeviceClass(req.GetDeviceClass())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%s: %s", err.Error(), req.GetDeviceClass())
+	}
+	if s.recycleBin == nil {
+		return nil, status.Error(codes.FailedPrecondition, "trash is disabled")
+	}
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get pool from device class: %v", err)
+	}
+
+	lv, err := pool.FindVolume(ctx, trashVolumePrefix+req.GetName())
+	if errors.Is(err, command.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "removed logical volume %s is not in the trash", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to find volume in the trash")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	requested := uint64(req.GetSizeBytes())
+	current := lv.Size()
+	if requested < current {
+		return nil, status.Errorf(codes.OutOfRange, "requested size %d is smaller than removed logical volume: %d", requested, current)
+	}
+	if requested > current {
+		free, err := pool.Free(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get free bytes: %v", err)
+		}
+		if free < requested-current {
+			return nil, status.Errorf(codes.ResourceExhausted, "no enough space left on VG: free=%d, requested=%d", free, requested-current)
+		}
+	}
+
+	lv, err = s.recycleBin.restore(ctx, pool, req.GetName(), req.GetNewName())
+	if errors.Is(err, command.ErrNotFound) {
+		// the volume has been purged just now.
+		return nil, status.Errorf(codes.NotFound, "removed logical volume %s is not in the trash", req.GetName())
+	}
+	if err != nil {
+		logger.Error(err, "failed to restore volume from the trash")
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if err := lv.Resize(ctx, requested); err != nil {
+		logger.Error(err, "failed to resize restored LV", "requested", requested, "current", current)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.notify()
+
+	logger.Info("undeleted a LV from the trash", "size", lv.Size())
+	return &proto.UndeleteLVResponse{
+		Volume: &proto.LogicalVolume{
+			Name:      lv.Name(),
+			SizeBytes: int64(lv.Size()),
+			DevMajor:  lv.MajorNumber(),
+			DevMinor:  lv.MinorNumber(),
+		},
+	}, nil
+}
@@ -0,0 +1,68 @@
+package lvmd
+
+import (
+	"testing"
+	"time"
+
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+func TestTrashedAt(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	at, ok := trashedAt([]string{"other", trashedAtTag(now)})
+	if !ok || !at.Equal(now) {
+		t.Errorf("expected %v, but got %v, %v", now, at, ok)
+	}
+
+	if _, ok := trashedAt([]string{"other"}); ok {
+		t.Error("a volume without the tag should not be trashed yet")
+	}
+	if _, ok := trashedAt([]string{trashedAtTagPrefix + "invalid"}); ok {
+		t.Error("an invalid tag should be ignored")
+	}
+}
+
+func TestGetTrashRetention(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":    0,
+		"0":   0,
+		"72h": 72 * time.Hour,
+		"30m": 30 * time.Minute,
+	}
+	for retention, expected := range cases {
+		dc := &lvmdTypes.DeviceClass{TrashRetention: retention}
+		if actual := GetTrashRetention(dc); actual != expected {
+			t.Errorf("%q: expected %v, but got %v", retention, expected, actual)
+		}
+	}
+
+	var b *RecycleBin
+	if b.enabled(&lvmdTypes.DeviceClass{TrashRetention: "72h"}) {
+		t.Error("nil RecycleBin should not be enabled")
+	}
+	b = NewRecycleBin(nil, nil)
+	if b.enabled(&lvmdTypes.DeviceClass{}) {
+		t.Error("RecycleBin should not be enabled without trash-retention")
+	}
+	if !b.enabled(&lvmdTypes.DeviceClass{TrashRetention: "72h"}) {
+		t.Error("RecycleBin should be enabled with trash-retention")
+	}
+}
+
+func TestIsInternalVolume(t *testing.T) {
+	cases := map[string]bool{
+		"pvc-1234":                         false,
+		readyWarmVolumePrefix + "ext4-0":   true,
+		warmingVolumePrefix + "ext4-0":     true,
+		wipingVolumePrefix + "pvc-1234":    true,
+		trashVolumePrefix + "pvc-1234":     true,
+		copyVolumePrefix + "pvc-1234":      true,
+		"topolvm-trashed-but-not-internal": false,
+	}
+	for name, expected := range cases {
+		if actual := isInternalVolume(name); actual != expected {
+			t.Errorf("%s: expected %v, but got %v", name, expected, actual)
+		}
+	}
+}
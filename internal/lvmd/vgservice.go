@@ -3,7 +3,10 @@ package lvmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/topolvm/topolvm/internal/lvmd/command"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
@@ -12,10 +15,13 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// NewVGService creates a VGServiceServer
-func NewVGService(manager *DeviceClassManager) (proto.VGServiceServer, func()) {
+// NewVGService creates a VGServiceServer.
+// wiper may be nil if no volume is wiped.
+func NewVGService(manager *DeviceClassManager, ocManager *LvcreateOptionClassManager, wiper *Wiper) (proto.VGServiceServer, func()) {
 	svc := &vgService{
 		dcManager: manager,
+		ocManager: ocManager,
+		wiper:     wiper,
 		watchers:  make(map[int]chan struct{}),
 	}
 
@@ -25,6 +31,8 @@ func NewVGService(manager *DeviceClassManager) (proto.VGServiceServer, func()) {
 type vgService struct {
 	proto.UnimplementedVGServiceServer
 	dcManager *DeviceClassManager
+	ocManager *LvcreateOptionClassManager
+	wiper     *Wiper
 
 	// mu protects watcherCounter and watchers. must take it when use them.
 	mu             sync.Mutex
@@ -53,6 +61,10 @@ func (s *vgService) GetLVList(ctx context.Context, req *proto.GetLVListRequest)
 			// do not send thin lvs if request is on TypeThick
 			continue
 		}
+		if isInternalVolume(lv.Name()) {
+			// the volumes in warm pools are not provisioned yet, and the volumes being wiped or in the trash are already removed
+			continue
+		}
 
 		vols = append(vols, &proto.LogicalVolume{
 			Name:      lv.Name(),
@@ -80,6 +92,11 @@ func (s *vgService) GetFreeBytes(ctx context.Context, req *proto.GetFreeBytesReq
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get free bytes: %v", err)
 	}
+	warm, err := warmVolumeBytes(ctx, dc, pool)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get bytes of the warm pool: %v", err)
+	}
+	free += warm
 
 	spare := GetSpare(dc)
 	if free < spare {
@@ -98,8 +115,16 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 	if err != nil {
 		return err
 	}
-	res := &proto.WatchResponse{}
+	pvs, err := command.ListPhysicalVolumes(server.Context())
+	if err != nil {
+		return err
+	}
+	res := &proto.WatchResponse{
+		LvcreateOptionClasses: s.ocManager.Names(),
+	}
 	for _, vg := range vgs {
+		pvItems, vgHealthErr := physicalVolumeItems(vg.Name(), pvs)
+
 		vgFree, err := vg.Free()
 		if err != nil {
 			return status.Error(codes.Internal, err.Error())
@@ -141,6 +166,11 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 			if err != nil {
 				return status.Errorf(codes.Internal, "failed to get pool usage: %v", err)
 			}
+			warm, err := warmVolumeBytes(server.Context(), dc, &thinPoolAdapter{pool, dc.ThinPoolConfig.OverprovisionRatio})
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			opb += warm
 			tpi.OverprovisionBytes = opb
 			if dc.Default {
 				res.FreeBytes = opb
@@ -149,12 +179,34 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 			// size bytes of the thinpool
 			tpi.SizeBytes = tpu.SizeBytes
 
+			healthErr := vgHealthErr
+			if healthErr == "" {
+				healthErr = thinPoolHealthError(pool)
+			}
+
+			pending, wipes := s.wiper.progress(dc.Name)
+			trashBytes, trash, err := trashItems(server.Context(), dc, &thinPoolAdapter{pool, dc.ThinPoolConfig.OverprovisionRatio}, time.Now())
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+
 			// include thinpoolitem in the response
 			res.Items = append(res.Items, &proto.WatchItem{
-				DeviceClass: dc.Name,
-				FreeBytes:   vgFree,
-				SizeBytes:   vgSize,
-				ThinPool:    tpi,
+				DeviceClass:      dc.Name,
+				FreeBytes:        vgFree,
+				SizeBytes:        vgSize,
+				ThinPool:         tpi,
+				VolumeGroup:      vg.Name(),
+				VolumeGroupUuid:  vg.UUID(),
+				PhysicalVolumes:  pvItems,
+				HealthError:      healthErr,
+				Default:          dc.Default,
+				PendingFreeBytes: pending,
+				Wipes:            wipes,
+				TrashBytes:       trashBytes,
+				Trash:            trash,
+				FsType:           dc.FsType,
+				MkfsOptions:      mkfsOptionItems(dc),
 			})
 		}
 
@@ -163,6 +215,12 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 			continue
 		}
 
+		warm, err := warmVolumeBytes(server.Context(), dc, &volumeGroupAdapter{vg})
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		vgFree += warm
+
 		spare := GetSpare(dc)
 		if vgFree < spare {
 			vgFree = 0
@@ -174,15 +232,68 @@ func (s *vgService) send(server proto.VGService_WatchServer) error {
 			res.FreeBytes = vgFree
 		}
 
+		pending, wipes := s.wiper.progress(dc.Name)
+		trashBytes, trash, err := trashItems(server.Context(), dc, &volumeGroupAdapter{vg}, time.Now())
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+
 		res.Items = append(res.Items, &proto.WatchItem{
-			DeviceClass: dc.Name,
-			FreeBytes:   vgFree,
-			SizeBytes:   vgSize,
+			DeviceClass:      dc.Name,
+			FreeBytes:        vgFree,
+			SizeBytes:        vgSize,
+			VolumeGroup:      vg.Name(),
+			VolumeGroupUuid:  vg.UUID(),
+			PhysicalVolumes:  pvItems,
+			HealthError:      vgHealthErr,
+			Default:          dc.Default,
+			PendingFreeBytes: pending,
+			Wipes:            wipes,
+			TrashBytes:       trashBytes,
+			Trash:            trash,
+			FsType:           dc.FsType,
+			MkfsOptions:      mkfsOptionItems(dc),
 		})
 	}
 	return server.Send(res)
 }
 
+// physicalVolumeItems returns the physical volumes of the volume group.
+// The second return value describes missing physical volumes, or is empty if there are none.
+func physicalVolumeItems(vgName string, pvs []*command.PhysicalVolume) ([]*proto.PhysicalVolumeItem, string) {
+	var items []*proto.PhysicalVolumeItem
+	var missing []string
+	for _, pv := range pvs {
+		if pv.VGName() != vgName {
+			continue
+		}
+		items = append(items, &proto.PhysicalVolumeItem{
+			Name:      pv.Name(),
+			SizeBytes: pv.Size(),
+			FreeBytes: pv.Free(),
+			Missing:   pv.Missing(),
+		})
+		if pv.Missing() {
+			missing = append(missing, pv.Name())
+		}
+	}
+	if len(missing) != 0 {
+		return items, fmt.Sprintf("physical volumes are missing: %s", strings.Join(missing, ","))
+	}
+	return items, ""
+}
+
+func thinPoolHealthError(pool *command.ThinPool) string {
+	attr, err := command.ParsedLVAttr(pool.Attr())
+	if err != nil {
+		return err.Error()
+	}
+	if err := attr.VerifyHealth(); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
 func (s *vgService) addWatcher(ch chan struct{}) int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -234,3 +345,14 @@ func (s *vgService) Watch(_ *proto.Empty, server proto.VGService_WatchServer) er
 		}
 	}
 }
+
+// mkfsOptionItems returns the default extra arguments of mkfs of the device class for each filesystem.
+func mkfsOptionItems(dc *lvmdTypes.DeviceClass) []*proto.MkfsOptions {
+	var items []*proto.MkfsOptions
+	for _, fsType := range []string{"ext4", "xfs", "btrfs"} {
+		if options := GetMkfsOptions(dc, fsType); len(options) > 0 {
+			items = append(items, &proto.MkfsOptions{FsType: fsType, Options: options})
+		}
+	}
+	return items
+}
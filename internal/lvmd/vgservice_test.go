@@ -322,6 +322,8 @@ func setupVGService(ctx context.Context, t *testing.T) (
 				},
 			},
 		),
+		NewLvcreateOptionClassManager(nil),
+		nil,
 	)
 
 	return vgService, notifier, vg, pool
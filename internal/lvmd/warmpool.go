@@ -0,0 +1,371 @@
+package lvmd
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/topolvm/topolvm/internal/filesystem"
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// warmVolumePrefix is the prefix of the names of all volumes in warm pools.
+	// They are hidden from GetLVList.
+	warmVolumePrefix = "topolvm-warm"
+	// readyWarmVolumePrefix is followed by the filesystem, or rawWarmVolume, and a random ID.
+	readyWarmVolumePrefix = "topolvm-warm-"
+	// warmingVolumePrefix is the prefix of the names of volumes being prepared, which cannot be claimed yet.
+	warmingVolumePrefix = "topolvm-warming-"
+	rawWarmVolume       = "raw"
+
+	warmPoolInterval = time.Minute
+)
+
+// WarmPool keeps pre-created volumes in the device classes with warm-pool configured.
+// CreateLV claims one of them by renaming it instead of creating a new volume.
+type WarmPool struct {
+	dcmapper   *DeviceClassManager
+	notifyFunc func()
+	refillCh   chan struct{}
+
+	// mu serializes claiming, releasing, and creating volumes so that a volume being claimed is not removed,
+	// and the space released for a volume is not taken by the warm pool again.
+	mu sync.Mutex
+}
+
+// NewWarmPool creates a WarmPool. Run must be called to fill the pools.
+func NewWarmPool(dcmapper *DeviceClassManager, notifyFunc func()) *WarmPool {
+	return &WarmPool{
+		dcmapper:   dcmapper,
+		notifyFunc: notifyFunc,
+		refillCh:   make(chan struct{}, 1),
+	}
+}
+
+// warmVolume is a volume ready in a warm pool.
+type warmVolume struct {
+	lv     *command.LogicalVolume
+	name   string
+	size   uint64
+	fsType string
+}
+
+// isWarmVolume returns true if the name is of a volume in a warm pool, whether it is ready or not.
+func isWarmVolume(name string) bool {
+	return strings.HasPrefix(name, warmVolumePrefix)
+}
+
+// parseWarmVolumeName returns the filesystem of a ready volume in a warm pool, which is empty if it is not formatted.
+// The second return value is false if the name is not of a ready volume.
+func parseWarmVolumeName(name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, readyWarmVolumePrefix)
+	if !ok {
+		return "", false
+	}
+	fsType, id, ok := strings.Cut(rest, "-")
+	if !ok || id == "" {
+		return "", false
+	}
+	if fsType == rawWarmVolume {
+		return "", true
+	}
+	return fsType, true
+}
+
+// warmVolumeID returns a random ID unique to a volume in a warm pool.
+func warmVolumeID(fsType string) string {
+	if fsType == "" {
+		fsType = rawWarmVolume
+	}
+	return fsType + "-" + strings.ToLower(rand.Text())
+}
+
+// listWarmVolumes returns the ready volumes and the volumes being prepared in the warm pool of the device class.
+func listWarmVolumes(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool) ([]warmVolume, []*command.LogicalVolume, error) {
+	lvs, err := pool.ListVolumes(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ready []warmVolume
+	var warming []*command.LogicalVolume
+	for _, lv := range lvs {
+		if dc.Type == lvmdTypes.TypeThick && lv.IsThin() {
+			continue
+		}
+		if strings.HasPrefix(lv.Name(), warmingVolumePrefix) {
+			warming = append(warming, lv)
+			continue
+		}
+		if fsType, ok := parseWarmVolumeName(lv.Name()); ok {
+			ready = append(ready, warmVolume{lv: lv, name: lv.Name(), size: lv.Size(), fsType: fsType})
+		}
+	}
+	return ready, warming, nil
+}
+
+// warmVolumeBytes returns the total size of the volumes ready in the warm pool of the device class.
+// They are reported as free because they are released when the space is needed.
+func warmVolumeBytes(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool) (uint64, error) {
+	if len(dc.WarmPool) == 0 {
+		return 0, nil
+	}
+	ready, _, err := listWarmVolumes(ctx, dc, pool)
+	if err != nil {
+		return 0, err
+	}
+	var total uint64
+	for _, v := range ready {
+		total += v.size
+	}
+	return total, nil
+}
+
+// pickWarmVolume returns the index of the volume to be claimed for a volume of the size and filesystem, or -1.
+// A volume formatted with the filesystem is preferred, and an unformatted one is taken otherwise.
+// Only an unformatted volume is taken for a block volume, whose fsType is empty.
+func pickWarmVolume(vols []warmVolume, size uint64, fsType string) int {
+	raw := -1
+	for i, v := range vols {
+		if v.size != size {
+			continue
+		}
+		if fsType != "" && v.fsType == fsType {
+			return i
+		}
+		if v.fsType == "" && raw == -1 {
+			raw = i
+		}
+	}
+	return raw
+}
+
+// excessWarmVolumes returns the volumes exceeding the counts of the configs, and the number of volumes missing
+// for each config.
+func excessWarmVolumes(vols []warmVolume, configs []*lvmdTypes.WarmPoolConfig) ([]warmVolume, []int) {
+	missing := make([]int, len(configs))
+	for i, c := range configs {
+		missing[i] = c.Count
+	}
+	var excess []warmVolume
+OUTER:
+	for _, v := range vols {
+		for i, c := range configs {
+			if v.size == c.SizeGB<<30 && v.fsType == c.FsType {
+				if missing[i] > 0 {
+					missing[i]--
+					continue OUTER
+				}
+				break
+			}
+		}
+		excess = append(excess, v)
+	}
+	return excess, missing
+}
+
+// enabled returns true if the device class has a warm pool.
+func (p *WarmPool) enabled(dc *lvmdTypes.DeviceClass) bool {
+	return p != nil && len(dc.WarmPool) != 0
+}
+
+func (p *WarmPool) notify() {
+	if p.notifyFunc != nil {
+		p.notifyFunc()
+	}
+}
+
+// triggerRefill makes Run fill the pools without waiting for the next interval.
+func (p *WarmPool) triggerRefill() {
+	select {
+	case p.refillCh <- struct{}{}:
+	default:
+	}
+}
+
+// claim renames a ready volume of the size and filesystem in the warm pool to the name, and returns it.
+// It returns nil if there is no such volume. The caller must hold p.mu.
+func (p *WarmPool) claim(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool, name string, size uint64, fsType string) (*command.LogicalVolume, error) {
+	ready, _, err := listWarmVolumes(ctx, dc, pool)
+	if err != nil {
+		return nil, err
+	}
+	i := pickWarmVolume(ready, size, fsType)
+	if i == -1 {
+		return nil, nil
+	}
+	lv := ready[i].lv
+	if err := lv.Rename(ctx, name); err != nil {
+		return nil, err
+	}
+	return lv, nil
+}
+
+// release removes ready volumes in the warm pool until the free space of the pool reaches required.
+// It returns the free space after removing them. The caller must hold p.mu.
+func (p *WarmPool) release(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool, required uint64) (uint64, error) {
+	free, err := pool.Free(ctx)
+	if err != nil {
+		return 0, err
+	}
+	ready, _, err := listWarmVolumes(ctx, dc, pool)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range ready {
+		if free >= required {
+			break
+		}
+		if err := v.lv.VG().RemoveVolume(ctx, v.name); err != nil {
+			return 0, err
+		}
+		log.FromContext(ctx).Info("released a volume in the warm pool", "name", v.name, "size", v.size)
+		// the free space of a volume group is not updated by removing a volume.
+		free += v.size
+	}
+	return free, nil
+}
+
+// Run fills the warm pools until ctx is done. It also removes the volumes which are no longer configured, or
+// were left by lvmd stopped while preparing them.
+func (p *WarmPool) Run(ctx context.Context) {
+	logger := log.FromContext(ctx)
+	ticker := time.NewTicker(warmPoolInterval)
+	defer ticker.Stop()
+
+	// every device class is scanned once to remove the volumes of warm pools removed from the config.
+	all := true
+	for {
+		for _, dc := range p.dcmapper.deviceClassByName {
+			if !all && len(dc.WarmPool) == 0 {
+				continue
+			}
+			if err := p.refill(ctx, dc); err != nil && ctx.Err() == nil {
+				logger.Error(err, "failed to fill the warm pool", "device-class", dc.Name)
+			}
+		}
+		all = false
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-p.refillCh:
+		}
+	}
+}
+
+// refill removes the volumes of the device class exceeding the config, and creates the missing ones.
+func (p *WarmPool) refill(ctx context.Context, dc *lvmdTypes.DeviceClass) error {
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return err
+	}
+
+	missing, err := p.removeExcess(ctx, dc, pool)
+	if err != nil {
+		return err
+	}
+
+	for i, c := range dc.WarmPool {
+		for range missing[i] {
+			created, err := p.prepare(ctx, dc, c)
+			if err != nil {
+				return err
+			}
+			if !created {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// removeExcess removes the volumes exceeding the config and the volumes left being prepared.
+// The volumes ready are reported as free, so only removing the latter changes the free space. It returns the number of volumes missing for each config.
+func (p *WarmPool) removeExcess(ctx context.Context, dc *lvmdTypes.DeviceClass, pool storagePool) ([]int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ready, warming, err := listWarmVolumes(ctx, dc, pool)
+	if err != nil {
+		return nil, err
+	}
+	// refill does not run concurrently, so the volumes being prepared were left by a failure.
+	for _, lv := range warming {
+		if err := lv.VG().RemoveVolume(ctx, lv.Name()); err != nil {
+			return nil, err
+		}
+		p.notify()
+	}
+	excess, missing := excessWarmVolumes(ready, dc.WarmPool)
+	for _, v := range excess {
+		if err := v.lv.VG().RemoveVolume(ctx, v.name); err != nil {
+			return nil, err
+		}
+		log.FromContext(ctx).Info("removed an excess volume in the warm pool", "name", v.name, "size", v.size)
+	}
+	return missing, nil
+}
+
+// prepare creates a volume of the config, formats it, and makes it ready to be claimed.
+// It returns false without creating a volume if there is not enough free space.
+func (p *WarmPool) prepare(ctx context.Context, dc *lvmdTypes.DeviceClass, c *lvmdTypes.WarmPoolConfig) (bool, error) {
+	size := c.SizeGB << 30
+	id := warmVolumeID(c.FsType)
+	lv, err := p.create(ctx, dc, warmingVolumePrefix+id, size)
+	if err != nil || lv == nil {
+		return false, err
+	}
+
+	if c.FsType != "" {
+		args := filesystem.MkfsArgs(c.FsType, GetMkfsOptions(dc, c.FsType), lv.Path())
+		out, err := exec.CommandContext(ctx, "mkfs."+c.FsType, args...).CombinedOutput()
+		if err != nil {
+			return false, errors.Join(fmt.Errorf("mkfs.%s failed: output=%s, error=%w", c.FsType, string(out), err),
+				lv.VG().RemoveVolume(ctx, lv.Name()))
+		}
+	}
+	if err := lv.Rename(ctx, readyWarmVolumePrefix+id); err != nil {
+		return false, err
+	}
+	p.notify()
+	log.FromContext(ctx).Info("prepared a volume in the warm pool", "name", lv.Name(), "size", size)
+	return true, nil
+}
+
+// create creates a volume unless it leaves less free space than the spare, and returns nil in that case.
+func (p *WarmPool) create(ctx context.Context, dc *lvmdTypes.DeviceClass, name string, size uint64) (*command.LogicalVolume, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// the pool is looked up every time to get the latest free space.
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return nil, err
+	}
+	free, err := pool.Free(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if free < size+GetSpare(dc) {
+		log.FromContext(ctx).V(1).Info("not enough free space for the warm pool", "device-class", dc.Name, "free", free, "size", size)
+		return nil, nil
+	}
+
+	var stripe uint
+	if dc.Stripe != nil {
+		stripe = *dc.Stripe
+	}
+	if err := pool.CreateVolume(ctx, name, size, nil, stripe, dc.StripeSize, dc.LVCreateOptions); err != nil {
+		return nil, err
+	}
+	return pool.FindVolume(ctx, name)
+}
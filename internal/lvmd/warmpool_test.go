@@ -0,0 +1,88 @@
+package lvmd
+
+import (
+	"slices"
+	"testing"
+
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+func TestParseWarmVolumeName(t *testing.T) {
+	cases := []struct {
+		name   string
+		warm   bool
+		fsType string
+		ready  bool
+	}{
+		{readyWarmVolumePrefix + warmVolumeID("ext4"), true, "ext4", true},
+		{readyWarmVolumePrefix + warmVolumeID(""), true, "", true},
+		{warmingVolumePrefix + warmVolumeID("xfs"), true, "", false},
+		{"topolvm-warm-ext4", true, "", false},
+		{"0b0a4d2c-4b5f-4d7a-9f5e-2f6c1a7e8d90", false, "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if warm := isWarmVolume(tc.name); warm != tc.warm {
+				t.Errorf("expected warm=%v, but got %v", tc.warm, warm)
+			}
+			fsType, ready := parseWarmVolumeName(tc.name)
+			if fsType != tc.fsType || ready != tc.ready {
+				t.Errorf("expected (%q, %v), but got (%q, %v)", tc.fsType, tc.ready, fsType, ready)
+			}
+		})
+	}
+}
+
+func TestPickWarmVolume(t *testing.T) {
+	vols := []warmVolume{
+		{name: "a", size: 1 << 30, fsType: "ext4"},
+		{name: "b", size: 2 << 30, fsType: ""},
+		{name: "c", size: 2 << 30, fsType: "xfs"},
+		{name: "d", size: 1 << 30, fsType: ""},
+	}
+	cases := []struct {
+		name     string
+		size     uint64
+		fsType   string
+		expected int
+	}{
+		{"formatted", 1 << 30, "ext4", 0},
+		{"formatted after raw", 2 << 30, "xfs", 2},
+		{"raw for another fs", 1 << 30, "xfs", 3},
+		{"raw for block", 2 << 30, "", 1},
+		{"no size", 3 << 30, "ext4", -1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := pickWarmVolume(vols, tc.size, tc.fsType); actual != tc.expected {
+				t.Errorf("expected %d, but got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestExcessWarmVolumes(t *testing.T) {
+	configs := []*lvmdTypes.WarmPoolConfig{
+		{SizeGB: 1, Count: 2, FsType: "ext4"},
+		{SizeGB: 2, Count: 1},
+	}
+	vols := []warmVolume{
+		{name: "a", size: 1 << 30, fsType: "ext4"},
+		{name: "b", size: 2 << 30, fsType: ""},
+		{name: "c", size: 2 << 30, fsType: ""},
+		{name: "d", size: 1 << 30, fsType: "xfs"},
+		{name: "e", size: 3 << 30, fsType: "ext4"},
+	}
+
+	excess, missing := excessWarmVolumes(vols, configs)
+	var names []string
+	for _, v := range excess {
+		names = append(names, v.name)
+	}
+	if !slices.Equal(names, []string{"c", "d", "e"}) {
+		t.Errorf("unexpected excess volumes: %v", names)
+	}
+	if !slices.Equal(missing, []int{1, 0}) {
+		t.Errorf("unexpected missing counts: %v", missing)
+	}
+}
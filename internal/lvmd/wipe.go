@@ -0,0 +1,285 @@
+package lvmd
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	mathrand "math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/topolvm/topolvm/internal/lvmd/command"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"golang.org/x/sys/unix"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// wipingVolumePrefix is followed by the name of a removed volume being wiped.
+	// The volumes being wiped are hidden from GetLVList.
+	wipingVolumePrefix = "topolvm-wiping-"
+
+	// wipeIoctlChunkSize is the size of a range discarded or zeroed by an ioctl.
+	wipeIoctlChunkSize = 1 << 30
+	// wipeWriteChunkSize is the size of random data written at a time.
+	wipeWriteChunkSize = 1 << 20
+
+	wipeRetryInterval  = time.Minute
+	wipeNotifyInterval = 10 * time.Second
+)
+
+// Wiper erases the data of the volumes removed from the device classes with wipe-policy, and then removes them.
+// The extents of a volume are not released until it is wiped, so the data does not leak to the next volume.
+type Wiper struct {
+	dcmapper *DeviceClassManager
+	wakeCh   chan struct{}
+
+	// mu protects wipes.
+	mu    sync.Mutex
+	wipes map[string]*wipeState
+}
+
+// wipeState is the progress of wiping a volume.
+type wipeState struct {
+	deviceClass string
+	size        uint64
+	wiped       atomic.Uint64
+}
+
+// NewWiper creates a Wiper. Run must be called to wipe the removed volumes.
+func NewWiper(dcmapper *DeviceClassManager) *Wiper {
+	return &Wiper{
+		dcmapper: dcmapper,
+		wakeCh:   make(chan struct{}, 1),
+		wipes:    make(map[string]*wipeState),
+	}
+}
+
+// needsWipe returns true if the volumes of the device class are wiped before they are removed.
+func needsWipe(dc *lvmdTypes.DeviceClass) bool {
+	return dc.WipePolicy != "" && dc.WipePolicy != lvmdTypes.WipeNone
+}
+
+// enabled returns true if the volumes removed from the device class are wiped.
+func (w *Wiper) enabled(dc *lvmdTypes.DeviceClass) bool {
+	return w != nil && needsWipe(dc)
+}
+
+func (w *Wiper) wake() {
+	select {
+	case w.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// queue renames the volume to be wiped in the background.
+// It returns an error wrapping command.ErrNotFound if the volume does not exist.
+func (w *Wiper) queue(ctx context.Context, dc *lvmdTypes.DeviceClass, vg *command.VolumeGroup, name string) error {
+	lv, err := vg.FindVolume(ctx, name)
+	if err != nil {
+		return err
+	}
+	return w.queueVolume(ctx, dc, lv, name)
+}
+
+// queueVolume renames the volume, which was removed as name, to be wiped in the background.
+func (w *Wiper) queueVolume(ctx context.Context, dc *lvmdTypes.DeviceClass, lv *command.LogicalVolume, name string) error {
+	if err := lv.Rename(ctx, wipingVolumePrefix+name); err != nil {
+		return err
+	}
+	w.track(name, dc.Name, lv.Size())
+	w.wake()
+	return nil
+}
+
+// track returns the progress of wiping the volume, which is created if it is not tracked yet.
+func (w *Wiper) track(name, deviceClass string, size uint64) *wipeState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	state, ok := w.wipes[name]
+	if !ok {
+		state = &wipeState{deviceClass: deviceClass, size: size}
+		w.wipes[name] = state
+	}
+	return state
+}
+
+func (w *Wiper) untrack(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.wipes, name)
+}
+
+// progress returns the total size of the volumes being wiped in the device class and the progress of each of them.
+func (w *Wiper) progress(deviceClass string) (uint64, []*proto.WipeItem) {
+	if w == nil {
+		return 0, nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var pending uint64
+	var items []*proto.WipeItem
+	for name, state := range w.wipes {
+		if state.deviceClass != deviceClass {
+			continue
+		}
+		pending += state.size
+		items = append(items, &proto.WipeItem{
+			Name:       name,
+			SizeBytes:  state.size,
+			WipedBytes: state.wiped.Load(),
+		})
+	}
+	slices.SortFunc(items, func(a, b *proto.WipeItem) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return pending, items
+}
+
+// Run wipes the removed volumes until ctx is done. The volumes left by lvmd stopped while wiping them are
+// wiped from the beginning again. notifyFunc is called when the progress or the free space changes.
+func (w *Wiper) Run(ctx context.Context, notifyFunc func()) {
+	logger := log.FromContext(ctx)
+	// a failed wipe is retried because the extents must not be released without being wiped.
+	ticker := time.NewTicker(wipeRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, dc := range w.dcmapper.deviceClassByName {
+			if err := w.wipeDeviceClass(ctx, dc, notifyFunc); err != nil && ctx.Err() == nil {
+				logger.Error(err, "failed to wipe removed volumes", "device-class", dc.Name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wakeCh:
+		}
+	}
+}
+
+// wipeDeviceClass wipes and removes the volumes queued in the device class one by one.
+func (w *Wiper) wipeDeviceClass(ctx context.Context, dc *lvmdTypes.DeviceClass, notifyFunc func()) error {
+	pool, err := storagePoolForDeviceClass(ctx, dc)
+	if err != nil {
+		return err
+	}
+	lvs, err := pool.ListVolumes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, lv := range lvs {
+		if dc.Type == lvmdTypes.TypeThick && lv.IsThin() {
+			continue
+		}
+		name, ok := strings.CutPrefix(lv.Name(), wipingVolumePrefix)
+		if !ok {
+			continue
+		}
+		logger := log.FromContext(ctx).WithValues("name", name, "policy", dc.WipePolicy)
+		state := w.track(name, dc.Name, lv.Size())
+
+		// the volumes queued before the policy was changed to none are removed without being wiped.
+		if needsWipe(dc) {
+			logger.Info("wiping a removed LV", "size", lv.Size())
+			if err := lv.MakeWritable(ctx); err != nil {
+				return err
+			}
+			lastNotified := time.Now()
+			err := wipeVolume(ctx, lv.Path(), lv.Size(), dc.WipePolicy, func(wiped uint64) {
+				state.wiped.Store(wiped)
+				if time.Since(lastNotified) >= wipeNotifyInterval {
+					lastNotified = time.Now()
+					notifyFunc()
+				}
+			})
+			if err != nil {
+				return fmt.Errorf("failed to wipe %s: %w", name, err)
+			}
+		}
+
+		if err := lv.VG().RemoveVolume(ctx, lv.Name()); err != nil {
+			return err
+		}
+		w.untrack(name)
+		notifyFunc()
+		logger.Info("removed a wiped LV", "size", lv.Size())
+	}
+	return nil
+}
+
+// wipeVolume erases the data of the block device by the policy.
+// progress is called with the number of bytes wiped so far after each chunk.
+func wipeVolume(ctx context.Context, path string, size uint64, policy lvmdTypes.WipePolicy, progress func(uint64)) error {
+	// O_EXCL fails if the device is still mounted or open exclusively by others.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_EXCL, 0)
+	if err != nil {
+		return err
+	}
+
+	var wipe func(offset, length uint64) error
+	chunkSize := uint64(wipeIoctlChunkSize)
+	switch policy {
+	case lvmdTypes.WipeDiscard:
+		wipe = func(offset, length uint64) error {
+			return ioctlRange(f, unix.BLKDISCARD, offset, length)
+		}
+	case lvmdTypes.WipeZero:
+		// BLKZEROOUT falls back to writing zeros if the device cannot zero ranges by itself.
+		wipe = func(offset, length uint64) error {
+			return ioctlRange(f, unix.BLKZEROOUT, offset, length)
+		}
+	case lvmdTypes.WipeRandom:
+		var seed [32]byte
+		if _, err := rand.Read(seed[:]); err != nil {
+			return errors.Join(err, f.Close())
+		}
+		// ChaCha8 is a cryptographically secure generator much faster than reading crypto/rand for every chunk.
+		rng := mathrand.NewChaCha8(seed)
+		buf := make([]byte, wipeWriteChunkSize)
+		chunkSize = wipeWriteChunkSize
+		wipe = func(offset, length uint64) error {
+			_, _ = rng.Read(buf[:length])
+			_, err := f.WriteAt(buf[:length], int64(offset))
+			return err
+		}
+	default:
+		return errors.Join(fmt.Errorf("unsupported wipe policy: %s", policy), f.Close())
+	}
+
+	for offset := uint64(0); offset < size; offset += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(err, f.Close())
+		}
+		length := min(chunkSize, size-offset)
+		if err := wipe(offset, length); err != nil {
+			return errors.Join(fmt.Errorf("failed to wipe at %d: %w", offset, err), f.Close())
+		}
+		progress(offset + length)
+	}
+	if err := f.Sync(); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	return f.Close()
+}
+
+// ioctlRange calls the ioctl of a block device taking a range, i.e. BLKDISCARD or BLKZEROOUT.
+func ioctlRange(f *os.File, req uint, offset, length uint64) error {
+	r := [2]uint64{offset, length}
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, f.Fd(), uintptr(req), uintptr(unsafe.Pointer(&r[0])))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package lvmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+)
+
+func TestWipeVolumeRandom(t *testing.T) {
+	size := uint64(wipeWriteChunkSize*2 + 4096)
+	path := filepath.Join(t.TempDir(), "volume")
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []uint64
+	err := wipeVolume(context.Background(), path, size, lvmdTypes.WipeRandom, func(wiped uint64) {
+		progress = append(progress, wiped)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []uint64{wipeWriteChunkSize, wipeWriteChunkSize * 2, size}
+	if !slices.Equal(progress, expected) {
+		t.Errorf("expected progress %v, but got %v", expected, progress)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(data)) != size {
+		t.Fatalf("the size changed: %d", len(data))
+	}
+	if bytes.Equal(data[size-4096:], make([]byte, 4096)) {
+		t.Error("the last chunk is not overwritten")
+	}
+}
+
+func TestWipeVolumeUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "volume")
+	if err := os.WriteFile(path, make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := wipeVolume(context.Background(), path, 4096, lvmdTypes.WipeNone, func(uint64) {})
+	if err == nil {
+		t.Error("should fail for the policy without wiping")
+	}
+}
+
+func TestWiperProgress(t *testing.T) {
+	w := NewWiper(NewDeviceClassManager(nil))
+	w.track("b", "ssd", 2<<30).wiped.Store(1 << 30)
+	w.track("a", "ssd", 1<<30)
+	w.track("c", "hdd", 4<<30)
+	// tracking again keeps the progress.
+	w.track("b", "ssd", 2<<30)
+
+	pending, items := w.progress("ssd")
+	if pending != 3<<30 {
+		t.Errorf("unexpected pending bytes: %d", pending)
+	}
+	if len(items) != 2 || items[0].Name != "a" || items[1].Name != "b" || items[1].WipedBytes != 1<<30 {
+		t.Errorf("unexpected items: %v", items)
+	}
+
+	w.untrack("c")
+	if pending, items := w.progress("hdd"); pending != 0 || len(items) != 0 {
+		t.Errorf("should be empty: %d, %v", pending, items)
+	}
+
+	var nilWiper *Wiper
+	if pending, items := nilWiper.progress("ssd"); pending != 0 || items != nil {
+		t.Errorf("should be empty: %d, %v", pending, items)
+	}
+}
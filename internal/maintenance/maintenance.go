@@ -0,0 +1,37 @@
+package maintenance
+
+import (
+	"strings"
+
+	"github.com/topolvm/topolvm"
+)
+
+// allDeviceClasses is the annotation value that puts all device-classes on a node into maintenance.
+const allDeviceClasses = "true"
+
+// IsUnderMaintenance returns true if the device-class of the node is under storage maintenance.
+// annotations are the annotations of the Node resource.
+//
+// The maintenance annotation takes either "true" (or an empty string) to stop provisioning
+// for all device-classes of the node, or a comma-separated list of device-class names.
+// The default device-class can be specified as "00default".
+func IsUnderMaintenance(annotations map[string]string, deviceClass string) bool {
+	val, ok := annotations[topolvm.GetMaintenanceKey()]
+	if !ok {
+		return false
+	}
+	val = strings.TrimSpace(val)
+	if val == "" || val == allDeviceClasses {
+		return true
+	}
+
+	if deviceClass == topolvm.DefaultDeviceClassName {
+		deviceClass = topolvm.DefaultDeviceClassAnnotationName
+	}
+	for _, dc := range strings.Split(val, ",") {
+		if strings.TrimSpace(dc) == deviceClass {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package maintenance
+
+import (
+	"testing"
+
+	"github.com/topolvm/topolvm"
+)
+
+func TestIsUnderMaintenance(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		deviceClass string
+		expected    bool
+	}{
+		{
+			name:        "no annotation",
+			annotations: map[string]string{},
+			deviceClass: "ssd",
+			expected:    false,
+		},
+		{
+			name:        "empty value means all device-classes",
+			annotations: map[string]string{topolvm.GetMaintenanceKey(): ""},
+			deviceClass: "ssd",
+			expected:    true,
+		},
+		{
+			name:        "true means all device-classes",
+			annotations: map[string]string{topolvm.GetMaintenanceKey(): "true"},
+			deviceClass: topolvm.DefaultDeviceClassName,
+			expected:    true,
+		},
+		{
+			name:        "listed device-class",
+			annotations: map[string]string{topolvm.GetMaintenanceKey(): "hdd, ssd"},
+			deviceClass: "ssd",
+			expected:    true,
+		},
+		{
+			name:        "unlisted device-class",
+			annotations: map[string]string{topolvm.GetMaintenanceKey(): "hdd"},
+			deviceClass: "ssd",
+			expected:    false,
+		},
+		{
+			name:        "default device-class",
+			annotations: map[string]string{topolvm.GetMaintenanceKey(): topolvm.DefaultDeviceClassAnnotationName},
+			deviceClass: topolvm.DefaultDeviceClassName,
+			expected:    true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := IsUnderMaintenance(tt.annotations, tt.deviceClass); actual != tt.expected {
+				t.Errorf("expected %v, but actual %v", tt.expected, actual)
+			}
+		})
+	}
+}
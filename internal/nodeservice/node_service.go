@@ -1,4 +1,4 @@
-package k8s
+package nodeservice
 
 import (
 	"context"
@@ -6,7 +6,11 @@ import (
 	"strconv"
 
 	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/maintenance"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -47,6 +51,41 @@ func (s NodeService) extractCapacityFromAnnotation(node *v1.PartialObjectMetadat
 	return strconv.ParseInt(c, 10, 64)
 }
 
+//+kubebuilder:rbac:groups=topolvm.io,resources=nodestorages,verbs=get;list;watch
+
+// extractCapacity returns the capacity in the annotation of the node, or in NodeStorage
+// of the node if the annotation is missing.
+func (s NodeService) extractCapacity(ctx context.Context, node *v1.PartialObjectMetadata, deviceClass string) (int64, error) {
+	c, err := s.extractCapacityFromAnnotation(node, deviceClass)
+	if !errors.Is(err, ErrDeviceClassNotFound) {
+		return c, err
+	}
+
+	var ns topolvmv1.NodeStorage
+	err = s.reader.Get(ctx, client.ObjectKey{Name: node.Name}, &ns)
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return 0, ErrDeviceClassNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	dc := ns.Status.DeviceClass(deviceClass)
+	if dc == nil {
+		return 0, ErrDeviceClassNotFound
+	}
+	return dc.Free.Value(), nil
+}
+
+// extractAvailableCapacity is the same as extractCapacity except that
+// it returns zero for nodes whose device-class is under storage maintenance.
+// It must not be used for expanding existing volumes, which continues during maintenance.
+func (s NodeService) extractAvailableCapacity(ctx context.Context, node *v1.PartialObjectMetadata, deviceClass string) (int64, error) {
+	if maintenance.IsUnderMaintenance(node.Annotations, deviceClass) {
+		return 0, nil
+	}
+	return s.extractCapacity(ctx, node, deviceClass)
+}
+
 // GetCapacityByName returns VG capacity of specified node by name.
 func (s NodeService) GetCapacityByName(ctx context.Context, name, deviceClass string) (int64, error) {
 	n := new(v1.PartialObjectMetadata)
@@ -56,7 +95,7 @@ func (s NodeService) GetCapacityByName(ctx context.Context, name, deviceClass st
 		return 0, err
 	}
 
-	return s.extractCapacityFromAnnotation(n, deviceClass)
+	return s.extractCapacity(ctx, n, deviceClass)
 }
 
 // GetCapacityByTopologyLabel returns VG capacity of specified node by TopoLVM's topology label.
@@ -71,7 +110,7 @@ func (s NodeService) GetCapacityByTopologyLabel(ctx context.Context, topology, d
 			if v != topology {
 				continue
 			}
-			return s.extractCapacityFromAnnotation(&node, dc)
+			return s.extractAvailableCapacity(ctx, &node, dc)
 		}
 	}
 
@@ -87,7 +126,7 @@ func (s NodeService) GetTotalCapacity(ctx context.Context, dc string) (int64, er
 
 	capacity := int64(0)
 	for _, node := range nl.Items {
-		c, _ := s.extractCapacityFromAnnotation(&node, dc)
+		c, _ := s.extractAvailableCapacity(ctx, &node, dc)
 		capacity += c
 	}
 	return capacity, nil
@@ -102,7 +141,7 @@ func (s NodeService) GetMaxCapacity(ctx context.Context, deviceClass string) (st
 	var nodeName string
 	var maxCapacity int64
 	for _, node := range nl.Items {
-		c, _ := s.extractCapacityFromAnnotation(&node, deviceClass)
+		c, _ := s.extractAvailableCapacity(ctx, &node, deviceClass)
 		if maxCapacity < c {
 			maxCapacity = c
 			nodeName = node.Name
@@ -0,0 +1,173 @@
+package podcapacity
+
+import (
+	"context"
+
+	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/internal/getter"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var logger = ctrl.Log.WithName("pod-capacity")
+
+type targetSC struct {
+	getter getter.Interface
+	cache  map[string]*storagev1.StorageClass
+}
+
+func (t *targetSC) Get(ctx context.Context, name string) (*storagev1.StorageClass, error) {
+	if sc, ok := t.cache[name]; ok {
+		return sc, nil
+	}
+
+	var sc storagev1.StorageClass
+	err := t.getter.Get(ctx, types.NamespacedName{Name: name}, &sc)
+	if err != nil {
+		if apierrs.IsNotFound(err) {
+			t.cache[name] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+	if sc.Provisioner != topolvm.GetPluginName() {
+		t.cache[name] = nil
+		return nil, nil
+	}
+	t.cache[name] = &sc
+	return &sc, nil
+}
+
+// VolumesCapacity returns the storage capacity requested by the unbound TopoLVM
+// PVCs and generic ephemeral volumes of the pod, keyed by device-class.
+// It returns nil if the pod already has a bound TopoLVM PVC because the node
+// to run the pod is fixed by the existing volume.
+func VolumesCapacity(ctx context.Context, g getter.Interface, pod *corev1.Pod) (map[string]int64, error) {
+	targetSC := &targetSC{g, map[string]*storagev1.StorageClass{}}
+	capacities := make(map[string]int64)
+	for _, vol := range pod.Spec.Volumes {
+		switch {
+		case vol.PersistentVolumeClaim != nil:
+			dc, requested, isAlreadyBound, err := pvcCapacity(ctx, g, pod, vol, targetSC)
+			if err != nil {
+				return nil, err
+			}
+			if isAlreadyBound {
+				// If there is a TopoLVM volume that has been bound, scheduling will not be performed because the node to be scheduled is already fixed.
+				return nil, nil
+			}
+			if len(dc) == 0 {
+				continue
+			}
+			capacities[dc] += requested
+		case vol.Ephemeral != nil && vol.Ephemeral.VolumeClaimTemplate != nil:
+			dc, requested, err := ephemeralCapacity(ctx, vol, targetSC)
+			if err != nil {
+				return nil, err
+			}
+			if len(dc) == 0 {
+				continue
+			}
+			capacities[dc] += requested
+		default:
+			continue
+		}
+	}
+	return capacities, nil
+}
+
+func pvcCapacity(
+	ctx context.Context,
+	g getter.Interface,
+	pod *corev1.Pod,
+	vol corev1.Volume,
+	targetSC *targetSC,
+) (string, int64, bool, error) {
+	pvcName := vol.PersistentVolumeClaim.ClaimName
+	name := types.NamespacedName{
+		Namespace: pod.Namespace,
+		Name:      pvcName,
+	}
+
+	var pvc corev1.PersistentVolumeClaim
+	if err := g.Get(ctx, name, &pvc); err != nil {
+		if !apierrs.IsNotFound(err) {
+			logger.Error(err, "failed to get pvc",
+				"pod", pod.Name,
+				"namespace", pod.Namespace,
+				"pvc", pvcName,
+			)
+			return "", 0, false, err
+		}
+		// Pods should be created even if their PVCs do not exist yet.
+		// TopoLVM does not care about such pods after they are created, though.
+		return "", 0, false, nil
+	}
+
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		// Storage class name may be empty (nil or ""). We have nothing to do with such PVCs.
+		// https://kubernetes.io/docs/concepts/storage/persistent-volumes/#class-1
+		return "", 0, false, nil
+	}
+	sc, err := targetSC.Get(ctx, *pvc.Spec.StorageClassName)
+	if err != nil {
+		return "", 0, false, err
+	}
+	if sc == nil {
+		return "", 0, false, nil
+	}
+
+	// If the Pod has a bound PVC of TopoLVM, the pod will be scheduled
+	// to the node of the existing PV.
+	if pvc.Status.Phase != corev1.ClaimPending {
+		return "", 0, true, nil
+	}
+
+	return deviceClass(sc), requestedSize(pvc.Spec.Resources), false, nil
+}
+
+func ephemeralCapacity(
+	ctx context.Context,
+	vol corev1.Volume,
+	targetSC *targetSC,
+) (string, int64, error) {
+	volumeClaimTemplate := vol.Ephemeral.VolumeClaimTemplate
+	if volumeClaimTemplate.Spec.StorageClassName == nil {
+		// empty class name may appear when DefaultStorageClass admission plugin
+		// is turned off, or there are no default StorageClass.
+		// https://kubernetes.io/docs/concepts/storage/persistent-volumes/#class-1
+		return "", 0, nil
+	}
+	sc, err := targetSC.Get(ctx, *volumeClaimTemplate.Spec.StorageClassName)
+	if err != nil {
+		return "", 0, err
+	}
+	if sc == nil {
+		return "", 0, nil
+	}
+
+	return deviceClass(sc), requestedSize(volumeClaimTemplate.Spec.Resources), nil
+}
+
+func requestedSize(resources corev1.VolumeResourceRequirements) int64 {
+	var requested = topolvm.DefaultSize
+	if req, ok := resources.Requests[corev1.ResourceStorage]; ok {
+		// Only use topolvm.DefaultSize if requested size is not available.
+		// If it is available from spec, use that instead.
+		if req.Value() != 0 {
+			requested = req.Value()
+		}
+	}
+	return requested
+}
+
+func deviceClass(sc *storagev1.StorageClass) string {
+	dc, ok := sc.Parameters[topolvm.GetDeviceClassKey()]
+	if !ok {
+		dc = topolvm.DefaultDeviceClassAnnotationName
+	}
+	return dc
+}
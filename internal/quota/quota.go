@@ -0,0 +1,109 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DeviceClassKey returns the key of TopoLVMQuota for the device-class.
+func DeviceClassKey(deviceClass string) corev1.ResourceName {
+	if deviceClass == topolvm.DefaultDeviceClassName {
+		return corev1.ResourceName(topolvm.DefaultDeviceClassAnnotationName)
+	}
+	return corev1.ResourceName(deviceClass)
+}
+
+// Usage returns the total size used in the namespace per device-class.
+//
+// It is the sum of the sizes of the LogicalVolumes created in the namespace, and the sizes
+// reserved by the PVCs of TopoLVM StorageClasses whose volumes are not created or expanded yet.
+// A pending PVC reserves its requested size because its LogicalVolume is created only after
+// a Pod is scheduled with WaitForFirstConsumer. A bound PVC reserves the difference between
+// its request and its capacity while it is being expanded.
+func Usage(ctx context.Context, r client.Reader, namespace string) (corev1.ResourceList, error) {
+	var lvs topolvmv1.LogicalVolumeList
+	err := r.List(ctx, &lvs, client.MatchingLabels{topolvm.GetNamespaceKey(): namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	used := corev1.ResourceList{}
+	for _, lv := range lvs.Items {
+		add(used, DeviceClassKey(lv.Spec.DeviceClass), lv.Spec.Size.Value())
+	}
+
+	var pvcs corev1.PersistentVolumeClaimList
+	if err := r.List(ctx, &pvcs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var scs storagev1.StorageClassList
+	if err := r.List(ctx, &scs); err != nil {
+		return nil, err
+	}
+	deviceClasses := map[string]string{}
+	for _, sc := range scs.Items {
+		if sc.Provisioner == topolvm.GetPluginName() {
+			deviceClasses[sc.Name] = sc.Parameters[topolvm.GetDeviceClassKey()]
+		}
+	}
+	for _, pvc := range pvcs.Items {
+		if pvc.Spec.StorageClassName == nil || pvc.DeletionTimestamp != nil {
+			continue
+		}
+		deviceClass, ok := deviceClasses[*pvc.Spec.StorageClassName]
+		if !ok {
+			continue
+		}
+		size := RequestedSize(&pvc)
+		if pvc.Status.Phase == corev1.ClaimBound {
+			capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+			size -= capacity.Value()
+		}
+		if size > 0 {
+			add(used, DeviceClassKey(deviceClass), size)
+		}
+	}
+	return used, nil
+}
+
+// RequestedSize returns the storage size requested by the PVC.
+// TopoLVM creates a volume of topolvm.DefaultSize if the PVC does not request the size.
+func RequestedSize(pvc *corev1.PersistentVolumeClaim) int64 {
+	req, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if !ok || req.Value() == 0 {
+		return topolvm.DefaultSize
+	}
+	return req.Value()
+}
+
+func add(used corev1.ResourceList, key corev1.ResourceName, size int64) {
+	q, ok := used[key]
+	if !ok {
+		q = *resource.NewQuantity(0, resource.BinarySI)
+	}
+	q.Add(*resource.NewQuantity(size, resource.BinarySI))
+	used[key] = q
+}
+
+// Check returns an error if adding size bytes to the device-class exceeds any of the quotas.
+func Check(quotas []topolvmv1.TopoLVMQuota, used corev1.ResourceList, key corev1.ResourceName, size int64) error {
+	for _, q := range quotas {
+		hard, ok := q.Spec.Hard[key]
+		if !ok {
+			continue
+		}
+		u := used[key]
+		if u.Value()+size > hard.Value() {
+			return fmt.Errorf("exceeded quota %s for device-class %s: requested %d, used %d, limited %d",
+				q.Name, key, size, u.Value(), hard.Value())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package quota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func testLV(name, namespace, deviceClass, size string) *topolvmv1.LogicalVolume {
+	return &topolvmv1.LogicalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{topolvm.GetNamespaceKey(): namespace},
+		},
+		Spec: topolvmv1.LogicalVolumeSpec{
+			Name:        name,
+			DeviceClass: deviceClass,
+			Size:        resource.MustParse(size),
+		},
+	}
+}
+
+func testPVC(name, namespace, storageClass, request, capacity string) *corev1.PersistentVolumeClaim {
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(request)},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+	}
+	if capacity != "" {
+		pvc.Status.Phase = corev1.ClaimBound
+		pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)}
+	}
+	return pvc
+}
+
+func TestUsage(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := topolvmv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		testLV("lv1", "ns1", "", "1Gi"),
+		testLV("lv2", "ns1", "ssd", "2Gi"),
+		testLV("lv3", "ns1", "ssd", "3Gi"),
+		testLV("lv4", "ns2", "ssd", "4Gi"),
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "topolvm-ssd"},
+			Provisioner: topolvm.GetPluginName(),
+			Parameters:  map[string]string{topolvm.GetDeviceClassKey(): "ssd"},
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "other"},
+			Provisioner: "other.example.com",
+		},
+		// pending with WaitForFirstConsumer
+		testPVC("pending", "ns1", "topolvm-ssd", "10Gi", ""),
+		// being expanded from 2Gi to 6Gi
+		testPVC("expanding", "ns1", "topolvm-ssd", "6Gi", "2Gi"),
+		// already counted by its LogicalVolume
+		testPVC("bound", "ns1", "topolvm-ssd", "3Gi", "3Gi"),
+		testPVC("other", "ns1", "other", "100Gi", ""),
+		testPVC("pending", "ns2", "topolvm-ssd", "100Gi", ""),
+	).Build()
+
+	used, err := Usage(context.Background(), c, "ns1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(used) != 2 {
+		t.Fatalf("unexpected usage: %v", used)
+	}
+	if q := used[topolvm.DefaultDeviceClassAnnotationName]; q.Value() != 1<<30 {
+		t.Errorf("unexpected usage of the default device-class: %s", q.String())
+	}
+	if q := used["ssd"]; q.Value() != 19<<30 {
+		t.Errorf("unexpected usage of ssd: %s", q.String())
+	}
+}
+
+func TestCheck(t *testing.T) {
+	quotas := []topolvmv1.TopoLVMQuota{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "quota"},
+			Spec: topolvmv1.TopoLVMQuotaSpec{
+				Hard: corev1.ResourceList{"ssd": resource.MustParse("10Gi")},
+			},
+		},
+	}
+	used := corev1.ResourceList{"ssd": resource.MustParse("8Gi")}
+
+	if err := Check(quotas, used, "ssd", 2<<30); err != nil {
+		t.Errorf("should be within the quota: %v", err)
+	}
+	if err := Check(quotas, used, "ssd", 2<<30+1); err == nil {
+		t.Error("should exceed the quota")
+	}
+	if err := Check(quotas, used, "hdd", 100<<30); err != nil {
+		t.Errorf("hdd has no quota: %v", err)
+	}
+}
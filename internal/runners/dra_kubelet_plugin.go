@@ -0,0 +1,251 @@
+package runners
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/dra"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/dynamic-resource-allocation/kubeletplugin"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var dkpLogger = ctrl.Log.WithName("runners").WithName("dra_kubelet_plugin")
+
+//+kubebuilder:rbac:groups=resource.k8s.io,resources=resourceclaims,verbs=get
+
+const (
+	// cdiVersion is the version of the CDI specs written by the plugin. hostPath of device nodes requires 0.5.0.
+	cdiVersion = "0.5.0"
+
+	// cdiClass is the class of the CDI devices. The CDI kind is "topolvm.io/volume".
+	cdiClass = "volume"
+
+	// ContainerDeviceDir is the directory in containers where the volumes of ResourceClaims are created.
+	ContainerDeviceDir = "/dev/topolvm"
+)
+
+// DRAKubeletPluginConfig is the configuration of the DRA kubelet plugin.
+type DRAKubeletPluginConfig struct {
+	// PluginDir is the directory where the socket of the plugin is created.
+	// It must be the same path inside and outside the container.
+	PluginDir string
+
+	// RegistrarDir is the directory where kubelet watches the registration sockets of plugins.
+	RegistrarDir string
+
+	// CDIDir is the directory where the container runtime reads CDI specs.
+	CDIDir string
+}
+
+type draKubeletPlugin struct {
+	client     client.Client
+	kubeClient kubernetes.Interface
+	nodeName   string
+	vgService  proto.VGServiceClient
+	config     DRAKubeletPluginConfig
+}
+
+var _ manager.LeaderElectionRunnable = &draKubeletPlugin{}
+var _ kubeletplugin.DRAPlugin = &draKubeletPlugin{}
+
+// NewDRAKubeletPlugin creates controller-runtime's manager.Runnable to serve the DRA kubelet plugin.
+// The plugin exposes the LogicalVolumes created for ResourceClaims to containers as block devices
+// through CDI specs.
+func NewDRAKubeletPlugin(vgServiceClient proto.VGServiceClient, client client.Client, kubeClient kubernetes.Interface,
+	nodeName string, config DRAKubeletPluginConfig) manager.Runnable {
+	return &draKubeletPlugin{
+		client:     client,
+		kubeClient: kubeClient,
+		nodeName:   nodeName,
+		vgService:  vgServiceClient,
+		config:     config,
+	}
+}
+
+// Start implements controller-runtime's manager.Runnable.
+func (p *draKubeletPlugin) Start(ctx context.Context) error {
+	if err := os.MkdirAll(p.config.CDIDir, 0755); err != nil {
+		return err
+	}
+	helper, err := kubeletplugin.Start(ctx, p,
+		kubeletplugin.DriverName(topolvm.GetPluginName()),
+		kubeletplugin.KubeClient(p.kubeClient),
+		kubeletplugin.NodeName(p.nodeName),
+		kubeletplugin.PluginDataDirectoryPath(p.config.PluginDir),
+		kubeletplugin.RegistrarDirectoryPath(p.config.RegistrarDir),
+	)
+	if err != nil {
+		return err
+	}
+	<-ctx.Done()
+	helper.Stop()
+	return nil
+}
+
+// NeedLeaderElection implements controller-runtime's manager.LeaderElectionRunnable.
+func (p *draKubeletPlugin) NeedLeaderElection() bool {
+	return false
+}
+
+// PrepareResourceClaims implements kubeletplugin.DRAPlugin.
+func (p *draKubeletPlugin) PrepareResourceClaims(ctx context.Context, claims []*resourcev1.ResourceClaim) (map[types.UID]kubeletplugin.PrepareResult, error) {
+	results := make(map[types.UID]kubeletplugin.PrepareResult, len(claims))
+	for _, claim := range claims {
+		devices, err := p.prepare(ctx, claim)
+		if err != nil {
+			dkpLogger.Error(err, "failed to prepare ResourceClaim", "name", claim.Name, "namespace", claim.Namespace)
+		}
+		results[claim.UID] = kubeletplugin.PrepareResult{Devices: devices, Err: err}
+	}
+	return results, nil
+}
+
+// prepare writes the CDI spec of the volumes allocated to the claim on the node.
+func (p *draKubeletPlugin) prepare(ctx context.Context, claim *resourcev1.ResourceClaim) ([]kubeletplugin.Device, error) {
+	if claim.Status.Allocation == nil {
+		return nil, fmt.Errorf("ResourceClaim %s/%s is not allocated", claim.Namespace, claim.Name)
+	}
+
+	var devices []kubeletplugin.Device
+	var cdiDevices []cdiDevice
+	paths := map[string]int{}
+	for i, result := range claim.Status.Allocation.Devices.Results {
+		if result.Driver != topolvm.GetPluginName() || result.Pool != p.nodeName {
+			continue
+		}
+		name := dra.VolumeName(claim.UID, i)
+		hostPath, err := p.devicePath(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		// The request name is a DNS label, and the subrequest name is omitted.
+		request, _, _ := strings.Cut(result.Request, "/")
+		path := filepath.Join(ContainerDeviceDir, request)
+		if n := paths[request]; n > 0 {
+			path = fmt.Sprintf("%s-%d", path, n)
+		}
+		paths[request]++
+
+		cdiDevices = append(cdiDevices, cdiDevice{
+			Name: name,
+			ContainerEdits: cdiContainerEdits{
+				DeviceNodes: []cdiDeviceNode{{Path: path, HostPath: hostPath, Permissions: "rw"}},
+			},
+		})
+		devices = append(devices, kubeletplugin.Device{
+			Requests:     []string{result.Request},
+			PoolName:     result.Pool,
+			DeviceName:   result.Device,
+			CDIDeviceIDs: []string{fmt.Sprintf("%s/%s=%s", topolvm.GetPluginName(), cdiClass, name)},
+			ShareID:      result.ShareID,
+		})
+	}
+
+	spec := cdiSpec{
+		Version: cdiVersion,
+		Kind:    topolvm.GetPluginName() + "/" + cdiClass,
+		Devices: cdiDevices,
+	}
+	if err := p.writeSpec(claim.UID, &spec); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// devicePath returns the path of the LVM logical volume of the LogicalVolume on the node.
+func (p *draKubeletPlugin) devicePath(ctx context.Context, name string) (string, error) {
+	lv := &topolvmv1.LogicalVolume{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: name}, lv); err != nil {
+		return "", fmt.Errorf("failed to get LogicalVolume %s: %w", name, err)
+	}
+	if lv.Spec.NodeName != p.nodeName {
+		return "", fmt.Errorf("LogicalVolume %s is not on node %s", name, p.nodeName)
+	}
+	if lv.Status.VolumeID == "" {
+		return "", fmt.Errorf("LogicalVolume %s is not created yet", name)
+	}
+
+	res, err := p.vgService.GetLVList(ctx, &proto.GetLVListRequest{DeviceClass: lv.Spec.DeviceClass})
+	if err != nil {
+		return "", err
+	}
+	for _, v := range res.Volumes {
+		if v.Name == lv.Status.VolumeID {
+			return v.Path, nil
+		}
+	}
+	return "", fmt.Errorf("LVM logical volume of LogicalVolume %s is not found", name)
+}
+
+// UnprepareResourceClaims implements kubeletplugin.DRAPlugin.
+func (p *draKubeletPlugin) UnprepareResourceClaims(ctx context.Context, claims []kubeletplugin.NamespacedObject) (map[types.UID]error, error) {
+	results := make(map[types.UID]error, len(claims))
+	for _, claim := range claims {
+		err := os.Remove(p.specPath(claim.UID))
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		results[claim.UID] = err
+	}
+	return results, nil
+}
+
+// HandleError implements kubeletplugin.DRAPlugin.
+// TopoLVM publishes ResourceSlices by itself, so errors are only logged.
+func (p *draKubeletPlugin) HandleError(ctx context.Context, err error, msg string) {
+	dkpLogger.Error(err, msg)
+}
+
+func (p *draKubeletPlugin) specPath(claimUID types.UID) string {
+	return filepath.Join(p.config.CDIDir, fmt.Sprintf("%s-%s.json", topolvm.GetPluginName(), claimUID))
+}
+
+// writeSpec writes the CDI spec of the claim atomically so that the container runtime never reads a partial file.
+func (p *draKubeletPlugin) writeSpec(claimUID types.UID, spec *cdiSpec) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	path := p.specPath(claimUID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// cdiSpec is a subset of the Container Device Interface specification.
+// https://github.com/cncf-tags/container-device-interface/blob/main/SPEC.md
+type cdiSpec struct {
+	Version string      `json:"cdiVersion"`
+	Kind    string      `json:"kind"`
+	Devices []cdiDevice `json:"devices"`
+}
+
+type cdiDevice struct {
+	Name           string            `json:"name"`
+	ContainerEdits cdiContainerEdits `json:"containerEdits"`
+}
+
+type cdiContainerEdits struct {
+	DeviceNodes []cdiDeviceNode `json:"deviceNodes,omitempty"`
+}
+
+type cdiDeviceNode struct {
+	Path        string `json:"path"`
+	HostPath    string `json:"hostPath,omitempty"`
+	Permissions string `json:"permissions,omitempty"`
+}
@@ -0,0 +1,107 @@
+package runners
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/dynamic-resource-allocation/kubeletplugin"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeLVLister struct {
+	proto.VGServiceClient
+	volumes []*proto.LogicalVolume
+}
+
+func (f *fakeLVLister) GetLVList(ctx context.Context, in *proto.GetLVListRequest, opts ...grpc.CallOption) (*proto.GetLVListResponse, error) {
+	return &proto.GetLVListResponse{Volumes: f.volumes}, nil
+}
+
+var _ = Describe("DRAKubeletPlugin", func() {
+	ctx := context.Background()
+	nodeName := "dra-node"
+
+	claim := &resourcev1.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "claim", UID: "claim-uid"},
+		Status: resourcev1.ResourceClaimStatus{
+			Allocation: &resourcev1.AllocationResult{
+				Devices: resourcev1.DeviceAllocationResult{
+					Results: []resourcev1.DeviceRequestAllocationResult{
+						{Request: "other", Driver: "other.example.com", Pool: nodeName, Device: "device"},
+						{Request: "data", Driver: topolvm.GetPluginName(), Pool: nodeName, Device: "ssd"},
+					},
+				},
+			},
+		},
+	}
+
+	newPlugin := func(objs ...client.Object) (*draKubeletPlugin, string) {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+		Expect(topolvmv1.AddToScheme(s)).To(Succeed())
+		c := fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build()
+		cdiDir := GinkgoT().TempDir()
+		vgService := &fakeLVLister{volumes: []*proto.LogicalVolume{{Name: "vol", Path: "/dev/ssd-vg/vol"}}}
+		p := NewDRAKubeletPlugin(vgService, c, nil, nodeName, DRAKubeletPluginConfig{CDIDir: cdiDir}).(*draKubeletPlugin)
+		return p, cdiDir
+	}
+
+	It("should write the CDI spec of the volumes and remove it", func() {
+		p, cdiDir := newPlugin(&topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "claim-uid-1"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "claim-uid-1", NodeName: nodeName, DeviceClass: "ssd", Size: resource.MustParse("1Gi")},
+			Status:     topolvmv1.LogicalVolumeStatus{VolumeID: "vol"},
+		})
+
+		results, err := p.PrepareResourceClaims(ctx, []*resourcev1.ResourceClaim{claim})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveKey(claim.UID))
+		result := results[claim.UID]
+		Expect(result.Err).NotTo(HaveOccurred())
+		Expect(result.Devices).To(HaveLen(1))
+		Expect(result.Devices[0].Requests).To(Equal([]string{"data"}))
+		Expect(result.Devices[0].CDIDeviceIDs).To(Equal([]string{topolvm.GetPluginName() + "/volume=claim-uid-1"}))
+
+		specPath := filepath.Join(cdiDir, topolvm.GetPluginName()+"-claim-uid.json")
+		data, err := os.ReadFile(specPath)
+		Expect(err).NotTo(HaveOccurred())
+		var spec cdiSpec
+		Expect(json.Unmarshal(data, &spec)).To(Succeed())
+		Expect(spec.Kind).To(Equal(topolvm.GetPluginName() + "/volume"))
+		Expect(spec.Devices).To(HaveLen(1))
+		Expect(spec.Devices[0].ContainerEdits.DeviceNodes).To(Equal([]cdiDeviceNode{
+			{Path: "/dev/topolvm/data", HostPath: "/dev/ssd-vg/vol", Permissions: "rw"},
+		}))
+
+		unprepared, err := p.UnprepareResourceClaims(ctx, []kubeletplugin.NamespacedObject{{UID: claim.UID}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unprepared[claim.UID]).NotTo(HaveOccurred())
+		_, err = os.Stat(specPath)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("should fail to prepare the claim until the volume is created", func() {
+		p, _ := newPlugin(&topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "claim-uid-1"},
+			Spec:       topolvmv1.LogicalVolumeSpec{Name: "claim-uid-1", NodeName: nodeName, DeviceClass: "ssd", Size: resource.MustParse("1Gi")},
+		})
+
+		results, err := p.PrepareResourceClaims(ctx, []*resourcev1.ResourceClaim{claim})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[claim.UID].Err).To(MatchError(ContainSubstring("not created yet")))
+	})
+})
@@ -11,7 +11,8 @@ import (
 
 type gRPCServerRunner struct {
 	srv            *grpc.Server
-	sockFile       string
+	network        string
+	address        string
 	leaderElection bool
 }
 
@@ -21,16 +22,25 @@ var _ manager.LeaderElectionRunnable = gRPCServerRunner{}
 // The server will listen on UNIX domain socket at sockFile.
 // If leaderElection is true, the server will run only when it is elected as leader.
 func NewGRPCRunner(srv *grpc.Server, sockFile string, leaderElection bool) manager.Runnable {
-	return gRPCServerRunner{srv, sockFile, leaderElection}
+	return gRPCServerRunner{srv, "unix", sockFile, leaderElection}
+}
+
+// NewGRPCTCPRunner creates controller-runtime's manager.Runnable for a gRPC server.
+// The server will listen on TCP at address.
+// If leaderElection is true, the server will run only when it is elected as leader.
+func NewGRPCTCPRunner(srv *grpc.Server, address string, leaderElection bool) manager.Runnable {
+	return gRPCServerRunner{srv, "tcp", address, leaderElection}
 }
 
 // Start implements controller-runtime's manager.Runnable.
 func (r gRPCServerRunner) Start(ctx context.Context) error {
-	err := os.Remove(r.sockFile)
-	if err != nil && !os.IsNotExist(err) {
-		return err
+	if r.network == "unix" {
+		err := os.Remove(r.address)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
-	lis, err := net.Listen("unix", r.sockFile)
+	lis, err := net.Listen(r.network, r.address)
 	if err != nil {
 		return err
 	}
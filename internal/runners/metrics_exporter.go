@@ -35,6 +35,8 @@ type NodeMetrics struct {
 	MetadataPercent    float64
 	FreeBytes          uint64
 	SizeBytes          uint64
+	PendingFreeBytes   uint64
+	TrashBytes         uint64
 	ThinPoolSizeBytes  uint64
 	OverProvisionBytes uint64
 	DeviceClass        string
@@ -50,12 +52,14 @@ type thinPoolMetricsExporter struct {
 }
 
 type metricsExporter struct {
-	client         client.Client
-	nodeName       string
-	vgService      proto.VGServiceClient
-	availableBytes *prometheus.GaugeVec
-	sizeBytes      *prometheus.GaugeVec
-	thinPool       *thinPoolMetricsExporter
+	client           client.Client
+	nodeName         string
+	vgService        proto.VGServiceClient
+	availableBytes   *prometheus.GaugeVec
+	sizeBytes        *prometheus.GaugeVec
+	pendingFreeBytes *prometheus.GaugeVec
+	trashBytes       *prometheus.GaugeVec
+	thinPool         *thinPoolMetricsExporter
 }
 
 var _ manager.LeaderElectionRunnable = &metricsExporter{}
@@ -80,6 +84,22 @@ func NewMetricsExporter(vgServiceClient proto.VGServiceClient, client client.Cli
 		ConstLabels: prometheus.Labels{"node": nodeName},
 	}, []string{"device_class"})
 
+	pendingFreeBytes := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   "volumegroup",
+		Name:        "pending_free_bytes",
+		Help:        "LVM VG bytes of removed volumes being wiped",
+		ConstLabels: prometheus.Labels{"node": nodeName},
+	}, []string{"device_class"})
+
+	trashBytes := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   metricsNamespace,
+		Subsystem:   "volumegroup",
+		Name:        "trash_bytes",
+		Help:        "LVM VG bytes of removed volumes kept in the trash",
+		ConstLabels: prometheus.Labels{"node": nodeName},
+	}, []string{"device_class"})
+
 	// metrics available under thinpool subsystem
 	tpSizeBytes := prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace:   metricsNamespace,
@@ -114,11 +134,13 @@ func NewMetricsExporter(vgServiceClient proto.VGServiceClient, client client.Cli
 	}, []string{"device_class"})
 
 	return &metricsExporter{
-		client:         client,
-		nodeName:       nodeName,
-		vgService:      vgServiceClient,
-		availableBytes: availableBytes,
-		sizeBytes:      sizeBytes,
+		client:           client,
+		nodeName:         nodeName,
+		vgService:        vgServiceClient,
+		availableBytes:   availableBytes,
+		sizeBytes:        sizeBytes,
+		pendingFreeBytes: pendingFreeBytes,
+		trashBytes:       trashBytes,
 		thinPool: &thinPoolMetricsExporter{
 			tpSizeBytes:      tpSizeBytes,
 			dataPercent:      dataPercent,
@@ -132,6 +154,8 @@ func (m *metricsExporter) getCollectors() []prometheus.Collector {
 	return []prometheus.Collector{
 		m.availableBytes,
 		m.sizeBytes,
+		m.pendingFreeBytes,
+		m.trashBytes,
 		m.thinPool.tpSizeBytes,
 		m.thinPool.dataPercent,
 		m.thinPool.metadataPercent,
@@ -172,6 +196,8 @@ func (m *metricsExporter) Start(ctx context.Context) error {
 				// metrics for volumegroup subsystem, these are exported from thinpool type as well
 				m.availableBytes.WithLabelValues(met.DeviceClass).Set(float64(met.FreeBytes))
 				m.sizeBytes.WithLabelValues(met.DeviceClass).Set(float64(met.SizeBytes))
+				m.pendingFreeBytes.WithLabelValues(met.DeviceClass).Set(float64(met.PendingFreeBytes))
+				m.trashBytes.WithLabelValues(met.DeviceClass).Set(float64(met.TrashBytes))
 
 				if met.DeviceClassType == TypeThin {
 					// metrics for thinpool subsystem exclusively
@@ -215,6 +241,8 @@ func (m *metricsExporter) updateNode(ctx context.Context, wc proto.VGService_Wat
 					DeviceClass:        item.DeviceClass,
 					FreeBytes:          item.FreeBytes,
 					SizeBytes:          item.SizeBytes,
+					PendingFreeBytes:   item.PendingFreeBytes,
+					TrashBytes:         item.TrashBytes,
 					ThinPoolSizeBytes:  item.ThinPool.SizeBytes,
 					DataPercent:        item.ThinPool.DataPercent,
 					MetadataPercent:    item.ThinPool.MetadataPercent,
@@ -223,10 +251,12 @@ func (m *metricsExporter) updateNode(ctx context.Context, wc proto.VGService_Wat
 				}
 			} else {
 				ch <- NodeMetrics{
-					DeviceClass:     item.DeviceClass,
-					FreeBytes:       item.FreeBytes,
-					SizeBytes:       item.SizeBytes,
-					DeviceClassType: TypeThick,
+					DeviceClass:      item.DeviceClass,
+					FreeBytes:        item.FreeBytes,
+					SizeBytes:        item.SizeBytes,
+					PendingFreeBytes: item.PendingFreeBytes,
+					TrashBytes:       item.TrashBytes,
+					DeviceClassType:  TypeThick,
 				}
 			}
 		}
@@ -259,6 +289,11 @@ func (m *metricsExporter) updateNode(ctx context.Context, wc proto.VGService_Wat
 		if err := m.client.Patch(ctx, nodeMetadata2, client.MergeFrom(&nodeMetadata)); err != nil {
 			return err
 		}
+
+		// The annotations above are kept for compatibility, so a failure here does not stop the exporter.
+		if err := updateNodeStorage(ctx, m.client, &nodeMetadata, res); err != nil {
+			meLogger.Error(err, "failed to update NodeStorage", "name", m.nodeName)
+		}
 	}
 
 	return nil
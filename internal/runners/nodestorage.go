@@ -0,0 +1,119 @@
+package runners
+
+import (
+	"context"
+	"strconv"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+//+kubebuilder:rbac:groups=topolvm.io,resources=nodestorages,verbs=get;list;watch;create
+//+kubebuilder:rbac:groups=topolvm.io,resources=nodestorages/status,verbs=get;update;patch
+
+// updateNodeStorage writes the storage of the node reported by lvmd to the NodeStorage of the same name.
+func updateNodeStorage(ctx context.Context, c client.Client, node *metav1.PartialObjectMetadata, res *proto.WatchResponse) error {
+	var ns topolvmv1.NodeStorage
+	err := c.Get(ctx, types.NamespacedName{Name: node.Name}, &ns)
+	switch {
+	case err == nil:
+	case apierrors.IsNotFound(err):
+		ns = topolvmv1.NodeStorage{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: node.Name,
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: corev1.SchemeGroupVersion.String(),
+					Kind:       "Node",
+					Name:       node.Name,
+					UID:        node.UID,
+				}},
+			},
+		}
+		if err := c.Create(ctx, &ns); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	status := nodeStorageStatus(res)
+	if equality.Semantic.DeepEqual(ns.Status, status) {
+		return nil
+	}
+	ns.Status = status
+	return c.Status().Update(ctx, &ns)
+}
+
+func nodeStorageStatus(res *proto.WatchResponse) topolvmv1.NodeStorageStatus {
+	status := topolvmv1.NodeStorageStatus{
+		LvcreateOptionClasses: res.LvcreateOptionClasses,
+	}
+	for _, item := range res.Items {
+		dc := topolvmv1.DeviceClassStorageStatus{
+			Name:            item.DeviceClass,
+			Default:         item.Default,
+			VolumeGroup:     item.VolumeGroup,
+			VolumeGroupUUID: item.VolumeGroupUuid,
+			FsType:          item.FsType,
+			Size:            *resource.NewQuantity(int64(item.SizeBytes), resource.BinarySI),
+			Free:            *resource.NewQuantity(int64(item.FreeBytes), resource.BinarySI),
+			Health:          topolvmv1.StorageHealthy,
+		}
+		for _, pv := range item.PhysicalVolumes {
+			dc.PhysicalVolumes = append(dc.PhysicalVolumes, topolvmv1.PhysicalVolumeStatus{
+				Name:    pv.Name,
+				Size:    *resource.NewQuantity(int64(pv.SizeBytes), resource.BinarySI),
+				Free:    *resource.NewQuantity(int64(pv.FreeBytes), resource.BinarySI),
+				Missing: pv.Missing,
+			})
+		}
+		if item.ThinPool != nil {
+			dc.Free = *resource.NewQuantity(int64(item.ThinPool.OverprovisionBytes), resource.BinarySI)
+			dc.ThinPool = &topolvmv1.ThinPoolStatus{
+				Size:            *resource.NewQuantity(int64(item.ThinPool.SizeBytes), resource.BinarySI),
+				DataPercent:     strconv.FormatFloat(item.ThinPool.DataPercent, 'f', 2, 64),
+				MetadataPercent: strconv.FormatFloat(item.ThinPool.MetadataPercent, 'f', 2, 64),
+			}
+		}
+		if item.PendingFreeBytes != 0 {
+			dc.PendingFree = resource.NewQuantity(int64(item.PendingFreeBytes), resource.BinarySI)
+		}
+		for _, wipe := range item.Wipes {
+			dc.Wipes = append(dc.Wipes, topolvmv1.VolumeWipeStatus{
+				Name:  wipe.Name,
+				Size:  *resource.NewQuantity(int64(wipe.SizeBytes), resource.BinarySI),
+				Wiped: *resource.NewQuantity(int64(wipe.WipedBytes), resource.BinarySI),
+			})
+		}
+		if item.TrashBytes != 0 {
+			dc.Trash = resource.NewQuantity(int64(item.TrashBytes), resource.BinarySI)
+		}
+		for _, trashed := range item.Trash {
+			dc.TrashedVolumes = append(dc.TrashedVolumes, topolvmv1.TrashedVolumeStatus{
+				Name:      trashed.Name,
+				Size:      *resource.NewQuantity(int64(trashed.SizeBytes), resource.BinarySI),
+				TrashedAt: metav1.Unix(trashed.TrashedAt, 0),
+				ExpiresAt: metav1.Unix(trashed.ExpiresAt, 0),
+			})
+		}
+		for _, mkfs := range item.MkfsOptions {
+			if dc.MkfsOptions == nil {
+				dc.MkfsOptions = make(map[string][]string)
+			}
+			dc.MkfsOptions[mkfs.FsType] = mkfs.Options
+		}
+		if item.HealthError != "" {
+			dc.Health = topolvmv1.StorageUnhealthy
+			dc.Message = item.HealthError
+		}
+		status.DeviceClasses = append(status.DeviceClasses, dc)
+	}
+	return status
+}
@@ -0,0 +1,126 @@
+package runners
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("NodeStorage", func() {
+	It("should write the storage reported by lvmd", func() {
+		ctx := context.Background()
+		nodeName := "nodestorage-node"
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: nodeName,
+			},
+		}
+		err := k8sClient.Create(ctx, node)
+		Expect(err).NotTo(HaveOccurred())
+		meta := &metav1.PartialObjectMetadata{ObjectMeta: node.ObjectMeta}
+
+		err = updateNodeStorage(ctx, k8sClient, meta, &proto.WatchResponse{
+			LvcreateOptionClasses: []string{"raid1"},
+			Items: []*proto.WatchItem{
+				{
+					DeviceClass: "ssd",
+					Default:     true,
+					VolumeGroup: "vg1",
+					FreeBytes:   10 << 30,
+					SizeBytes:   20 << 30,
+					PhysicalVolumes: []*proto.PhysicalVolumeItem{
+						{Name: "/dev/sda", SizeBytes: 20 << 30, FreeBytes: 10 << 30},
+					},
+					PendingFreeBytes: 2 << 30,
+					Wipes: []*proto.WipeItem{
+						{Name: "removed", SizeBytes: 2 << 30, WipedBytes: 1 << 30},
+					},
+					TrashBytes: 3 << 30,
+					Trash: []*proto.TrashItem{
+						{Name: "trashed", SizeBytes: 3 << 30, TrashedAt: 1700000000, ExpiresAt: 1700259200},
+					},
+					FsType: "xfs",
+					MkfsOptions: []*proto.MkfsOptions{
+						{FsType: "xfs", Options: []string{"-m", "reflink=1"}},
+					},
+				},
+				{
+					DeviceClass: "thin",
+					VolumeGroup: "vg2",
+					FreeBytes:   1 << 30,
+					SizeBytes:   20 << 30,
+					ThinPool: &proto.ThinPoolItem{
+						SizeBytes:          10 << 30,
+						OverprovisionBytes: 50 << 30,
+						DataPercent:        12.345,
+						MetadataPercent:    1,
+					},
+					HealthError: "physical volumes are missing: /dev/sdb",
+				},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var ns topolvmv1.NodeStorage
+		err = k8sClient.Get(ctx, client.ObjectKey{Name: nodeName}, &ns)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.OwnerReferences).To(HaveLen(1))
+		Expect(ns.OwnerReferences[0].UID).To(Equal(node.UID))
+		Expect(ns.Status.DeviceClasses).To(HaveLen(2))
+		Expect(ns.Status.LvcreateOptionClasses).To(Equal([]string{"raid1"}))
+
+		ssd := ns.Status.DeviceClass("")
+		Expect(ssd).NotTo(BeNil())
+		Expect(ssd.Name).To(Equal("ssd"))
+		Expect(ssd.VolumeGroup).To(Equal("vg1"))
+		Expect(ssd.Free.Equal(resource.MustParse("10Gi"))).To(BeTrue())
+		Expect(ssd.PhysicalVolumes).To(HaveLen(1))
+		Expect(ssd.PhysicalVolumes[0].Name).To(Equal("/dev/sda"))
+		Expect(ssd.ThinPool).To(BeNil())
+		Expect(ssd.Health).To(Equal(topolvmv1.StorageHealthy))
+		Expect(ssd.PendingFree).NotTo(BeNil())
+		Expect(ssd.PendingFree.Equal(resource.MustParse("2Gi"))).To(BeTrue())
+		Expect(ssd.Wipes).To(HaveLen(1))
+		Expect(ssd.Wipes[0].Name).To(Equal("removed"))
+		Expect(ssd.Wipes[0].Wiped.Equal(resource.MustParse("1Gi"))).To(BeTrue())
+		Expect(ssd.Trash).NotTo(BeNil())
+		Expect(ssd.Trash.Equal(resource.MustParse("3Gi"))).To(BeTrue())
+		Expect(ssd.TrashedVolumes).To(HaveLen(1))
+		Expect(ssd.TrashedVolumes[0].Name).To(Equal("trashed"))
+		Expect(ssd.TrashedVolumes[0].ExpiresAt.Unix()).To(Equal(int64(1700259200)))
+		Expect(ssd.FsType).To(Equal("xfs"))
+		Expect(ssd.MkfsOptions).To(Equal(map[string][]string{"xfs": {"-m", "reflink=1"}}))
+
+		thin := ns.Status.DeviceClass("thin")
+		Expect(thin).NotTo(BeNil())
+		Expect(thin.Free.Equal(resource.MustParse("50Gi"))).To(BeTrue())
+		Expect(thin.ThinPool).NotTo(BeNil())
+		Expect(thin.ThinPool.DataPercent).To(Equal("12.35"))
+		Expect(thin.ThinPool.MetadataPercent).To(Equal("1.00"))
+		Expect(thin.Health).To(Equal(topolvmv1.StorageUnhealthy))
+		Expect(thin.Message).To(Equal("physical volumes are missing: /dev/sdb"))
+		Expect(thin.PendingFree).To(BeNil())
+		Expect(thin.Trash).To(BeNil())
+		Expect(thin.MkfsOptions).To(BeNil())
+
+		By("updating the status of the existing NodeStorage")
+		err = updateNodeStorage(ctx, k8sClient, meta, &proto.WatchResponse{
+			Items: []*proto.WatchItem{
+				{DeviceClass: "ssd", Default: true, VolumeGroup: "vg1", FreeBytes: 5 << 30, SizeBytes: 20 << 30},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Get(ctx, client.ObjectKey{Name: nodeName}, &ns)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ns.Status.DeviceClasses).To(HaveLen(1))
+		Expect(ns.Status.DeviceClasses[0].Free.Equal(resource.MustParse("5Gi"))).To(BeTrue())
+	})
+})
@@ -0,0 +1,182 @@
+package runners
+
+import (
+	"context"
+	"io"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/dra"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+var rspLogger = ctrl.Log.WithName("runners").WithName("resourceslice_publisher")
+
+//+kubebuilder:rbac:groups=resource.k8s.io,resources=resourceslices,verbs=get;list;watch;create;update;delete
+
+type resourceSlicePublisher struct {
+	client    client.Client
+	nodeName  string
+	vgService proto.VGServiceClient
+}
+
+var _ manager.LeaderElectionRunnable = &resourceSlicePublisher{}
+
+// NewResourceSlicePublisher creates controller-runtime's manager.Runnable to publish
+// a ResourceSlice describing the device-classes of a node for Dynamic Resource Allocation.
+func NewResourceSlicePublisher(vgServiceClient proto.VGServiceClient, client client.Client, nodeName string) manager.Runnable {
+	return &resourceSlicePublisher{
+		client:    client,
+		nodeName:  nodeName,
+		vgService: vgServiceClient,
+	}
+}
+
+// Start implements controller-runtime's manager.Runnable.
+func (p *resourceSlicePublisher) Start(ctx context.Context) error {
+	wc, err := p.vgService.Watch(ctx, &proto.Empty{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		res, err := wc.Recv()
+		switch {
+		case err == io.EOF:
+			return nil
+		case status.Code(err) == codes.Canceled:
+			return nil
+		case err == nil:
+		default:
+			return err
+		}
+
+		if err := p.publish(ctx, res); err != nil {
+			return err
+		}
+	}
+}
+
+// NeedLeaderElection implements controller-runtime's manager.LeaderElectionRunnable.
+func (p *resourceSlicePublisher) NeedLeaderElection() bool {
+	return false
+}
+
+func (p *resourceSlicePublisher) publish(ctx context.Context, res *proto.WatchResponse) error {
+	var node metav1.PartialObjectMetadata
+	node.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Node"))
+	if err := p.client.Get(ctx, types.NamespacedName{Name: p.nodeName}, &node); err != nil {
+		return err
+	}
+	if node.DeletionTimestamp != nil {
+		rspLogger.Info("node is deleting")
+		return nil
+	}
+
+	allocated, err := p.allocatedBytes(ctx)
+	if err != nil {
+		return err
+	}
+	devices := p.devices(res, allocated)
+
+	slice := &resourcev1.ResourceSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: dra.SliceName(p.nodeName),
+		},
+	}
+	op, err := controllerutil.CreateOrUpdate(ctx, p.client, slice, func() error {
+		slice.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       "Node",
+			Name:       node.Name,
+			UID:        node.UID,
+		}}
+		if slice.CreationTimestamp.IsZero() || !equality.Semantic.DeepEqual(slice.Spec.Devices, devices) {
+			slice.Spec.Pool.Generation++
+		}
+		slice.Spec.Driver = topolvm.GetPluginName()
+		slice.Spec.Pool.Name = p.nodeName
+		slice.Spec.Pool.ResourceSliceCount = 1
+		slice.Spec.NodeName = ptr.To(p.nodeName)
+		slice.Spec.Devices = devices
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if op != controllerutil.OperationResultNone {
+		rspLogger.Info("published ResourceSlice", "name", slice.Name, "operation", op, "generation", slice.Spec.Pool.Generation)
+	}
+	return nil
+}
+
+// allocatedBytes returns the total size of the LogicalVolumes created for ResourceClaims on the node by device-class.
+// The scheduler subtracts the capacity consumed by allocated claims by itself, so these sizes are added back
+// to the free bytes reported by lvmd to avoid counting them twice.
+func (p *resourceSlicePublisher) allocatedBytes(ctx context.Context) (map[string]int64, error) {
+	var lvList topolvmv1.LogicalVolumeList
+	if err := p.client.List(ctx, &lvList, client.HasLabels{topolvm.GetResourceClaimKey()}); err != nil {
+		return nil, err
+	}
+
+	allocated := make(map[string]int64)
+	for _, lv := range lvList.Items {
+		if lv.Spec.NodeName != p.nodeName || lv.Status.VolumeID == "" {
+			continue
+		}
+		allocated[lv.Spec.DeviceClass] += lv.Spec.Size.Value()
+	}
+	return allocated, nil
+}
+
+func (p *resourceSlicePublisher) devices(res *proto.WatchResponse, allocated map[string]int64) []resourcev1.Device {
+	devices := make([]resourcev1.Device, 0, len(res.Items))
+	for _, item := range res.Items {
+		name, ok := dra.DeviceName(item.DeviceClass)
+		if !ok {
+			rspLogger.Info("skip device-class whose name is not a DNS label", "device_class", item.DeviceClass)
+			continue
+		}
+
+		dcType := TypeThick
+		free := item.FreeBytes
+		if item.ThinPool != nil {
+			dcType = TypeThin
+			free = item.ThinPool.OverprovisionBytes
+		}
+
+		devices = append(devices, resourcev1.Device{
+			Name: name,
+			Attributes: map[resourcev1.QualifiedName]resourcev1.DeviceAttribute{
+				dra.AttributeDeviceClass: {StringValue: ptr.To(item.DeviceClass)},
+				dra.AttributeType:        {StringValue: ptr.To(dcType)},
+			},
+			Capacity: map[resourcev1.QualifiedName]resourcev1.DeviceCapacity{
+				dra.CapacitySize: {
+					Value: *resource.NewQuantity(int64(free)+allocated[item.DeviceClass], resource.BinarySI),
+					RequestPolicy: &resourcev1.CapacityRequestPolicy{
+						Default: resource.NewQuantity(topolvm.DefaultSize, resource.BinarySI),
+						ValidRange: &resourcev1.CapacityRequestPolicyRange{
+							Min: resource.NewQuantity(topolvm.MinimumSectorSize, resource.BinarySI),
+						},
+					},
+				},
+			},
+			AllowMultipleAllocations: ptr.To(true),
+		})
+	}
+	return devices
+}
@@ -0,0 +1,117 @@
+package runners
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/dra"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func sizeOf(device resourcev1.Device) int64 {
+	q := device.Capacity[dra.CapacitySize].Value
+	return q.Value()
+}
+
+var _ = Describe("ResourceSlicePublisher", func() {
+	It("should publish a ResourceSlice for the device-classes of the node", func() {
+		ctx := context.Background()
+		nodeName := "publisher-node"
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: nodeName,
+			},
+		}
+		err := k8sClient.Create(ctx, node)
+		Expect(err).NotTo(HaveOccurred())
+
+		By("creating a LogicalVolume allocated for a ResourceClaim")
+		lv := &topolvmv1.LogicalVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "claim-volume",
+				Labels: map[string]string{
+					topolvm.GetResourceClaimKey(): "claim-uid",
+				},
+			},
+			Spec: topolvmv1.LogicalVolumeSpec{
+				Name:        "claim-volume",
+				NodeName:    nodeName,
+				DeviceClass: "ssd",
+				Size:        resource.MustParse("1Gi"),
+			},
+		}
+		err = k8sClient.Create(ctx, lv)
+		Expect(err).NotTo(HaveOccurred())
+		lv.Status.VolumeID = "claim-volume"
+		err = k8sClient.Status().Update(ctx, lv)
+		Expect(err).NotTo(HaveOccurred())
+
+		p := NewResourceSlicePublisher(nil, k8sClient, nodeName).(*resourceSlicePublisher)
+		err = p.publish(ctx, &proto.WatchResponse{
+			FreeBytes: 10 << 30,
+			Items: []*proto.WatchItem{
+				{DeviceClass: "", FreeBytes: 10 << 30, SizeBytes: 20 << 30},
+				{DeviceClass: "ssd", FreeBytes: 5 << 30, SizeBytes: 20 << 30},
+				{DeviceClass: "thin", FreeBytes: 1 << 30, SizeBytes: 20 << 30, ThinPool: &proto.ThinPoolItem{
+					OverprovisionBytes: 50 << 30,
+					SizeBytes:          10 << 30,
+				}},
+				{DeviceClass: "Invalid_Name", FreeBytes: 1 << 30, SizeBytes: 20 << 30},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var slice resourcev1.ResourceSlice
+		err = k8sClient.Get(ctx, client.ObjectKey{Name: dra.SliceName(nodeName)}, &slice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slice.OwnerReferences).To(HaveLen(1))
+		Expect(slice.OwnerReferences[0].UID).To(Equal(node.UID))
+		Expect(slice.Spec.Driver).To(Equal(topolvm.GetPluginName()))
+		Expect(slice.Spec.Pool.Name).To(Equal(nodeName))
+		Expect(slice.Spec.Pool.Generation).To(Equal(int64(1)))
+		Expect(slice.Spec.NodeName).To(HaveValue(Equal(nodeName)))
+
+		devices := map[string]resourcev1.Device{}
+		for _, d := range slice.Spec.Devices {
+			devices[d.Name] = d
+		}
+		Expect(devices).To(HaveLen(3))
+
+		Expect(devices).To(HaveKey("00default"))
+		Expect(devices["00default"].Attributes[dra.AttributeDeviceClass].StringValue).To(HaveValue(Equal("")))
+		Expect(devices["00default"].Attributes[dra.AttributeType].StringValue).To(HaveValue(Equal(TypeThick)))
+		Expect(sizeOf(devices["00default"])).To(Equal(int64(10 << 30)))
+
+		// The size of the allocated volume is added back to the free bytes.
+		Expect(devices).To(HaveKey("ssd"))
+		Expect(sizeOf(devices["ssd"])).To(Equal(int64(6 << 30)))
+
+		Expect(devices).To(HaveKey("thin"))
+		Expect(devices["thin"].Attributes[dra.AttributeType].StringValue).To(HaveValue(Equal(TypeThin)))
+		Expect(sizeOf(devices["thin"])).To(Equal(int64(50 << 30)))
+
+		By("publishing the changed capacity")
+		err = p.publish(ctx, &proto.WatchResponse{
+			FreeBytes: 8 << 30,
+			Items: []*proto.WatchItem{
+				{DeviceClass: "", FreeBytes: 8 << 30, SizeBytes: 20 << 30},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = k8sClient.Get(ctx, client.ObjectKey{Name: dra.SliceName(nodeName)}, &slice)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(slice.Spec.Pool.Generation).To(Equal(int64(2)))
+		Expect(slice.Spec.Devices).To(HaveLen(1))
+		Expect(sizeOf(slice.Spec.Devices[0])).To(Equal(int64(8 << 30)))
+	})
+})
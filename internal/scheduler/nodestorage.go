@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/getter"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fillCapacityAnnotations sets the capacity annotations of the requested device-classes
+// missing in the nodes from the NodeStorage of the nodes.
+func fillCapacityAnnotations(ctx context.Context, g getter.Interface, nodes []corev1.Node, requested map[string]int64) error {
+	for i := range nodes {
+		node := &nodes[i]
+		var ns *topolvmv1.NodeStorage
+		for dc := range requested {
+			key := topolvm.GetCapacityKeyPrefix() + dc
+			if _, ok := node.Annotations[key]; ok {
+				continue
+			}
+
+			if ns == nil {
+				ns = &topolvmv1.NodeStorage{}
+				err := g.Get(ctx, client.ObjectKey{Name: node.Name}, ns)
+				if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+					break
+				}
+				if err != nil {
+					return err
+				}
+			}
+
+			name := dc
+			if name == topolvm.DefaultDeviceClassAnnotationName {
+				name = topolvm.DefaultDeviceClassName
+			}
+			status := ns.Status.DeviceClass(name)
+			if status == nil {
+				continue
+			}
+			if node.Annotations == nil {
+				node.Annotations = make(map[string]string)
+			}
+			node.Annotations[key] = strconv.FormatInt(status.Free.Value(), 10)
+		}
+	}
+	return nil
+}
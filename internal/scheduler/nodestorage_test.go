@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFillCapacityAnnotations(t *testing.T) {
+	g := &mapGetter{
+		nss: map[string]*topolvmv1.NodeStorage{
+			"node1": {
+				ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+				Status: topolvmv1.NodeStorageStatus{
+					DeviceClasses: []topolvmv1.DeviceClassStorageStatus{
+						{Name: "ssd", Default: true, Free: resource.MustParse("10Gi")},
+						{Name: "hdd", Free: resource.MustParse("20Gi")},
+					},
+				},
+			},
+		},
+	}
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node2",
+				Annotations: map[string]string{
+					topolvm.GetCapacityKeyPrefix() + "ssd": "1024",
+				},
+			},
+		},
+	}
+	requested := map[string]int64{
+		topolvm.DefaultDeviceClassAnnotationName: 1,
+		"ssd":                                    1,
+		"hdd":                                    1,
+		"nvme":                                   1,
+	}
+
+	err := fillCapacityAnnotations(context.Background(), g, nodes, requested)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []map[string]string{
+		{
+			topolvm.GetCapacityKeyPrefix() + topolvm.DefaultDeviceClassAnnotationName: "10737418240",
+			topolvm.GetCapacityKeyPrefix() + "ssd":                                    "10737418240",
+			topolvm.GetCapacityKeyPrefix() + "hdd":                                    "21474836480",
+		},
+		{
+			topolvm.GetCapacityKeyPrefix() + "ssd": "1024",
+		},
+	}
+	for i, node := range nodes {
+		if !reflect.DeepEqual(node.Annotations, expected[i]) {
+			t.Errorf("unexpected annotations of %s: %v", node.Name, node.Annotations)
+		}
+	}
+	if g.calls != 2 {
+		t.Errorf("NodeStorage should be read once per node: %d", g.calls)
+	}
+}
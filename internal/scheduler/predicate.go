@@ -8,11 +8,12 @@ import (
 	"sync"
 
 	"github.com/topolvm/topolvm"
+	"github.com/topolvm/topolvm/internal/maintenance"
 	corev1 "k8s.io/api/core/v1"
 )
 
-func filterNodes(nodes corev1.NodeList, requested map[string]int64) ExtenderFilterResult {
-	if len(requested) == 0 {
+func filterNodes(nodes corev1.NodeList, requested map[string]int64, sourceNodes []string) ExtenderFilterResult {
+	if len(requested) == 0 && len(sourceNodes) == 0 {
 		return ExtenderFilterResult{
 			Nodes: &nodes,
 		}
@@ -25,7 +26,7 @@ func filterNodes(nodes corev1.NodeList, requested map[string]int64) ExtenderFilt
 		reason := &failedNodes[i]
 		node := nodes.Items[i]
 		go func() {
-			*reason = filterNode(node, requested)
+			*reason = filterNode(node, requested, sourceNodes)
 			wg.Done()
 		}()
 	}
@@ -44,8 +45,18 @@ func filterNodes(nodes corev1.NodeList, requested map[string]int64) ExtenderFilt
 	return result
 }
 
-func filterNode(node corev1.Node, requested map[string]int64) string {
+func filterNode(node corev1.Node, requested map[string]int64, sourceNodes []string) string {
+	// Volumes restored from snapshots or cloned from other volumes
+	// can be provisioned only on the node of their source volumes.
+	for _, n := range sourceNodes {
+		if node.Name != n {
+			return "source volume is on another node: " + n
+		}
+	}
 	for dc, required := range requested {
+		if maintenance.IsUnderMaintenance(node.Annotations, dc) {
+			return "device-class under storage maintenance: " + dc
+		}
 		val, ok := node.Annotations[topolvm.GetCapacityKeyPrefix()+dc]
 		if !ok {
 			return "no capacity annotation"
@@ -78,6 +89,14 @@ func extractRequestedSize(pod *corev1.Pod) map[string]int64 {
 	return result
 }
 
+func extractSourceNodes(pod *corev1.Pod) []string {
+	val := pod.Annotations[topolvm.GetSourceNodeKey()]
+	if len(val) == 0 {
+		return nil
+	}
+	return strings.Split(val, ",")
+}
+
 func (s scheduler) predicate(w http.ResponseWriter, r *http.Request) {
 	var input ExtenderArgs
 
@@ -88,8 +107,16 @@ func (s scheduler) predicate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	requested := extractRequestedSize(input.Pod)
-	result := filterNodes(*input.Nodes, requested)
+	requested, err := s.requestedSize(r.Context(), input.Pod)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.fillCapacity(r.Context(), input.Nodes.Items, requested); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := filterNodes(*input.Nodes, requested, extractSourceNodes(input.Pod))
 	w.Header().Set("content-type", "application/json")
 	_ = json.NewEncoder(w).Encode(result)
 }
@@ -31,11 +31,17 @@ func testNode(name string, cap1Gb, cap2Gb, cap3Gb int64) corev1.Node {
 	}
 }
 
+func maintenanceNode(node corev1.Node, value string) corev1.Node {
+	node.Annotations[topolvm.GetMaintenanceKey()] = value
+	return node
+}
+
 func TestFilterNodes(t *testing.T) {
 	testCases := []struct {
-		nodes     corev1.NodeList
-		requested map[string]int64
-		expect    ExtenderFilterResult
+		nodes       corev1.NodeList
+		requested   map[string]int64
+		sourceNodes []string
+		expect      ExtenderFilterResult
 	}{
 		{
 			nodes: corev1.NodeList{
@@ -116,10 +122,76 @@ func TestFilterNodes(t *testing.T) {
 				FailedNodes: map[string]string{},
 			},
 		},
+		{
+			nodes: corev1.NodeList{
+				Items: []corev1.Node{
+					testNode("10.1.1.1", 10, 10, 10),
+					testNode("10.1.1.2", 10, 10, 10),
+					testNode("10.1.1.3", 1, 10, 10),
+				},
+			},
+			requested: map[string]int64{
+				deviceClass1: 5 << 30,
+			},
+			sourceNodes: []string{"10.1.1.2"},
+			expect: ExtenderFilterResult{
+				Nodes: &corev1.NodeList{
+					Items: []corev1.Node{
+						testNode("10.1.1.2", 10, 10, 10),
+					},
+				},
+				FailedNodes: FailedNodesMap{
+					"10.1.1.1": "source volume is on another node: 10.1.1.2",
+					"10.1.1.3": "source volume is on another node: 10.1.1.2",
+				},
+			},
+		},
+		{
+			nodes: corev1.NodeList{
+				Items: []corev1.Node{
+					testNode("10.1.1.1", 10, 10, 10),
+					testNode("10.1.1.2", 10, 10, 10),
+				},
+			},
+			requested: map[string]int64{
+				deviceClass1: 5 << 30,
+			},
+			sourceNodes: []string{"10.1.1.1", "10.1.1.2"},
+			expect: ExtenderFilterResult{
+				Nodes: &corev1.NodeList{},
+				FailedNodes: FailedNodesMap{
+					"10.1.1.1": "source volume is on another node: 10.1.1.2",
+					"10.1.1.2": "source volume is on another node: 10.1.1.1",
+				},
+			},
+		},
+		{
+			nodes: corev1.NodeList{
+				Items: []corev1.Node{
+					testNode("10.1.1.1", 10, 10, 10),
+					maintenanceNode(testNode("10.1.1.2", 10, 10, 10), "true"),
+					maintenanceNode(testNode("10.1.1.3", 10, 10, 10), deviceClass2),
+				},
+			},
+			requested: map[string]int64{
+				deviceClass1: 5 << 30,
+			},
+			expect: ExtenderFilterResult{
+				Nodes: &corev1.NodeList{
+					Items: []corev1.Node{
+						testNode("10.1.1.1", 10, 10, 10),
+						testNode("10.1.1.3", 10, 10, 10),
+					},
+				},
+				FailedNodes: FailedNodesMap{
+					"10.1.1.2": "device-class under storage maintenance: " + deviceClass1,
+				},
+			},
+		},
 	}
 
 	for _, tt := range testCases {
-		result := filterNodes(tt.nodes, tt.requested)
+		result := filterNodes(tt.nodes, tt.requested, tt.sourceNodes)
 		if len(result.Nodes.Items) != len(tt.expect.Nodes.Items) {
 			t.Fatalf("not match length of filtered NodeList: expect=%d actual=%d", len(tt.expect.Nodes.Items), len(result.Nodes.Items))
 		}
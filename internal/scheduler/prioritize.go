@@ -5,7 +5,6 @@ import (
 	"math"
 	"net/http"
 	"strconv"
-	"strings"
 	"sync"
 
 	"github.com/topolvm/topolvm"
@@ -38,12 +37,10 @@ func capacityToScore(capacity uint64, divisor float64) int {
 	}
 }
 
-func scoreNodes(pod *corev1.Pod, nodes []corev1.Node, defaultDivisor float64, divisors map[string]float64) []HostPriority {
+func scoreNodes(requested map[string]int64, nodes []corev1.Node, defaultDivisor float64, divisors map[string]float64) []HostPriority {
 	var dcs []string
-	for k := range pod.Annotations {
-		if strings.HasPrefix(k, topolvm.GetCapacityKeyPrefix()) {
-			dcs = append(dcs, k[len(topolvm.GetCapacityKeyPrefix()):])
-		}
+	for dc := range requested {
+		dcs = append(dcs, dc)
 	}
 	if len(dcs) == 0 {
 		return nil
@@ -94,12 +91,21 @@ func (s scheduler) prioritize(w http.ResponseWriter, r *http.Request) {
 
 	reader := http.MaxBytesReader(w, r.Body, 10<<20)
 	err := json.NewDecoder(reader).Decode(&input)
-	if err != nil {
+	if err != nil || input.Nodes == nil || input.Pod == nil {
 		http.Error(w, "Bad Request.", http.StatusBadRequest)
 		return
 	}
 
-	result := scoreNodes(input.Pod, input.Nodes.Items, s.defaultDivisor, s.divisors)
+	requested, err := s.requestedSize(r.Context(), input.Pod)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := s.fillCapacity(r.Context(), input.Nodes.Items, requested); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result := scoreNodes(requested, input.Nodes.Items, s.defaultDivisor, s.divisors)
 
 	w.Header().Set("content-type", "application/json")
 	_ = json.NewEncoder(w).Encode(result)
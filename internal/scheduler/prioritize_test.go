@@ -85,7 +85,7 @@ func TestScoreNodes(t *testing.T) {
 		deviceClass1: 4,
 		deviceClass2: 10,
 	}
-	result := scoreNodes(pod, input, defaultDivisor, divisors)
+	result := scoreNodes(extractRequestedSize(pod), input, defaultDivisor, divisors)
 	if !reflect.DeepEqual(result, expected) {
 		t.Errorf("expected scoreNodes() to be %#v, but actual %#v", expected, result)
 	}
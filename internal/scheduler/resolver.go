@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/topolvm/topolvm/internal/getter"
+	"github.com/topolvm/topolvm/internal/podcapacity"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// resolvedCacheTTL is how long the capacity resolved for a pod is reused.
+// The scheduler calls predicate and prioritize for the same pod in a row,
+// and retries unschedulable pods, so a short TTL is enough.
+const resolvedCacheTTL = 30 * time.Second
+
+type resolvedEntry struct {
+	requested map[string]int64
+	expiresAt time.Time
+}
+
+// volumeResolver resolves the capacity requested by a pod from its PVCs,
+// generic ephemeral volumes and StorageClasses. It is used for pods that
+// have not been annotated by the pod mutating webhook.
+type volumeResolver struct {
+	getter getter.Interface
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[types.UID]resolvedEntry
+}
+
+func newVolumeResolver(g getter.Interface) *volumeResolver {
+	return &volumeResolver{
+		getter: g,
+		now:    time.Now,
+		cache:  make(map[types.UID]resolvedEntry),
+	}
+}
+
+func (r *volumeResolver) resolve(ctx context.Context, pod *corev1.Pod) (map[string]int64, error) {
+	now := r.now()
+	if pod.UID != "" {
+		r.mu.Lock()
+		entry, ok := r.cache[pod.UID]
+		r.mu.Unlock()
+		if ok && now.Before(entry.expiresAt) {
+			return entry.requested, nil
+		}
+	}
+
+	requested, err := podcapacity.VolumesCapacity(ctx, r.getter, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	if pod.UID != "" {
+		r.mu.Lock()
+		for uid, e := range r.cache {
+			if !now.Before(e.expiresAt) {
+				delete(r.cache, uid)
+			}
+		}
+		r.cache[pod.UID] = resolvedEntry{requested: requested, expiresAt: now.Add(resolvedCacheTTL)}
+		r.mu.Unlock()
+	}
+	return requested, nil
+}
@@ -0,0 +1,236 @@
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/topolvm/topolvm"
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type mapGetter struct {
+	pvcs  map[client.ObjectKey]*corev1.PersistentVolumeClaim
+	scs   map[string]*storagev1.StorageClass
+	nss   map[string]*topolvmv1.NodeStorage
+	calls int
+}
+
+func (g *mapGetter) Get(_ context.Context, key client.ObjectKey, obj client.Object) error {
+	g.calls++
+	switch o := obj.(type) {
+	case *corev1.PersistentVolumeClaim:
+		if pvc, ok := g.pvcs[key]; ok {
+			pvc.DeepCopyInto(o)
+			return nil
+		}
+		return apierrors.NewNotFound(schema.GroupResource{Resource: "persistentvolumeclaims"}, key.Name)
+	case *storagev1.StorageClass:
+		if sc, ok := g.scs[key.Name]; ok {
+			sc.DeepCopyInto(o)
+			return nil
+		}
+		return apierrors.NewNotFound(schema.GroupResource{Group: "storage.k8s.io", Resource: "storageclasses"}, key.Name)
+	case *topolvmv1.NodeStorage:
+		if ns, ok := g.nss[key.Name]; ok {
+			ns.DeepCopyInto(o)
+			return nil
+		}
+		return apierrors.NewNotFound(schema.GroupResource{Group: "topolvm.io", Resource: "nodestorages"}, key.Name)
+	}
+	panic("unexpected object")
+}
+
+func testPVC(name, scName string, size int64, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: name},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &scName,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: *resource.NewQuantity(size, resource.BinarySI),
+				},
+			},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func newTestGetter() *mapGetter {
+	return &mapGetter{
+		pvcs: map[client.ObjectKey]*corev1.PersistentVolumeClaim{
+			{Namespace: "test", Name: "pending-ssd"}: testPVC("pending-ssd", "topolvm-ssd", 3<<30, corev1.ClaimPending),
+			{Namespace: "test", Name: "pending-hdd"}: testPVC("pending-hdd", "topolvm-hdd", 0, corev1.ClaimPending),
+			{Namespace: "test", Name: "bound-ssd"}:   testPVC("bound-ssd", "topolvm-ssd", 1<<30, corev1.ClaimBound),
+			{Namespace: "test", Name: "other"}:       testPVC("other", "other", 1<<30, corev1.ClaimPending),
+		},
+		scs: map[string]*storagev1.StorageClass{
+			"topolvm-ssd": {
+				ObjectMeta:  metav1.ObjectMeta{Name: "topolvm-ssd"},
+				Provisioner: topolvm.GetPluginName(),
+				Parameters:  map[string]string{topolvm.GetDeviceClassKey(): "ssd"},
+			},
+			"topolvm-hdd": {
+				ObjectMeta:  metav1.ObjectMeta{Name: "topolvm-hdd"},
+				Provisioner: topolvm.GetPluginName(),
+			},
+			"other": {
+				ObjectMeta:  metav1.ObjectMeta{Name: "other"},
+				Provisioner: "other.example.com",
+			},
+		},
+	}
+}
+
+func pvcVolume(claimName string) corev1.Volume {
+	return corev1.Volume{
+		Name: claimName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		},
+	}
+}
+
+func ephemeralVolume(name, scName string, size int64) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Ephemeral: &corev1.EphemeralVolumeSource{
+				VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+					Spec: corev1.PersistentVolumeClaimSpec{
+						StorageClassName: &scName,
+						Resources: corev1.VolumeResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: *resource.NewQuantity(size, resource.BinarySI),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestVolumeResolver(t *testing.T) {
+	testCases := []struct {
+		name     string
+		volumes  []corev1.Volume
+		expected map[string]int64
+	}{
+		{
+			name:     "pending pvcs",
+			volumes:  []corev1.Volume{pvcVolume("pending-ssd"), pvcVolume("pending-hdd")},
+			expected: map[string]int64{"ssd": 3 << 30, topolvm.DefaultDeviceClassAnnotationName: topolvm.DefaultSize},
+		},
+		{
+			name:     "generic ephemeral volumes",
+			volumes:  []corev1.Volume{ephemeralVolume("eph1", "topolvm-ssd", 2<<30), pvcVolume("pending-ssd")},
+			expected: map[string]int64{"ssd": 5 << 30},
+		},
+		{
+			name:     "non-topolvm and missing pvcs",
+			volumes:  []corev1.Volume{pvcVolume("other"), pvcVolume("missing"), ephemeralVolume("eph2", "other", 1<<30)},
+			expected: map[string]int64{},
+		},
+		{
+			name:     "bound pvc",
+			volumes:  []corev1.Volume{pvcVolume("pending-ssd"), pvcVolume("bound-ssd")},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newVolumeResolver(newTestGetter())
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "pod"},
+				Spec:       corev1.PodSpec{Volumes: tt.volumes},
+			}
+			requested, err := r.resolve(context.Background(), pod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(requested, tt.expected) {
+				t.Errorf("expected %#v, but actual %#v", tt.expected, requested)
+			}
+		})
+	}
+}
+
+func TestVolumeResolverCache(t *testing.T) {
+	g := newTestGetter()
+	r := newVolumeResolver(g)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "pod", UID: "uid"},
+		Spec:       corev1.PodSpec{Volumes: []corev1.Volume{pvcVolume("pending-ssd")}},
+	}
+	expected := map[string]int64{"ssd": 3 << 30}
+
+	for i := 0; i < 2; i++ {
+		requested, err := r.resolve(context.Background(), pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(requested, expected) {
+			t.Errorf("expected %#v, but actual %#v", expected, requested)
+		}
+	}
+	if g.calls != 2 {
+		t.Errorf("the second call should be served from the cache: calls=%d", g.calls)
+	}
+
+	now = now.Add(resolvedCacheTTL)
+	if _, err := r.resolve(context.Background(), pod); err != nil {
+		t.Fatal(err)
+	}
+	if g.calls != 4 {
+		t.Errorf("the expired entry should be resolved again: calls=%d", g.calls)
+	}
+}
+
+func TestPredicateWithoutAnnotations(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "pod", UID: "uid"},
+		Spec:       corev1.PodSpec{Volumes: []corev1.Volume{pvcVolume("pending-ssd")}},
+	}
+	s := scheduler{defaultDivisor: 1, resolver: newVolumeResolver(newTestGetter())}
+	requested, err := s.requestedSize(context.Background(), pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := corev1.NodeList{
+		Items: []corev1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "10.1.1.1",
+					Annotations: map[string]string{topolvm.GetCapacityKeyPrefix() + "ssd": "2147483648"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "10.1.1.2",
+					Annotations: map[string]string{topolvm.GetCapacityKeyPrefix() + "ssd": "4294967296"},
+				},
+			},
+		},
+	}
+	result := filterNodes(nodes, requested, nil)
+	if len(result.Nodes.Items) != 1 || result.Nodes.Items[0].Name != "10.1.1.2" {
+		t.Errorf("wrong result.Nodes: %#v", result.Nodes)
+	}
+	if _, ok := result.FailedNodes["10.1.1.1"]; !ok {
+		t.Error("result.FailedNodes does not contain 10.1.1.1")
+	}
+}
@@ -1,13 +1,19 @@
 package scheduler
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+
+	"github.com/topolvm/topolvm/internal/getter"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type scheduler struct {
 	defaultDivisor float64
 	divisors       map[string]float64
+	resolver       *volumeResolver
+	nodeStorages   getter.Interface
 }
 
 func (s scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -23,14 +29,38 @@ func (s scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// NewHandler return new http.Handler of the scheduler extender
-func NewHandler(defaultDiv float64, divisors map[string]float64) (http.Handler, error) {
+// NewHandler return new http.Handler of the scheduler extender.
+// If g is not nil, the requested capacity of pods without
+// "capacity.topolvm.io/<device-class>" annotations is resolved from
+// their PVCs and StorageClasses read via g.
+// If ns is not nil, the capacity of nodes without the annotations is read
+// from NodeStorage via ns.
+func NewHandler(defaultDiv float64, divisors map[string]float64, g, ns getter.Interface) (http.Handler, error) {
 	for _, divisor := range divisors {
 		if divisor <= 0 {
 			return nil, fmt.Errorf("invalid divisor: %f", divisor)
 		}
 	}
-	return scheduler{defaultDiv, divisors}, nil
+	s := scheduler{defaultDivisor: defaultDiv, divisors: divisors, nodeStorages: ns}
+	if g != nil {
+		s.resolver = newVolumeResolver(g)
+	}
+	return s, nil
+}
+
+func (s scheduler) requestedSize(ctx context.Context, pod *corev1.Pod) (map[string]int64, error) {
+	requested := extractRequestedSize(pod)
+	if len(requested) != 0 || s.resolver == nil {
+		return requested, nil
+	}
+	return s.resolver.resolve(ctx, pod)
+}
+
+func (s scheduler) fillCapacity(ctx context.Context, nodes []corev1.Node, requested map[string]int64) error {
+	if s.nodeStorages == nil {
+		return nil
+	}
+	return fillCapacityAnnotations(ctx, s.nodeStorages, nodes, requested)
 }
 
 func status(w http.ResponseWriter, _ *http.Request) {
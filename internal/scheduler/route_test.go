@@ -47,7 +47,7 @@ func testPredicate(t *testing.T) {
 
 	handler, err := NewHandler(1, map[string]float64{
 		"dc1": 1,
-	})
+	}, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -94,7 +94,7 @@ func testPrioritize(t *testing.T) {
 
 	handler, err := NewHandler(1, map[string]float64{
 		"dc1": 1,
-	})
+	}, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,48 @@
+package seed
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// NewHTTPClient returns a client to download the data of VolumeSeeds.
+// It refuses to connect to loopback, link-local, private, unspecified and multicast addresses
+// unless they are in allowed, so that VolumeSeeds cannot reach the services in the node or the cluster network.
+// The addresses are checked when connecting to them, i.e. after the names are resolved and also on redirects.
+// Proxies are not used because they would connect to the destinations instead.
+func NewHTTPClient(allowed []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			return checkAddress(address, allowed)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Transport: transport}
+}
+
+// checkAddress returns an error wrapping ErrInvalid if the address must not be connected to.
+func checkAddress(address string, allowed []netip.Prefix) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: invalid address %s: %v", ErrInvalid, address, err)
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	if addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() {
+		return fmt.Errorf("%w: connecting to %s is not allowed", ErrInvalid, addr)
+	}
+	return nil
+}
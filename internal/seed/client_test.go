@@ -0,0 +1,74 @@
+package seed
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestCheckAddress(t *testing.T) {
+	allowed := []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}
+	cases := []struct {
+		address string
+		ok      bool
+	}{
+		{"203.0.113.1:443", true},
+		{"[2001:db8::1]:443", true},
+		{"10.1.2.3:5000", true},
+		{"10.2.0.1:5000", false},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"192.168.0.1:80", false},
+		{"[fd00::1]:80", false},
+		{"0.0.0.0:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"224.0.0.1:80", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.address, func(t *testing.T) {
+			err := checkAddress(tc.address, allowed)
+			if tc.ok && err != nil {
+				t.Errorf("should be allowed: %v", err)
+			}
+			if !tc.ok && !errors.Is(err, ErrInvalid) {
+				t.Errorf("should be rejected, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://[::1]:1/", http.StatusFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPClient(nil).Get(server.URL)
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("the server on the loopback address should be rejected, but got %v", err)
+	}
+
+	addr := netip.MustParseAddrPort(server.Listener.Addr().String()).Addr()
+	client := NewHTTPClient([]netip.Prefix{netip.PrefixFrom(addr, addr.BitLen())})
+	res, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("unexpected status: %s", res.Status)
+	}
+
+	_, err = client.Get(server.URL + "/redirect")
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("the redirect to the loopback address should be rejected, but got %v", err)
+	}
+}
@@ -0,0 +1,334 @@
+package seed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+const (
+	defaultRegistry = "registry-1.docker.io"
+	maxManifestSize = 4 << 20
+
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var (
+	digestRegexp     = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+	authParamsRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// Reference is a reference of an image in a registry.
+type Reference struct {
+	// Registry is the host of the registry, e.g. "ghcr.io".
+	Registry string
+	// Repository is the name of the repository in the registry, e.g. "library/busybox".
+	Repository string
+	// Tag is the tag or the digest of the image.
+	Tag string
+}
+
+// ParseReference parses a reference like "ghcr.io/example/model:v1" or "busybox@sha256:...".
+// An image without a registry is in Docker Hub.
+func ParseReference(s string) (*Reference, error) {
+	ref := &Reference{Registry: defaultRegistry, Tag: "latest"}
+	name, digest, hasDigest := strings.Cut(s, "@")
+	if hasDigest && !digestRegexp.MatchString(digest) {
+		return nil, fmt.Errorf("%w: invalid digest in image reference %q", ErrInvalid, s)
+	}
+	// the digest takes precedence over the tag.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if hasDigest {
+		ref.Tag = digest
+	}
+
+	if first, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry = first
+		name = rest
+	}
+	if ref.Registry == "docker.io" {
+		ref.Registry = defaultRegistry
+	}
+	if ref.Registry == defaultRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || ref.Tag == "" || name != strings.ToLower(name) {
+		return nil, fmt.Errorf("%w: invalid image reference %q", ErrInvalid, s)
+	}
+	ref.Repository = name
+	return ref, nil
+}
+
+// Credentials are the username and password to pull images from a registry.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// FindCredentials returns the credentials for the registry in the content of a .dockerconfigjson file,
+// or nil if it has none for the registry.
+func FindCredentials(dockerConfigJSON []byte, registry string) (*Credentials, error) {
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(dockerConfigJSON, &config); err != nil {
+		return nil, fmt.Errorf("%w: invalid .dockerconfigjson: %v", ErrInvalid, err)
+	}
+	for key, auth := range config.Auths {
+		if registryOf(key) != registry {
+			continue
+		}
+		if auth.Auth == "" {
+			return &Credentials{Username: auth.Username, Password: auth.Password}, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid auth of %s in .dockerconfigjson: %v", ErrInvalid, key, err)
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid auth of %s in .dockerconfigjson", ErrInvalid, key)
+		}
+		return &Credentials{Username: username, Password: password}, nil
+	}
+	return nil, nil
+}
+
+// registryOf returns the registry of a key of .dockerconfigjson like "ghcr.io" or "https://index.docker.io/v1/".
+func registryOf(key string) string {
+	_, rest, ok := strings.Cut(key, "://")
+	if ok {
+		key = rest
+	}
+	key, _, _ = strings.Cut(key, "/")
+	if key == "docker.io" || key == "index.docker.io" {
+		return defaultRegistry
+	}
+	return key
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+	Platform  *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform,omitempty"`
+}
+
+// manifest is an image manifest or an image index.
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Manifests []descriptor `json:"manifests"`
+	Layers    []descriptor `json:"layers"`
+}
+
+// ExtractImage pulls the image given by reference, and extracts its layers by e.
+// The image is pulled with credentials if it is not nil, or anonymously otherwise.
+// The image for linux and the architecture of this node is chosen if the reference is an image index.
+func ExtractImage(ctx context.Context, client *http.Client, reference string, credentials *Credentials, e *Extractor) error {
+	ref, err := ParseReference(reference)
+	if err != nil {
+		return err
+	}
+	c := &registryClient{client: client, ref: ref, credentials: credentials}
+
+	m, err := c.manifest(ctx, ref.Tag)
+	if err != nil {
+		return err
+	}
+	if len(m.Manifests) > 0 {
+		digest := ""
+		for _, d := range m.Manifests {
+			if d.Platform != nil && d.Platform.OS == "linux" && d.Platform.Architecture == runtime.GOARCH {
+				digest = d.Digest
+				break
+			}
+		}
+		if digest == "" {
+			return fmt.Errorf("%w: image %s has no manifest for linux/%s", ErrInvalid, reference, runtime.GOARCH)
+		}
+		if m, err = c.manifest(ctx, digest); err != nil {
+			return err
+		}
+	}
+
+	for _, layer := range m.Layers {
+		if !strings.Contains(layer.MediaType, "tar") || strings.Contains(layer.MediaType, "zstd") {
+			return fmt.Errorf("%w: unsupported layer media type %s", ErrInvalid, layer.MediaType)
+		}
+		if err := c.extractLayer(ctx, layer, e); err != nil {
+			return fmt.Errorf("failed to extract layer %s: %w", layer.Digest, err)
+		}
+	}
+	return nil
+}
+
+// registryClient calls the API of a registry with a bearer token or the basic authentication if required.
+// The token is obtained with the credentials if they are given, or anonymously otherwise.
+type registryClient struct {
+	client      *http.Client
+	ref         *Reference
+	credentials *Credentials
+	token       string
+	basic       bool
+}
+
+func (c *registryClient) manifest(ctx context.Context, tag string) (*manifest, error) {
+	res, err := c.get(ctx, "manifests/"+tag, strings.Join([]string{
+		mediaTypeOCIIndex, mediaTypeOCIManifest, mediaTypeDockerList, mediaTypeDockerManifest,
+	}, ", "))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	var body io.Reader = io.LimitReader(res.Body, maxManifestSize)
+	if digestRegexp.MatchString(tag) {
+		body = &verifyingReader{r: body, h: sha256.New(), expected: strings.TrimPrefix(tag, "sha256:")}
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%w: invalid manifest of %s: %v", ErrInvalid, tag, err)
+	}
+	return m, nil
+}
+
+func (c *registryClient) extractLayer(ctx context.Context, layer descriptor, e *Extractor) error {
+	if !digestRegexp.MatchString(layer.Digest) {
+		return fmt.Errorf("%w: unsupported digest %s", ErrInvalid, layer.Digest)
+	}
+	res, err := c.get(ctx, "blobs/"+layer.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	r, err := decompress(&verifyingReader{r: res.Body, h: sha256.New(), expected: strings.TrimPrefix(layer.Digest, "sha256:")})
+	if err != nil {
+		return err
+	}
+	return e.Extract(r)
+}
+
+// get calls GET /v2/<repository>/<path>. It obtains a token and retries if the registry requires it.
+func (c *registryClient) get(ctx context.Context, path, accept string) (*http.Response, error) {
+	u := fmt.Sprintf("https://%s/v2/%s/%s", c.ref.Registry, c.ref.Repository, path)
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		switch {
+		case c.token != "":
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		case c.basic:
+			req.SetBasicAuth(c.credentials.Username, c.credentials.Password)
+		}
+		res, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode == http.StatusOK {
+			return res, nil
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized || c.token != "" || c.basic {
+			return nil, fmt.Errorf("failed to get %s: %s", u, res.Status)
+		}
+		if err := c.authenticate(ctx, res.Header.Get("WWW-Authenticate")); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// authenticate obtains a token from the realm given by the challenge of the registry,
+// or uses the basic authentication if the registry requires it.
+func (c *registryClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, rest, _ := strings.Cut(challenge, " ")
+	if strings.EqualFold(scheme, "Basic") && c.credentials != nil {
+		c.basic = true
+		return nil
+	}
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("%w: unsupported authentication of %s: %q", ErrInvalid, c.ref.Registry, challenge)
+	}
+	params := map[string]string{}
+	for _, m := range authParamsRegexp.FindAllStringSubmatch(rest, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("%w: no realm in the challenge of %s: %q", ErrInvalid, c.ref.Registry, challenge)
+	}
+	if params["scope"] == "" {
+		params["scope"] = fmt.Sprintf("repository:%s:pull", c.ref.Repository)
+	}
+
+	u, err := url.Parse(params["realm"])
+	if err != nil {
+		return fmt.Errorf("%w: invalid realm %q: %v", ErrInvalid, params["realm"], err)
+	}
+	q := u.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", params["scope"])
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.credentials != nil {
+		req.SetBasicAuth(c.credentials.Username, c.credentials.Password)
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get a token from %s: %s", params["realm"], res.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode the token from %s: %w", params["realm"], err)
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("no token is returned from %s", params["realm"])
+	}
+	return nil
+}
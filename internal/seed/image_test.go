@@ -0,0 +1,192 @@
+package seed
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	cases := []struct {
+		ref      string
+		expected *Reference
+	}{
+		{"busybox", &Reference{Registry: defaultRegistry, Repository: "library/busybox", Tag: "latest"}},
+		{"docker.io/example/model:v1", &Reference{Registry: defaultRegistry, Repository: "example/model", Tag: "v1"}},
+		{"ghcr.io/example/model:v1", &Reference{Registry: "ghcr.io", Repository: "example/model", Tag: "v1"}},
+		{"localhost:5000/model@" + digest, &Reference{Registry: "localhost:5000", Repository: "model", Tag: digest}},
+		{"example/model:v1@" + digest, &Reference{Registry: defaultRegistry, Repository: "example/model", Tag: digest}},
+		{"model@sha256:abc", nil},
+		{"Example/model", nil},
+		{"ghcr.io/", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.ref, func(t *testing.T) {
+			actual, err := ParseReference(tc.ref)
+			if tc.expected == nil {
+				if err == nil {
+					t.Errorf("should fail: %+v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *actual != *tc.expected {
+				t.Errorf("expected %+v, but got %+v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+// testRegistry serves an image index of an image with the layers, which requires a token.
+// The token is issued with the credentials if they are given, or anonymously otherwise.
+func testRegistry(t *testing.T, credentials *Credentials, layers ...[]byte) *httptest.Server {
+	t.Helper()
+	blobs := map[string][]byte{}
+	add := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		blobs[digest] = data
+		return digest
+	}
+	m := manifest{MediaType: mediaTypeOCIManifest}
+	for i, layer := range layers {
+		mediaType := "application/vnd.oci.image.layer.v1.tar"
+		if i%2 == 1 {
+			layer = gzipped(t, layer)
+			mediaType += "+gzip"
+		}
+		m.Layers = append(m.Layers, descriptor{MediaType: mediaType, Digest: add(layer), Size: int64(len(layer))})
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := manifest{MediaType: mediaTypeOCIIndex, Manifests: []descriptor{{MediaType: mediaTypeOCIManifest, Digest: add(data)}}}
+	index.Manifests[0].Platform = &struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	}{Architecture: runtime.GOARCH, OS: "linux"}
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:example/model:pull" {
+				http.Error(w, "invalid scope", http.StatusBadRequest)
+				return
+			}
+			if credentials != nil {
+				if username, password, _ := r.BasicAuth(); username != credentials.Username || password != credentials.Password {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/example/model/manifests/v1":
+			_, _ = w.Write(indexData)
+		default:
+			name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			blob, ok := blobs[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(blob)
+		}
+	}))
+	return server
+}
+
+func TestExtractImage(t *testing.T) {
+	server := testRegistry(t, nil,
+		tarball(t,
+			tarEntry{name: "data/model.bin", typeflag: tar.TypeReg, body: "v1"},
+			tarEntry{name: "data/old", typeflag: tar.TypeReg, body: "old"},
+		),
+		tarball(t,
+			tarEntry{name: "data/model.bin", typeflag: tar.TypeReg, body: "v2"},
+			tarEntry{name: "data/.wh.old", typeflag: tar.TypeReg},
+		),
+	)
+	defer server.Close()
+
+	e, dir := newTestExtractor(t)
+	reference := strings.TrimPrefix(server.URL, "https://") + "/example/model:v1"
+	if err := ExtractImage(context.Background(), server.Client(), reference, nil, e); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(dir, "data/model.bin"), "v2")
+	assertNotExist(t, filepath.Join(dir, "data/old"))
+}
+
+func TestExtractImageWithCredentials(t *testing.T) {
+	credentials := &Credentials{Username: "user", Password: "secret"}
+	server := testRegistry(t, credentials, tarball(t, tarEntry{name: "model.bin", typeflag: tar.TypeReg, body: "private"}))
+	defer server.Close()
+	reference := strings.TrimPrefix(server.URL, "https://") + "/example/model:v1"
+
+	e, _ := newTestExtractor(t)
+	if err := ExtractImage(context.Background(), server.Client(), reference, nil, e); err == nil {
+		t.Error("pulling the image anonymously should fail")
+	}
+
+	e, dir := newTestExtractor(t)
+	if err := ExtractImage(context.Background(), server.Client(), reference, credentials, e); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(dir, "model.bin"), "private")
+}
+
+func TestFindCredentials(t *testing.T) {
+	config := []byte(`{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("hub:pass:word")) + `"},
+		"ghcr.io": {"username": "gh", "password": "token"}
+	}}`)
+	cases := []struct {
+		registry string
+		expected *Credentials
+	}{
+		{defaultRegistry, &Credentials{Username: "hub", Password: "pass:word"}},
+		{"ghcr.io", &Credentials{Username: "gh", Password: "token"}},
+		{"quay.io", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.registry, func(t *testing.T) {
+			actual, err := FindCredentials(config, tc.registry)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (actual == nil) != (tc.expected == nil) || (actual != nil && *actual != *tc.expected) {
+				t.Errorf("expected %+v, but got %+v", tc.expected, actual)
+			}
+		})
+	}
+
+	if _, err := FindCredentials([]byte("{"), "ghcr.io"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("invalid .dockerconfigjson should be rejected, but got %v", err)
+	}
+}
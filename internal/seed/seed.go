@@ -0,0 +1,196 @@
+// Package seed fetches the data of VolumeSeeds and writes it into volumes.
+package seed
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+	"github.com/topolvm/topolvm/internal/backup"
+	"github.com/topolvm/topolvm/internal/filesystem"
+)
+
+const chunkSize = 1 << 20
+
+var (
+	// ErrInvalid means the VolumeSeed or the data is invalid. Retrying does not help.
+	ErrInvalid = errors.New("invalid seed")
+	// ErrChecksumMismatch means the data does not match the checksum given by the VolumeSeed.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	// ErrTooLarge means the data does not fit in the volume.
+	ErrTooLarge = errors.New("data is larger than the volume")
+)
+
+// Validate returns an error wrapping ErrInvalid if the spec cannot be populated.
+func Validate(spec *topolvmv1.VolumeSeedSpec) error {
+	switch {
+	case (spec.HTTP == nil) == (spec.Image == nil):
+		return fmt.Errorf("%w: exactly one of http and image must be given", ErrInvalid)
+	case spec.HTTP != nil && spec.HTTP.URL == "":
+		return fmt.Errorf("%w: http.url is empty", ErrInvalid)
+	case spec.Image != nil && spec.Image.Reference == "":
+		return fmt.Errorf("%w: image.reference is empty", ErrInvalid)
+	}
+	if !filesystem.IsSupported(FsType(spec)) {
+		return fmt.Errorf("%w: unsupported fsType %s", ErrInvalid, spec.FsType)
+	}
+	if spec.Image != nil {
+		if _, err := ParseReference(spec.Image.Reference); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsFilesystem returns true if the data of the spec is extracted into a filesystem.
+func IsFilesystem(spec *topolvmv1.VolumeSeedSpec) bool {
+	return spec.Image != nil || spec.Format == topolvmv1.SeedFormatTar
+}
+
+// FsType returns the filesystem of the volume populated from the spec.
+func FsType(spec *topolvmv1.VolumeSeedSpec) string {
+	if spec.FsType == "" {
+		return "ext4"
+	}
+	return spec.FsType
+}
+
+// OpenHTTP downloads the file of source. The file is decompressed if it is compressed by gzip.
+// If source has a checksum, reading the end of the file returns ErrChecksumMismatch when it does not match.
+func OpenHTTP(ctx context.Context, client *http.Client, source *topolvmv1.HTTPSeedSource) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("failed to get %s: %s", source.URL, res.Status)
+	}
+
+	var body io.Reader = res.Body
+	if source.SHA256 != "" {
+		body = &verifyingReader{r: res.Body, h: sha256.New(), expected: source.SHA256}
+	}
+	r, err := decompress(body)
+	if err != nil {
+		_ = res.Body.Close()
+		return nil, err
+	}
+	return readCloser{Reader: r, Closer: res.Body}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// verifyingReader returns ErrChecksumMismatch instead of io.EOF if the data does not match the expected digest.
+type verifyingReader struct {
+	r        io.Reader
+	h        hash.Hash
+	expected string
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.h.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if actual := hex.EncodeToString(v.h.Sum(nil)); actual != v.expected {
+			return n, fmt.Errorf("%w: expected sha256 %s, got %s", ErrChecksumMismatch, v.expected, actual)
+		}
+	}
+	return n, err
+}
+
+// decompress returns a reader of the decompressed data if r is compressed by gzip.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return br, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return zr, nil
+}
+
+// WriteRaw writes the data read from r into dst from the beginning, and returns the number of bytes written.
+// If zeroGaps is false, the chunks of zeros are skipped because dst reads zeros, e.g. a new thin volume.
+// Otherwise, the rest of dst up to size is filled with zeros.
+// progress is called with the number of bytes written so far after each chunk.
+func WriteRaw(r io.Reader, dst io.WriterAt, size uint64, zeroGaps bool, progress func(int64)) (int64, error) {
+	buf := make([]byte, chunkSize)
+	var offset uint64
+	var written int64
+	for {
+		n, err := readFull(r, buf)
+		if n > 0 {
+			if offset+uint64(n) > size {
+				return written, fmt.Errorf("%w: the size is %d bytes", ErrTooLarge, size)
+			}
+			if zeroGaps || !isZero(buf[:n]) {
+				if _, err := dst.WriteAt(buf[:n], int64(offset)); err != nil {
+					return written, fmt.Errorf("failed to write at %d: %w", offset, err)
+				}
+				written += int64(n)
+				if progress != nil {
+					progress(written)
+				}
+			}
+			offset += uint64(n)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	if zeroGaps {
+		if err := backup.WriteZeros(dst, offset, size); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// readFull reads into buf until it is full or r returns an error. Unlike io.ReadFull, io.EOF is returned as is
+// so that a truncated stream returning io.ErrUnexpectedEOF is not taken as the end of the data.
+func readFull(r io.Reader, buf []byte) (int, error) {
+	n := 0
+	for n < len(buf) {
+		m, err := r.Read(buf[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
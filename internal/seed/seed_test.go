@@ -0,0 +1,162 @@
+package seed
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	topolvmv1 "github.com/topolvm/topolvm/api/v1"
+)
+
+// writerAtBuffer is an in-memory io.WriterAt.
+type writerAtBuffer []byte
+
+func (b writerAtBuffer) WriteAt(p []byte, off int64) (int, error) {
+	return copy(b[off:], p), nil
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenHTTP(t *testing.T) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("seed"), 1000)
+	compressed := gzipped(t, data)
+	sum := sha256.Sum256(compressed)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/plain":
+			_, _ = w.Write(data)
+		case "/gzip":
+			_, _ = w.Write(compressed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name   string
+		source topolvmv1.HTTPSeedSource
+		err    error
+	}{
+		{"plain", topolvmv1.HTTPSeedSource{URL: server.URL + "/plain"}, nil},
+		{"gzip with checksum", topolvmv1.HTTPSeedSource{URL: server.URL + "/gzip", SHA256: hex.EncodeToString(sum[:])}, nil},
+		{"checksum mismatch", topolvmv1.HTTPSeedSource{URL: server.URL + "/plain", SHA256: hex.EncodeToString(sum[:])}, ErrChecksumMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := OpenHTTP(ctx, server.Client(), &tc.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = r.Close() }()
+			read, err := io.ReadAll(r)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, but got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(read, data) {
+				t.Error("unexpected data")
+			}
+		})
+	}
+
+	if _, err := OpenHTTP(ctx, server.Client(), &topolvmv1.HTTPSeedSource{URL: server.URL + "/missing"}); err == nil {
+		t.Error("should fail for 404")
+	}
+}
+
+func TestWriteRaw(t *testing.T) {
+	data := make([]byte, 3*chunkSize+100)
+	copy(data, "head")
+	copy(data[2*chunkSize+10:], "tail")
+
+	dirty := make(writerAtBuffer, 4*chunkSize)
+	for i := range dirty {
+		dirty[i] = 0xaa
+	}
+	written, err := WriteRaw(bytes.NewReader(data), dirty, uint64(len(dirty)), true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != int64(len(data)) {
+		t.Errorf("unexpected written bytes: %d", written)
+	}
+	if !bytes.Equal(dirty[:len(data)], data) || !isZero(dirty[len(data):]) {
+		t.Error("the volume should have the data followed by zeros")
+	}
+
+	// the chunks of zeros are not written to a volume reading zeros.
+	clean := make(writerAtBuffer, 4*chunkSize)
+	var progress []int64
+	written, err = WriteRaw(bytes.NewReader(data), clean, uint64(len(clean)), false, func(n int64) { progress = append(progress, n) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 2*chunkSize {
+		t.Errorf("unexpected written bytes: %d", written)
+	}
+	if len(progress) != 2 || progress[1] != written {
+		t.Errorf("unexpected progress: %v", progress)
+	}
+	if !bytes.Equal(clean[:len(data)], data) {
+		t.Error("the volume should have the data")
+	}
+
+	_, err = WriteRaw(bytes.NewReader(data), make(writerAtBuffer, len(data)), chunkSize, true, nil)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("expected ErrTooLarge, but got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		spec  topolvmv1.VolumeSeedSpec
+		valid bool
+	}{
+		{"http", topolvmv1.VolumeSeedSpec{HTTP: &topolvmv1.HTTPSeedSource{URL: "https://example.com/a.img"}}, true},
+		{"image", topolvmv1.VolumeSeedSpec{Image: &topolvmv1.ImageSeedSource{Reference: "busybox"}}, true},
+		{"none", topolvmv1.VolumeSeedSpec{}, false},
+		{"both", topolvmv1.VolumeSeedSpec{
+			HTTP:  &topolvmv1.HTTPSeedSource{URL: "https://example.com/a.img"},
+			Image: &topolvmv1.ImageSeedSource{Reference: "busybox"},
+		}, false},
+		{"invalid image", topolvmv1.VolumeSeedSpec{Image: &topolvmv1.ImageSeedSource{Reference: "Busybox"}}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(&tc.spec)
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.valid && !errors.Is(err, ErrInvalid) {
+				t.Errorf("expected ErrInvalid, but got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package seed
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// Extractor extracts tarballs into a directory. The files cannot be created outside the directory
+// by absolute paths, ".." or symbolic links. The layers of an image are extracted by the same Extractor in order,
+// and the whiteout files of OCI image layers remove the files of the lower layers.
+type Extractor struct {
+	root     *os.Root
+	progress func(int64)
+	written  int64
+}
+
+// NewExtractor returns an Extractor into root. progress is called with the number of bytes written so far.
+func NewExtractor(root *os.Root, progress func(int64)) *Extractor {
+	return &Extractor{root: root, progress: progress}
+}
+
+// Written returns the number of bytes of the regular files written so far.
+func (e *Extractor) Written() int64 {
+	return e.written
+}
+
+// Extract extracts a tarball read from r. Device files and named pipes are skipped.
+// r is read to the end so that a checksum of the data is verified.
+func (e *Extractor) Extract(r io.Reader) error {
+	// the entries in this tarball, which are not removed by opaque whiteouts in it.
+	extracted := make(map[string]bool)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tarball: %w", err)
+		}
+
+		name := cleanName(hdr.Name)
+		if name == "." {
+			continue
+		}
+		dir, base := path.Split(name)
+		dir = cleanName(dir)
+		switch {
+		case base == opaqueWhiteout:
+			if err := e.clearDir(dir, extracted); err != nil {
+				return err
+			}
+			continue
+		case strings.HasPrefix(base, whiteoutPrefix):
+			if err := e.root.RemoveAll(path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := e.extractEntry(tr, hdr, name, dir); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		extracted[name] = true
+	}
+
+	_, err := io.Copy(io.Discard, r)
+	return err
+}
+
+func (e *Extractor) extractEntry(tr *tar.Reader, hdr *tar.Header, name, dir string) error {
+	// an entry replaces the existing one unless both are directories.
+	if fi, err := e.root.Lstat(name); err == nil && (!fi.IsDir() || hdr.Typeflag != tar.TypeDir) {
+		if err := e.root.RemoveAll(name); err != nil {
+			return err
+		}
+	}
+	if err := e.root.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	mode := hdr.FileInfo().Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := e.root.Mkdir(name, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	case tar.TypeReg:
+		if err := e.writeFile(tr, name); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := e.root.Symlink(hdr.Linkname, name); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		if err := e.root.Link(cleanName(hdr.Linkname), name); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	// the owners cannot be changed if not running as root.
+	if err := e.root.Lchown(name, hdr.Uid, hdr.Gid); err != nil && !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	if hdr.Typeflag == tar.TypeSymlink {
+		return nil
+	}
+	if err := e.root.Chmod(name, mode); err != nil {
+		return err
+	}
+	return e.root.Chtimes(name, hdr.ModTime, hdr.ModTime)
+}
+
+func (e *Extractor) writeFile(r io.Reader, name string) error {
+	f, err := e.root.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, err := f.Write(buf[:n]); err != nil {
+				_ = f.Close()
+				return err
+			}
+			e.written += int64(n)
+			if e.progress != nil {
+				e.progress(e.written)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// clearDir removes the entries in dir except for those extracted from the current tarball.
+func (e *Extractor) clearDir(dir string, extracted map[string]bool) error {
+	entries, err := fs.ReadDir(e.root.FS(), dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if extracted[name] {
+			continue
+		}
+		if err := e.root.RemoveAll(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanName returns the name relative to the root of the tarball without "..".
+func cleanName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
@@ -0,0 +1,154 @@
+package seed
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+}
+
+func tarball(t *testing.T, entries ...tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0644, Size: int64(len(e.body))}
+		if e.typeflag == tar.TypeDir {
+			hdr.Mode = 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestExtractor(t *testing.T) (*Extractor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = root.Close() })
+	return NewExtractor(root, nil), dir
+}
+
+func assertFile(t *testing.T, path, expected string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("failed to read %s: %v", path, err)
+		return
+	}
+	if string(data) != expected {
+		t.Errorf("unexpected content of %s: %q", path, data)
+	}
+}
+
+func assertNotExist(t *testing.T, path string) {
+	t.Helper()
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("%s should not exist: %v", path, err)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	e, dir := newTestExtractor(t)
+	err := e.Extract(bytes.NewReader(tarball(t,
+		tarEntry{name: "./", typeflag: tar.TypeDir},
+		tarEntry{name: "data/", typeflag: tar.TypeDir},
+		tarEntry{name: "data/model.bin", typeflag: tar.TypeReg, body: "weights"},
+		tarEntry{name: "data/link", typeflag: tar.TypeSymlink, linkname: "model.bin"},
+		tarEntry{name: "data/hard", typeflag: tar.TypeLink, linkname: "data/model.bin"},
+		tarEntry{name: "nested/dir/file", typeflag: tar.TypeReg, body: "nested"},
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(dir, "data/model.bin"), "weights")
+	assertFile(t, filepath.Join(dir, "data/link"), "weights")
+	assertFile(t, filepath.Join(dir, "data/hard"), "weights")
+	assertFile(t, filepath.Join(dir, "nested/dir/file"), "nested")
+	if e.Written() != int64(len("weights")+len("nested")) {
+		t.Errorf("unexpected written bytes: %d", e.Written())
+	}
+}
+
+func TestExtractOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "root")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = root.Close() }()
+
+	// ".." and absolute paths are resolved in the root.
+	e := NewExtractor(root, nil)
+	err = e.Extract(bytes.NewReader(tarball(t,
+		tarEntry{name: "../escaped", typeflag: tar.TypeReg, body: "a"},
+		tarEntry{name: "/abs", typeflag: tar.TypeReg, body: "b"},
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNotExist(t, filepath.Join(parent, "escaped"))
+	assertFile(t, filepath.Join(dir, "escaped"), "a")
+	assertFile(t, filepath.Join(dir, "abs"), "b")
+
+	// a symbolic link cannot be followed outside the root.
+	err = e.Extract(bytes.NewReader(tarball(t,
+		tarEntry{name: "out", typeflag: tar.TypeSymlink, linkname: parent},
+		tarEntry{name: "out/escaped", typeflag: tar.TypeReg, body: "c"},
+	)))
+	if err == nil {
+		t.Error("should fail to write through a symbolic link outside the root")
+	}
+	assertNotExist(t, filepath.Join(parent, "escaped"))
+}
+
+func TestExtractWhiteouts(t *testing.T) {
+	e, dir := newTestExtractor(t)
+	err := e.Extract(bytes.NewReader(tarball(t,
+		tarEntry{name: "keep", typeflag: tar.TypeReg, body: "lower"},
+		tarEntry{name: "removed", typeflag: tar.TypeReg, body: "lower"},
+		tarEntry{name: "opaque/old", typeflag: tar.TypeReg, body: "lower"},
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = e.Extract(bytes.NewReader(tarball(t,
+		tarEntry{name: "keep", typeflag: tar.TypeReg, body: "upper"},
+		tarEntry{name: ".wh.removed", typeflag: tar.TypeReg},
+		tarEntry{name: "opaque/", typeflag: tar.TypeDir},
+		tarEntry{name: "opaque/new", typeflag: tar.TypeReg, body: "upper"},
+		tarEntry{name: "opaque/.wh..wh..opq", typeflag: tar.TypeReg},
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filepath.Join(dir, "keep"), "upper")
+	assertNotExist(t, filepath.Join(dir, "removed"))
+	assertNotExist(t, filepath.Join(dir, ".wh.removed"))
+	assertNotExist(t, filepath.Join(dir, "opaque/old"))
+	assertFile(t, filepath.Join(dir, "opaque/new"), "upper")
+}
@@ -3,18 +3,31 @@ package controller
 import (
 	internalController "github.com/topolvm/topolvm/internal/controller"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/credentials"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// SeedConfig restricts the downloads of the data of VolumeSeeds.
+type SeedConfig = internalController.SeedConfig
+
 // SetupLogicalVolumeReconcilerWithServices creates LogicalVolumeReconciler and sets up with manager.
+// replicationPort is the port of the replication API of lvmd to copy volumes from other nodes. Zero disables it.
+// replicationCredentials are the mutual TLS credentials to call the replication API.
+// apiReader is used to read the pull secrets of VolumeSeeds without caching all the Secrets.
+// seedConfig restricts the downloads of the data of VolumeSeeds.
 func SetupLogicalVolumeReconcilerWithServices(
 	mgr ctrl.Manager,
 	client client.Client,
+	apiReader client.Reader,
 	nodeName string,
 	vgService proto.VGServiceClient,
 	lvService proto.LVServiceClient,
+	replicationPort int,
+	replicationCredentials credentials.TransportCredentials,
+	seedConfig SeedConfig,
 ) error {
-	reconciler := internalController.NewLogicalVolumeReconcilerWithServices(client, nodeName, vgService, lvService)
+	reconciler := internalController.NewLogicalVolumeReconcilerWithServices(client, apiReader, nodeName, vgService, lvService,
+		replicationPort, replicationCredentials, seedConfig)
 	return reconciler.SetupWithManager(mgr)
 }
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// BackupConfig restricts the locations of LogicalVolumeBackups and LogicalVolumeRestores.
+type BackupConfig = internalController.BackupConfig
+
+// SetupLogicalVolumeBackupReconciler creates LogicalVolumeBackupReconciler and sets up with manager.
+func SetupLogicalVolumeBackupReconciler(
+	mgr ctrl.Manager,
+	client client.Client,
+	apiReader client.Reader,
+	nodeName string,
+	vgService proto.VGServiceClient,
+	lvService proto.LVServiceClient,
+	config BackupConfig,
+) error {
+	reconciler := internalController.NewLogicalVolumeBackupReconciler(client, apiReader, nodeName, vgService, lvService, config)
+	return reconciler.SetupWithManager(mgr)
+}
+
+// SetupLogicalVolumeRestoreReconciler creates LogicalVolumeRestoreReconciler and sets up with manager.
+func SetupLogicalVolumeRestoreReconciler(
+	mgr ctrl.Manager,
+	client client.Client,
+	apiReader client.Reader,
+	nodeName string,
+	vgService proto.VGServiceClient,
+	config BackupConfig,
+) error {
+	reconciler := internalController.NewLogicalVolumeRestoreReconciler(client, apiReader, nodeName, vgService, config)
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,19 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupLogicalVolumeCopyReconciler creates LogicalVolumeCopyReconciler and sets up with manager.
+func SetupLogicalVolumeCopyReconciler(
+	mgr ctrl.Manager,
+	client client.Client,
+	nodeName string,
+	lvService proto.LVServiceClient,
+) error {
+	reconciler := internalController.NewLogicalVolumeCopyReconciler(client, nodeName, lvService)
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,27 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	"github.com/topolvm/topolvm/pkg/lvmd/proto"
+	"google.golang.org/grpc/credentials"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupLogicalVolumeReplicationReconciler creates LogicalVolumeReplicationReconciler and sets up with manager.
+func SetupLogicalVolumeReplicationReconciler(
+	mgr ctrl.Manager,
+	client client.Client,
+	nodeName string,
+	lvService proto.LVServiceClient,
+	replicationPort int,
+	replicationCredentials credentials.TransportCredentials,
+) error {
+	reconciler := internalController.NewLogicalVolumeReplicationReconciler(client, nodeName, lvService,
+		replicationPort, replicationCredentials)
+	return reconciler.SetupWithManager(mgr)
+}
+
+// NewNodeReplicationDialer returns a function returning a client of the replication API of lvmd on a node
+// and a function to close it. It can be passed to driver.NewSnapshotMetadataServer.
+var NewNodeReplicationDialer = internalController.NewNodeReplicationDialer
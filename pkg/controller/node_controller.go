@@ -7,7 +7,7 @@ import (
 )
 
 // SetupNodeReconciler creates NodeReconciler and sets up with manager.
-func SetupNodeReconciler(mgr ctrl.Manager, client client.Client, skipNodeFinalize bool) error {
-	reconciler := internalController.NewNodeReconciler(client, skipNodeFinalize)
+func SetupNodeReconciler(mgr ctrl.Manager, client client.Client, skipNodeFinalize, orphanVolumes bool) error {
+	reconciler := internalController.NewNodeReconciler(client, skipNodeFinalize, orphanVolumes)
 	return reconciler.SetupWithManager(mgr)
 }
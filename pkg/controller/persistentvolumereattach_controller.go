@@ -0,0 +1,13 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupPersistentVolumeReattachReconciler creates PersistentVolumeReattachReconciler and sets up with manager.
+func SetupPersistentVolumeReattachReconciler(mgr ctrl.Manager, client client.Client) error {
+	reconciler := internalController.NewPersistentVolumeReattachReconciler(client, mgr.GetEventRecorder("topolvm-controller"))
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupResourceClaimReconciler creates ResourceClaimReconciler and sets up with manager.
+func SetupResourceClaimReconciler(mgr ctrl.Manager, client client.Client) error {
+	reconciler := internalController.NewResourceClaimReconciler(client, mgr.GetEventRecorder("topolvm-controller"))
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupSnapshotRevertReconciler creates SnapshotRevertReconciler and sets up with manager.
+func SetupSnapshotRevertReconciler(mgr ctrl.Manager, client client.Client, apiReader client.Reader) error {
+	reconciler := internalController.NewSnapshotRevertReconciler(client, apiReader, mgr.GetEventRecorder("topolvm-controller"))
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupSnapshotScheduleReconciler creates SnapshotScheduleReconciler and sets up with manager.
+func SetupSnapshotScheduleReconciler(mgr ctrl.Manager, client client.Client) error {
+	reconciler := internalController.NewSnapshotScheduleReconciler(client, mgr.GetEventRecorder("topolvm-controller"))
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,13 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupTopoLVMQuotaReconciler creates TopoLVMQuotaReconciler and sets up with manager.
+func SetupTopoLVMQuotaReconciler(mgr ctrl.Manager, client client.Client) error {
+	reconciler := internalController.NewTopoLVMQuotaReconciler(client)
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,19 @@
+package controller
+
+import (
+	internalController "github.com/topolvm/topolvm/internal/controller"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetupVolumeSeedReconciler creates VolumeSeedReconciler and sets up with manager.
+func SetupVolumeSeedReconciler(mgr ctrl.Manager, client client.Client) error {
+	reconciler := internalController.NewVolumeSeedReconciler(client)
+	return reconciler.SetupWithManager(mgr)
+}
+
+// SetupVolumeSeedPopulatorReconciler creates VolumeSeedPopulatorReconciler and sets up with manager.
+func SetupVolumeSeedPopulatorReconciler(mgr ctrl.Manager, client client.Client) error {
+	reconciler := internalController.NewVolumeSeedPopulatorReconciler(client, mgr.GetEventRecorder("topolvm-controller"))
+	return reconciler.SetupWithManager(mgr)
+}
@@ -0,0 +1,10 @@
+package driver
+
+import (
+	internalDriver "github.com/topolvm/topolvm/internal/driver"
+)
+
+// NodeDialer returns a client of the replication API of lvmd on the node and a function to close it.
+type NodeDialer = internalDriver.NodeDialer
+
+var NewSnapshotMetadataServer = internalDriver.NewSnapshotMetadataServer
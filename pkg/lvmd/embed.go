@@ -6,6 +6,7 @@ import (
 	internalLvmd "github.com/topolvm/topolvm/internal/lvmd"
 	"github.com/topolvm/topolvm/pkg/lvmd/proto"
 	lvmdTypes "github.com/topolvm/topolvm/pkg/lvmd/types"
+	"google.golang.org/grpc/credentials"
 )
 
 func NewEmbeddedServiceClients(
@@ -19,5 +20,46 @@ func NewEmbeddedServiceClients(
 	dcManager := internalLvmd.NewDeviceClassManager(deviceClasses)
 	lvOptionClassManager := internalLvmd.NewLvcreateOptionClassManager(LvcreateOptionClasses)
 
-	return internalLvmd.NewEmbeddedServiceClients(ctx, dcManager, lvOptionClassManager)
+	lvService, vgService, _ := internalLvmd.NewEmbeddedServiceClients(ctx, dcManager, lvOptionClassManager, nil)
+	return lvService, vgService
+}
+
+// NewEmbeddedServices creates the clients of the embedded lvmd and the server of its replication API.
+// Peer nodes call the server to apply the changes of their volumes to the replicas on this node,
+// and it must be served with the credentials returned by NewReplicationServerCredentials.
+// If replicationTLS is nil, the embedded lvmd does not replicate volumes to the peers.
+func NewEmbeddedServices(
+	ctx context.Context,
+	deviceClasses []*lvmdTypes.DeviceClass,
+	LvcreateOptionClasses []*lvmdTypes.LvcreateOptionClass,
+	replicationTLS *lvmdTypes.ReplicationTLS,
+) (
+	proto.LVServiceClient,
+	proto.VGServiceClient,
+	proto.ReplicationServiceServer,
+	error,
+) {
+	var creds credentials.TransportCredentials
+	if replicationTLS != nil {
+		var err error
+		creds, err = internalLvmd.ReplicationClientCredentials(replicationTLS)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	dcManager := internalLvmd.NewDeviceClassManager(deviceClasses)
+	lvOptionClassManager := internalLvmd.NewLvcreateOptionClassManager(LvcreateOptionClasses)
+
+	lvService, vgService, replicationService := internalLvmd.NewEmbeddedServiceClients(ctx, dcManager, lvOptionClassManager, creds)
+	return lvService, vgService, replicationService, nil
+}
+
+// NewReplicationServerCredentials returns the mutual TLS credentials of the replication API server.
+func NewReplicationServerCredentials(cfg *lvmdTypes.ReplicationTLS) (credentials.TransportCredentials, error) {
+	return internalLvmd.ReplicationServerCredentials(cfg)
+}
+
+// NewReplicationClientCredentials returns the mutual TLS credentials to call the replication API of the peers.
+func NewReplicationClientCredentials(cfg *lvmdTypes.ReplicationTLS) (credentials.TransportCredentials, error) {
+	return internalLvmd.ReplicationClientCredentials(cfg)
 }
@@ -164,7 +164,9 @@ type CreateLVRequest struct {
 	Tags                []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"` // Tags to add to the volume during creation
 	DeviceClass         string                 `protobuf:"bytes,4,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
 	LvcreateOptionClass string                 `protobuf:"bytes,5,opt,name=lvcreate_option_class,json=lvcreateOptionClass,proto3" json:"lvcreate_option_class,omitempty"`
-	SizeBytes           int64                  `protobuf:"varint,6,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Volume size in canonical CSI bytes.
+	SizeBytes           int64                  `protobuf:"varint,6,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`      // Volume size in canonical CSI bytes.
+	FsType              string                 `protobuf:"bytes,7,opt,name=fs_type,json=fsType,proto3" json:"fs_type,omitempty"`                // Filesystem the volume is formatted with when it is published. Empty for block volumes.
+	MkfsOptions         []string               `protobuf:"bytes,8,rep,name=mkfs_options,json=mkfsOptions,proto3" json:"mkfs_options,omitempty"` // Extra arguments of mkfs the volume is formatted with. A pre-formatted volume is claimed only if they are the defaults of the device class.
 	unknownFields       protoimpl.UnknownFields
 	sizeCache           protoimpl.SizeCache
 }
@@ -234,6 +236,20 @@ func (x *CreateLVRequest) GetSizeBytes() int64 {
 	return 0
 }
 
+func (x *CreateLVRequest) GetFsType() string {
+	if x != nil {
+		return x.FsType
+	}
+	return ""
+}
+
+func (x *CreateLVRequest) GetMkfsOptions() []string {
+	if x != nil {
+		return x.MkfsOptions
+	}
+	return nil
+}
+
 // Represents the response of CreateLV.
 type CreateLVResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -284,6 +300,7 @@ type RemoveLVRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name.
 	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	SkipTrash     bool                   `protobuf:"varint,3,opt,name=skip_trash,json=skipTrash,proto3" json:"skip_trash,omitempty"` // Remove the volume without keeping it in the trash, e.g. a snapshot for a VolumeSnapshot.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -332,6 +349,13 @@ func (x *RemoveLVRequest) GetDeviceClass() string {
 	return ""
 }
 
+func (x *RemoveLVRequest) GetSkipTrash() bool {
+	if x != nil {
+		return x.SkipTrash
+	}
+	return false
+}
+
 type CreateLVSnapshotRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name.
@@ -568,28 +592,33 @@ func (x *ResizeLVResponse) GetSizeBytes() int64 {
 	return 0
 }
 
-// Represents the response of GetLVList.
-type GetLVListResponse struct {
+// Represents the input for MergeLVSnapshot.
+//
+// The snapshot must be a thin snapshot of the volume, and the volume must not be open.
+// The snapshot is consumed by the merge.
+type MergeLVSnapshotRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Volumes       []*LogicalVolume       `protobuf:"bytes,1,rep,name=volumes,proto3" json:"volumes,omitempty"` // Information of volumes.
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`         // The logical volume name to be reverted.
+	Snapshot      string                 `protobuf:"bytes,2,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // The snapshot logical volume name to merge into the volume.
+	DeviceClass   string                 `protobuf:"bytes,3,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetLVListResponse) Reset() {
-	*x = GetLVListResponse{}
+func (x *MergeLVSnapshotRequest) Reset() {
+	*x = MergeLVSnapshotRequest{}
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetLVListResponse) String() string {
+func (x *MergeLVSnapshotRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetLVListResponse) ProtoMessage() {}
+func (*MergeLVSnapshotRequest) ProtoMessage() {}
 
-func (x *GetLVListResponse) ProtoReflect() protoreflect.Message {
+func (x *MergeLVSnapshotRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -601,40 +630,53 @@ func (x *GetLVListResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetLVListResponse.ProtoReflect.Descriptor instead.
-func (*GetLVListResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use MergeLVSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*MergeLVSnapshotRequest) Descriptor() ([]byte, []int) {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *GetLVListResponse) GetVolumes() []*LogicalVolume {
+func (x *MergeLVSnapshotRequest) GetName() string {
 	if x != nil {
-		return x.Volumes
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-// Represents the response of GetFreeBytes.
-type GetFreeBytesResponse struct {
+func (x *MergeLVSnapshotRequest) GetSnapshot() string {
+	if x != nil {
+		return x.Snapshot
+	}
+	return ""
+}
+
+func (x *MergeLVSnapshotRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+type MergeLVSnapshotResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FreeBytes     uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space of the volume group in bytes.
+	Volume        *LogicalVolume         `protobuf:"bytes,1,opt,name=volume,proto3" json:"volume,omitempty"` // Information of the reverted volume.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetFreeBytesResponse) Reset() {
-	*x = GetFreeBytesResponse{}
+func (x *MergeLVSnapshotResponse) Reset() {
+	*x = MergeLVSnapshotResponse{}
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetFreeBytesResponse) String() string {
+func (x *MergeLVSnapshotResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetFreeBytesResponse) ProtoMessage() {}
+func (*MergeLVSnapshotResponse) ProtoMessage() {}
 
-func (x *GetFreeBytesResponse) ProtoReflect() protoreflect.Message {
+func (x *MergeLVSnapshotResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -646,39 +688,41 @@ func (x *GetFreeBytesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetFreeBytesResponse.ProtoReflect.Descriptor instead.
-func (*GetFreeBytesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use MergeLVSnapshotResponse.ProtoReflect.Descriptor instead.
+func (*MergeLVSnapshotResponse) Descriptor() ([]byte, []int) {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *GetFreeBytesResponse) GetFreeBytes() uint64 {
+func (x *MergeLVSnapshotResponse) GetVolume() *LogicalVolume {
 	if x != nil {
-		return x.FreeBytes
+		return x.Volume
 	}
-	return 0
+	return nil
 }
 
-type GetLVListRequest struct {
+// Represents a range of a logical volume.
+type BlockRange struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	DeviceClass   string                 `protobuf:"bytes,1,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	Offset        uint64                 `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"` // Offset of the range in bytes.
+	Length        uint64                 `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"` // Length of the range in bytes.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GetLVListRequest) Reset() {
-	*x = GetLVListRequest{}
+func (x *BlockRange) Reset() {
+	*x = BlockRange{}
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetLVListRequest) String() string {
+func (x *BlockRange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetLVListRequest) ProtoMessage() {}
+func (*BlockRange) ProtoMessage() {}
 
-func (x *GetLVListRequest) ProtoReflect() protoreflect.Message {
+func (x *BlockRange) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -690,39 +734,54 @@ func (x *GetLVListRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetLVListRequest.ProtoReflect.Descriptor instead.
-func (*GetLVListRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use BlockRange.ProtoReflect.Descriptor instead.
+func (*BlockRange) Descriptor() ([]byte, []int) {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *GetLVListRequest) GetDeviceClass() string {
+func (x *BlockRange) GetOffset() uint64 {
 	if x != nil {
-		return x.DeviceClass
+		return x.Offset
 	}
-	return ""
+	return 0
 }
 
-type GetFreeBytesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	DeviceClass   string                 `protobuf:"bytes,1,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *BlockRange) GetLength() uint64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
 }
 
-func (x *GetFreeBytesRequest) Reset() {
-	*x = GetFreeBytesRequest{}
+// Represents the input for GetLVBlockMetadata.
+//
+// If base_name is empty, the ranges allocated in the thin pool are returned.
+// Otherwise, the ranges which differ from the base are returned.
+type GetLVBlockMetadataRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Name           string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                         // The thin logical volume name to be examined.
+	BaseName       string                 `protobuf:"bytes,2,opt,name=base_name,json=baseName,proto3" json:"base_name,omitempty"` // The thin logical volume name to be compared with.
+	DeviceClass    string                 `protobuf:"bytes,3,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	StartingOffset uint64                 `protobuf:"varint,4,opt,name=starting_offset,json=startingOffset,proto3" json:"starting_offset,omitempty"` // Ranges ending before this offset in bytes are skipped.
+	MaxResults     int32                  `protobuf:"varint,5,opt,name=max_results,json=maxResults,proto3" json:"max_results,omitempty"`             // The maximum number of ranges in a response. Zero means the default.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetLVBlockMetadataRequest) Reset() {
+	*x = GetLVBlockMetadataRequest{}
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GetFreeBytesRequest) String() string {
+func (x *GetLVBlockMetadataRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetFreeBytesRequest) ProtoMessage() {}
+func (*GetLVBlockMetadataRequest) ProtoMessage() {}
 
-func (x *GetFreeBytesRequest) ProtoReflect() protoreflect.Message {
+func (x *GetLVBlockMetadataRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -734,41 +793,69 @@ func (x *GetFreeBytesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetFreeBytesRequest.ProtoReflect.Descriptor instead.
-func (*GetFreeBytesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetLVBlockMetadataRequest.ProtoReflect.Descriptor instead.
+func (*GetLVBlockMetadataRequest) Descriptor() ([]byte, []int) {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *GetFreeBytesRequest) GetDeviceClass() string {
+func (x *GetLVBlockMetadataRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *GetLVBlockMetadataRequest) GetBaseName() string {
+	if x != nil {
+		return x.BaseName
+	}
+	return ""
+}
+
+func (x *GetLVBlockMetadataRequest) GetDeviceClass() string {
 	if x != nil {
 		return x.DeviceClass
 	}
 	return ""
 }
 
-// Represents the stream output from Watch.
-type WatchResponse struct {
+func (x *GetLVBlockMetadataRequest) GetStartingOffset() uint64 {
+	if x != nil {
+		return x.StartingOffset
+	}
+	return 0
+}
+
+func (x *GetLVBlockMetadataRequest) GetMaxResults() int32 {
+	if x != nil {
+		return x.MaxResults
+	}
+	return 0
+}
+
+// Represents the response of GetLVBlockMetadata.
+type GetLVBlockMetadataResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FreeBytes     uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space of the default volume group in bytes. In the case of thin pools, free space on the thinpool with overprovision in bytes.
-	Items         []*WatchItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	SizeBytes     uint64                 `protobuf:"varint,1,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of the logical volume in bytes.
+	Ranges        []*BlockRange          `protobuf:"bytes,2,rep,name=ranges,proto3" json:"ranges,omitempty"`                         // Ranges in ascending order of offset.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WatchResponse) Reset() {
-	*x = WatchResponse{}
+func (x *GetLVBlockMetadataResponse) Reset() {
+	*x = GetLVBlockMetadataResponse{}
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WatchResponse) String() string {
+func (x *GetLVBlockMetadataResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchResponse) ProtoMessage() {}
+func (*GetLVBlockMetadataResponse) ProtoMessage() {}
 
-func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+func (x *GetLVBlockMetadataResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -780,50 +867,56 @@ func (x *WatchResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
-func (*WatchResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetLVBlockMetadataResponse.ProtoReflect.Descriptor instead.
+func (*GetLVBlockMetadataResponse) Descriptor() ([]byte, []int) {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *WatchResponse) GetFreeBytes() uint64 {
+func (x *GetLVBlockMetadataResponse) GetSizeBytes() uint64 {
 	if x != nil {
-		return x.FreeBytes
+		return x.SizeBytes
 	}
 	return 0
 }
 
-func (x *WatchResponse) GetItems() []*WatchItem {
+func (x *GetLVBlockMetadataResponse) GetRanges() []*BlockRange {
 	if x != nil {
-		return x.Items
+		return x.Ranges
 	}
 	return nil
 }
 
-// Represents the details of thinpool.
-type ThinPoolItem struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	DataPercent        float64                `protobuf:"fixed64,1,opt,name=data_percent,json=dataPercent,proto3" json:"data_percent,omitempty"`                     // Data percent occupied on the thinpool, used for monitoring.
-	MetadataPercent    float64                `protobuf:"fixed64,2,opt,name=metadata_percent,json=metadataPercent,proto3" json:"metadata_percent,omitempty"`         // Metadata percent occupied on the thinpool, used for monitoring.
-	OverprovisionBytes uint64                 `protobuf:"varint,3,opt,name=overprovision_bytes,json=overprovisionBytes,proto3" json:"overprovision_bytes,omitempty"` // Free space on the thinpool with overprovision, used for annotating node.
-	SizeBytes          uint64                 `protobuf:"varint,4,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`                            // Physical data space size of the thinpool.
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
-}
-
-func (x *ThinPoolItem) Reset() {
-	*x = ThinPoolItem{}
+// Represents the input for ReplicateLV.
+//
+// lvmd takes a thin snapshot of the volume, and sends the ranges changed since the base snapshot
+// to the ReplicationService of the peer. All the allocated ranges are sent if base_snapshot is empty.
+// The base snapshot is removed when the replication succeeds.
+type ReplicateLVRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The thin logical volume name to be replicated.
+	DeviceClass     string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	Snapshot        string                 `protobuf:"bytes,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"`                                        // The snapshot logical volume name to be taken for this replication.
+	BaseSnapshot    string                 `protobuf:"bytes,4,opt,name=base_snapshot,json=baseSnapshot,proto3" json:"base_snapshot,omitempty"`            // The snapshot logical volume name taken by the last replication.
+	PeerAddress     string                 `protobuf:"bytes,5,opt,name=peer_address,json=peerAddress,proto3" json:"peer_address,omitempty"`               // The address of the ReplicationService of the peer lvmd.
+	PeerDeviceClass string                 `protobuf:"bytes,6,opt,name=peer_device_class,json=peerDeviceClass,proto3" json:"peer_device_class,omitempty"` // The device class of the replica on the peer.
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ReplicateLVRequest) Reset() {
+	*x = ReplicateLVRequest{}
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ThinPoolItem) String() string {
+func (x *ReplicateLVRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ThinPoolItem) ProtoMessage() {}
+func (*ReplicateLVRequest) ProtoMessage() {}
 
-func (x *ThinPoolItem) ProtoReflect() protoreflect.Message {
+func (x *ReplicateLVRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -835,65 +928,136 @@ func (x *ThinPoolItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ThinPoolItem.ProtoReflect.Descriptor instead.
-func (*ThinPoolItem) Descriptor() ([]byte, []int) {
+// Deprecated: Use ReplicateLVRequest.ProtoReflect.Descriptor instead.
+func (*ReplicateLVRequest) Descriptor() ([]byte, []int) {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *ThinPoolItem) GetDataPercent() float64 {
+func (x *ReplicateLVRequest) GetName() string {
 	if x != nil {
-		return x.DataPercent
+		return x.Name
 	}
-	return 0
+	return ""
 }
 
-func (x *ThinPoolItem) GetMetadataPercent() float64 {
+func (x *ReplicateLVRequest) GetDeviceClass() string {
 	if x != nil {
-		return x.MetadataPercent
+		return x.DeviceClass
 	}
-	return 0
+	return ""
 }
 
-func (x *ThinPoolItem) GetOverprovisionBytes() uint64 {
+func (x *ReplicateLVRequest) GetSnapshot() string {
 	if x != nil {
-		return x.OverprovisionBytes
+		return x.Snapshot
 	}
-	return 0
+	return ""
 }
 
-func (x *ThinPoolItem) GetSizeBytes() uint64 {
+func (x *ReplicateLVRequest) GetBaseSnapshot() string {
+	if x != nil {
+		return x.BaseSnapshot
+	}
+	return ""
+}
+
+func (x *ReplicateLVRequest) GetPeerAddress() string {
+	if x != nil {
+		return x.PeerAddress
+	}
+	return ""
+}
+
+func (x *ReplicateLVRequest) GetPeerDeviceClass() string {
+	if x != nil {
+		return x.PeerDeviceClass
+	}
+	return ""
+}
+
+// Represents the response of ReplicateLV.
+type ReplicateLVResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	SizeBytes        uint64                 `protobuf:"varint,1,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`                      // Size of the replicated snapshot in bytes.
+	TransferredBytes uint64                 `protobuf:"varint,2,opt,name=transferred_bytes,json=transferredBytes,proto3" json:"transferred_bytes,omitempty"` // Amount of data sent to the peer in bytes.
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ReplicateLVResponse) Reset() {
+	*x = ReplicateLVResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateLVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateLVResponse) ProtoMessage() {}
+
+func (x *ReplicateLVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateLVResponse.ProtoReflect.Descriptor instead.
+func (*ReplicateLVResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ReplicateLVResponse) GetSizeBytes() uint64 {
 	if x != nil {
 		return x.SizeBytes
 	}
 	return 0
 }
 
-// Represents the response corresponding to device class targets.
-type WatchItem struct {
+func (x *ReplicateLVResponse) GetTransferredBytes() uint64 {
+	if x != nil {
+		return x.TransferredBytes
+	}
+	return 0
+}
+
+// Represents a message of ApplyLVDelta.
+//
+// The first message must have name, device_class and size_bytes. The others have offset and data.
+type ApplyLVDeltaRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FreeBytes     uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space in the volume group in bytes.
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The replica logical volume name.
 	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
-	SizeBytes     uint64                 `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of volume group in bytes.
-	ThinPool      *ThinPoolItem          `protobuf:"bytes,4,opt,name=thin_pool,json=thinPool,proto3" json:"thin_pool,omitempty"`
+	SizeBytes     uint64                 `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of the source volume in bytes.
+	Incremental   bool                   `protobuf:"varint,4,opt,name=incremental,proto3" json:"incremental,omitempty"`              // If true, the replica must exist. Otherwise, it is created if it does not exist.
+	Offset        uint64                 `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`                        // Offset of data in bytes.
+	Data          []byte                 `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WatchItem) Reset() {
-	*x = WatchItem{}
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+func (x *ApplyLVDeltaRequest) Reset() {
+	*x = ApplyLVDeltaRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WatchItem) String() string {
+func (x *ApplyLVDeltaRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchItem) ProtoMessage() {}
+func (*ApplyLVDeltaRequest) ProtoMessage() {}
 
-func (x *WatchItem) ProtoReflect() protoreflect.Message {
-	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[15]
+func (x *ApplyLVDeltaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -904,66 +1068,1403 @@ func (x *WatchItem) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchItem.ProtoReflect.Descriptor instead.
-func (*WatchItem) Descriptor() ([]byte, []int) {
-	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use ApplyLVDeltaRequest.ProtoReflect.Descriptor instead.
+func (*ApplyLVDeltaRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *WatchItem) GetFreeBytes() uint64 {
+func (x *ApplyLVDeltaRequest) GetName() string {
 	if x != nil {
-		return x.FreeBytes
+		return x.Name
 	}
-	return 0
+	return ""
 }
 
-func (x *WatchItem) GetDeviceClass() string {
+func (x *ApplyLVDeltaRequest) GetDeviceClass() string {
 	if x != nil {
 		return x.DeviceClass
 	}
 	return ""
 }
 
-func (x *WatchItem) GetSizeBytes() uint64 {
+func (x *ApplyLVDeltaRequest) GetSizeBytes() uint64 {
 	if x != nil {
 		return x.SizeBytes
 	}
 	return 0
 }
 
-func (x *WatchItem) GetThinPool() *ThinPoolItem {
+func (x *ApplyLVDeltaRequest) GetIncremental() bool {
 	if x != nil {
-		return x.ThinPool
+		return x.Incremental
+	}
+	return false
+}
+
+func (x *ApplyLVDeltaRequest) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ApplyLVDeltaRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
 	}
 	return nil
 }
 
-var File_pkg_lvmd_proto_lvmd_proto protoreflect.FileDescriptor
+// Represents the response of ApplyLVDelta.
+type ApplyLVDeltaResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volume        *LogicalVolume         `protobuf:"bytes,1,opt,name=volume,proto3" json:"volume,omitempty"`                                  // Information of the replica.
+	AppliedBytes  uint64                 `protobuf:"varint,2,opt,name=applied_bytes,json=appliedBytes,proto3" json:"applied_bytes,omitempty"` // Amount of data written to the replica in bytes.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_pkg_lvmd_proto_lvmd_proto_rawDesc = "" +
-	"\n" +
-	"\x19pkg/lvmd/proto/lvmd.proto\x12\x05proto\"\a\n" +
-	"\x05Empty\"\xbe\x01\n" +
-	"\rLogicalVolume\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
-	"\tdev_major\x18\x03 \x01(\rR\bdevMajor\x12\x1b\n" +
-	"\tdev_minor\x18\x04 \x01(\rR\bdevMinor\x12\x12\n" +
-	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x1d\n" +
-	"\n" +
-	"size_bytes\x18\x06 \x01(\x03R\tsizeBytes\x12\x12\n" +
-	"\x04path\x18\a \x01(\tR\x04path\x12\x12\n" +
-	"\x04attr\x18\b \x01(\tR\x04attrJ\x04\b\x02\x10\x03\"\xb5\x01\n" +
-	"\x0fCreateLVRequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
-	"\x04tags\x18\x03 \x03(\tR\x04tags\x12!\n" +
-	"\fdevice_class\x18\x04 \x01(\tR\vdeviceClass\x122\n" +
-	"\x15lvcreate_option_class\x18\x05 \x01(\tR\x13lvcreateOptionClass\x12\x1d\n" +
+func (x *ApplyLVDeltaResponse) Reset() {
+	*x = ApplyLVDeltaResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApplyLVDeltaResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyLVDeltaResponse) ProtoMessage() {}
+
+func (x *ApplyLVDeltaResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyLVDeltaResponse.ProtoReflect.Descriptor instead.
+func (*ApplyLVDeltaResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ApplyLVDeltaResponse) GetVolume() *LogicalVolume {
+	if x != nil {
+		return x.Volume
+	}
+	return nil
+}
+
+func (x *ApplyLVDeltaResponse) GetAppliedBytes() uint64 {
+	if x != nil {
+		return x.AppliedBytes
+	}
+	return 0
+}
+
+// Represents the input for RemoveLVReplica.
+type RemoveLVReplicaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The replica logical volume name.
+	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveLVReplicaRequest) Reset() {
+	*x = RemoveLVReplicaRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveLVReplicaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveLVReplicaRequest) ProtoMessage() {}
+
+func (x *RemoveLVReplicaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveLVReplicaRequest.ProtoReflect.Descriptor instead.
+func (*RemoveLVReplicaRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RemoveLVReplicaRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RemoveLVReplicaRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+// Represents the input for ReadLV.
+//
+// lvmd reads the snapshot of the volume taken by PrepareLVCopy for copy_id.
+// The request fails with FAILED_PRECONDITION if the copy is not prepared.
+type ReadLVRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name to be read.
+	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	CopyId        string                 `protobuf:"bytes,3,opt,name=copy_id,json=copyId,proto3" json:"copy_id,omitempty"` // The ID of the copy prepared on the node of the volume.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadLVRequest) Reset() {
+	*x = ReadLVRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadLVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadLVRequest) ProtoMessage() {}
+
+func (x *ReadLVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadLVRequest.ProtoReflect.Descriptor instead.
+func (*ReadLVRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ReadLVRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ReadLVRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *ReadLVRequest) GetCopyId() string {
+	if x != nil {
+		return x.CopyId
+	}
+	return ""
+}
+
+// Represents a message of ReadLV.
+//
+// The first message has size_bytes. The others have offset and data of the allocated ranges in ascending order.
+type ReadLVResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SizeBytes     uint64                 `protobuf:"varint,1,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of the volume in bytes.
+	Offset        uint64                 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`                        // Offset of data in bytes.
+	Data          []byte                 `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadLVResponse) Reset() {
+	*x = ReadLVResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadLVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadLVResponse) ProtoMessage() {}
+
+func (x *ReadLVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadLVResponse.ProtoReflect.Descriptor instead.
+func (*ReadLVResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ReadLVResponse) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *ReadLVResponse) GetOffset() uint64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReadLVResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// Represents the input for PrepareLVCopy.
+//
+// lvmd takes a snapshot of the volume for the copy, which is served to the peers by ReadLV.
+// The snapshot of a thick volume has the same size as the volume so that it does not overflow.
+type PrepareLVCopyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name to be copied.
+	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	CopyId        string                 `protobuf:"bytes,3,opt,name=copy_id,json=copyId,proto3" json:"copy_id,omitempty"` // The ID of the copy, which is the name of the destination logical volume.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PrepareLVCopyRequest) Reset() {
+	*x = PrepareLVCopyRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PrepareLVCopyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PrepareLVCopyRequest) ProtoMessage() {}
+
+func (x *PrepareLVCopyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PrepareLVCopyRequest.ProtoReflect.Descriptor instead.
+func (*PrepareLVCopyRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *PrepareLVCopyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PrepareLVCopyRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *PrepareLVCopyRequest) GetCopyId() string {
+	if x != nil {
+		return x.CopyId
+	}
+	return ""
+}
+
+// Represents the input for RemoveLVCopy.
+//
+// lvmd removes the snapshot taken for the copy in any device class.
+type RemoveLVCopyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CopyId        string                 `protobuf:"bytes,1,opt,name=copy_id,json=copyId,proto3" json:"copy_id,omitempty"` // The ID of the copy given to PrepareLVCopy.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveLVCopyRequest) Reset() {
+	*x = RemoveLVCopyRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveLVCopyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveLVCopyRequest) ProtoMessage() {}
+
+func (x *RemoveLVCopyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveLVCopyRequest.ProtoReflect.Descriptor instead.
+func (*RemoveLVCopyRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *RemoveLVCopyRequest) GetCopyId() string {
+	if x != nil {
+		return x.CopyId
+	}
+	return ""
+}
+
+// Represents the input for CopyLV.
+//
+// lvmd creates the logical volume and copies the source volume on the same node into it.
+// If both device classes are in the same thin pool, a thin snapshot of the source is created instead.
+type CopyLVRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Name                string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The logical volume name.
+	DeviceClass         string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	LvcreateOptionClass string                 `protobuf:"bytes,3,opt,name=lvcreate_option_class,json=lvcreateOptionClass,proto3" json:"lvcreate_option_class,omitempty"`
+	SizeBytes           int64                  `protobuf:"varint,4,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`         // Volume size in canonical CSI bytes.
+	SourceVolume        string                 `protobuf:"bytes,5,opt,name=source_volume,json=sourceVolume,proto3" json:"source_volume,omitempty"` // The logical volume name to be copied.
+	SourceDeviceClass   string                 `protobuf:"bytes,6,opt,name=source_device_class,json=sourceDeviceClass,proto3" json:"source_device_class,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *CopyLVRequest) Reset() {
+	*x = CopyLVRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyLVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyLVRequest) ProtoMessage() {}
+
+func (x *CopyLVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyLVRequest.ProtoReflect.Descriptor instead.
+func (*CopyLVRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *CopyLVRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CopyLVRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *CopyLVRequest) GetLvcreateOptionClass() string {
+	if x != nil {
+		return x.LvcreateOptionClass
+	}
+	return ""
+}
+
+func (x *CopyLVRequest) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *CopyLVRequest) GetSourceVolume() string {
+	if x != nil {
+		return x.SourceVolume
+	}
+	return ""
+}
+
+func (x *CopyLVRequest) GetSourceDeviceClass() string {
+	if x != nil {
+		return x.SourceDeviceClass
+	}
+	return ""
+}
+
+// Represents the response of CopyLV.
+type CopyLVResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volume        *LogicalVolume         `protobuf:"bytes,1,opt,name=volume,proto3" json:"volume,omitempty"`                               // Information of the created volume.
+	CopiedBytes   uint64                 `protobuf:"varint,2,opt,name=copied_bytes,json=copiedBytes,proto3" json:"copied_bytes,omitempty"` // Bytes copied from the source. It is zero for a thin snapshot.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CopyLVResponse) Reset() {
+	*x = CopyLVResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CopyLVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CopyLVResponse) ProtoMessage() {}
+
+func (x *CopyLVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CopyLVResponse.ProtoReflect.Descriptor instead.
+func (*CopyLVResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CopyLVResponse) GetVolume() *LogicalVolume {
+	if x != nil {
+		return x.Volume
+	}
+	return nil
+}
+
+func (x *CopyLVResponse) GetCopiedBytes() uint64 {
+	if x != nil {
+		return x.CopiedBytes
+	}
+	return 0
+}
+
+// Represents the input for UndeleteLV.
+//
+// The removed volume must still be in the trash of the device class.
+// It is renamed to "new_name", and expanded to at least "size_bytes".
+type UndeleteLVRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The name of the removed logical volume.
+	DeviceClass   string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	NewName       string                 `protobuf:"bytes,3,opt,name=new_name,json=newName,proto3" json:"new_name,omitempty"`        // The logical volume name to restore the volume as.
+	SizeBytes     int64                  `protobuf:"varint,4,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Volume size in canonical CSI bytes.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndeleteLVRequest) Reset() {
+	*x = UndeleteLVRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndeleteLVRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndeleteLVRequest) ProtoMessage() {}
+
+func (x *UndeleteLVRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndeleteLVRequest.ProtoReflect.Descriptor instead.
+func (*UndeleteLVRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *UndeleteLVRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UndeleteLVRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *UndeleteLVRequest) GetNewName() string {
+	if x != nil {
+		return x.NewName
+	}
+	return ""
+}
+
+func (x *UndeleteLVRequest) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+// Represents the response of UndeleteLV.
+type UndeleteLVResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volume        *LogicalVolume         `protobuf:"bytes,1,opt,name=volume,proto3" json:"volume,omitempty"` // Information of the restored volume.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UndeleteLVResponse) Reset() {
+	*x = UndeleteLVResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UndeleteLVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UndeleteLVResponse) ProtoMessage() {}
+
+func (x *UndeleteLVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UndeleteLVResponse.ProtoReflect.Descriptor instead.
+func (*UndeleteLVResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *UndeleteLVResponse) GetVolume() *LogicalVolume {
+	if x != nil {
+		return x.Volume
+	}
+	return nil
+}
+
+// Represents the response of GetLVList.
+type GetLVListResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Volumes       []*LogicalVolume       `protobuf:"bytes,1,rep,name=volumes,proto3" json:"volumes,omitempty"` // Information of volumes.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLVListResponse) Reset() {
+	*x = GetLVListResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLVListResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLVListResponse) ProtoMessage() {}
+
+func (x *GetLVListResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLVListResponse.ProtoReflect.Descriptor instead.
+func (*GetLVListResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GetLVListResponse) GetVolumes() []*LogicalVolume {
+	if x != nil {
+		return x.Volumes
+	}
+	return nil
+}
+
+// Represents the response of GetFreeBytes.
+type GetFreeBytesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FreeBytes     uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space of the volume group in bytes.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFreeBytesResponse) Reset() {
+	*x = GetFreeBytesResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFreeBytesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFreeBytesResponse) ProtoMessage() {}
+
+func (x *GetFreeBytesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFreeBytesResponse.ProtoReflect.Descriptor instead.
+func (*GetFreeBytesResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetFreeBytesResponse) GetFreeBytes() uint64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+type GetLVListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceClass   string                 `protobuf:"bytes,1,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLVListRequest) Reset() {
+	*x = GetLVListRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLVListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLVListRequest) ProtoMessage() {}
+
+func (x *GetLVListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLVListRequest.ProtoReflect.Descriptor instead.
+func (*GetLVListRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetLVListRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+type GetFreeBytesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	DeviceClass   string                 `protobuf:"bytes,1,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFreeBytesRequest) Reset() {
+	*x = GetFreeBytesRequest{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFreeBytesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFreeBytesRequest) ProtoMessage() {}
+
+func (x *GetFreeBytesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFreeBytesRequest.ProtoReflect.Descriptor instead.
+func (*GetFreeBytesRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *GetFreeBytesRequest) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+// Represents the stream output from Watch.
+type WatchResponse struct {
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	FreeBytes             uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space of the default volume group in bytes. In the case of thin pools, free space on the thinpool with overprovision in bytes.
+	Items                 []*WatchItem           `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`
+	LvcreateOptionClasses []string               `protobuf:"bytes,3,rep,name=lvcreate_option_classes,json=lvcreateOptionClasses,proto3" json:"lvcreate_option_classes,omitempty"` // Names of the lvcreate-option-classes configured in LVMd.
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *WatchResponse) GetFreeBytes() uint64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *WatchResponse) GetItems() []*WatchItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *WatchResponse) GetLvcreateOptionClasses() []string {
+	if x != nil {
+		return x.LvcreateOptionClasses
+	}
+	return nil
+}
+
+// Represents the details of thinpool.
+type ThinPoolItem struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	DataPercent        float64                `protobuf:"fixed64,1,opt,name=data_percent,json=dataPercent,proto3" json:"data_percent,omitempty"`                     // Data percent occupied on the thinpool, used for monitoring.
+	MetadataPercent    float64                `protobuf:"fixed64,2,opt,name=metadata_percent,json=metadataPercent,proto3" json:"metadata_percent,omitempty"`         // Metadata percent occupied on the thinpool, used for monitoring.
+	OverprovisionBytes uint64                 `protobuf:"varint,3,opt,name=overprovision_bytes,json=overprovisionBytes,proto3" json:"overprovision_bytes,omitempty"` // Free space on the thinpool with overprovision, used for annotating node.
+	SizeBytes          uint64                 `protobuf:"varint,4,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`                            // Physical data space size of the thinpool.
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ThinPoolItem) Reset() {
+	*x = ThinPoolItem{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThinPoolItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThinPoolItem) ProtoMessage() {}
+
+func (x *ThinPoolItem) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThinPoolItem.ProtoReflect.Descriptor instead.
+func (*ThinPoolItem) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ThinPoolItem) GetDataPercent() float64 {
+	if x != nil {
+		return x.DataPercent
+	}
+	return 0
+}
+
+func (x *ThinPoolItem) GetMetadataPercent() float64 {
+	if x != nil {
+		return x.MetadataPercent
+	}
+	return 0
+}
+
+func (x *ThinPoolItem) GetOverprovisionBytes() uint64 {
+	if x != nil {
+		return x.OverprovisionBytes
+	}
+	return 0
+}
+
+func (x *ThinPoolItem) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+// Represents a physical volume of a volume group.
+type PhysicalVolumeItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`                             // Device path of the physical volume.
+	SizeBytes     uint64                 `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of the physical volume in bytes.
+	FreeBytes     uint64                 `protobuf:"varint,3,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space in the physical volume in bytes.
+	Missing       bool                   `protobuf:"varint,4,opt,name=missing,proto3" json:"missing,omitempty"`                      // True if the physical volume is missing.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PhysicalVolumeItem) Reset() {
+	*x = PhysicalVolumeItem{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PhysicalVolumeItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PhysicalVolumeItem) ProtoMessage() {}
+
+func (x *PhysicalVolumeItem) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PhysicalVolumeItem.ProtoReflect.Descriptor instead.
+func (*PhysicalVolumeItem) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *PhysicalVolumeItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PhysicalVolumeItem) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *PhysicalVolumeItem) GetFreeBytes() uint64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *PhysicalVolumeItem) GetMissing() bool {
+	if x != nil {
+		return x.Missing
+	}
+	return false
+}
+
+// Represents the response corresponding to device class targets.
+type WatchItem struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	FreeBytes        uint64                 `protobuf:"varint,1,opt,name=free_bytes,json=freeBytes,proto3" json:"free_bytes,omitempty"` // Free space in the volume group in bytes.
+	DeviceClass      string                 `protobuf:"bytes,2,opt,name=device_class,json=deviceClass,proto3" json:"device_class,omitempty"`
+	SizeBytes        uint64                 `protobuf:"varint,3,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"` // Size of volume group in bytes.
+	ThinPool         *ThinPoolItem          `protobuf:"bytes,4,opt,name=thin_pool,json=thinPool,proto3" json:"thin_pool,omitempty"`
+	VolumeGroup      string                 `protobuf:"bytes,5,opt,name=volume_group,json=volumeGroup,proto3" json:"volume_group,omitempty"` // Name of the volume group.
+	PhysicalVolumes  []*PhysicalVolumeItem  `protobuf:"bytes,6,rep,name=physical_volumes,json=physicalVolumes,proto3" json:"physical_volumes,omitempty"`
+	HealthError      string                 `protobuf:"bytes,7,opt,name=health_error,json=healthError,proto3" json:"health_error,omitempty"`                   // Reason why the volume group or the thin pool is unhealthy. Empty if healthy.
+	Default          bool                   `protobuf:"varint,8,opt,name=default,proto3" json:"default,omitempty"`                                             // True if the device class is the default one.
+	PendingFreeBytes uint64                 `protobuf:"varint,9,opt,name=pending_free_bytes,json=pendingFreeBytes,proto3" json:"pending_free_bytes,omitempty"` // Size of the volumes being wiped, which become free after the wipe.
+	Wipes            []*WipeItem            `protobuf:"bytes,10,rep,name=wipes,proto3" json:"wipes,omitempty"`                                                 // Volumes being wiped.
+	TrashBytes       uint64                 `protobuf:"varint,11,opt,name=trash_bytes,json=trashBytes,proto3" json:"trash_bytes,omitempty"`                    // Size of the removed volumes kept in the trash.
+	Trash            []*TrashItem           `protobuf:"bytes,12,rep,name=trash,proto3" json:"trash,omitempty"`                                                 // Removed volumes kept in the trash.
+	VolumeGroupUuid  string                 `protobuf:"bytes,13,opt,name=volume_group_uuid,json=volumeGroupUuid,proto3" json:"volume_group_uuid,omitempty"`    // UUID of the volume group, which identifies the disks across re-registrations of the node.
+	FsType           string                 `protobuf:"bytes,14,opt,name=fs_type,json=fsType,proto3" json:"fs_type,omitempty"`                                 // Default filesystem of the volumes. Empty if not configured.
+	MkfsOptions      []*MkfsOptions         `protobuf:"bytes,15,rep,name=mkfs_options,json=mkfsOptions,proto3" json:"mkfs_options,omitempty"`                  // Default extra arguments of mkfs for each filesystem.
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *WatchItem) Reset() {
+	*x = WatchItem{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchItem) ProtoMessage() {}
+
+func (x *WatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchItem.ProtoReflect.Descriptor instead.
+func (*WatchItem) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *WatchItem) GetFreeBytes() uint64 {
+	if x != nil {
+		return x.FreeBytes
+	}
+	return 0
+}
+
+func (x *WatchItem) GetDeviceClass() string {
+	if x != nil {
+		return x.DeviceClass
+	}
+	return ""
+}
+
+func (x *WatchItem) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *WatchItem) GetThinPool() *ThinPoolItem {
+	if x != nil {
+		return x.ThinPool
+	}
+	return nil
+}
+
+func (x *WatchItem) GetVolumeGroup() string {
+	if x != nil {
+		return x.VolumeGroup
+	}
+	return ""
+}
+
+func (x *WatchItem) GetPhysicalVolumes() []*PhysicalVolumeItem {
+	if x != nil {
+		return x.PhysicalVolumes
+	}
+	return nil
+}
+
+func (x *WatchItem) GetHealthError() string {
+	if x != nil {
+		return x.HealthError
+	}
+	return ""
+}
+
+func (x *WatchItem) GetDefault() bool {
+	if x != nil {
+		return x.Default
+	}
+	return false
+}
+
+func (x *WatchItem) GetPendingFreeBytes() uint64 {
+	if x != nil {
+		return x.PendingFreeBytes
+	}
+	return 0
+}
+
+func (x *WatchItem) GetWipes() []*WipeItem {
+	if x != nil {
+		return x.Wipes
+	}
+	return nil
+}
+
+func (x *WatchItem) GetTrashBytes() uint64 {
+	if x != nil {
+		return x.TrashBytes
+	}
+	return 0
+}
+
+func (x *WatchItem) GetTrash() []*TrashItem {
+	if x != nil {
+		return x.Trash
+	}
+	return nil
+}
+
+func (x *WatchItem) GetVolumeGroupUuid() string {
+	if x != nil {
+		return x.VolumeGroupUuid
+	}
+	return ""
+}
+
+func (x *WatchItem) GetFsType() string {
+	if x != nil {
+		return x.FsType
+	}
+	return ""
+}
+
+func (x *WatchItem) GetMkfsOptions() []*MkfsOptions {
+	if x != nil {
+		return x.MkfsOptions
+	}
+	return nil
+}
+
+// Represents the extra arguments of mkfs for a filesystem.
+type MkfsOptions struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FsType        string                 `protobuf:"bytes,1,opt,name=fs_type,json=fsType,proto3" json:"fs_type,omitempty"`
+	Options       []string               `protobuf:"bytes,2,rep,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MkfsOptions) Reset() {
+	*x = MkfsOptions{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MkfsOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MkfsOptions) ProtoMessage() {}
+
+func (x *MkfsOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MkfsOptions.ProtoReflect.Descriptor instead.
+func (*MkfsOptions) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *MkfsOptions) GetFsType() string {
+	if x != nil {
+		return x.FsType
+	}
+	return ""
+}
+
+func (x *MkfsOptions) GetOptions() []string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// Represents the progress of wiping a removed volume.
+type WipeItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The name of the removed volume.
+	SizeBytes     uint64                 `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	WipedBytes    uint64                 `protobuf:"varint,3,opt,name=wiped_bytes,json=wipedBytes,proto3" json:"wiped_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WipeItem) Reset() {
+	*x = WipeItem{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WipeItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WipeItem) ProtoMessage() {}
+
+func (x *WipeItem) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WipeItem.ProtoReflect.Descriptor instead.
+func (*WipeItem) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *WipeItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WipeItem) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *WipeItem) GetWipedBytes() uint64 {
+	if x != nil {
+		return x.WipedBytes
+	}
+	return 0
+}
+
+// Represents a removed volume kept in the trash until it expires.
+type TrashItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // The name of the removed volume.
+	SizeBytes     uint64                 `protobuf:"varint,2,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	TrashedAt     int64                  `protobuf:"varint,3,opt,name=trashed_at,json=trashedAt,proto3" json:"trashed_at,omitempty"` // Unix time when the volume was removed.
+	ExpiresAt     int64                  `protobuf:"varint,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // Unix time when the volume is purged.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrashItem) Reset() {
+	*x = TrashItem{}
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrashItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrashItem) ProtoMessage() {}
+
+func (x *TrashItem) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_lvmd_proto_lvmd_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrashItem.ProtoReflect.Descriptor instead.
+func (*TrashItem) Descriptor() ([]byte, []int) {
+	return file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *TrashItem) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TrashItem) GetSizeBytes() uint64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *TrashItem) GetTrashedAt() int64 {
+	if x != nil {
+		return x.TrashedAt
+	}
+	return 0
+}
+
+func (x *TrashItem) GetExpiresAt() int64 {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return 0
+}
+
+var File_pkg_lvmd_proto_lvmd_proto protoreflect.FileDescriptor
+
+const file_pkg_lvmd_proto_lvmd_proto_rawDesc = "" +
+	"\n" +
+	"\x19pkg/lvmd/proto/lvmd.proto\x12\x05proto\"\a\n" +
+	"\x05Empty\"\xbe\x01\n" +
+	"\rLogicalVolume\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tdev_major\x18\x03 \x01(\rR\bdevMajor\x12\x1b\n" +
+	"\tdev_minor\x18\x04 \x01(\rR\bdevMinor\x12\x12\n" +
+	"\x04tags\x18\x05 \x03(\tR\x04tags\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x06 \x01(\x03R\tsizeBytes\x12\x12\n" +
+	"\x04path\x18\a \x01(\tR\x04path\x12\x12\n" +
+	"\x04attr\x18\b \x01(\tR\x04attrJ\x04\b\x02\x10\x03\"\xf1\x01\n" +
+	"\x0fCreateLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12!\n" +
+	"\fdevice_class\x18\x04 \x01(\tR\vdeviceClass\x122\n" +
+	"\x15lvcreate_option_class\x18\x05 \x01(\tR\x13lvcreateOptionClass\x12\x1d\n" +
 	"\n" +
-	"size_bytes\x18\x06 \x01(\x03R\tsizeBytesJ\x04\b\x02\x10\x03\"@\n" +
+	"size_bytes\x18\x06 \x01(\x03R\tsizeBytes\x12\x17\n" +
+	"\afs_type\x18\a \x01(\tR\x06fsType\x12!\n" +
+	"\fmkfs_options\x18\b \x03(\tR\vmkfsOptionsJ\x04\b\x02\x10\x03\"@\n" +
 	"\x10CreateLVResponse\x12,\n" +
-	"\x06volume\x18\x01 \x01(\v2\x14.proto.LogicalVolumeR\x06volume\"H\n" +
+	"\x06volume\x18\x01 \x01(\v2\x14.proto.LogicalVolumeR\x06volume\"g\n" +
 	"\x0fRemoveLVRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
-	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\"\xcf\x01\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x1d\n" +
+	"\n" +
+	"skip_trash\x18\x03 \x01(\bR\tskipTrash\"\xcf\x01\n" +
 	"\x17CreateLVSnapshotRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
 	"\x04tags\x18\x02 \x03(\tR\x04tags\x12!\n" +
@@ -982,7 +2483,87 @@ const file_pkg_lvmd_proto_lvmd_proto_rawDesc = "" +
 	"\fdevice_class\x18\x03 \x01(\tR\vdeviceClassJ\x04\b\x02\x10\x03\"1\n" +
 	"\x10ResizeLVResponse\x12\x1d\n" +
 	"\n" +
-	"size_bytes\x18\x01 \x01(\x03R\tsizeBytes\"C\n" +
+	"size_bytes\x18\x01 \x01(\x03R\tsizeBytes\"k\n" +
+	"\x16MergeLVSnapshotRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bsnapshot\x18\x02 \x01(\tR\bsnapshot\x12!\n" +
+	"\fdevice_class\x18\x03 \x01(\tR\vdeviceClass\"G\n" +
+	"\x17MergeLVSnapshotResponse\x12,\n" +
+	"\x06volume\x18\x01 \x01(\v2\x14.proto.LogicalVolumeR\x06volume\"<\n" +
+	"\n" +
+	"BlockRange\x12\x16\n" +
+	"\x06offset\x18\x01 \x01(\x04R\x06offset\x12\x16\n" +
+	"\x06length\x18\x02 \x01(\x04R\x06length\"\xb9\x01\n" +
+	"\x19GetLVBlockMetadataRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1b\n" +
+	"\tbase_name\x18\x02 \x01(\tR\bbaseName\x12!\n" +
+	"\fdevice_class\x18\x03 \x01(\tR\vdeviceClass\x12'\n" +
+	"\x0fstarting_offset\x18\x04 \x01(\x04R\x0estartingOffset\x12\x1f\n" +
+	"\vmax_results\x18\x05 \x01(\x05R\n" +
+	"maxResults\"f\n" +
+	"\x1aGetLVBlockMetadataResponse\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x01 \x01(\x04R\tsizeBytes\x12)\n" +
+	"\x06ranges\x18\x02 \x03(\v2\x11.proto.BlockRangeR\x06ranges\"\xdb\x01\n" +
+	"\x12ReplicateLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x1a\n" +
+	"\bsnapshot\x18\x03 \x01(\tR\bsnapshot\x12#\n" +
+	"\rbase_snapshot\x18\x04 \x01(\tR\fbaseSnapshot\x12!\n" +
+	"\fpeer_address\x18\x05 \x01(\tR\vpeerAddress\x12*\n" +
+	"\x11peer_device_class\x18\x06 \x01(\tR\x0fpeerDeviceClass\"a\n" +
+	"\x13ReplicateLVResponse\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x01 \x01(\x04R\tsizeBytes\x12+\n" +
+	"\x11transferred_bytes\x18\x02 \x01(\x04R\x10transferredBytes\"\xb9\x01\n" +
+	"\x13ApplyLVDeltaRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x03 \x01(\x04R\tsizeBytes\x12 \n" +
+	"\vincremental\x18\x04 \x01(\bR\vincremental\x12\x16\n" +
+	"\x06offset\x18\x05 \x01(\x04R\x06offset\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data\"i\n" +
+	"\x14ApplyLVDeltaResponse\x12,\n" +
+	"\x06volume\x18\x01 \x01(\v2\x14.proto.LogicalVolumeR\x06volume\x12#\n" +
+	"\rapplied_bytes\x18\x02 \x01(\x04R\fappliedBytes\"O\n" +
+	"\x16RemoveLVReplicaRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\"_\n" +
+	"\rReadLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x17\n" +
+	"\acopy_id\x18\x03 \x01(\tR\x06copyId\"[\n" +
+	"\x0eReadLVResponse\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x01 \x01(\x04R\tsizeBytes\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x04R\x06offset\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\"f\n" +
+	"\x14PrepareLVCopyRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x17\n" +
+	"\acopy_id\x18\x03 \x01(\tR\x06copyId\".\n" +
+	"\x13RemoveLVCopyRequest\x12\x17\n" +
+	"\acopy_id\x18\x01 \x01(\tR\x06copyId\"\xee\x01\n" +
+	"\rCopyLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x122\n" +
+	"\x15lvcreate_option_class\x18\x03 \x01(\tR\x13lvcreateOptionClass\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x04 \x01(\x03R\tsizeBytes\x12#\n" +
+	"\rsource_volume\x18\x05 \x01(\tR\fsourceVolume\x12.\n" +
+	"\x13source_device_class\x18\x06 \x01(\tR\x11sourceDeviceClass\"a\n" +
+	"\x0eCopyLVResponse\x12,\n" +
+	"\x06volume\x18\x01 \x01(\v2\x14.proto.LogicalVolumeR\x06volume\x12!\n" +
+	"\fcopied_bytes\x18\x02 \x01(\x04R\vcopiedBytes\"\x84\x01\n" +
+	"\x11UndeleteLVRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12!\n" +
+	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x19\n" +
+	"\bnew_name\x18\x03 \x01(\tR\anewName\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x04 \x01(\x03R\tsizeBytes\"B\n" +
+	"\x12UndeleteLVResponse\x12,\n" +
+	"\x06volume\x18\x01 \x01(\v2\x14.proto.LogicalVolumeR\x06volume\"C\n" +
 	"\x11GetLVListResponse\x12.\n" +
 	"\avolumes\x18\x01 \x03(\v2\x14.proto.LogicalVolumeR\avolumes\"5\n" +
 	"\x14GetFreeBytesResponse\x12\x1d\n" +
@@ -991,29 +2572,80 @@ const file_pkg_lvmd_proto_lvmd_proto_rawDesc = "" +
 	"\x10GetLVListRequest\x12!\n" +
 	"\fdevice_class\x18\x01 \x01(\tR\vdeviceClass\"8\n" +
 	"\x13GetFreeBytesRequest\x12!\n" +
-	"\fdevice_class\x18\x01 \x01(\tR\vdeviceClass\"V\n" +
+	"\fdevice_class\x18\x01 \x01(\tR\vdeviceClass\"\x8e\x01\n" +
 	"\rWatchResponse\x12\x1d\n" +
 	"\n" +
 	"free_bytes\x18\x01 \x01(\x04R\tfreeBytes\x12&\n" +
-	"\x05items\x18\x02 \x03(\v2\x10.proto.WatchItemR\x05items\"\xac\x01\n" +
+	"\x05items\x18\x02 \x03(\v2\x10.proto.WatchItemR\x05items\x126\n" +
+	"\x17lvcreate_option_classes\x18\x03 \x03(\tR\x15lvcreateOptionClasses\"\xac\x01\n" +
 	"\fThinPoolItem\x12!\n" +
 	"\fdata_percent\x18\x01 \x01(\x01R\vdataPercent\x12)\n" +
 	"\x10metadata_percent\x18\x02 \x01(\x01R\x0fmetadataPercent\x12/\n" +
 	"\x13overprovision_bytes\x18\x03 \x01(\x04R\x12overprovisionBytes\x12\x1d\n" +
 	"\n" +
-	"size_bytes\x18\x04 \x01(\x04R\tsizeBytes\"\x9e\x01\n" +
+	"size_bytes\x18\x04 \x01(\x04R\tsizeBytes\"\x80\x01\n" +
+	"\x12PhysicalVolumeItem\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x02 \x01(\x04R\tsizeBytes\x12\x1d\n" +
+	"\n" +
+	"free_bytes\x18\x03 \x01(\x04R\tfreeBytes\x12\x18\n" +
+	"\amissing\x18\x04 \x01(\bR\amissing\"\xde\x04\n" +
 	"\tWatchItem\x12\x1d\n" +
 	"\n" +
 	"free_bytes\x18\x01 \x01(\x04R\tfreeBytes\x12!\n" +
 	"\fdevice_class\x18\x02 \x01(\tR\vdeviceClass\x12\x1d\n" +
 	"\n" +
 	"size_bytes\x18\x03 \x01(\x04R\tsizeBytes\x120\n" +
-	"\tthin_pool\x18\x04 \x01(\v2\x13.proto.ThinPoolItemR\bthinPool2\x8c\x02\n" +
+	"\tthin_pool\x18\x04 \x01(\v2\x13.proto.ThinPoolItemR\bthinPool\x12!\n" +
+	"\fvolume_group\x18\x05 \x01(\tR\vvolumeGroup\x12D\n" +
+	"\x10physical_volumes\x18\x06 \x03(\v2\x19.proto.PhysicalVolumeItemR\x0fphysicalVolumes\x12!\n" +
+	"\fhealth_error\x18\a \x01(\tR\vhealthError\x12\x18\n" +
+	"\adefault\x18\b \x01(\bR\adefault\x12,\n" +
+	"\x12pending_free_bytes\x18\t \x01(\x04R\x10pendingFreeBytes\x12%\n" +
+	"\x05wipes\x18\n" +
+	" \x03(\v2\x0f.proto.WipeItemR\x05wipes\x12\x1f\n" +
+	"\vtrash_bytes\x18\v \x01(\x04R\n" +
+	"trashBytes\x12&\n" +
+	"\x05trash\x18\f \x03(\v2\x10.proto.TrashItemR\x05trash\x12*\n" +
+	"\x11volume_group_uuid\x18\r \x01(\tR\x0fvolumeGroupUuid\x12\x17\n" +
+	"\afs_type\x18\x0e \x01(\tR\x06fsType\x125\n" +
+	"\fmkfs_options\x18\x0f \x03(\v2\x12.proto.MkfsOptionsR\vmkfsOptions\"@\n" +
+	"\vMkfsOptions\x12\x17\n" +
+	"\afs_type\x18\x01 \x01(\tR\x06fsType\x12\x18\n" +
+	"\aoptions\x18\x02 \x03(\tR\aoptions\"^\n" +
+	"\bWipeItem\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x02 \x01(\x04R\tsizeBytes\x12\x1f\n" +
+	"\vwiped_bytes\x18\x03 \x01(\x04R\n" +
+	"wipedBytes\"|\n" +
+	"\tTrashItem\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\x02 \x01(\x04R\tsizeBytes\x12\x1d\n" +
+	"\n" +
+	"trashed_at\x18\x03 \x01(\x03R\ttrashedAt\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\x03R\texpiresAt2\xf1\x05\n" +
 	"\tLVService\x12;\n" +
 	"\bCreateLV\x12\x16.proto.CreateLVRequest\x1a\x17.proto.CreateLVResponse\x120\n" +
 	"\bRemoveLV\x12\x16.proto.RemoveLVRequest\x1a\f.proto.Empty\x12;\n" +
 	"\bResizeLV\x12\x16.proto.ResizeLVRequest\x1a\x17.proto.ResizeLVResponse\x12S\n" +
-	"\x10CreateLVSnapshot\x12\x1e.proto.CreateLVSnapshotRequest\x1a\x1f.proto.CreateLVSnapshotResponse2\xc3\x01\n" +
+	"\x10CreateLVSnapshot\x12\x1e.proto.CreateLVSnapshotRequest\x1a\x1f.proto.CreateLVSnapshotResponse\x12P\n" +
+	"\x0fMergeLVSnapshot\x12\x1d.proto.MergeLVSnapshotRequest\x1a\x1e.proto.MergeLVSnapshotResponse\x12[\n" +
+	"\x12GetLVBlockMetadata\x12 .proto.GetLVBlockMetadataRequest\x1a!.proto.GetLVBlockMetadataResponse0\x01\x12D\n" +
+	"\vReplicateLV\x12\x19.proto.ReplicateLVRequest\x1a\x1a.proto.ReplicateLVResponse\x125\n" +
+	"\x06CopyLV\x12\x14.proto.CopyLVRequest\x1a\x15.proto.CopyLVResponse\x12A\n" +
+	"\n" +
+	"UndeleteLV\x12\x18.proto.UndeleteLVRequest\x1a\x19.proto.UndeleteLVResponse\x12:\n" +
+	"\rPrepareLVCopy\x12\x1b.proto.PrepareLVCopyRequest\x1a\f.proto.Empty\x128\n" +
+	"\fRemoveLVCopy\x12\x1a.proto.RemoveLVCopyRequest\x1a\f.proto.Empty2\xb5\x02\n" +
+	"\x12ReplicationService\x12I\n" +
+	"\fApplyLVDelta\x12\x1a.proto.ApplyLVDeltaRequest\x1a\x1b.proto.ApplyLVDeltaResponse(\x01\x12>\n" +
+	"\x0fRemoveLVReplica\x12\x1d.proto.RemoveLVReplicaRequest\x1a\f.proto.Empty\x127\n" +
+	"\x06ReadLV\x12\x14.proto.ReadLVRequest\x1a\x15.proto.ReadLVResponse0\x01\x12[\n" +
+	"\x12GetLVBlockMetadata\x12 .proto.GetLVBlockMetadataRequest\x1a!.proto.GetLVBlockMetadataResponse0\x012\xc3\x01\n" +
 	"\tVGService\x12>\n" +
 	"\tGetLVList\x12\x17.proto.GetLVListRequest\x1a\x18.proto.GetLVListResponse\x12G\n" +
 	"\fGetFreeBytes\x12\x1a.proto.GetFreeBytesRequest\x1a\x1b.proto.GetFreeBytesResponse\x12-\n" +
@@ -1031,50 +2663,103 @@ func file_pkg_lvmd_proto_lvmd_proto_rawDescGZIP() []byte {
 	return file_pkg_lvmd_proto_lvmd_proto_rawDescData
 }
 
-var file_pkg_lvmd_proto_lvmd_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_pkg_lvmd_proto_lvmd_proto_msgTypes = make([]protoimpl.MessageInfo, 38)
 var file_pkg_lvmd_proto_lvmd_proto_goTypes = []any{
-	(*Empty)(nil),                    // 0: proto.Empty
-	(*LogicalVolume)(nil),            // 1: proto.LogicalVolume
-	(*CreateLVRequest)(nil),          // 2: proto.CreateLVRequest
-	(*CreateLVResponse)(nil),         // 3: proto.CreateLVResponse
-	(*RemoveLVRequest)(nil),          // 4: proto.RemoveLVRequest
-	(*CreateLVSnapshotRequest)(nil),  // 5: proto.CreateLVSnapshotRequest
-	(*CreateLVSnapshotResponse)(nil), // 6: proto.CreateLVSnapshotResponse
-	(*ResizeLVRequest)(nil),          // 7: proto.ResizeLVRequest
-	(*ResizeLVResponse)(nil),         // 8: proto.ResizeLVResponse
-	(*GetLVListResponse)(nil),        // 9: proto.GetLVListResponse
-	(*GetFreeBytesResponse)(nil),     // 10: proto.GetFreeBytesResponse
-	(*GetLVListRequest)(nil),         // 11: proto.GetLVListRequest
-	(*GetFreeBytesRequest)(nil),      // 12: proto.GetFreeBytesRequest
-	(*WatchResponse)(nil),            // 13: proto.WatchResponse
-	(*ThinPoolItem)(nil),             // 14: proto.ThinPoolItem
-	(*WatchItem)(nil),                // 15: proto.WatchItem
+	(*Empty)(nil),                      // 0: proto.Empty
+	(*LogicalVolume)(nil),              // 1: proto.LogicalVolume
+	(*CreateLVRequest)(nil),            // 2: proto.CreateLVRequest
+	(*CreateLVResponse)(nil),           // 3: proto.CreateLVResponse
+	(*RemoveLVRequest)(nil),            // 4: proto.RemoveLVRequest
+	(*CreateLVSnapshotRequest)(nil),    // 5: proto.CreateLVSnapshotRequest
+	(*CreateLVSnapshotResponse)(nil),   // 6: proto.CreateLVSnapshotResponse
+	(*ResizeLVRequest)(nil),            // 7: proto.ResizeLVRequest
+	(*ResizeLVResponse)(nil),           // 8: proto.ResizeLVResponse
+	(*MergeLVSnapshotRequest)(nil),     // 9: proto.MergeLVSnapshotRequest
+	(*MergeLVSnapshotResponse)(nil),    // 10: proto.MergeLVSnapshotResponse
+	(*BlockRange)(nil),                 // 11: proto.BlockRange
+	(*GetLVBlockMetadataRequest)(nil),  // 12: proto.GetLVBlockMetadataRequest
+	(*GetLVBlockMetadataResponse)(nil), // 13: proto.GetLVBlockMetadataResponse
+	(*ReplicateLVRequest)(nil),         // 14: proto.ReplicateLVRequest
+	(*ReplicateLVResponse)(nil),        // 15: proto.ReplicateLVResponse
+	(*ApplyLVDeltaRequest)(nil),        // 16: proto.ApplyLVDeltaRequest
+	(*ApplyLVDeltaResponse)(nil),       // 17: proto.ApplyLVDeltaResponse
+	(*RemoveLVReplicaRequest)(nil),     // 18: proto.RemoveLVReplicaRequest
+	(*ReadLVRequest)(nil),              // 19: proto.ReadLVRequest
+	(*ReadLVResponse)(nil),             // 20: proto.ReadLVResponse
+	(*PrepareLVCopyRequest)(nil),       // 21: proto.PrepareLVCopyRequest
+	(*RemoveLVCopyRequest)(nil),        // 22: proto.RemoveLVCopyRequest
+	(*CopyLVRequest)(nil),              // 23: proto.CopyLVRequest
+	(*CopyLVResponse)(nil),             // 24: proto.CopyLVResponse
+	(*UndeleteLVRequest)(nil),          // 25: proto.UndeleteLVRequest
+	(*UndeleteLVResponse)(nil),         // 26: proto.UndeleteLVResponse
+	(*GetLVListResponse)(nil),          // 27: proto.GetLVListResponse
+	(*GetFreeBytesResponse)(nil),       // 28: proto.GetFreeBytesResponse
+	(*GetLVListRequest)(nil),           // 29: proto.GetLVListRequest
+	(*GetFreeBytesRequest)(nil),        // 30: proto.GetFreeBytesRequest
+	(*WatchResponse)(nil),              // 31: proto.WatchResponse
+	(*ThinPoolItem)(nil),               // 32: proto.ThinPoolItem
+	(*PhysicalVolumeItem)(nil),         // 33: proto.PhysicalVolumeItem
+	(*WatchItem)(nil),                  // 34: proto.WatchItem
+	(*MkfsOptions)(nil),                // 35: proto.MkfsOptions
+	(*WipeItem)(nil),                   // 36: proto.WipeItem
+	(*TrashItem)(nil),                  // 37: proto.TrashItem
 }
 var file_pkg_lvmd_proto_lvmd_proto_depIdxs = []int32{
 	1,  // 0: proto.CreateLVResponse.volume:type_name -> proto.LogicalVolume
 	1,  // 1: proto.CreateLVSnapshotResponse.snapshot:type_name -> proto.LogicalVolume
-	1,  // 2: proto.GetLVListResponse.volumes:type_name -> proto.LogicalVolume
-	15, // 3: proto.WatchResponse.items:type_name -> proto.WatchItem
-	14, // 4: proto.WatchItem.thin_pool:type_name -> proto.ThinPoolItem
-	2,  // 5: proto.LVService.CreateLV:input_type -> proto.CreateLVRequest
-	4,  // 6: proto.LVService.RemoveLV:input_type -> proto.RemoveLVRequest
-	7,  // 7: proto.LVService.ResizeLV:input_type -> proto.ResizeLVRequest
-	5,  // 8: proto.LVService.CreateLVSnapshot:input_type -> proto.CreateLVSnapshotRequest
-	11, // 9: proto.VGService.GetLVList:input_type -> proto.GetLVListRequest
-	12, // 10: proto.VGService.GetFreeBytes:input_type -> proto.GetFreeBytesRequest
-	0,  // 11: proto.VGService.Watch:input_type -> proto.Empty
-	3,  // 12: proto.LVService.CreateLV:output_type -> proto.CreateLVResponse
-	0,  // 13: proto.LVService.RemoveLV:output_type -> proto.Empty
-	8,  // 14: proto.LVService.ResizeLV:output_type -> proto.ResizeLVResponse
-	6,  // 15: proto.LVService.CreateLVSnapshot:output_type -> proto.CreateLVSnapshotResponse
-	9,  // 16: proto.VGService.GetLVList:output_type -> proto.GetLVListResponse
-	10, // 17: proto.VGService.GetFreeBytes:output_type -> proto.GetFreeBytesResponse
-	13, // 18: proto.VGService.Watch:output_type -> proto.WatchResponse
-	12, // [12:19] is the sub-list for method output_type
-	5,  // [5:12] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	1,  // 2: proto.MergeLVSnapshotResponse.volume:type_name -> proto.LogicalVolume
+	11, // 3: proto.GetLVBlockMetadataResponse.ranges:type_name -> proto.BlockRange
+	1,  // 4: proto.ApplyLVDeltaResponse.volume:type_name -> proto.LogicalVolume
+	1,  // 5: proto.CopyLVResponse.volume:type_name -> proto.LogicalVolume
+	1,  // 6: proto.UndeleteLVResponse.volume:type_name -> proto.LogicalVolume
+	1,  // 7: proto.GetLVListResponse.volumes:type_name -> proto.LogicalVolume
+	34, // 8: proto.WatchResponse.items:type_name -> proto.WatchItem
+	32, // 9: proto.WatchItem.thin_pool:type_name -> proto.ThinPoolItem
+	33, // 10: proto.WatchItem.physical_volumes:type_name -> proto.PhysicalVolumeItem
+	36, // 11: proto.WatchItem.wipes:type_name -> proto.WipeItem
+	37, // 12: proto.WatchItem.trash:type_name -> proto.TrashItem
+	35, // 13: proto.WatchItem.mkfs_options:type_name -> proto.MkfsOptions
+	2,  // 14: proto.LVService.CreateLV:input_type -> proto.CreateLVRequest
+	4,  // 15: proto.LVService.RemoveLV:input_type -> proto.RemoveLVRequest
+	7,  // 16: proto.LVService.ResizeLV:input_type -> proto.ResizeLVRequest
+	5,  // 17: proto.LVService.CreateLVSnapshot:input_type -> proto.CreateLVSnapshotRequest
+	9,  // 18: proto.LVService.MergeLVSnapshot:input_type -> proto.MergeLVSnapshotRequest
+	12, // 19: proto.LVService.GetLVBlockMetadata:input_type -> proto.GetLVBlockMetadataRequest
+	14, // 20: proto.LVService.ReplicateLV:input_type -> proto.ReplicateLVRequest
+	23, // 21: proto.LVService.CopyLV:input_type -> proto.CopyLVRequest
+	25, // 22: proto.LVService.UndeleteLV:input_type -> proto.UndeleteLVRequest
+	21, // 23: proto.LVService.PrepareLVCopy:input_type -> proto.PrepareLVCopyRequest
+	22, // 24: proto.LVService.RemoveLVCopy:input_type -> proto.RemoveLVCopyRequest
+	16, // 25: proto.ReplicationService.ApplyLVDelta:input_type -> proto.ApplyLVDeltaRequest
+	18, // 26: proto.ReplicationService.RemoveLVReplica:input_type -> proto.RemoveLVReplicaRequest
+	19, // 27: proto.ReplicationService.ReadLV:input_type -> proto.ReadLVRequest
+	12, // 28: proto.ReplicationService.GetLVBlockMetadata:input_type -> proto.GetLVBlockMetadataRequest
+	29, // 29: proto.VGService.GetLVList:input_type -> proto.GetLVListRequest
+	30, // 30: proto.VGService.GetFreeBytes:input_type -> proto.GetFreeBytesRequest
+	0,  // 31: proto.VGService.Watch:input_type -> proto.Empty
+	3,  // 32: proto.LVService.CreateLV:output_type -> proto.CreateLVResponse
+	0,  // 33: proto.LVService.RemoveLV:output_type -> proto.Empty
+	8,  // 34: proto.LVService.ResizeLV:output_type -> proto.ResizeLVResponse
+	6,  // 35: proto.LVService.CreateLVSnapshot:output_type -> proto.CreateLVSnapshotResponse
+	10, // 36: proto.LVService.MergeLVSnapshot:output_type -> proto.MergeLVSnapshotResponse
+	13, // 37: proto.LVService.GetLVBlockMetadata:output_type -> proto.GetLVBlockMetadataResponse
+	15, // 38: proto.LVService.ReplicateLV:output_type -> proto.ReplicateLVResponse
+	24, // 39: proto.LVService.CopyLV:output_type -> proto.CopyLVResponse
+	26, // 40: proto.LVService.UndeleteLV:output_type -> proto.UndeleteLVResponse
+	0,  // 41: proto.LVService.PrepareLVCopy:output_type -> proto.Empty
+	0,  // 42: proto.LVService.RemoveLVCopy:output_type -> proto.Empty
+	17, // 43: proto.ReplicationService.ApplyLVDelta:output_type -> proto.ApplyLVDeltaResponse
+	0,  // 44: proto.ReplicationService.RemoveLVReplica:output_type -> proto.Empty
+	20, // 45: proto.ReplicationService.ReadLV:output_type -> proto.ReadLVResponse
+	13, // 46: proto.ReplicationService.GetLVBlockMetadata:output_type -> proto.GetLVBlockMetadataResponse
+	27, // 47: proto.VGService.GetLVList:output_type -> proto.GetLVListResponse
+	28, // 48: proto.VGService.GetFreeBytes:output_type -> proto.GetFreeBytesResponse
+	31, // 49: proto.VGService.Watch:output_type -> proto.WatchResponse
+	32, // [32:50] is the sub-list for method output_type
+	14, // [14:32] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_pkg_lvmd_proto_lvmd_proto_init() }
@@ -1088,9 +2773,9 @@ func file_pkg_lvmd_proto_lvmd_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_lvmd_proto_lvmd_proto_rawDesc), len(file_pkg_lvmd_proto_lvmd_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   16,
+			NumMessages:   38,
 			NumExtensions: 0,
-			NumServices:   2,
+			NumServices:   3,
 		},
 		GoTypes:           file_pkg_lvmd_proto_lvmd_proto_goTypes,
 		DependencyIndexes: file_pkg_lvmd_proto_lvmd_proto_depIdxs,
@@ -26,10 +26,17 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	LVService_CreateLV_FullMethodName         = "/proto.LVService/CreateLV"
-	LVService_RemoveLV_FullMethodName         = "/proto.LVService/RemoveLV"
-	LVService_ResizeLV_FullMethodName         = "/proto.LVService/ResizeLV"
-	LVService_CreateLVSnapshot_FullMethodName = "/proto.LVService/CreateLVSnapshot"
+	LVService_CreateLV_FullMethodName           = "/proto.LVService/CreateLV"
+	LVService_RemoveLV_FullMethodName           = "/proto.LVService/RemoveLV"
+	LVService_ResizeLV_FullMethodName           = "/proto.LVService/ResizeLV"
+	LVService_CreateLVSnapshot_FullMethodName   = "/proto.LVService/CreateLVSnapshot"
+	LVService_MergeLVSnapshot_FullMethodName    = "/proto.LVService/MergeLVSnapshot"
+	LVService_GetLVBlockMetadata_FullMethodName = "/proto.LVService/GetLVBlockMetadata"
+	LVService_ReplicateLV_FullMethodName        = "/proto.LVService/ReplicateLV"
+	LVService_CopyLV_FullMethodName             = "/proto.LVService/CopyLV"
+	LVService_UndeleteLV_FullMethodName         = "/proto.LVService/UndeleteLV"
+	LVService_PrepareLVCopy_FullMethodName      = "/proto.LVService/PrepareLVCopy"
+	LVService_RemoveLVCopy_FullMethodName       = "/proto.LVService/RemoveLVCopy"
 )
 
 // LVServiceClient is the client API for LVService service.
@@ -45,6 +52,20 @@ type LVServiceClient interface {
 	// Resize a logical volume.
 	ResizeLV(ctx context.Context, in *ResizeLVRequest, opts ...grpc.CallOption) (*ResizeLVResponse, error)
 	CreateLVSnapshot(ctx context.Context, in *CreateLVSnapshotRequest, opts ...grpc.CallOption) (*CreateLVSnapshotResponse, error)
+	// Merge a thin snapshot back into its origin volume.
+	MergeLVSnapshot(ctx context.Context, in *MergeLVSnapshotRequest, opts ...grpc.CallOption) (*MergeLVSnapshotResponse, error)
+	// Stream the allocated or changed ranges of a thin logical volume.
+	GetLVBlockMetadata(ctx context.Context, in *GetLVBlockMetadataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetLVBlockMetadataResponse], error)
+	// Replicate a thin logical volume to the peer lvmd.
+	ReplicateLV(ctx context.Context, in *ReplicateLVRequest, opts ...grpc.CallOption) (*ReplicateLVResponse, error)
+	// Create a logical volume with the data of another logical volume, possibly in another device class.
+	CopyLV(ctx context.Context, in *CopyLVRequest, opts ...grpc.CallOption) (*CopyLVResponse, error)
+	// Restore a removed logical volume from the trash.
+	UndeleteLV(ctx context.Context, in *UndeleteLVRequest, opts ...grpc.CallOption) (*UndeleteLVResponse, error)
+	// Take a snapshot of a logical volume to be copied to another node by ReadLV.
+	PrepareLVCopy(ctx context.Context, in *PrepareLVCopyRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Remove the snapshot taken by PrepareLVCopy.
+	RemoveLVCopy(ctx context.Context, in *RemoveLVCopyRequest, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type lVServiceClient struct {
@@ -95,6 +116,85 @@ func (c *lVServiceClient) CreateLVSnapshot(ctx context.Context, in *CreateLVSnap
 	return out, nil
 }
 
+func (c *lVServiceClient) MergeLVSnapshot(ctx context.Context, in *MergeLVSnapshotRequest, opts ...grpc.CallOption) (*MergeLVSnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MergeLVSnapshotResponse)
+	err := c.cc.Invoke(ctx, LVService_MergeLVSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lVServiceClient) GetLVBlockMetadata(ctx context.Context, in *GetLVBlockMetadataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetLVBlockMetadataResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LVService_ServiceDesc.Streams[0], LVService_GetLVBlockMetadata_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetLVBlockMetadataRequest, GetLVBlockMetadataResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LVService_GetLVBlockMetadataClient = grpc.ServerStreamingClient[GetLVBlockMetadataResponse]
+
+func (c *lVServiceClient) ReplicateLV(ctx context.Context, in *ReplicateLVRequest, opts ...grpc.CallOption) (*ReplicateLVResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicateLVResponse)
+	err := c.cc.Invoke(ctx, LVService_ReplicateLV_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lVServiceClient) CopyLV(ctx context.Context, in *CopyLVRequest, opts ...grpc.CallOption) (*CopyLVResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CopyLVResponse)
+	err := c.cc.Invoke(ctx, LVService_CopyLV_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lVServiceClient) UndeleteLV(ctx context.Context, in *UndeleteLVRequest, opts ...grpc.CallOption) (*UndeleteLVResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UndeleteLVResponse)
+	err := c.cc.Invoke(ctx, LVService_UndeleteLV_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lVServiceClient) PrepareLVCopy(ctx context.Context, in *PrepareLVCopyRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, LVService_PrepareLVCopy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lVServiceClient) RemoveLVCopy(ctx context.Context, in *RemoveLVCopyRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, LVService_RemoveLVCopy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LVServiceServer is the server API for LVService service.
 // All implementations must embed UnimplementedLVServiceServer
 // for forward compatibility.
@@ -108,6 +208,20 @@ type LVServiceServer interface {
 	// Resize a logical volume.
 	ResizeLV(context.Context, *ResizeLVRequest) (*ResizeLVResponse, error)
 	CreateLVSnapshot(context.Context, *CreateLVSnapshotRequest) (*CreateLVSnapshotResponse, error)
+	// Merge a thin snapshot back into its origin volume.
+	MergeLVSnapshot(context.Context, *MergeLVSnapshotRequest) (*MergeLVSnapshotResponse, error)
+	// Stream the allocated or changed ranges of a thin logical volume.
+	GetLVBlockMetadata(*GetLVBlockMetadataRequest, grpc.ServerStreamingServer[GetLVBlockMetadataResponse]) error
+	// Replicate a thin logical volume to the peer lvmd.
+	ReplicateLV(context.Context, *ReplicateLVRequest) (*ReplicateLVResponse, error)
+	// Create a logical volume with the data of another logical volume, possibly in another device class.
+	CopyLV(context.Context, *CopyLVRequest) (*CopyLVResponse, error)
+	// Restore a removed logical volume from the trash.
+	UndeleteLV(context.Context, *UndeleteLVRequest) (*UndeleteLVResponse, error)
+	// Take a snapshot of a logical volume to be copied to another node by ReadLV.
+	PrepareLVCopy(context.Context, *PrepareLVCopyRequest) (*Empty, error)
+	// Remove the snapshot taken by PrepareLVCopy.
+	RemoveLVCopy(context.Context, *RemoveLVCopyRequest) (*Empty, error)
 	mustEmbedUnimplementedLVServiceServer()
 }
 
@@ -130,6 +244,27 @@ func (UnimplementedLVServiceServer) ResizeLV(context.Context, *ResizeLVRequest)
 func (UnimplementedLVServiceServer) CreateLVSnapshot(context.Context, *CreateLVSnapshotRequest) (*CreateLVSnapshotResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateLVSnapshot not implemented")
 }
+func (UnimplementedLVServiceServer) MergeLVSnapshot(context.Context, *MergeLVSnapshotRequest) (*MergeLVSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergeLVSnapshot not implemented")
+}
+func (UnimplementedLVServiceServer) GetLVBlockMetadata(*GetLVBlockMetadataRequest, grpc.ServerStreamingServer[GetLVBlockMetadataResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method GetLVBlockMetadata not implemented")
+}
+func (UnimplementedLVServiceServer) ReplicateLV(context.Context, *ReplicateLVRequest) (*ReplicateLVResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReplicateLV not implemented")
+}
+func (UnimplementedLVServiceServer) CopyLV(context.Context, *CopyLVRequest) (*CopyLVResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CopyLV not implemented")
+}
+func (UnimplementedLVServiceServer) UndeleteLV(context.Context, *UndeleteLVRequest) (*UndeleteLVResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UndeleteLV not implemented")
+}
+func (UnimplementedLVServiceServer) PrepareLVCopy(context.Context, *PrepareLVCopyRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrepareLVCopy not implemented")
+}
+func (UnimplementedLVServiceServer) RemoveLVCopy(context.Context, *RemoveLVCopyRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveLVCopy not implemented")
+}
 func (UnimplementedLVServiceServer) mustEmbedUnimplementedLVServiceServer() {}
 func (UnimplementedLVServiceServer) testEmbeddedByValue()                   {}
 
@@ -223,6 +358,125 @@ func _LVService_CreateLVSnapshot_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LVService_MergeLVSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeLVSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).MergeLVSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_MergeLVSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).MergeLVSnapshot(ctx, req.(*MergeLVSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LVService_GetLVBlockMetadata_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetLVBlockMetadataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LVServiceServer).GetLVBlockMetadata(m, &grpc.GenericServerStream[GetLVBlockMetadataRequest, GetLVBlockMetadataResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LVService_GetLVBlockMetadataServer = grpc.ServerStreamingServer[GetLVBlockMetadataResponse]
+
+func _LVService_ReplicateLV_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicateLVRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).ReplicateLV(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_ReplicateLV_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).ReplicateLV(ctx, req.(*ReplicateLVRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LVService_CopyLV_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyLVRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).CopyLV(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_CopyLV_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).CopyLV(ctx, req.(*CopyLVRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LVService_UndeleteLV_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UndeleteLVRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).UndeleteLV(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_UndeleteLV_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).UndeleteLV(ctx, req.(*UndeleteLVRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LVService_PrepareLVCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrepareLVCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).PrepareLVCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_PrepareLVCopy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).PrepareLVCopy(ctx, req.(*PrepareLVCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LVService_RemoveLVCopy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveLVCopyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LVServiceServer).RemoveLVCopy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LVService_RemoveLVCopy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LVServiceServer).RemoveLVCopy(ctx, req.(*RemoveLVCopyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LVService_ServiceDesc is the grpc.ServiceDesc for LVService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -246,8 +500,268 @@ var LVService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CreateLVSnapshot",
 			Handler:    _LVService_CreateLVSnapshot_Handler,
 		},
+		{
+			MethodName: "MergeLVSnapshot",
+			Handler:    _LVService_MergeLVSnapshot_Handler,
+		},
+		{
+			MethodName: "ReplicateLV",
+			Handler:    _LVService_ReplicateLV_Handler,
+		},
+		{
+			MethodName: "CopyLV",
+			Handler:    _LVService_CopyLV_Handler,
+		},
+		{
+			MethodName: "UndeleteLV",
+			Handler:    _LVService_UndeleteLV_Handler,
+		},
+		{
+			MethodName: "PrepareLVCopy",
+			Handler:    _LVService_PrepareLVCopy_Handler,
+		},
+		{
+			MethodName: "RemoveLVCopy",
+			Handler:    _LVService_RemoveLVCopy_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetLVBlockMetadata",
+			Handler:       _LVService_GetLVBlockMetadata_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "pkg/lvmd/proto/lvmd.proto",
+}
+
+const (
+	ReplicationService_ApplyLVDelta_FullMethodName       = "/proto.ReplicationService/ApplyLVDelta"
+	ReplicationService_RemoveLVReplica_FullMethodName    = "/proto.ReplicationService/RemoveLVReplica"
+	ReplicationService_ReadLV_FullMethodName             = "/proto.ReplicationService/ReadLV"
+	ReplicationService_GetLVBlockMetadata_FullMethodName = "/proto.ReplicationService/GetLVBlockMetadata"
+)
+
+// ReplicationServiceClient is the client API for ReplicationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Service to receive replicas of logical volumes from other nodes.
+type ReplicationServiceClient interface {
+	// Apply the streamed ranges to the replica logical volume.
+	ApplyLVDelta(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ApplyLVDeltaRequest, ApplyLVDeltaResponse], error)
+	// Remove a replica logical volume.
+	RemoveLVReplica(ctx context.Context, in *RemoveLVReplicaRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Stream the allocated ranges of a logical volume prepared by PrepareLVCopy to copy it to another node.
+	ReadLV(ctx context.Context, in *ReadLVRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadLVResponse], error)
+	// Stream the block metadata of a thin logical volume as GetLVBlockMetadata of LVService does.
+	// topolvm-controller calls this to serve the CSI SnapshotMetadata service for the snapshots on any node.
+	GetLVBlockMetadata(ctx context.Context, in *GetLVBlockMetadataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetLVBlockMetadataResponse], error)
+}
+
+type replicationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReplicationServiceClient(cc grpc.ClientConnInterface) ReplicationServiceClient {
+	return &replicationServiceClient{cc}
+}
+
+func (c *replicationServiceClient) ApplyLVDelta(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ApplyLVDeltaRequest, ApplyLVDeltaResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReplicationService_ServiceDesc.Streams[0], ReplicationService_ApplyLVDelta_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ApplyLVDeltaRequest, ApplyLVDeltaResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReplicationService_ApplyLVDeltaClient = grpc.ClientStreamingClient[ApplyLVDeltaRequest, ApplyLVDeltaResponse]
+
+func (c *replicationServiceClient) RemoveLVReplica(ctx context.Context, in *RemoveLVReplicaRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, ReplicationService_RemoveLVReplica_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *replicationServiceClient) ReadLV(ctx context.Context, in *ReadLVRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ReadLVResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReplicationService_ServiceDesc.Streams[1], ReplicationService_ReadLV_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ReadLVRequest, ReadLVResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReplicationService_ReadLVClient = grpc.ServerStreamingClient[ReadLVResponse]
+
+func (c *replicationServiceClient) GetLVBlockMetadata(ctx context.Context, in *GetLVBlockMetadataRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GetLVBlockMetadataResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ReplicationService_ServiceDesc.Streams[2], ReplicationService_GetLVBlockMetadata_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GetLVBlockMetadataRequest, GetLVBlockMetadataResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReplicationService_GetLVBlockMetadataClient = grpc.ServerStreamingClient[GetLVBlockMetadataResponse]
+
+// ReplicationServiceServer is the server API for ReplicationService service.
+// All implementations must embed UnimplementedReplicationServiceServer
+// for forward compatibility.
+//
+// Service to receive replicas of logical volumes from other nodes.
+type ReplicationServiceServer interface {
+	// Apply the streamed ranges to the replica logical volume.
+	ApplyLVDelta(grpc.ClientStreamingServer[ApplyLVDeltaRequest, ApplyLVDeltaResponse]) error
+	// Remove a replica logical volume.
+	RemoveLVReplica(context.Context, *RemoveLVReplicaRequest) (*Empty, error)
+	// Stream the allocated ranges of a logical volume prepared by PrepareLVCopy to copy it to another node.
+	ReadLV(*ReadLVRequest, grpc.ServerStreamingServer[ReadLVResponse]) error
+	// Stream the block metadata of a thin logical volume as GetLVBlockMetadata of LVService does.
+	// topolvm-controller calls this to serve the CSI SnapshotMetadata service for the snapshots on any node.
+	GetLVBlockMetadata(*GetLVBlockMetadataRequest, grpc.ServerStreamingServer[GetLVBlockMetadataResponse]) error
+	mustEmbedUnimplementedReplicationServiceServer()
+}
+
+// UnimplementedReplicationServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReplicationServiceServer struct{}
+
+func (UnimplementedReplicationServiceServer) ApplyLVDelta(grpc.ClientStreamingServer[ApplyLVDeltaRequest, ApplyLVDeltaResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ApplyLVDelta not implemented")
+}
+func (UnimplementedReplicationServiceServer) RemoveLVReplica(context.Context, *RemoveLVReplicaRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveLVReplica not implemented")
+}
+func (UnimplementedReplicationServiceServer) ReadLV(*ReadLVRequest, grpc.ServerStreamingServer[ReadLVResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ReadLV not implemented")
+}
+func (UnimplementedReplicationServiceServer) GetLVBlockMetadata(*GetLVBlockMetadataRequest, grpc.ServerStreamingServer[GetLVBlockMetadataResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method GetLVBlockMetadata not implemented")
+}
+func (UnimplementedReplicationServiceServer) mustEmbedUnimplementedReplicationServiceServer() {}
+func (UnimplementedReplicationServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeReplicationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReplicationServiceServer will
+// result in compilation errors.
+type UnsafeReplicationServiceServer interface {
+	mustEmbedUnimplementedReplicationServiceServer()
+}
+
+func RegisterReplicationServiceServer(s grpc.ServiceRegistrar, srv ReplicationServiceServer) {
+	// If the following call pancis, it indicates UnimplementedReplicationServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ReplicationService_ServiceDesc, srv)
+}
+
+func _ReplicationService_ApplyLVDelta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ReplicationServiceServer).ApplyLVDelta(&grpc.GenericServerStream[ApplyLVDeltaRequest, ApplyLVDeltaResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReplicationService_ApplyLVDeltaServer = grpc.ClientStreamingServer[ApplyLVDeltaRequest, ApplyLVDeltaResponse]
+
+func _ReplicationService_RemoveLVReplica_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveLVReplicaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicationServiceServer).RemoveLVReplica(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ReplicationService_RemoveLVReplica_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicationServiceServer).RemoveLVReplica(ctx, req.(*RemoveLVReplicaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReplicationService_ReadLV_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ReadLVRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplicationServiceServer).ReadLV(m, &grpc.GenericServerStream[ReadLVRequest, ReadLVResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReplicationService_ReadLVServer = grpc.ServerStreamingServer[ReadLVResponse]
+
+func _ReplicationService_GetLVBlockMetadata_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetLVBlockMetadataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplicationServiceServer).GetLVBlockMetadata(m, &grpc.GenericServerStream[GetLVBlockMetadataRequest, GetLVBlockMetadataResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ReplicationService_GetLVBlockMetadataServer = grpc.ServerStreamingServer[GetLVBlockMetadataResponse]
+
+// ReplicationService_ServiceDesc is the grpc.ServiceDesc for ReplicationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ReplicationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.ReplicationService",
+	HandlerType: (*ReplicationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RemoveLVReplica",
+			Handler:    _ReplicationService_RemoveLVReplica_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ApplyLVDelta",
+			Handler:       _ReplicationService_ApplyLVDelta_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ReadLV",
+			Handler:       _ReplicationService_ReadLV_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GetLVBlockMetadata",
+			Handler:       _ReplicationService_GetLVBlockMetadata_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/lvmd/proto/lvmd.proto",
 }
 
@@ -7,6 +7,20 @@ const (
 	TypeThick = DeviceType("thick")
 )
 
+// WipePolicy is how the data of logical volumes is erased when they are removed.
+type WipePolicy string
+
+const (
+	// WipeNone removes logical volumes without erasing the data. This is the default.
+	WipeNone = WipePolicy("none")
+	// WipeDiscard discards the blocks of logical volumes.
+	WipeDiscard = WipePolicy("blkdiscard")
+	// WipeZero overwrites logical volumes with zeros.
+	WipeZero = WipePolicy("zero-fill")
+	// WipeRandom overwrites logical volumes with random data.
+	WipeRandom = WipePolicy("random")
+)
+
 // ThinPoolConfig holds the configuration of thin pool in a volume group
 type ThinPoolConfig struct {
 	// Name of thinpool
@@ -36,6 +50,26 @@ type DeviceClass struct {
 	Type DeviceType `json:"type"`
 	// ThinPoolConfig holds the configuration for thinpool in this volume group corresponding to the device-class
 	ThinPoolConfig *ThinPoolConfig `json:"thin-pool"`
+	// WarmPool holds the configuration of logical volumes created in advance to be claimed by new volumes
+	WarmPool []*WarmPoolConfig `json:"warm-pool"`
+	// WipePolicy is how the data of logical volumes is erased before they are removed
+	WipePolicy WipePolicy `json:"wipe-policy"`
+	// TrashRetention is how long removed logical volumes are kept in the trash before they are purged, e.g. "72h"
+	TrashRetention string `json:"trash-retention"`
+	// FsType is the filesystem volumes are formatted with when the StorageClass does not specify one
+	FsType string `json:"fs-type"`
+	// MkfsOptions are extra arguments to pass to mkfs for each filesystem, e.g. {"xfs": ["-m", "reflink=1"]}
+	MkfsOptions map[string][]string `json:"mkfs-options"`
+}
+
+// WarmPoolConfig holds the configuration of pre-created logical volumes of a size in a device-class
+type WarmPoolConfig struct {
+	// SizeGB is the size of the logical volumes in GiB. Only the volumes of exactly this size claim them.
+	SizeGB uint64 `json:"size-gb"`
+	// Count is the number of the logical volumes kept in the pool
+	Count int `json:"count"`
+	// FsType is the filesystem created on the logical volumes. They are not formatted if it is empty.
+	FsType string `json:"fs-type"`
 }
 
 type LvcreateOptionClass struct {
@@ -44,3 +78,15 @@ type LvcreateOptionClass struct {
 	// Options are extra arguments to pass to lvcreate
 	Options []string `json:"options"`
 }
+
+// ReplicationTLS holds the mutual TLS configuration of the replication API between nodes
+type ReplicationTLS struct {
+	// CertFile is the certificate presented to the peers, both as a server and as a client
+	CertFile string `json:"cert-file"`
+	// KeyFile is the private key of the certificate
+	KeyFile string `json:"key-file"`
+	// CAFile is the CA certificate to verify the certificates of the peers
+	CAFile string `json:"ca-file"`
+	// PeerName is the DNS name that the certificates of the peers must have
+	PeerName string `json:"peer-name"`
+}